@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -37,7 +38,7 @@ database changes. Useful for validating content before full processing.`,
 
 		// Create analyzer and run analysis
 		analyzer := dryrun.NewAnalyzer(limit)
-		result, err := analyzer.Analyze(filePath)
+		result, err := analyzer.Analyze(context.Background(), filePath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error during dry-run analysis: %v\n", err)
 			os.Exit(1)
@@ -0,0 +1,39 @@
+package main
+
+import "fmt"
+
+// defaultAbortAfterFailures is the default of the --abort-after-failures flag
+const defaultAbortAfterFailures = 10
+
+// failureBreaker stops a download loop once too many items in a row failed to
+// download, which usually means that the provider is down: every remaining item
+// would otherwise burn its full retry budget.
+type failureBreaker struct {
+	threshold   int // Consecutive failures that trip the breaker, 0 = never
+	consecutive int
+}
+
+func newFailureBreaker(threshold int) *failureBreaker {
+	return &failureBreaker{threshold: threshold}
+}
+
+// Record records the download outcome of an item. It returns true when the
+// breaker trips, i.e. the loop must stop.
+func (b *failureBreaker) Record(downloaded bool) bool {
+	if downloaded {
+		b.consecutive = 0
+		return false
+	}
+	b.consecutive++
+	return b.Tripped()
+}
+
+// Tripped reports whether the threshold of consecutive failures was reached
+func (b *failureBreaker) Tripped() bool {
+	return b.threshold > 0 && b.consecutive >= b.threshold
+}
+
+// Message explains why the loop stopped
+func (b *failureBreaker) Message() string {
+	return fmt.Sprintf("provider appears down: %d consecutive downloads failed, aborting the remaining items", b.consecutive)
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestFailureBreaker(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		outcomes  []bool // Download outcome of each item, true = downloaded
+		processed int    // Items processed before the loop stops
+	}{
+		{name: "provider down aborts early", threshold: 3, outcomes: []bool{true, true, false, false, false, false, false}, processed: 5},
+		{name: "success resets the count", threshold: 3, outcomes: []bool{false, false, true, false, false, true, false}, processed: 7},
+		{name: "disabled never aborts", threshold: 0, outcomes: []bool{false, false, false, false, false}, processed: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := newFailureBreaker(tt.threshold)
+			processed := 0
+			for _, downloaded := range tt.outcomes {
+				processed++
+				if breaker.Record(downloaded) {
+					break
+				}
+			}
+
+			if processed != tt.processed {
+				t.Errorf("processed %d items, want %d", processed, tt.processed)
+			}
+			if aborted := processed < len(tt.outcomes); breaker.Tripped() != aborted {
+				t.Errorf("Tripped() = %v, want %v", breaker.Tripped(), aborted)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/database"
 	"github.com/glefebvre/stalkeer/internal/downloader"
 	"github.com/spf13/cobra"
 )
@@ -16,13 +17,28 @@ var cleanupCmd = &cobra.Command{
 that are older than the retention period (default: 24 hours).
 
 Orphaned temp files can occur when downloads are interrupted or the application
-crashes before completing the move to the final destination.`,
+crashes before completing the move to the final destination.
+
+With --orphans, scan the movies and TV shows directories instead and report media
+files that have no corresponding completed download. Add --delete to remove them.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
 		retentionHours, _ := cmd.Flags().GetInt("retention-hours")
+		orphans, _ := cmd.Flags().GetBool("orphans")
+		deleteOrphans, _ := cmd.Flags().GetBool("delete")
 
 		cfg := config.Get()
 
+		if deleteOrphans && !orphans {
+			fmt.Fprintln(os.Stderr, "Error: --delete can only be used with --orphans")
+			os.Exit(1)
+		}
+
+		if orphans {
+			runOrphanCleanup(cfg, deleteOrphans, dryRun)
+			return
+		}
+
 		fmt.Println("=== Temp File Cleanup ===")
 		if dryRun {
 			fmt.Println("Mode: DRY RUN (no files will be deleted)")
@@ -51,8 +67,53 @@ crashes before completing the move to the final destination.`,
 	},
 }
 
+// runOrphanCleanup reports, and optionally removes, media files without a completed download
+func runOrphanCleanup(cfg *config.Config, deleteOrphans, dryRun bool) {
+	fmt.Println("=== Orphaned Media Cleanup ===")
+	if dryRun {
+		fmt.Println("Mode: DRY RUN (no files will be deleted)")
+	} else if !deleteOrphans {
+		fmt.Println("Mode: REPORT (use --delete to remove orphaned files)")
+	}
+	fmt.Printf("Movies path:   %s\n", cfg.Downloads.MoviesPath)
+	fmt.Printf("TV shows path: %s\n\n", cfg.Downloads.TVShowsPath)
+
+	if err := database.Initialize(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing database: %v\n", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	stats, err := downloader.CleanupOrphanedMediaFiles(database.Get(), downloader.OrphanCleanupOptions{
+		Roots:  []string{cfg.Downloads.MoviesPath, cfg.Downloads.TVShowsPath},
+		Delete: deleteOrphans,
+		DryRun: dryRun,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error during orphan cleanup: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, path := range stats.Orphans {
+		fmt.Printf("  %s\n", path)
+	}
+
+	fmt.Println("\n=== Orphan Summary ===")
+	fmt.Printf("Files scanned:    %d\n", stats.Scanned)
+	fmt.Printf("Referenced:       %d\n", stats.Referenced)
+	fmt.Printf("Orphaned:         %d (%s)\n", len(stats.Orphans), formatBytes(stats.OrphanBytes))
+	if deleteOrphans && !dryRun {
+		fmt.Printf("Deleted:          %d\n", stats.Deleted)
+		if stats.DeleteErrors > 0 {
+			fmt.Printf("Delete errors:    %d\n", stats.DeleteErrors)
+		}
+	}
+}
+
 func init() {
 	cleanupCmd.Flags().Bool("dry-run", false, "preview cleanup without deleting files")
 	cleanupCmd.Flags().Int("retention-hours", 24, "delete temp files older than this many hours")
+	cleanupCmd.Flags().Bool("orphans", false, "scan media directories for files without a completed download")
+	cleanupCmd.Flags().Bool("delete", false, "delete orphaned media files (requires --orphans)")
 	rootCmd.AddCommand(cleanupCmd)
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"text/tabwriter"
 
 	"github.com/glefebvre/stalkeer/internal/config"
 	"github.com/spf13/cobra"
@@ -13,6 +15,12 @@ var configCmd = &cobra.Command{
 	Long:  `Display the current configuration settings loaded from config.yml`,
 	Run: func(cmd *cobra.Command, args []string) {
 		showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+		explain, _ := cmd.Flags().GetBool("explain")
+
+		if explain {
+			printConfigSources(showSecrets)
+			return
+		}
 
 		cfg := config.Get()
 		fmt.Println("=== Stalkeer Configuration ===")
@@ -32,7 +40,22 @@ var configCmd = &cobra.Command{
 	},
 }
 
+// printConfigSources prints each resolved configuration key with its value and source
+func printConfigSources(showSecrets bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, setting := range config.Explain(showSecrets) {
+		source := setting.Source
+		if setting.EnvVar != "" {
+			source = fmt.Sprintf("%s (%s)", source, setting.EnvVar)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, setting.Value, source)
+	}
+	w.Flush()
+}
+
 func init() {
 	configCmd.Flags().Bool("show-secrets", false, "reveal password fields")
+	configCmd.Flags().Bool("explain", false, "show each resolved key with its value and source (default, file, env)")
 	rootCmd.AddCommand(configCmd)
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/external/tmdb"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/processor"
+	"github.com/spf13/cobra"
+)
+
+var enrichCmd = &cobra.Command{
+	Use:   "enrich",
+	Short: "Backfill TMDB metadata for processed entries without a Movie or TV show",
+	Long: `Query all processed movie and TV show entries that have no TMDB association,
+for example entries processed with --skip-tmdb or before TMDB was configured,
+run the same TMDB enrichment as the process command and link them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+		contentType, _ := cmd.Flags().GetString("content-type")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+
+		switch models.ContentType(contentType) {
+		case "", models.ContentTypeMovies, models.ContentTypeTVShows:
+		default:
+			fmt.Fprintln(os.Stderr, "Error: --content-type must be one of: movies, tvshows")
+			os.Exit(1)
+		}
+
+		if err := config.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+		cfg := config.Get()
+
+		if !cfg.TMDB.Enabled || cfg.TMDB.APIKey == "" {
+			fmt.Fprintln(os.Stderr, "Error: TMDB integration is disabled or API key is not configured")
+			os.Exit(1)
+		}
+
+		if err := database.Initialize(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error initializing database: %v\n", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		tmdbClient := tmdb.NewClient(processor.TMDBClientConfig(cfg.TMDB))
+		if cfg.TMDB.ValidateKey {
+			if err := tmdbClient.Validate(context.Background()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		opts := processor.EnrichTMDBOptions{
+			Limit:           limit,
+			ContentType:     models.ContentType(contentType),
+			Language:        cfg.TMDB.Language,
+			Verbose:         verbose,
+			EpisodeDetails:  cfg.TMDB.EpisodeDetails,
+			NormalizeGenres: cfg.TMDB.NormalizeGenres,
+		}
+
+		fmt.Println("Starting TMDB backfill...")
+
+		stats, err := processor.EnrichMissingTMDB(database.Get(), tmdbClient, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error during backfill: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("\n=== Backfill Summary ===")
+		fmt.Printf("Processed: %d\n", stats.Processed)
+		fmt.Printf("Matched:   %d\n", stats.Matched)
+		fmt.Printf("Not found: %d\n", stats.NotFound)
+		fmt.Printf("Errors:    %d\n", stats.Errors)
+	},
+}
+
+func init() {
+	enrichCmd.Flags().Int("limit", 0, "maximum number of entries to process (0 = no limit)")
+	enrichCmd.Flags().String("content-type", "", "only enrich this content type (movies, tvshows)")
+	enrichCmd.Flags().BoolP("verbose", "v", false, "verbose output")
+	rootCmd.AddCommand(enrichCmd)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -39,11 +40,13 @@ TVShow records are deduplicated by TMDB ID to minimise API calls.`,
 		}
 		defer database.Close()
 
-		tmdbClient := tmdb.NewClient(tmdb.Config{
-			APIKey:            cfg.TMDB.APIKey,
-			Language:          cfg.TMDB.Language,
-			RequestsPerSecond: cfg.TMDB.RequestsPerSecond,
-		})
+		tmdbClient := tmdb.NewClient(processor.TMDBClientConfig(cfg.TMDB))
+		if cfg.TMDB.ValidateKey {
+			if err := tmdbClient.Validate(context.Background()); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
 
 		db := database.Get()
 		opts := processor.EnrichTVDBOptions{
@@ -3,20 +3,15 @@ package main
 import (
 	"fmt"
 	"path/filepath"
+
+	"github.com/glefebvre/stalkeer/internal/display"
+	"github.com/glefebvre/stalkeer/internal/downloader"
 )
 
-// formatBytes converts a byte count to a human-readable string (e.g. "1.23 MB").
+// formatBytes converts a byte count to a human-readable string (e.g. "1.23 MB")
+// in the units of display.byte_units.
 func formatBytes(bytes int64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
-	}
-	div, exp := int64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.2f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+	return display.FormatBytes(bytes, 2)
 }
 
 // sanitizeFilename replaces characters that are invalid on common filesystems with underscores.
@@ -49,22 +44,51 @@ func valueOrEmpty(ptr *string) string {
 	return *ptr
 }
 
+// destRoot returns the root directory and fallback base of a Radarr or Sonarr
+// download. A --movies-path or --tvshows-path override replaces both the path
+// reported by Radarr or Sonarr and the configured base, so that the download
+// lands under the override.
+func destRoot(arrPath, configBase, override string) (string, string) {
+	if override != "" {
+		return "", override
+	}
+	return arrPath, configBase
+}
+
 // buildSonarrDestPath constructs the base destination path for a TV show episode download.
 // It uses seriesPath (from the Sonarr API) as the authoritative root directory, which
 // already encodes the correct Sonarr root folder. When seriesPath is empty it falls back
-// to joining fallbackBase with a sanitised seriesTitle.
+// to joining fallbackBase with a sanitised seriesTitle. Titles are shortened to keep
+// each path component within maxFilenameBytes, see downloader.FitFilename.
+// The second return value is true when the fallback was used.
+func buildSonarrDestPath(seriesPath, fallbackBase, seriesTitle string, seasonNum, episodeNum, maxFilenameBytes int) (string, bool) {
+	root := seriesPath
+	usedFallback := false
+	if root == "" {
+		root = filepath.Join(fallbackBase, downloader.FitFilename(sanitizeFilename(seriesTitle), "", maxFilenameBytes))
+		usedFallback = true
+	}
+	return filepath.Join(
+		root,
+		fmt.Sprintf("Season %02d", seasonNum),
+		downloader.FitMediaFilename(sanitizeFilename(seriesTitle), fmt.Sprintf(" - S%02dE%02d", seasonNum, episodeNum), maxFilenameBytes),
+	), usedFallback
+}
+
+// buildSonarrSeasonPackDestPath constructs the base destination path for a season pack
+// download, in the season directory of the series like buildSonarrDestPath.
 // The second return value is true when the fallback was used.
-func buildSonarrDestPath(seriesPath, fallbackBase, seriesTitle string, seasonNum, episodeNum int) (string, bool) {
+func buildSonarrSeasonPackDestPath(seriesPath, fallbackBase, seriesTitle string, seasonNum, maxFilenameBytes int) (string, bool) {
 	root := seriesPath
 	usedFallback := false
 	if root == "" {
-		root = filepath.Join(fallbackBase, sanitizeFilename(seriesTitle))
+		root = filepath.Join(fallbackBase, downloader.FitFilename(sanitizeFilename(seriesTitle), "", maxFilenameBytes))
 		usedFallback = true
 	}
 	return filepath.Join(
 		root,
 		fmt.Sprintf("Season %02d", seasonNum),
-		fmt.Sprintf("%s - S%02dE%02d", sanitizeFilename(seriesTitle), seasonNum, episodeNum),
+		downloader.FitMediaFilename(sanitizeFilename(seriesTitle), fmt.Sprintf(" - S%02d", seasonNum), maxFilenameBytes),
 	), usedFallback
 }
 
@@ -73,8 +97,8 @@ func buildSonarrDestPath(seriesPath, fallbackBase, seriesTitle string, seasonNum
 // When moviePath is empty it falls back to joining fallbackBase with the standard
 // movie directory name.
 // The second return value is true when the fallback was used.
-func buildRadarrDestPath(moviePath, fallbackBase, movieTitle string, movieYear int) (string, bool) {
-	fileBase := fmt.Sprintf("%s (%d)", sanitizeFilename(movieTitle), movieYear)
+func buildRadarrDestPath(moviePath, fallbackBase, movieTitle string, movieYear, maxFilenameBytes int) (string, bool) {
+	fileBase := downloader.FitMediaFilename(sanitizeFilename(movieTitle), fmt.Sprintf(" (%d)", movieYear), maxFilenameBytes)
 	root := moviePath
 	usedFallback := false
 	if root == "" {
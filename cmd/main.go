@@ -19,10 +19,14 @@ and downloads missing items from Radarr and Sonarr via direct links.`,
 	},
 }
 
-var configFile string
+var (
+	configFile string
+	configDir  string
+)
 
 func init() {
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "config file (default is ./config.yml)")
+	rootCmd.PersistentFlags().StringVar(&configDir, "config-dir", "", "directory of yaml config files merged in name order")
 	cobra.OnInitialize(initConfig)
 }
 
@@ -32,6 +36,8 @@ func initConfig() {
 		return
 	}
 
+	config.SetConfigFile(configFile)
+	config.SetConfigDir(configDir)
 	if err := config.Load(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/matcher"
+)
+
+// matcherConfig returns the matcher configuration of the matcher settings, using
+// the matcher defaults for unset thresholds
+func matcherConfig(cfg config.MatcherConfig) matcher.Config {
+	c := matcher.DefaultConfig()
+	if cfg.MinConfidence > 0 {
+		c.MinConfidence = cfg.MinConfidence
+	}
+	if cfg.FuzzyThreshold > 0 {
+		c.FuzzyThreshold = cfg.FuzzyThreshold
+	}
+	return c
+}
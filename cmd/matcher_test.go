@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/matcher"
+)
+
+func TestMatcherConfig(t *testing.T) {
+	if got := matcherConfig(config.MatcherConfig{}); got != matcher.DefaultConfig() {
+		t.Errorf("matcherConfig() of unset thresholds = %+v, want the defaults %+v", got, matcher.DefaultConfig())
+	}
+
+	got := matcherConfig(config.MatcherConfig{MinConfidence: 0.9, FuzzyThreshold: 0.5})
+	if got.MinConfidence != 0.9 || got.FuzzyThreshold != 0.5 {
+		t.Errorf("matcherConfig() = %+v, want min confidence 0.9 and fuzzy threshold 0.5", got)
+	}
+}
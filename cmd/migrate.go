@@ -13,7 +13,11 @@ import (
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Run database migrations",
-	Long:  `Initialize or update database schema to the latest version`,
+	Long: `Apply pending versioned migrations to bring the database schema to the latest version.
+
+Applied migrations are recorded in the schema_migrations table, so running the
+command again is a no-op. Use --down N to roll back the last N migrations and
+--status to list applied and pending migrations.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Load configuration
 		if err := config.Load(); err != nil {
@@ -25,18 +29,74 @@ var migrateCmd = &cobra.Command{
 		// Initialize loggers
 		logger.InitializeLoggers(cfg.GetAppLogLevel(), cfg.GetDatabaseLogLevel())
 
-		fmt.Println("Running database migrations...")
+		down, _ := cmd.Flags().GetInt("down")
+		showStatus, _ := cmd.Flags().GetBool("status")
+
+		if down < 0 {
+			fmt.Fprintln(os.Stderr, "Error: --down must be a positive number of migrations")
+			os.Exit(1)
+		}
 
-		if err := database.Initialize(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing database: %v\n", err)
+		if err := database.Connect(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error connecting to database: %v\n", err)
 			os.Exit(1)
 		}
 		defer database.Close()
 
-		fmt.Println("Database migrations completed successfully")
+		switch {
+		case showStatus:
+			statuses, err := database.Status(database.Get())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error fetching migration status: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("%-8s %-40s %s\n", "VERSION", "NAME", "APPLIED AT")
+			for _, status := range statuses {
+				appliedAt := "pending"
+				if status.AppliedAt != nil {
+					appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				fmt.Printf("%-8d %-40s %s\n", status.Version, status.Name, appliedAt)
+			}
+
+		case down > 0:
+			fmt.Printf("Rolling back %d migration(s)...\n", down)
+
+			reverted, err := database.Rollback(database.Get(), down)
+			for _, m := range reverted {
+				fmt.Printf("  reverted %d (%s)\n", m.Version, m.Name)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error rolling back migrations: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Rolled back %d migration(s)\n", len(reverted))
+
+		default:
+			fmt.Println("Running database migrations...")
+
+			applied, err := database.Migrate(database.Get())
+			for _, m := range applied {
+				fmt.Printf("  applied %d (%s)\n", m.Version, m.Name)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error running migrations: %v\n", err)
+				os.Exit(1)
+			}
+
+			if len(applied) == 0 {
+				fmt.Println("Database schema is up to date")
+			} else {
+				fmt.Println("Database migrations completed successfully")
+			}
+		}
 	},
 }
 
 func init() {
+	migrateCmd.Flags().Int("down", 0, "roll back the last N applied migrations")
+	migrateCmd.Flags().Bool("status", false, "print applied and pending migrations")
 	rootCmd.AddCommand(migrateCmd)
 }
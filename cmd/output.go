@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// Supported values for the --output flag
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// downloadStats summarizes a radarr or sonarr download run
+type downloadStats struct {
+	Total      int  `json:"total"`
+	Matched    int  `json:"matched"`
+	NotFound   int  `json:"not_found"`
+	Downloaded int  `json:"downloaded"`
+	Failed     int  `json:"failed"`
+	Skipped    int  `json:"skipped"`
+	DryRun     bool `json:"dry_run"`
+
+	// Seasons downloaded from a season pack, their episodes are counted as skipped
+	SeasonPacks int `json:"season_packs,omitempty"`
+
+	// Items whose streams all failed the --check-urls check of a dry run
+	Unreachable int `json:"unreachable,omitempty"`
+
+	// Set when the run stopped early after --abort-after-failures consecutive failures
+	Aborted bool `json:"aborted,omitempty"`
+}
+
+// summaryOutput writes the final summary of a command in the format selected with --output.
+type summaryOutput struct {
+	format string
+	stdout *os.File
+}
+
+// newSummaryOutput reads the --output flag of cmd. In JSON mode os.Stdout is redirected
+// to stderr so that progress messages and logs do not mix with the JSON document, which
+// is written to the original stdout. Close restores os.Stdout.
+func newSummaryOutput(cmd *cobra.Command) (*summaryOutput, error) {
+	format, _ := cmd.Flags().GetString("output")
+	out := &summaryOutput{format: format, stdout: os.Stdout}
+
+	switch format {
+	case outputText:
+	case outputJSON:
+		os.Stdout = os.Stderr
+	default:
+		return nil, fmt.Errorf("invalid output format %q (must be one of: %s, %s)", format, outputText, outputJSON)
+	}
+
+	return out, nil
+}
+
+// JSON reports whether the summary must be written as JSON
+func (o *summaryOutput) JSON() bool {
+	return o.format == outputJSON
+}
+
+// WriteJSON writes v as a single JSON object to the original stdout
+func (o *summaryOutput) WriteJSON(v interface{}) error {
+	return writeJSONSummary(o.stdout, v)
+}
+
+// Close restores os.Stdout if it was redirected
+func (o *summaryOutput) Close() {
+	os.Stdout = o.stdout
+}
+
+// writeJSONSummary encodes v as a single JSON object followed by a newline.
+func writeJSONSummary(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// writeSummary writes a command summary as JSON, exiting on failure.
+func writeSummary(out *summaryOutput, summary interface{}) {
+	if err := out.WriteJSON(summary); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing JSON summary: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/processor"
+	"github.com/spf13/cobra"
+)
+
+func TestWriteJSONSummary_ProcessStatistics(t *testing.T) {
+	stats := &processor.Statistics{
+		TotalLines: 10,
+		Processed:  8,
+		Movies:     5,
+		TVShows:    3,
+		Duration:   2 * time.Second,
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSONSummary(&buf, stats); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if got["processed"] != float64(8) {
+		t.Errorf("expected processed=8, got %v", got["processed"])
+	}
+	if got["tvshows"] != float64(3) {
+		t.Errorf("expected tvshows=3, got %v", got["tvshows"])
+	}
+}
+
+func TestNewSummaryOutput_InvalidFormat(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", outputText, "")
+	cmd.Flags().Set("output", "yaml")
+
+	if _, err := newSummaryOutput(cmd); err == nil {
+		t.Error("expected error for unsupported output format")
+	}
+}
+
+func TestNewSummaryOutput_JSONRedirectsStdout(t *testing.T) {
+	original := os.Stdout
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", outputText, "")
+	cmd.Flags().Set("output", outputJSON)
+
+	out, err := newSummaryOutput(cmd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if os.Stdout != os.Stderr {
+		t.Error("expected stdout to be redirected to stderr in JSON mode")
+	}
+
+	out.Close()
+	if os.Stdout != original {
+		t.Error("expected Close to restore stdout")
+	}
+}
+
+func TestWriteSummary_JSONToOriginalStdout(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	original := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = original }()
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("output", outputText, "")
+	cmd.Flags().Set("output", outputJSON)
+	out, err := newSummaryOutput(cmd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	writeSummary(out, &processor.Statistics{TotalLines: 3, Processed: 1, Movies: 1})
+	out.Close()
+	w.Close()
+	os.Stdout = original
+
+	output, _ := io.ReadAll(r)
+	var stats processor.Statistics
+	if err := json.Unmarshal(output, &stats); err != nil {
+		t.Fatalf("stdout is not a single JSON object: %v\n%s", err, output)
+	}
+	if stats.TotalLines != 3 || stats.Processed != 1 {
+		t.Errorf("expected the summary to round-trip, got %+v", stats)
+	}
+}
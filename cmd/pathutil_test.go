@@ -4,11 +4,13 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/downloader"
 )
 
 func TestBuildSonarrDestPath_UseSeriesPath(t *testing.T) {
 	t.Run("primary root folder", func(t *testing.T) {
-		got, fallback := buildSonarrDestPath("/downloads/sonarr/Breaking Bad", "./data/sonarr", "Breaking Bad", 1, 1)
+		got, fallback := buildSonarrDestPath("/downloads/sonarr/Breaking Bad", "./data/sonarr", "Breaking Bad", 1, 1, downloader.DefaultMaxFilenameBytes)
 		if fallback {
 			t.Error("expected no fallback")
 		}
@@ -19,7 +21,7 @@ func TestBuildSonarrDestPath_UseSeriesPath(t *testing.T) {
 	})
 
 	t.Run("secondary root folder (sonarr-bis)", func(t *testing.T) {
-		got, fallback := buildSonarrDestPath("/downloads/sonarr-bis/Malcolm in the Middle", "./data/sonarr", "Malcolm in the Middle", 1, 1)
+		got, fallback := buildSonarrDestPath("/downloads/sonarr-bis/Malcolm in the Middle", "./data/sonarr", "Malcolm in the Middle", 1, 1, downloader.DefaultMaxFilenameBytes)
 		if fallback {
 			t.Error("expected no fallback")
 		}
@@ -33,7 +35,7 @@ func TestBuildSonarrDestPath_UseSeriesPath(t *testing.T) {
 	})
 
 	t.Run("season and episode zero-padding", func(t *testing.T) {
-		got, _ := buildSonarrDestPath("/downloads/sonarr/Show", "./data/sonarr", "Show", 3, 12)
+		got, _ := buildSonarrDestPath("/downloads/sonarr/Show", "./data/sonarr", "Show", 3, 12, downloader.DefaultMaxFilenameBytes)
 		if !strings.HasSuffix(got, "Season 03"+string(filepath.Separator)+"Show - S03E12") {
 			t.Errorf("unexpected path suffix, got %q", got)
 		}
@@ -41,7 +43,7 @@ func TestBuildSonarrDestPath_UseSeriesPath(t *testing.T) {
 }
 
 func TestBuildSonarrDestPath_EmptyPathFallback(t *testing.T) {
-	got, fallback := buildSonarrDestPath("", "./data/sonarr", "My Show", 2, 5)
+	got, fallback := buildSonarrDestPath("", "./data/sonarr", "My Show", 2, 5, downloader.DefaultMaxFilenameBytes)
 	if !fallback {
 		t.Error("expected fallback=true when seriesPath is empty")
 	}
@@ -53,7 +55,7 @@ func TestBuildSonarrDestPath_EmptyPathFallback(t *testing.T) {
 
 func TestBuildRadarrDestPath_UseMoviePath(t *testing.T) {
 	t.Run("primary root folder", func(t *testing.T) {
-		got, fallback := buildRadarrDestPath("/downloads/radarr/The Matrix (1999)", "./data/radarr", "The Matrix", 1999)
+		got, fallback := buildRadarrDestPath("/downloads/radarr/The Matrix (1999)", "./data/radarr", "The Matrix", 1999, downloader.DefaultMaxFilenameBytes)
 		if fallback {
 			t.Error("expected no fallback")
 		}
@@ -64,7 +66,7 @@ func TestBuildRadarrDestPath_UseMoviePath(t *testing.T) {
 	})
 
 	t.Run("secondary root folder (4k)", func(t *testing.T) {
-		got, fallback := buildRadarrDestPath("/downloads/radarr-4k/Inception (2010)", "./data/radarr", "Inception", 2010)
+		got, fallback := buildRadarrDestPath("/downloads/radarr-4k/Inception (2010)", "./data/radarr", "Inception", 2010, downloader.DefaultMaxFilenameBytes)
 		if fallback {
 			t.Error("expected no fallback")
 		}
@@ -76,7 +78,7 @@ func TestBuildRadarrDestPath_UseMoviePath(t *testing.T) {
 }
 
 func TestBuildRadarrDestPath_EmptyPathFallback(t *testing.T) {
-	got, fallback := buildRadarrDestPath("", "./data/radarr", "Dune", 2021)
+	got, fallback := buildRadarrDestPath("", "./data/radarr", "Dune", 2021, downloader.DefaultMaxFilenameBytes)
 	if !fallback {
 		t.Error("expected fallback=true when moviePath is empty")
 	}
@@ -85,3 +87,88 @@ func TestBuildRadarrDestPath_EmptyPathFallback(t *testing.T) {
 		t.Errorf("got %q, want %q", got, want)
 	}
 }
+
+func TestBuildSonarrSeasonPackDestPath(t *testing.T) {
+	got, fallback := buildSonarrSeasonPackDestPath("/downloads/sonarr/Breaking Bad", "./data/sonarr", "Breaking Bad", 2, downloader.DefaultMaxFilenameBytes)
+	if fallback {
+		t.Error("expected no fallback")
+	}
+	want := filepath.Join("/downloads/sonarr/Breaking Bad", "Season 02", "Breaking Bad - S02")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got, fallback = buildSonarrSeasonPackDestPath("", "./data/sonarr", "Breaking Bad", 2, downloader.DefaultMaxFilenameBytes)
+	if !fallback {
+		t.Error("expected fallback when series path is empty")
+	}
+	want = filepath.Join("./data/sonarr", "Breaking Bad", "Season 02", "Breaking Bad - S02")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBuildDestPaths_LongTitle(t *testing.T) {
+	title := strings.Repeat("Very Long Title ", 30)
+
+	movie, _ := buildRadarrDestPath("", "./data/radarr", title, 2021, downloader.DefaultMaxFilenameBytes)
+	episode, _ := buildSonarrDestPath("", "./data/sonarr", title, 1, 2, downloader.DefaultMaxFilenameBytes)
+
+	for _, path := range []string{movie, episode} {
+		for _, component := range strings.Split(filepath.ToSlash(path), "/") {
+			if len(component) > downloader.DefaultMaxFilenameBytes {
+				t.Errorf("component of %d bytes in %q", len(component), path)
+			}
+		}
+	}
+	if !strings.HasSuffix(movie, "… (2021)") {
+		t.Errorf("expected the movie file to keep its year, got %q", filepath.Base(movie))
+	}
+	if !strings.HasSuffix(episode, "… - S01E02") {
+		t.Errorf("expected the episode file to keep its SxxExx marker, got %q", filepath.Base(episode))
+	}
+}
+
+func TestDestPathOverrideFlags(t *testing.T) {
+	t.Run("movies-path", func(t *testing.T) {
+		if err := radarrCmd.Flags().Set("movies-path", "/mnt/external/movies"); err != nil {
+			t.Fatalf("failed to set --movies-path: %v", err)
+		}
+		defer radarrCmd.Flags().Set("movies-path", "")
+
+		override, _ := radarrCmd.Flags().GetString("movies-path")
+		root, base := destRoot("/downloads/radarr/The Matrix (1999)", "./data/radarr", override)
+		got, _ := buildRadarrDestPath(root, base, "The Matrix", 1999, downloader.DefaultMaxFilenameBytes)
+
+		want := filepath.Join("/mnt/external/movies", "The Matrix (1999)", "The Matrix (1999)")
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("tvshows-path", func(t *testing.T) {
+		if err := sonarrCmd.Flags().Set("tvshows-path", "/mnt/external/tv"); err != nil {
+			t.Fatalf("failed to set --tvshows-path: %v", err)
+		}
+		defer sonarrCmd.Flags().Set("tvshows-path", "")
+
+		override, _ := sonarrCmd.Flags().GetString("tvshows-path")
+		root, base := destRoot("/downloads/sonarr/Breaking Bad", "./data/sonarr", override)
+		got, _ := buildSonarrDestPath(root, base, "Breaking Bad", 1, 1, downloader.DefaultMaxFilenameBytes)
+
+		want := filepath.Join("/mnt/external/tv", "Breaking Bad", "Season 01", "Breaking Bad - S01E01")
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("no override", func(t *testing.T) {
+		root, base := destRoot("/downloads/radarr/The Matrix (1999)", "./data/radarr", "")
+		got, _ := buildRadarrDestPath(root, base, "The Matrix", 1999, downloader.DefaultMaxFilenameBytes)
+
+		want := filepath.Join("/downloads/radarr/The Matrix (1999)", "The Matrix (1999)")
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/glefebvre/stalkeer/internal/downloader"
+	"github.com/glefebvre/stalkeer/internal/models"
+)
+
+// urlChecker checks that a stream URL is alive, see downloader.CheckURL
+type urlChecker func(ctx context.Context, url string) downloader.URLCheck
+
+// previewCandidates prints the stream a dry run would download. With a checker the
+// candidates are checked in download order, the way a real run falls back to the next
+// one, and the first reachable one is reported. It returns false when none is reachable.
+func previewCandidates(ctx context.Context, candidates []models.ProcessedLine, check urlChecker) bool {
+	for _, c := range candidates {
+		res := "unknown"
+		if c.Resolution != nil {
+			res = *c.Resolution
+		}
+		url := valueOrEmpty(c.LineURL)
+
+		if check == nil {
+			fmt.Printf("  Would download (%s): %s\n", res, url)
+			return true
+		}
+
+		result := check(ctx, url)
+		if result.Reachable {
+			fmt.Printf("  Would download (%s): %s [reachable, %d]\n", res, url, result.StatusCode)
+			return true
+		}
+		if result.Err != nil {
+			fmt.Printf("  Unreachable (%s): %s [%v]\n", res, url, result.Err)
+		} else {
+			fmt.Printf("  Unreachable (%s): %s [%d]\n", res, url, result.StatusCode)
+		}
+	}
+	return false
+}
+
+// newURLChecker returns the checker of --check-urls, nil when the flag is off
+func newURLChecker(dl *downloader.Downloader, auth downloader.StreamAuth, enabled bool) urlChecker {
+	if !enabled {
+		return nil
+	}
+	return func(ctx context.Context, url string) downloader.URLCheck {
+		return dl.CheckURL(ctx, url, auth)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/downloader"
+	"github.com/glefebvre/stalkeer/internal/models"
+)
+
+func TestPreviewCandidates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dead" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	line := func(path string) models.ProcessedLine {
+		url := server.URL + path
+		return models.ProcessedLine{LineURL: &url}
+	}
+	dl := downloader.New(0, 0)
+	check := newURLChecker(dl, downloader.StreamAuth{}, true)
+
+	tests := []struct {
+		name       string
+		candidates []models.ProcessedLine
+		check      urlChecker
+		want       bool
+	}{
+		{"no check", []models.ProcessedLine{line("/dead")}, nil, true},
+		{"first reachable", []models.ProcessedLine{line("/alive")}, check, true},
+		{"falls back to next candidate", []models.ProcessedLine{line("/dead"), line("/alive")}, check, true},
+		{"none reachable", []models.ProcessedLine{line("/dead")}, check, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := previewCandidates(context.Background(), tt.candidates, tt.check); got != tt.want {
+				t.Errorf("previewCandidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewURLChecker_Disabled(t *testing.T) {
+	if check := newURLChecker(downloader.New(0, 0), downloader.StreamAuth{}, false); check != nil {
+		t.Error("expected no checker when disabled")
+	}
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/glefebvre/stalkeer/internal/config"
 	"github.com/glefebvre/stalkeer/internal/database"
@@ -19,6 +22,13 @@ This command performs full processing including content type detection and metad
 extraction.`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		out, err := newSummaryOutput(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+
 		// Load configuration
 		if err := config.Load(); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
@@ -54,16 +64,28 @@ extraction.`,
 		}
 
 		force, _ := cmd.Flags().GetBool("force")
+		changedOnly, _ := cmd.Flags().GetBool("changed-only")
+		markRemoved, _ := cmd.Flags().GetBool("mark-removed")
 		limit, _ := cmd.Flags().GetInt("limit")
 		batchSize, _ := cmd.Flags().GetInt("batch-size")
 		progress, _ := cmd.Flags().GetInt("progress")
 		skipTMDB, _ := cmd.Flags().GetBool("skip-tmdb")
 		tmdbLanguage, _ := cmd.Flags().GetString("tmdb-language")
+		tmdbParallel, _ := cmd.Flags().GetInt("tmdb-parallel")
+		dedupeByMetadata, _ := cmd.Flags().GetBool("dedupe-by-metadata")
+
+		if force && changedOnly {
+			fmt.Fprintln(os.Stderr, "Error: --force and --changed-only cannot be used together")
+			os.Exit(1)
+		}
 
 		fmt.Printf("Processing M3U file: %s\n", filePath)
 		if force {
 			fmt.Println("Force mode: will re-process existing entries")
 		}
+		if changedOnly {
+			fmt.Println("Changed-only mode: will only process new entries and entries whose URL changed")
+		}
 		if limit > 0 {
 			fmt.Printf("Processing limit: %d entries\n", limit)
 		}
@@ -72,6 +94,16 @@ extraction.`,
 		} else if tmdbLanguage != "" {
 			fmt.Printf("TMDB language: %s\n", tmdbLanguage)
 		}
+		if dedupeByMetadata {
+			if skipTMDB {
+				fmt.Println("Warning: --dedupe-by-metadata has no effect with --skip-tmdb")
+			} else {
+				fmt.Println("Metadata dedupe: keeping the highest resolution entry per TMDB title")
+			}
+		}
+		if !skipTMDB && tmdbParallel > 1 {
+			fmt.Printf("TMDB parallel enrichments: %d\n", tmdbParallel)
+		}
 		fmt.Println()
 
 		// Initialize database
@@ -91,31 +123,64 @@ extraction.`,
 		// Process the file
 		opts := processor.ProcessOptions{
 			Force:            force,
+			ChangedOnly:      changedOnly,
+			MarkRemoved:      markRemoved,
 			Limit:            limit,
 			BatchSize:        batchSize,
 			ProgressInterval: progress,
 			SkipTMDB:         skipTMDB,
 			TMDBLanguage:     tmdbLanguage,
+			TMDBParallel:     tmdbParallel,
+			DedupeByMetadata: dedupeByMetadata,
 		}
 
-		stats, err := proc.Process(opts)
+		// Stop cleanly on SIGINT/SIGTERM, keeping the entries processed so far
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		stats, err := proc.Process(ctx, opts)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error processing file: %v\n", err)
 			os.Exit(1)
 		}
 
+		if out.JSON() {
+			writeSummary(out, stats)
+			return
+		}
+
 		// Display statistics
-		fmt.Printf("\n=== Processing Complete ===\n")
+		if stats.Cancelled {
+			fmt.Printf("\n=== Processing Cancelled (partial results) ===\n")
+		} else {
+			fmt.Printf("\n=== Processing Complete ===\n")
+		}
 		fmt.Printf("Total lines in file:  %d\n", stats.TotalLines)
 		fmt.Printf("Successfully processed: %d\n", stats.Processed)
 		fmt.Printf("Duplicates skipped:   %d\n", stats.DuplicatesFound)
+		if stats.URLDuplicates > 0 {
+			fmt.Printf("URL duplicates:       %d\n", stats.URLDuplicates)
+		}
+		if dedupeByMetadata {
+			fmt.Printf("Metadata duplicates:  %d\n", stats.MetadataDuplicates)
+		}
+		if changedOnly {
+			fmt.Printf("Unchanged skipped:    %d\n", stats.Unchanged)
+			fmt.Printf("Changed (updated):    %d\n", stats.Changed)
+		}
 		fmt.Printf("Filtered out:         %d\n", stats.FilteredOut)
+		if markRemoved {
+			fmt.Printf("Marked removed:       %d\n", stats.Removed)
+		}
 		fmt.Printf("Errors:               %d\n", stats.Errors)
 		fmt.Printf("\nContent breakdown:\n")
 		fmt.Printf("  Movies:        %d\n", stats.Movies)
 		fmt.Printf("  TV Shows:      %d\n", stats.TVShows)
 		fmt.Printf("  Channels:      %d\n", stats.Channels)
 		fmt.Printf("  Uncategorized: %d\n", stats.Uncategorized)
+		if stats.Reclassified > 0 {
+			fmt.Printf("  Reclassified from stream content type: %d\n", stats.Reclassified)
+		}
 
 		if !skipTMDB {
 			fmt.Printf("\nTMDB Enrichment:\n")
@@ -141,16 +206,25 @@ extraction.`,
 			}
 		}
 
+		if stats.Cancelled {
+			fmt.Println("\nProcessing was interrupted, run it again to process the remaining entries.")
+			return
+		}
 		fmt.Println("\nProcessing completed successfully!")
 	},
 }
 
 func init() {
 	processCmd.Flags().Bool("force", false, "re-process existing entries")
+	processCmd.Flags().Bool("changed-only", false, "only process new entries and entries whose URL changed")
+	processCmd.Flags().Bool("mark-removed", false, "mark stored entries absent from the playlist as removed")
 	processCmd.Flags().Int("limit", 0, "maximum number of items to process (0 = no limit)")
 	processCmd.Flags().Int("batch-size", 100, "batch size for database inserts")
 	processCmd.Flags().Int("progress", 1000, "show progress every N entries")
 	processCmd.Flags().Bool("skip-tmdb", false, "skip TMDB metadata enrichment")
 	processCmd.Flags().String("tmdb-language", "", "TMDB API language (e.g., 'en-US', 'fr-FR')")
+	processCmd.Flags().Int("tmdb-parallel", 1, "number of concurrent TMDB enrichments")
+	processCmd.Flags().Bool("dedupe-by-metadata", false, "keep only the highest resolution entry per TMDB movie or episode")
+	processCmd.Flags().String("output", outputText, "summary output format (text, json)")
 	rootCmd.AddCommand(processCmd)
 }
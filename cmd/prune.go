@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/processor"
+	"github.com/spf13/cobra"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete entries no longer present in the playlist",
+	Long: `Delete processed entries that were not found in the playlist by any of the
+last N processing runs. Each 'process' run records when it last saw an entry, so
+channels or titles dropped by the provider can be removed once they have been
+missing for long enough.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		olderThan, _ := cmd.Flags().GetInt("older-than")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if olderThan <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: --older-than must be greater than zero")
+			os.Exit(1)
+		}
+
+		if err := config.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := database.Initialize(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error initializing database: %v\n", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		fmt.Println("=== Prune Stale Entries ===")
+		if dryRun {
+			fmt.Println("Mode: DRY RUN (no entries will be deleted)")
+		}
+		fmt.Printf("Delete entries not seen in the last %d run(s)\n\n", olderThan)
+
+		stats, err := processor.PruneStaleLines(database.Get(), processor.PruneOptions{
+			Runs:   olderThan,
+			DryRun: dryRun,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error during prune: %v\n", err)
+			os.Exit(1)
+		}
+
+		if stats.Cutoff == nil {
+			fmt.Printf("Fewer than %d processing runs recorded, nothing to prune\n", olderThan)
+			return
+		}
+
+		fmt.Printf("Cutoff:  %s\n", stats.Cutoff.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Stale:   %d\n", stats.Matched)
+		if !dryRun {
+			fmt.Printf("Deleted: %d\n", stats.Deleted)
+		}
+	},
+}
+
+func init() {
+	pruneCmd.Flags().Int("older-than", 3, "delete entries not seen in the last N processing runs")
+	pruneCmd.Flags().Bool("dry-run", false, "preview stale entries without deleting them")
+	rootCmd.AddCommand(pruneCmd)
+}
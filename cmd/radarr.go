@@ -10,6 +10,7 @@ import (
 	"github.com/glefebvre/stalkeer/internal/database"
 	"github.com/glefebvre/stalkeer/internal/downloader"
 	"github.com/glefebvre/stalkeer/internal/external/radarr"
+	"github.com/glefebvre/stalkeer/internal/external/tmdb"
 	"github.com/glefebvre/stalkeer/internal/logger"
 	"github.com/glefebvre/stalkeer/internal/matcher"
 	"github.com/glefebvre/stalkeer/internal/models"
@@ -23,11 +24,22 @@ var radarrCmd = &cobra.Command{
 	Long: `Fetch missing movies from Radarr, match them against the local database using TMDB metadata,
 and download matched items from M3U playlist stream URLs.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		out, err := newSummaryOutput(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		checkURLs, _ := cmd.Flags().GetBool("check-urls")
 		limit, _ := cmd.Flags().GetInt("limit")
 		parallel, _ := cmd.Flags().GetInt("parallel")
 		force, _ := cmd.Flags().GetBool("force")
 		verbose, _ := cmd.Flags().GetBool("verbose")
+		genre, _ := cmd.Flags().GetString("genre")
+		abortAfter, _ := cmd.Flags().GetInt("abort-after-failures")
+		moviesPath, _ := cmd.Flags().GetString("movies-path")
 
 		// Load configuration
 		if err := config.Load(); err != nil {
@@ -55,10 +67,16 @@ and download matched items from M3U playlist stream URLs.`,
 			fmt.Println("Mode: DRY RUN (no downloads will occur)")
 		}
 		fmt.Printf("Radarr URL: %s\n", cfg.Radarr.URL)
+		if genre != "" {
+			fmt.Printf("Genre filter: %s\n", genre)
+		}
 		if limit > 0 {
 			fmt.Printf("Limit: %d movies\n", limit)
 		}
 		fmt.Printf("Parallel downloads: %d\n", parallel)
+		if moviesPath != "" {
+			fmt.Printf("Movies path: %s\n", moviesPath)
+		}
 		fmt.Println()
 
 		// Initialize database
@@ -69,7 +87,7 @@ and download matched items from M3U playlist stream URLs.`,
 		defer database.Close()
 
 		// Create Radarr client
-		radarrClient := radarr.New(radarr.Config{
+		radarrClient, err := radarr.New(radarr.Config{
 			BaseURL: cfg.Radarr.URL,
 			APIKey:  cfg.Radarr.APIKey,
 			Timeout: time.Duration(cfg.Downloads.Timeout) * time.Second,
@@ -82,10 +100,25 @@ and download matched items from M3U playlist stream URLs.`,
 				JitterFraction:    0.1,
 			},
 		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating Radarr client: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Wait for Radarr to be up, e.g. when started alongside it
+		ctx := context.Background()
+		if err := radarrClient.WaitUntilReachable(ctx, retry.Config{
+			MaxAttempts:       cfg.Radarr.StartupRetries,
+			InitialBackoff:    time.Duration(cfg.Radarr.StartupBackoff) * time.Second,
+			MaxBackoff:        60 * time.Second,
+			BackoffMultiplier: 2.0,
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error connecting to Radarr: %v\n", err)
+			os.Exit(1)
+		}
 
 		// Fetch missing movies
 		fmt.Println("Fetching missing movies from Radarr...")
-		ctx := context.Background()
 		missingMovies, err := radarrClient.GetMissingMovies(ctx, radarr.FetchOptions{Limit: limit})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error fetching missing movies: %v\n", err)
@@ -94,35 +127,37 @@ and download matched items from M3U playlist stream URLs.`,
 
 		fmt.Printf("Found %d missing movies in Radarr\n\n", len(missingMovies))
 
+		// Match and download
+		stats := downloadStats{
+			Total:  len(missingMovies),
+			DryRun: dryRun,
+		}
+
 		if len(missingMovies) == 0 {
+			if out.JSON() {
+				writeSummary(out, stats)
+				return
+			}
 			fmt.Println("No missing movies to download!")
 			return
 		}
 
-		// Match and download
-		stats := struct {
-			Total      int
-			Matched    int
-			NotFound   int
-			Downloaded int
-			Failed     int
-			Skipped    int
-		}{
-			Total: len(missingMovies),
-		}
-
 		db := database.Get()
-		dl := downloader.New(
-			time.Duration(cfg.Downloads.Timeout)*time.Second,
+		dl := downloader.NewWithTimeouts(
+			downloader.NewTimeouts(cfg.Downloads),
 			cfg.Downloads.RetryAttempts,
 		)
+		defer dl.WaitRefreshes()
+		checker := newURLChecker(dl, downloader.NewStreamAuth(cfg.Downloads), dryRun && checkURLs)
+		breaker := newFailureBreaker(abortAfter)
+		matchCfg := matcherConfig(cfg.Matcher)
 
 		for i, movie := range missingMovies {
 			fmt.Printf("[%d/%d] Processing: %s (%d)\n", i+1, len(missingMovies), movie.Title, movie.Year)
 
 			// Match against database using TVDB ID as primary key, falling back to TMDB ID then fuzzy title/year
 			dbMovie, _, confidence, err := matcher.MatchMovieByTVDB(
-				db, movie.TvdbID, movie.TMDBID, movie.Title, movie.Year,
+				db, movie.TvdbID, movie.TMDBID, movie.Title, movie.Year, matchCfg,
 			)
 
 			if err != nil {
@@ -147,6 +182,15 @@ and download matched items from M3U playlist stream URLs.`,
 				}
 			}
 
+			// Only download content of the requested genre
+			if genre != "" && (dbMovie.TMDBGenres == nil || !tmdb.HasGenre(*dbMovie.TMDBGenres, genre)) {
+				if verbose {
+					fmt.Printf("  Not in genre %q\n", genre)
+				}
+				stats.Skipped++
+				continue
+			}
+
 			// Check if already downloaded (unless force)
 			if !force {
 				var downloadedCount int64
@@ -169,6 +213,7 @@ and download matched items from M3U playlist stream URLs.`,
 				stats.Failed++
 				continue
 			}
+			candidates = matcher.PreferLanguages(candidates, cfg.Downloads.LanguagePriority)
 
 			if len(candidates) == 0 {
 				if verbose {
@@ -179,22 +224,22 @@ and download matched items from M3U playlist stream URLs.`,
 			}
 
 			if dryRun {
-				c := candidates[0]
-				res := "unknown"
-				if c.Resolution != nil {
-					res = *c.Resolution
+				if previewCandidates(ctx, candidates, checker) {
+					stats.Downloaded++
+				} else {
+					stats.Unreachable++
 				}
-				fmt.Printf("  Would download (%s): %s\n", res, valueOrEmpty(c.LineURL))
-				stats.Downloaded++
 				continue
 			}
 
 			// Download - use movie.Path from Radarr as the authoritative root so that
-			// movies assigned to secondary root folders land in the correct directory.
+			// movies assigned to secondary root folders land in the correct directory,
+			// unless --movies-path overrides it.
+			root, base := destRoot(movie.Path, cfg.Downloads.MoviesPath, moviesPath)
 			baseDestPath, usedFallback := buildRadarrDestPath(
-				movie.Path, cfg.Downloads.MoviesPath, movie.Title, movie.Year,
+				root, base, movie.Title, movie.Year, cfg.Downloads.MaxFilenameBytes,
 			)
-			if usedFallback {
+			if usedFallback && moviesPath == "" {
 				fmt.Printf("  Warning: movie.Path is empty for %q, falling back to movies_path\n", movie.Title)
 			}
 
@@ -204,29 +249,46 @@ and download matched items from M3U playlist stream URLs.`,
 					continue
 				}
 
+				// Multi-part streams are downloaded as one file from their first part
+				var partURLs []string
+				if cfg.Downloads.JoinParts {
+					following, err := downloader.HasPrecedingPart(db, &candidate)
+					if err == nil && !following {
+						partURLs, err = downloader.FollowingPartURLs(db, &candidate)
+					}
+					if err != nil {
+						fmt.Printf("  Failed to get multi-part stream: %v\n", err)
+						continue
+					}
+					if following {
+						continue
+					}
+				}
+
 				res := "unknown"
 				if candidate.Resolution != nil {
 					res = *candidate.Resolution
 				}
 				fmt.Printf("  -> attempt %d/%d (%s): %s\n", j+1, len(candidates), res, *candidate.LineURL)
+				if len(partURLs) > 0 {
+					fmt.Printf("     joining %d following part(s)\n", len(partURLs))
+				}
 
 				var lastUpdate time.Time
-				result, dlErr := dl.Download(ctx, downloader.DownloadOptions{
-					URL:             *candidate.LineURL,
-					BaseDestPath:    baseDestPath,
-					TempDir:         cfg.Downloads.TempDir,
-					ProcessedLineID: candidate.ID,
-					OnProgress: func(dlBytes, total int64) {
-						if total > 0 {
-							now := time.Now()
-							if now.Sub(lastUpdate) >= 1*time.Second {
-								pct := float64(dlBytes) / float64(total) * 100
-								fmt.Printf("\r  Progress: %.1f%% (%d / %d bytes)", pct, dlBytes, total)
-								lastUpdate = now
-							}
+				opts := downloader.OptionsFromConfig(cfg, &candidate)
+				opts.BaseDestPath = baseDestPath
+				opts.PartURLs = partURLs
+				opts.OnProgress = func(dlBytes, total int64) {
+					if total > 0 {
+						now := time.Now()
+						if now.Sub(lastUpdate) >= 1*time.Second {
+							pct := float64(dlBytes) / float64(total) * 100
+							fmt.Printf("\r  Progress: %.1f%% (%s / %s)", pct, formatBytes(dlBytes), formatBytes(total))
+							lastUpdate = now
 						}
-					},
-				})
+					}
+				}
+				result, dlErr := dl.Download(ctx, opts)
 
 				if dlErr != nil {
 					fmt.Printf("\n  Download failed: %v\n", dlErr)
@@ -234,7 +296,7 @@ and download matched items from M3U playlist stream URLs.`,
 					continue
 				}
 
-				fmt.Printf("\n  Downloaded: %s (%.2f MB)\n", result.FilePath, float64(result.FileSize)/(1024*1024))
+				fmt.Printf("\n  Downloaded: %s (%s)\n", result.FilePath, formatBytes(result.FileSize))
 				downloaded = true
 				stats.Downloaded++
 				break
@@ -243,6 +305,16 @@ and download matched items from M3U playlist stream URLs.`,
 			if !downloaded {
 				stats.Failed++
 			}
+			if breaker.Record(downloaded) {
+				fmt.Printf("\n%s\n", breaker.Message())
+				stats.Aborted = true
+				break
+			}
+		}
+
+		if out.JSON() {
+			writeSummary(out, stats)
+			return
 		}
 
 		// Print summary
@@ -257,15 +329,26 @@ and download matched items from M3U playlist stream URLs.`,
 		}
 		fmt.Printf("Failed:           %d\n", stats.Failed)
 		fmt.Printf("Skipped:          %d\n", stats.Skipped)
+		if stats.Aborted {
+			fmt.Println("Aborted:          provider appears down")
+		}
+		if checkURLs {
+			fmt.Printf("Unreachable:      %d\n", stats.Unreachable)
+		}
 	},
 }
 
 func init() {
 	radarrCmd.Flags().Bool("dry-run", false, "preview matches without downloading")
+	radarrCmd.Flags().Bool("check-urls", false, "with --dry-run, check that the matched stream URLs are reachable")
 	radarrCmd.Flags().Int("limit", 0, "maximum number of movies to process (0 = no limit)")
 	radarrCmd.Flags().Int("parallel", 0, "number of concurrent downloads")
+	radarrCmd.Flags().String("movies-path", "", "download into this directory instead of the Radarr movie folder and downloads.movies_path")
 	radarrCmd.Flags().Bool("force", false, "re-download existing files")
 	radarrCmd.Flags().BoolP("verbose", "v", false, "verbose output")
+	radarrCmd.Flags().String("genre", "", "only download movies of this TMDB genre")
+	radarrCmd.Flags().Int("abort-after-failures", defaultAbortAfterFailures, "stop after this many consecutive failed downloads (0 = never)")
+	radarrCmd.Flags().String("output", outputText, "summary output format (text, json)")
 	radarrCmd.Flags().Bool("resume", false, "resume incomplete downloads before fetching new items")
 	rootCmd.AddCommand(radarrCmd)
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/glefebvre/stalkeer/internal/classifier"
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/processor"
+	"github.com/spf13/cobra"
+)
+
+var reclassifyCmd = &cobra.Command{
+	Use:   "reclassify",
+	Short: "Re-run classification on stored entries",
+	Long: `Re-run content classification on the stored tvg-name and group-title of every
+entry, without re-parsing the playlist or calling TMDB. Use it after changing the
+classifier group overrides. Entries whose TMDB match no longer fits their new
+classification are unlinked; run 'enrich' afterwards to match them again.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		contentType, _ := cmd.Flags().GetString("content-type")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		switch models.ContentType(contentType) {
+		case "", models.ContentTypeMovies, models.ContentTypeTVShows, models.ContentTypeUncategorized:
+		default:
+			fmt.Fprintf(os.Stderr, "Error: invalid --content-type %q (must be movies, tvshows or uncategorized)\n", contentType)
+			os.Exit(1)
+		}
+
+		if err := config.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		c := classifier.New()
+		if err := c.LoadFromConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading classifier group overrides: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := database.Initialize(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error initializing database: %v\n", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		fmt.Println("=== Reclassify Entries ===")
+		if dryRun {
+			fmt.Println("Mode: DRY RUN (no entries will be updated)")
+		}
+		if contentType != "" {
+			fmt.Printf("Content type: %s\n", contentType)
+		}
+		fmt.Println()
+
+		stats, err := processor.Reclassify(database.Get(), c, processor.ReclassifyOptions{
+			ContentType: models.ContentType(contentType),
+			DryRun:      dryRun,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error during reclassification: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Scanned:      %d\n", stats.Scanned)
+		fmt.Printf("Changed type: %d\n", stats.Changed)
+		types := make([]string, 0, len(stats.ByType))
+		for t := range stats.ByType {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		for _, t := range types {
+			fmt.Printf("  -> %-13s %d\n", t+":", stats.ByType[t])
+		}
+		fmt.Printf("Unlinked:     %d\n", stats.Unlinked)
+		if stats.Unlinked > 0 && !dryRun {
+			fmt.Println("\nRun 'stalkeer enrich' to match the unlinked entries with TMDB again.")
+		}
+	},
+}
+
+func init() {
+	reclassifyCmd.Flags().String("content-type", "", "only reclassify entries of this type: movies, tvshows, uncategorized")
+	reclassifyCmd.Flags().Bool("dry-run", false, "preview changes without updating entries")
+	rootCmd.AddCommand(reclassifyCmd)
+}
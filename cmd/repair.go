@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/downloader"
+	"github.com/spf13/cobra"
+)
+
+var repairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Reconcile inconsistent records",
+	Long: `Reconcile records left inconsistent by interrupted runs or past bugs.
+
+With --downloads, entries linked to a download record that no longer exists are
+unlinked, and completed downloads that no entry points at are linked back to the
+entry that started them, or to the entry whose destination path matches their
+download path. Downloads that cannot be linked back are reported as orphaned, and
+deleted with --delete. Older downloads of an entry that was downloaded again are
+kept.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		downloads, _ := cmd.Flags().GetBool("downloads")
+		deleteOrphans, _ := cmd.Flags().GetBool("delete")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if !downloads {
+			fmt.Fprintln(os.Stderr, "Error: nothing to repair, use --downloads")
+			os.Exit(1)
+		}
+
+		if err := config.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := database.Initialize(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error initializing database: %v\n", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		fmt.Println("=== Repair Downloads ===")
+		if dryRun {
+			fmt.Println("Mode: DRY RUN (no records will be updated)")
+		}
+		fmt.Println()
+
+		cfg := config.Get()
+		stats, err := downloader.RepairDownloads(database.Get(), downloader.RepairOptions{
+			DryRun:           dryRun,
+			Delete:           deleteOrphans,
+			MoviesPath:       cfg.Downloads.MoviesPath,
+			TVShowsPath:      cfg.Downloads.TVShowsPath,
+			MaxFilenameBytes: cfg.Downloads.MaxFilenameBytes,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error during repair: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, link := range stats.Relinked {
+			fmt.Printf("  relinked: download %d -> entry %d (%s)\n", link.DownloadID, link.LineID, link.Path)
+		}
+		for _, id := range stats.Orphaned {
+			fmt.Printf("  orphaned: download %d\n", id)
+		}
+
+		fmt.Printf("Dangling links: %d\n", len(stats.Dangling))
+		fmt.Printf("Relinked:       %d\n", len(stats.Relinked))
+		fmt.Printf("Orphaned:       %d\n", len(stats.Orphaned))
+		if deleteOrphans && !dryRun {
+			fmt.Printf("Deleted:        %d\n", stats.Deleted)
+		} else if !deleteOrphans && len(stats.Orphaned) > 0 {
+			fmt.Println("\nRun with --delete to delete the orphaned downloads.")
+		}
+	},
+}
+
+func init() {
+	repairCmd.Flags().Bool("downloads", false, "reconcile download records and the entries linked to them")
+	repairCmd.Flags().Bool("delete", false, "delete orphaned downloads that cannot be linked back to an entry")
+	repairCmd.Flags().Bool("dry-run", false, "report the changes without updating records")
+	rootCmd.AddCommand(repairCmd)
+}
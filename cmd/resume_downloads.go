@@ -26,6 +26,8 @@ The command will:
 - Clean up stale locks from crashed processes
 - Attempt to resume partial downloads where supported
 - Retry failed downloads (respecting max retry limits)
+- Back off failing downloads: a download retried N times waits
+  --min-retry-interval x 2^(N-1) after its last retry (capped at 24h)
 - Report progress and statistics
 
 Use --dry-run to preview which downloads would be resumed without actually downloading.`,
@@ -35,6 +37,7 @@ Use --dry-run to preview which downloads would be resumed without actually downl
 		limit, _ := cmd.Flags().GetInt("limit")
 		parallel, _ := cmd.Flags().GetInt("parallel")
 		maxRetries, _ := cmd.Flags().GetInt("max-retries")
+		minRetryInterval, _ := cmd.Flags().GetDuration("min-retry-interval")
 		cleanStaleLocks, _ := cmd.Flags().GetBool("clean-stale-locks")
 		verbose, _ := cmd.Flags().GetBool("verbose")
 		service, _ := cmd.Flags().GetString("service")
@@ -73,10 +76,11 @@ Use --dry-run to preview which downloads would be resumed without actually downl
 		})
 
 		// Create downloader and state manager
-		dl := downloader.New(
-			time.Duration(cfg.Downloads.Timeout)*time.Second,
+		dl := downloader.NewWithTimeouts(
+			downloader.NewTimeouts(cfg.Downloads),
 			cfg.Downloads.RetryAttempts,
 		)
+		defer dl.WaitRefreshes()
 		stateManager := dl.GetStateManager()
 
 		// Clean up stale locks if requested
@@ -94,11 +98,12 @@ Use --dry-run to preview which downloads would be resumed without actually downl
 
 		// Build resume options
 		opts := downloader.ResumeOptions{
-			MaxRetries: maxRetries,
-			Limit:      limit,
-			Parallel:   parallel,
-			DryRun:     dryRun,
-			Verbose:    verbose,
+			MaxRetries:       maxRetries,
+			MinRetryInterval: minRetryInterval,
+			Limit:            limit,
+			Parallel:         parallel,
+			DryRun:           dryRun,
+			Verbose:          verbose,
 		}
 
 		// Filter by service if specified
@@ -141,6 +146,7 @@ func init() {
 	resumeDownloadsCmd.Flags().Int("limit", 0, "maximum number of downloads to process (0 = no limit)")
 	resumeDownloadsCmd.Flags().Int("parallel", 0, "number of concurrent downloads")
 	resumeDownloadsCmd.Flags().Int("max-retries", 0, "maximum retry attempts (downloads exceeding this will be skipped)")
+	resumeDownloadsCmd.Flags().Duration("min-retry-interval", 5*time.Minute, "backoff after the first retry of a failing download, doubled with each retry (0 = retry immediately)")
 	resumeDownloadsCmd.Flags().Bool("clean-stale-locks", true, "clean up stale download locks before resuming")
 	resumeDownloadsCmd.Flags().BoolP("verbose", "v", false, "verbose output")
 	resumeDownloadsCmd.Flags().String("service", "all", "filter by service type: all, radarr, sonarr")
@@ -81,7 +81,7 @@ filters, and statistics.`,
 		serverErr := make(chan error, 1)
 		go func() {
 			log.Info(fmt.Sprintf("API server listening on http://%s:%d", address, port))
-			log.Info(fmt.Sprintf("Health check: http://%s:%d/health", address, port))
+			log.Info(fmt.Sprintf("Health check: http://%s:%d/health (probes: /livez, /readyz)", address, port))
 			log.Info(fmt.Sprintf("API base URL: http://%s:%d/api/v1", address, port))
 
 			if err := server.Run(port); err != nil && err != http.ErrServerClosed {
@@ -97,7 +97,8 @@ filters, and statistics.`,
 			}).Error("server error", err)
 			os.Exit(1)
 		case <-time.After(100 * time.Millisecond):
-			// Server started successfully, wait for shutdown signal
+			// Server started successfully, accept traffic and wait for shutdown signal
+			server.SetReady(true)
 			shutdownHandler.Wait()
 		}
 
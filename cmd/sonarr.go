@@ -2,19 +2,24 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/glefebvre/stalkeer/internal/config"
 	"github.com/glefebvre/stalkeer/internal/database"
 	"github.com/glefebvre/stalkeer/internal/downloader"
 	"github.com/glefebvre/stalkeer/internal/external/sonarr"
+	"github.com/glefebvre/stalkeer/internal/external/tmdb"
 	"github.com/glefebvre/stalkeer/internal/logger"
 	"github.com/glefebvre/stalkeer/internal/matcher"
 	"github.com/glefebvre/stalkeer/internal/models"
 	"github.com/glefebvre/stalkeer/internal/retry"
 	"github.com/spf13/cobra"
+	"gorm.io/gorm"
 )
 
 var sonarrCmd = &cobra.Command{
@@ -23,12 +28,31 @@ var sonarrCmd = &cobra.Command{
 	Long: `Fetch missing TV episodes from Sonarr, match them against the local database using TMDB metadata,
 and download matched items from M3U playlist stream URLs.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		out, err := newSummaryOutput(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		checkURLs, _ := cmd.Flags().GetBool("check-urls")
 		limit, _ := cmd.Flags().GetInt("limit")
 		parallel, _ := cmd.Flags().GetInt("parallel")
 		force, _ := cmd.Flags().GetBool("force")
 		verbose, _ := cmd.Flags().GetBool("verbose")
+		genre, _ := cmd.Flags().GetString("genre")
+		abortAfter, _ := cmd.Flags().GetInt("abort-after-failures")
+		tvshowsPath, _ := cmd.Flags().GetString("tvshows-path")
 		seriesID, _ := cmd.Flags().GetInt("series-id")
+		season, _ := cmd.Flags().GetInt("season")
+		episodeRange, _ := cmd.Flags().GetString("episode-range")
+
+		episodeNumbers, err := parseEpisodeRange(episodeRange)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --episode-range: %v\n", err)
+			os.Exit(1)
+		}
 
 		// Load configuration
 		if err := config.Load(); err != nil {
@@ -59,10 +83,22 @@ and download matched items from M3U playlist stream URLs.`,
 		if seriesID > 0 {
 			fmt.Printf("Series ID filter: %d\n", seriesID)
 		}
+		if season >= 0 {
+			fmt.Printf("Season filter: %d\n", season)
+		}
+		if episodeNumbers != nil {
+			fmt.Printf("Episode filter: %s\n", episodeRange)
+		}
+		if genre != "" {
+			fmt.Printf("Genre filter: %s\n", genre)
+		}
 		if limit > 0 {
 			fmt.Printf("Limit: %d episodes\n", limit)
 		}
 		fmt.Printf("Parallel downloads: %d\n", parallel)
+		if tvshowsPath != "" {
+			fmt.Printf("TV shows path: %s\n", tvshowsPath)
+		}
 		fmt.Println()
 
 		// Initialize database
@@ -73,7 +109,7 @@ and download matched items from M3U playlist stream URLs.`,
 		defer database.Close()
 
 		// Create Sonarr client
-		sonarrClient := sonarr.New(sonarr.Config{
+		sonarrClient, err := sonarr.New(sonarr.Config{
 			BaseURL: cfg.Sonarr.URL,
 			APIKey:  cfg.Sonarr.APIKey,
 			Timeout: time.Duration(cfg.Downloads.Timeout) * time.Second,
@@ -86,55 +122,73 @@ and download matched items from M3U playlist stream URLs.`,
 				JitterFraction:    0.1,
 			},
 		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating Sonarr client: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Wait for Sonarr to be up, e.g. when started alongside it
+		ctx := context.Background()
+		if err := sonarrClient.WaitUntilReachable(ctx, retry.Config{
+			MaxAttempts:       cfg.Sonarr.StartupRetries,
+			InitialBackoff:    time.Duration(cfg.Sonarr.StartupBackoff) * time.Second,
+			MaxBackoff:        60 * time.Second,
+			BackoffMultiplier: 2.0,
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error connecting to Sonarr: %v\n", err)
+			os.Exit(1)
+		}
 
 		// Fetch missing episodes
 		fmt.Println("Fetching missing episodes from Sonarr...")
-		ctx := context.Background()
 		missingEpisodes, err := sonarrClient.GetMissingEpisodes(ctx, sonarr.FetchOptions{Limit: limit})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error fetching missing episodes: %v\n", err)
 			os.Exit(1)
 		}
 
-		// Filter by series ID if specified
-		if seriesID > 0 {
-			filtered := make([]sonarr.Episode, 0)
-			for _, ep := range missingEpisodes {
-				if ep.SeriesID == seriesID {
-					filtered = append(filtered, ep)
-				}
-			}
-			missingEpisodes = filtered
-		}
+		// Filter by series ID, season and episode numbers if specified
+		missingEpisodes = filterEpisodes(missingEpisodes, seriesID, season, episodeNumbers)
 
 		fmt.Printf("Found %d missing episodes in Sonarr\n\n", len(missingEpisodes))
 
+		// Match and download
+		stats := downloadStats{
+			Total:  len(missingEpisodes),
+			DryRun: dryRun,
+		}
+
 		if len(missingEpisodes) == 0 {
+			if out.JSON() {
+				writeSummary(out, stats)
+				return
+			}
 			fmt.Println("No missing episodes to download!")
 			return
 		}
 
-		// Match and download
-		stats := struct {
-			Total      int
-			Matched    int
-			NotFound   int
-			Downloaded int
-			Failed     int
-			Skipped    int
-		}{
-			Total: len(missingEpisodes),
-		}
-
 		db := database.Get()
-		dl := downloader.New(
-			time.Duration(cfg.Downloads.Timeout)*time.Second,
+		dl := downloader.NewWithTimeouts(
+			downloader.NewTimeouts(cfg.Downloads),
 			cfg.Downloads.RetryAttempts,
 		)
+		defer dl.WaitRefreshes()
+		checker := newURLChecker(dl, downloader.NewStreamAuth(cfg.Downloads), dryRun && checkURLs)
+		breaker := newFailureBreaker(abortAfter)
+		matchCfg := matcherConfig(cfg.Matcher)
 
 		// We need to fetch series info for each episode
 		seriesCache := make(map[int]*sonarr.Series)
 
+		// Count the missing episodes of each season, so that seasons missing entirely
+		// are downloaded from a season pack when the playlist has one
+		missingBySeason := make(map[seasonKey]int)
+		for _, ep := range missingEpisodes {
+			missingBySeason[seasonKey{ep.SeriesID, ep.SeasonNumber}]++
+		}
+		checkedSeasons := make(map[seasonKey]bool)
+		packSeasons := make(map[seasonKey]bool)
+
 		for i, episode := range missingEpisodes {
 			// Get series info
 			series, ok := seriesCache[episode.SeriesID]
@@ -149,12 +203,48 @@ and download matched items from M3U playlist stream URLs.`,
 				seriesCache[episode.SeriesID] = series
 			}
 
+			key := seasonKey{episode.SeriesID, episode.SeasonNumber}
+			if !checkedSeasons[key] {
+				checkedSeasons[key] = true
+				if matcher.PreferSeasonPack(series, episode.SeasonNumber, missingBySeason[key]) {
+					packSeasons[key] = downloadSeasonPack(ctx, seasonPackDownload{
+						db:               db,
+						dl:               dl,
+						series:           series,
+						season:           episode.SeasonNumber,
+						genre:            genre,
+						tvshowsPath:      cfg.Downloads.TVShowsPath,
+						pathOverride:     tvshowsPath,
+						maxFilenameBytes: cfg.Downloads.MaxFilenameBytes,
+						dryRun:           dryRun,
+						checker:          checker,
+						force:            force,
+						verbose:          verbose,
+						breaker:          breaker,
+					}, &stats)
+					if breaker.Tripped() {
+						fmt.Printf("\n%s\n", breaker.Message())
+						stats.Aborted = true
+						break
+					}
+				}
+			}
+
 			fmt.Printf("[%d/%d] Processing: %s S%02dE%02d - %s\n",
 				i+1, len(missingEpisodes), series.Title, episode.SeasonNumber, episode.EpisodeNumber, episode.Title)
 
+			if packSeasons[key] {
+				if verbose {
+					fmt.Println("  Covered by season pack")
+				}
+				stats.Skipped++
+				continue
+			}
+
 			// Match against database using TVDB ID from Sonarr
 			dbShow, _, confidence, err := matcher.MatchTVShowByTVDB(
 				db, series.TvdbID, 0, series.Title, episode.SeasonNumber, episode.EpisodeNumber,
+				cfg.Matcher.RequireExactEpisode, matchCfg,
 			)
 
 			if err != nil {
@@ -170,6 +260,15 @@ and download matched items from M3U playlist stream URLs.`,
 				dbShow.TMDBTitle, *dbShow.Season, *dbShow.Episode, confidence)
 			stats.Matched++
 
+			// Only download content of the requested genre
+			if genre != "" && (dbShow.TMDBGenres == nil || !tmdb.HasGenre(*dbShow.TMDBGenres, genre)) {
+				if verbose {
+					fmt.Printf("  Not in genre %q\n", genre)
+				}
+				stats.Skipped++
+				continue
+			}
+
 			// Check if already downloaded (unless force)
 			if !force {
 				var downloadedCount int64
@@ -192,6 +291,7 @@ and download matched items from M3U playlist stream URLs.`,
 				stats.Failed++
 				continue
 			}
+			candidates = matcher.PreferLanguages(candidates, cfg.Downloads.LanguagePriority)
 
 			if len(candidates) == 0 {
 				if verbose {
@@ -202,80 +302,42 @@ and download matched items from M3U playlist stream URLs.`,
 			}
 
 			if dryRun {
-				c := candidates[0]
-				res := "unknown"
-				if c.Resolution != nil {
-					res = *c.Resolution
+				if previewCandidates(ctx, candidates, checker) {
+					stats.Downloaded++
+				} else {
+					stats.Unreachable++
 				}
-				fmt.Printf("  Would download (%s): %s\n", res, valueOrEmpty(c.LineURL))
-				stats.Downloaded++
 				continue
 			}
 
 			// Download - use series.Path from Sonarr as the authoritative root so that
-			// series assigned to secondary root folders land in the correct directory.
+			// series assigned to secondary root folders land in the correct directory,
+			// unless --tvshows-path overrides it.
+			root, base := destRoot(series.Path, cfg.Downloads.TVShowsPath, tvshowsPath)
 			baseDestPath, usedFallback := buildSonarrDestPath(
-				series.Path, cfg.Downloads.TVShowsPath, series.Title,
-				episode.SeasonNumber, episode.EpisodeNumber,
+				root, base, series.Title,
+				episode.SeasonNumber, episode.EpisodeNumber, cfg.Downloads.MaxFilenameBytes,
 			)
-			if usedFallback {
+			if usedFallback && tvshowsPath == "" {
 				fmt.Printf("  Warning: series.Path is empty for %q, falling back to tvshows_path\n", series.Title)
 			}
 
-			downloaded := false
-			for j, candidate := range candidates {
-				if candidate.LineURL == nil || *candidate.LineURL == "" {
-					continue
-				}
-
-				res := "unknown"
-				if candidate.Resolution != nil {
-					res = *candidate.Resolution
-				}
-				fmt.Printf("  -> attempt %d/%d (%s): %s\n", j+1, len(candidates), res, *candidate.LineURL)
-
-				var lastUpdate time.Time
-				startTime := time.Now()
-				result, dlErr := dl.Download(ctx, downloader.DownloadOptions{
-					URL:             *candidate.LineURL,
-					BaseDestPath:    baseDestPath,
-					TempDir:         cfg.Downloads.TempDir,
-					ProcessedLineID: candidate.ID,
-					OnProgress: func(dlBytes, total int64) {
-						if total > 0 {
-							now := time.Now()
-							if now.Sub(lastUpdate) >= 1*time.Second {
-								pct := float64(dlBytes) / float64(total) * 100
-								elapsed := now.Sub(startTime)
-								speed := float64(dlBytes) / elapsed.Seconds()
-								remaining := time.Duration(0)
-								if speed > 0 {
-									remaining = time.Duration(float64(total-dlBytes)/speed) * time.Second
-								}
-								fmt.Printf("\r  Progress: %.1f%% - %s / %s - Elapsed: %v - Remaining: %v",
-									pct, formatBytes(dlBytes), formatBytes(total),
-									elapsed.Round(time.Second), remaining.Round(time.Second))
-								lastUpdate = now
-							}
-						}
-					},
-				})
-
-				if dlErr != nil {
-					fmt.Printf("\n  Download failed: %v\n", dlErr)
-					db.Model(&candidate).Update("state", models.StateFailed)
-					continue
-				}
-
-				fmt.Printf("\n  Downloaded: %s (%.2f MB)\n", result.FilePath, float64(result.FileSize)/(1024*1024))
-				downloaded = true
+			downloaded := downloadCandidates(ctx, dl, db, candidates, baseDestPath)
+			if downloaded {
 				stats.Downloaded++
+			} else {
+				stats.Failed++
+			}
+			if breaker.Record(downloaded) {
+				fmt.Printf("\n%s\n", breaker.Message())
+				stats.Aborted = true
 				break
 			}
+		}
 
-			if !downloaded {
-				stats.Failed++
-			}
+		if out.JSON() {
+			writeSummary(out, stats)
+			return
 		}
 
 		// Print summary
@@ -290,16 +352,240 @@ and download matched items from M3U playlist stream URLs.`,
 		}
 		fmt.Printf("Failed:           %d\n", stats.Failed)
 		fmt.Printf("Skipped:          %d\n", stats.Skipped)
+		if stats.Aborted {
+			fmt.Println("Aborted:          provider appears down")
+		}
+		if checkURLs {
+			fmt.Printf("Unreachable:      %d\n", stats.Unreachable)
+		}
+		if stats.SeasonPacks > 0 {
+			fmt.Printf("Season packs:     %d\n", stats.SeasonPacks)
+		}
 	},
 }
 
 func init() {
 	sonarrCmd.Flags().Bool("dry-run", false, "preview matches without downloading")
+	sonarrCmd.Flags().Bool("check-urls", false, "with --dry-run, check that the matched stream URLs are reachable")
 	sonarrCmd.Flags().Int("limit", 0, "maximum number of episodes to process (0 = no limit)")
 	sonarrCmd.Flags().Int("parallel", 0, "number of concurrent downloads")
+	sonarrCmd.Flags().String("tvshows-path", "", "download into this directory instead of the Sonarr series folder and downloads.tvshows_path")
 	sonarrCmd.Flags().Bool("force", false, "re-download existing files")
 	sonarrCmd.Flags().BoolP("verbose", "v", false, "verbose output")
+	sonarrCmd.Flags().String("genre", "", "only download episodes of this TMDB genre")
+	sonarrCmd.Flags().Int("abort-after-failures", defaultAbortAfterFailures, "stop after this many consecutive failed downloads (0 = never)")
+	sonarrCmd.Flags().String("output", outputText, "summary output format (text, json)")
 	sonarrCmd.Flags().Int("series-id", 0, "filter to specific Sonarr series ID")
+	sonarrCmd.Flags().Int("season", -1, "filter to a specific season number (-1 = all seasons)")
+	sonarrCmd.Flags().String("episode-range", "", "filter to episode numbers, e.g. '1-5', '3' or '1,3,5' ('*' = all)")
 	sonarrCmd.Flags().Bool("resume", false, "resume incomplete downloads before fetching new episodes")
 	rootCmd.AddCommand(sonarrCmd)
 }
+
+// parseEpisodeRange parses an episode selection such as "1-5", "3" or "1,3,5" into
+// the set of selected episode numbers. Ranges and single numbers can be combined
+// ("1-3,7"). An empty selection or "*" selects every episode and returns nil.
+func parseEpisodeRange(spec string) (map[int]bool, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "*" {
+		return nil, nil
+	}
+
+	episodes := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := parseEpisodeNumber(first)
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if isRange {
+			if end, err = parseEpisodeNumber(last); err != nil {
+				return nil, err
+			}
+			if end < start {
+				return nil, fmt.Errorf("range %q ends before it starts", part)
+			}
+		}
+
+		for ep := start; ep <= end; ep++ {
+			episodes[ep] = true
+		}
+	}
+	return episodes, nil
+}
+
+func parseEpisodeNumber(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%q is not a valid episode number", s)
+	}
+	return n, nil
+}
+
+// filterEpisodes keeps the episodes of seriesID (0 = all series), season (-1 = all
+// seasons) and episodeNumbers (nil = all episodes)
+func filterEpisodes(episodes []sonarr.Episode, seriesID, season int, episodeNumbers map[int]bool) []sonarr.Episode {
+	filtered := make([]sonarr.Episode, 0, len(episodes))
+	for _, ep := range episodes {
+		if seriesID > 0 && ep.SeriesID != seriesID {
+			continue
+		}
+		if season >= 0 && ep.SeasonNumber != season {
+			continue
+		}
+		if episodeNumbers != nil && !episodeNumbers[ep.EpisodeNumber] {
+			continue
+		}
+		filtered = append(filtered, ep)
+	}
+	return filtered
+}
+
+// seasonKey identifies a season of a Sonarr series
+type seasonKey struct {
+	seriesID int
+	season   int
+}
+
+// seasonPackDownload holds what downloadSeasonPack needs to download a season pack
+type seasonPackDownload struct {
+	db               *gorm.DB
+	dl               *downloader.Downloader
+	series           *sonarr.Series
+	season           int
+	genre            string
+	tvshowsPath      string
+	pathOverride     string // --tvshows-path, replaces series.Path and tvshowsPath when set
+	maxFilenameBytes int
+	dryRun           bool
+	checker          urlChecker // Checks the pack URL in dry-run mode, nil = no check
+	force            bool
+	verbose          bool
+	breaker          *failureBreaker // Records the outcome of an attempted download, nil = not recorded
+}
+
+// downloadSeasonPack downloads a whole season from its season pack entry. It returns
+// true when the season is covered by the pack, i.e. the pack was downloaded (or would
+// be in dry-run mode) or already is, so that its episodes are not downloaded one by one
+// and are counted as skipped. It returns false when the playlist has no usable pack
+// for the season.
+func downloadSeasonPack(ctx context.Context, p seasonPackDownload, stats *downloadStats) bool {
+	pack, err := matcher.FindSeasonPack(p.db, p.series.TvdbID, 0, p.season)
+	if err != nil {
+		if p.verbose && !errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Printf("Failed to look up season pack of %s season %d: %v\n", p.series.Title, p.season, err)
+		}
+		return false
+	}
+
+	// Only download content of the requested genre
+	if p.genre != "" && (pack.TMDBGenres == nil || !tmdb.HasGenre(*pack.TMDBGenres, p.genre)) {
+		return false
+	}
+
+	fmt.Printf("Season pack: %s S%02d\n", p.series.Title, p.season)
+
+	// Check if already downloaded (unless force)
+	if !p.force {
+		var downloadedCount int64
+		p.db.Model(&models.ProcessedLine{}).
+			Where("tv_show_id = ? AND state = ?", pack.ID, models.StateDownloaded).
+			Count(&downloadedCount)
+		if downloadedCount > 0 {
+			if p.verbose {
+				fmt.Println("  Already downloaded (use --force to re-download)")
+			}
+			return true
+		}
+	}
+
+	candidates, err := matcher.FindTVShowDownloadCandidates(p.db, pack.ID)
+	if err == nil {
+		candidates = matcher.PreferLanguages(candidates, config.Get().Downloads.LanguagePriority)
+	}
+	if err != nil || len(candidates) == 0 {
+		if p.verbose {
+			fmt.Println("  No season pack stream available, downloading episodes")
+		}
+		return false
+	}
+
+	if p.dryRun {
+		if !previewCandidates(ctx, candidates, p.checker) {
+			fmt.Println("  No reachable season pack stream, downloading episodes")
+			return false
+		}
+		stats.SeasonPacks++
+		return true
+	}
+
+	root, base := destRoot(p.series.Path, p.tvshowsPath, p.pathOverride)
+	baseDestPath, usedFallback := buildSonarrSeasonPackDestPath(root, base, p.series.Title, p.season, p.maxFilenameBytes)
+	if usedFallback && p.pathOverride == "" {
+		fmt.Printf("  Warning: series.Path is empty for %q, falling back to tvshows_path\n", p.series.Title)
+	}
+
+	downloaded := downloadCandidates(ctx, p.dl, p.db, candidates, baseDestPath)
+	if p.breaker != nil {
+		p.breaker.Record(downloaded)
+	}
+	if !downloaded {
+		fmt.Println("  Season pack download failed, downloading episodes")
+		return false
+	}
+	stats.SeasonPacks++
+	return true
+}
+
+// downloadCandidates downloads the first candidate that succeeds, in order, and marks
+// the candidates that failed. It returns false when every candidate failed.
+func downloadCandidates(ctx context.Context, dl *downloader.Downloader, db *gorm.DB, candidates []models.ProcessedLine, baseDestPath string) bool {
+	for j, candidate := range candidates {
+		if candidate.LineURL == nil || *candidate.LineURL == "" {
+			continue
+		}
+
+		res := "unknown"
+		if candidate.Resolution != nil {
+			res = *candidate.Resolution
+		}
+		fmt.Printf("  -> attempt %d/%d (%s): %s\n", j+1, len(candidates), res, *candidate.LineURL)
+
+		var lastUpdate time.Time
+		startTime := time.Now()
+		opts := downloader.OptionsFromConfig(config.Get(), &candidate)
+		opts.BaseDestPath = baseDestPath
+		opts.OnProgress = func(dlBytes, total int64) {
+			if total > 0 {
+				now := time.Now()
+				if now.Sub(lastUpdate) >= 1*time.Second {
+					pct := float64(dlBytes) / float64(total) * 100
+					elapsed := now.Sub(startTime)
+					speed := float64(dlBytes) / elapsed.Seconds()
+					remaining := time.Duration(0)
+					if speed > 0 {
+						remaining = time.Duration(float64(total-dlBytes)/speed) * time.Second
+					}
+					fmt.Printf("\r  Progress: %.1f%% - %s / %s - Elapsed: %v - Remaining: %v",
+						pct, formatBytes(dlBytes), formatBytes(total),
+						elapsed.Round(time.Second), remaining.Round(time.Second))
+					lastUpdate = now
+				}
+			}
+		}
+		result, dlErr := dl.Download(ctx, opts)
+
+		if dlErr != nil {
+			fmt.Printf("\n  Download failed: %v\n", dlErr)
+			db.Model(&candidate).Update("state", models.StateFailed)
+			continue
+		}
+
+		fmt.Printf("\n  Downloaded: %s (%s)\n", result.FilePath, formatBytes(result.FileSize))
+		return true
+	}
+	return false
+}
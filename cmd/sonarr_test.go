@@ -0,0 +1,100 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/external/sonarr"
+)
+
+func TestParseEpisodeRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []int // nil selects every episode
+		wantErr bool
+	}{
+		{name: "empty selects all", spec: ""},
+		{name: "wildcard selects all", spec: "*"},
+		{name: "single episode", spec: "3", want: []int{3}},
+		{name: "range", spec: "1-5", want: []int{1, 2, 3, 4, 5}},
+		{name: "list", spec: "1,3,5", want: []int{1, 3, 5}},
+		{name: "range and list", spec: "1-3, 7", want: []int{1, 2, 3, 7}},
+		{name: "single episode range", spec: "4-4", want: []int{4}},
+		{name: "not a number", spec: "abc", wantErr: true},
+		{name: "zero", spec: "0", wantErr: true},
+		{name: "reversed range", spec: "5-1", wantErr: true},
+		{name: "open range", spec: "1-", wantErr: true},
+		{name: "negative", spec: "-3", wantErr: true},
+		{name: "empty list element", spec: "1,,3", wantErr: true},
+		{name: "too many dashes", spec: "1-3-5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEpisodeRange(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseEpisodeRange(%q) expected an error, got %v", tt.spec, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseEpisodeRange(%q) unexpected error: %v", tt.spec, err)
+			}
+
+			var want map[int]bool
+			if tt.want != nil {
+				want = make(map[int]bool, len(tt.want))
+				for _, ep := range tt.want {
+					want[ep] = true
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("parseEpisodeRange(%q) = %v, want %v", tt.spec, got, want)
+			}
+		})
+	}
+}
+
+func TestFilterEpisodes(t *testing.T) {
+	episodes := []sonarr.Episode{
+		{ID: 1, SeriesID: 10, SeasonNumber: 1, EpisodeNumber: 1},
+		{ID: 2, SeriesID: 10, SeasonNumber: 2, EpisodeNumber: 1},
+		{ID: 3, SeriesID: 10, SeasonNumber: 2, EpisodeNumber: 4},
+		{ID: 4, SeriesID: 20, SeasonNumber: 2, EpisodeNumber: 2},
+		{ID: 5, SeriesID: 20, SeasonNumber: 0, EpisodeNumber: 1},
+	}
+
+	ids := func(episodes []sonarr.Episode) []int {
+		result := make([]int, len(episodes))
+		for i, ep := range episodes {
+			result[i] = ep.ID
+		}
+		return result
+	}
+
+	tests := []struct {
+		name           string
+		seriesID       int
+		season         int
+		episodeNumbers map[int]bool
+		want           []int
+	}{
+		{"no filter", 0, -1, nil, []int{1, 2, 3, 4, 5}},
+		{"series", 20, -1, nil, []int{4, 5}},
+		{"season", 0, 2, nil, []int{2, 3, 4}},
+		{"specials season", 0, 0, nil, []int{5}},
+		{"episodes", 0, -1, map[int]bool{1: true, 2: true}, []int{1, 2, 4, 5}},
+		{"series, season and episodes", 10, 2, map[int]bool{4: true}, []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(filterEpisodes(episodes, tt.seriesID, tt.season, tt.episodeNumbers))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterEpisodes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
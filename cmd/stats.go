@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/processor"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Manage the item statistics",
+	Long: `Manage the item statistics served by the /api/v1/stats endpoint.
+
+The endpoint reads precomputed counts from the stats_snapshot table, which is
+refreshed at the end of each 'process' run and by 'stats refresh'.`,
+}
+
+var statsRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Recompute the item statistics snapshot",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := database.Initialize(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error initializing database: %v\n", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		stats, err := processor.RefreshStatsSnapshot(database.Get())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error refreshing statistics: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Println("=== Statistics Snapshot ===")
+		fmt.Printf("Refreshed at: %s\n", stats.RefreshedAt.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Total items:  %d\n", stats.TotalItems)
+		printStatsCounts("By content type", stats.ByContentType)
+		printStatsCounts("By state", stats.ByState)
+		printStatsCounts("By resolution", stats.ByResolution)
+	},
+}
+
+// printStatsCounts prints counts sorted by key
+func printStatsCounts(title string, counts map[string]int64) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fmt.Printf("\n%s:\n", title)
+	for _, key := range keys {
+		fmt.Printf("  %-15s %d\n", key, counts[key])
+	}
+}
+
+func init() {
+	statsCmd.AddCommand(statsRefreshCmd)
+	rootCmd.AddCommand(statsCmd)
+}
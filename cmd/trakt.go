@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/downloader"
+	"github.com/glefebvre/stalkeer/internal/external/trakt"
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/glefebvre/stalkeer/internal/matcher"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/retry"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var traktCmd = &cobra.Command{
+	Use:   "trakt",
+	Short: "Download movies and episodes from a Trakt watchlist or list",
+	Long: `Fetch the movies and episodes of a Trakt watchlist or personal list, match them against
+the local database using TMDB metadata, and download matched items from M3U playlist stream URLs.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		out, err := newSummaryOutput(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		checkURLs, _ := cmd.Flags().GetBool("check-urls")
+		limit, _ := cmd.Flags().GetInt("limit")
+		force, _ := cmd.Flags().GetBool("force")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		user, _ := cmd.Flags().GetString("user")
+		list, _ := cmd.Flags().GetString("list")
+
+		// Load configuration
+		if err := config.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+		cfg := config.Get()
+
+		// Override configuration
+		if user == "" {
+			user = cfg.Trakt.Username
+		}
+		if list == "" {
+			list = cfg.Trakt.List
+		}
+
+		// Initialize loggers
+		logger.InitializeLoggers(cfg.GetAppLogLevel(), cfg.GetDatabaseLogLevel())
+
+		// Validate configuration
+		if cfg.Trakt.ClientID == "" || cfg.Trakt.AccessToken == "" {
+			fmt.Fprintln(os.Stderr, "Error: Trakt client ID and access token must be configured")
+			os.Exit(1)
+		}
+
+		fmt.Println("=== Trakt Download Command ===")
+		if dryRun {
+			fmt.Println("Mode: DRY RUN (no downloads will occur)")
+		}
+		fmt.Printf("Trakt list: %s/%s\n", user, list)
+		if limit > 0 {
+			fmt.Printf("Limit: %d items\n", limit)
+		}
+		fmt.Println()
+
+		// Initialize database
+		if err := database.Initialize(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error initializing database: %v\n", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		// Create Trakt client
+		traktClient, err := trakt.New(trakt.Config{
+			BaseURL:     cfg.Trakt.URL,
+			ClientID:    cfg.Trakt.ClientID,
+			AccessToken: cfg.Trakt.AccessToken,
+			Timeout:     time.Duration(cfg.Downloads.Timeout) * time.Second,
+			Logger:      logger.AppLogger(),
+			RetryConfig: retry.Config{
+				MaxAttempts:       cfg.Downloads.RetryAttempts,
+				InitialBackoff:    2 * time.Second,
+				MaxBackoff:        30 * time.Second,
+				BackoffMultiplier: 2.0,
+				JitterFraction:    0.1,
+			},
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating Trakt client: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Fetch list items
+		fmt.Println("Fetching items from Trakt...")
+		ctx := context.Background()
+		items, err := traktClient.GetItems(ctx, user, list)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching Trakt items: %v\n", err)
+			os.Exit(1)
+		}
+		if limit > 0 && len(items) > limit {
+			items = items[:limit]
+		}
+
+		fmt.Printf("Found %d items in Trakt\n\n", len(items))
+
+		// Match and download
+		stats := downloadStats{
+			Total:  len(items),
+			DryRun: dryRun,
+		}
+
+		if len(items) == 0 {
+			if out.JSON() {
+				writeSummary(out, stats)
+				return
+			}
+			fmt.Println("No items to download!")
+			return
+		}
+
+		db := database.Get()
+		dl := downloader.NewWithTimeouts(
+			downloader.NewTimeouts(cfg.Downloads),
+			cfg.Downloads.RetryAttempts,
+		)
+		checker := newURLChecker(dl, downloader.NewStreamAuth(cfg.Downloads), dryRun && checkURLs)
+
+		for i, item := range items {
+			fmt.Printf("[%d/%d] Processing: %s\n", i+1, len(items), traktItemLabel(item))
+
+			candidates, baseDestPath, ok := matchTraktItem(db, item, cfg, force, verbose, &stats)
+			if !ok {
+				continue
+			}
+
+			if len(candidates) == 0 {
+				if verbose {
+					fmt.Println("  No stream URL available")
+				}
+				stats.Skipped++
+				continue
+			}
+
+			if dryRun {
+				if previewCandidates(ctx, candidates, checker) {
+					stats.Downloaded++
+				} else {
+					stats.Unreachable++
+				}
+				continue
+			}
+
+			if downloadCandidates(ctx, dl, db, candidates, baseDestPath) {
+				stats.Downloaded++
+			} else {
+				stats.Failed++
+			}
+		}
+
+		if out.JSON() {
+			writeSummary(out, stats)
+			return
+		}
+
+		// Print summary
+		fmt.Println("\n=== Download Summary ===")
+		fmt.Printf("Total items:      %d\n", stats.Total)
+		fmt.Printf("Matched:          %d\n", stats.Matched)
+		fmt.Printf("Not found:        %d\n", stats.NotFound)
+		if dryRun {
+			fmt.Printf("Would download:   %d\n", stats.Downloaded)
+		} else {
+			fmt.Printf("Downloaded:       %d\n", stats.Downloaded)
+		}
+		fmt.Printf("Failed:           %d\n", stats.Failed)
+		fmt.Printf("Skipped:          %d\n", stats.Skipped)
+		if checkURLs {
+			fmt.Printf("Unreachable:      %d\n", stats.Unreachable)
+		}
+	},
+}
+
+// traktItemLabel describes a Trakt list item for progress output
+func traktItemLabel(item trakt.ListItem) string {
+	switch {
+	case item.Movie != nil:
+		return fmt.Sprintf("%s (%d)", item.Movie.Title, item.Movie.Year)
+	case item.Show != nil && item.Episode != nil:
+		return fmt.Sprintf("%s S%02dE%02d", item.Show.Title, item.Episode.Season, item.Episode.Number)
+	default:
+		return fmt.Sprintf("unsupported %s item", item.Type)
+	}
+}
+
+// matchTraktItem matches a Trakt movie or episode against the database and returns
+// its quality-ordered download candidates and the base destination path. ok is false
+// when the item is skipped, not found or failed; stats are updated accordingly.
+func matchTraktItem(db *gorm.DB, item trakt.ListItem, cfg *config.Config, force, verbose bool, stats *downloadStats) (candidates []models.ProcessedLine, baseDestPath string, ok bool) {
+	var (
+		column   string
+		id       uint
+		findFunc func(*gorm.DB, uint) ([]models.ProcessedLine, error)
+	)
+	matchCfg := matcherConfig(cfg.Matcher)
+
+	switch {
+	case item.Type == trakt.ItemTypeMovie && item.Movie != nil:
+		movie := item.Movie
+		dbMovie, _, confidence, err := matcher.MatchMovieByTMDB(db, movie.IDs.TMDB, movie.Title, movie.Year, matchCfg)
+		if err != nil {
+			if verbose {
+				fmt.Printf("  Not found in database (TMDB ID: %d)\n", movie.IDs.TMDB)
+			}
+			stats.NotFound++
+			return nil, "", false
+		}
+		fmt.Printf("  Matched: %s (%d) - Confidence: %d%%\n", dbMovie.TMDBTitle, dbMovie.TMDBYear, confidence)
+
+		column, id, findFunc = "movie_id", dbMovie.ID, matcher.FindMovieDownloadCandidates
+		baseDestPath, _ = buildRadarrDestPath("", cfg.Downloads.MoviesPath, movie.Title, movie.Year, cfg.Downloads.MaxFilenameBytes)
+
+	case item.Type == trakt.ItemTypeEpisode && item.Show != nil && item.Episode != nil:
+		show, episode := item.Show, item.Episode
+		dbShow, _, confidence, err := matcher.MatchTVShowByTVDB(
+			db, show.IDs.TVDB, show.IDs.TMDB, show.Title, episode.Season, episode.Number,
+			cfg.Matcher.RequireExactEpisode, matchCfg,
+		)
+		if err != nil {
+			if verbose {
+				fmt.Printf("  Not found in database (TMDB ID: %d, S%02dE%02d)\n",
+					show.IDs.TMDB, episode.Season, episode.Number)
+			}
+			stats.NotFound++
+			return nil, "", false
+		}
+		fmt.Printf("  Matched: %s S%02dE%02d - Confidence: %d%%\n",
+			dbShow.TMDBTitle, episode.Season, episode.Number, confidence)
+
+		column, id, findFunc = "tv_show_id", dbShow.ID, matcher.FindTVShowDownloadCandidates
+		baseDestPath, _ = buildSonarrDestPath("", cfg.Downloads.TVShowsPath, show.Title, episode.Season, episode.Number, cfg.Downloads.MaxFilenameBytes)
+
+	default:
+		if verbose {
+			fmt.Printf("  Unsupported item type %q\n", item.Type)
+		}
+		stats.Skipped++
+		return nil, "", false
+	}
+	stats.Matched++
+
+	// Check if already downloaded (unless force)
+	if !force {
+		var downloadedCount int64
+		db.Model(&models.ProcessedLine{}).
+			Where(column+" = ? AND state = ?", id, models.StateDownloaded).
+			Count(&downloadedCount)
+		if downloadedCount > 0 {
+			if verbose {
+				fmt.Println("  Already downloaded (use --force to re-download)")
+			}
+			stats.Skipped++
+			return nil, "", false
+		}
+	}
+
+	candidates, err := findFunc(db, id)
+	if err != nil {
+		fmt.Printf("  Failed to get candidates: %v\n", err)
+		stats.Failed++
+		return nil, "", false
+	}
+	return matcher.PreferLanguages(candidates, cfg.Downloads.LanguagePriority), baseDestPath, true
+}
+
+func init() {
+	traktCmd.Flags().Bool("dry-run", false, "preview matches without downloading")
+	traktCmd.Flags().Bool("check-urls", false, "with --dry-run, check that the matched stream URLs are reachable")
+	traktCmd.Flags().Int("limit", 0, "maximum number of items to process (0 = no limit)")
+	traktCmd.Flags().Bool("force", false, "re-download existing files")
+	traktCmd.Flags().BoolP("verbose", "v", false, "verbose output")
+	traktCmd.Flags().String("user", "", "Trakt user owning the list (default: trakt.username)")
+	traktCmd.Flags().String("list", "", "list slug or ID, 'watchlist' for the watchlist (default: trakt.list)")
+	traktCmd.Flags().String("output", outputText, "summary output format (text, json)")
+	rootCmd.AddCommand(traktCmd)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/downloader"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check that completed downloads still exist on disk",
+	Long: `Check the destination file of every completed download. Downloads whose file
+was deleted out-of-band are moved back to pending and their entries are no longer
+reported as downloaded; run 'resume-downloads' afterwards to fetch them again.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if err := config.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := database.Initialize(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error initializing database: %v\n", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
+		fmt.Println("=== Verify Downloads ===")
+		if dryRun {
+			fmt.Println("Mode: DRY RUN (no downloads will be updated)")
+		}
+		fmt.Println()
+
+		stats, err := downloader.VerifyCompletedDownloads(database.Get(), downloader.VerifyOptions{DryRun: dryRun})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error during verification: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, path := range stats.Missing {
+			fmt.Printf("  missing: %s\n", path)
+		}
+
+		fmt.Printf("Checked:    %d\n", stats.Checked)
+		fmt.Printf("Missing:    %d\n", len(stats.Missing))
+		fmt.Printf("Reconciled: %d\n", stats.Reconciled)
+		if stats.Errors > 0 {
+			fmt.Printf("Errors:     %d\n", stats.Errors)
+		}
+		if stats.Reconciled > 0 {
+			fmt.Println("\nRun 'stalkeer resume-downloads' to download the missing files again.")
+		}
+	},
+}
+
+func init() {
+	verifyCmd.Flags().Bool("dry-run", false, "report missing files without updating downloads")
+	rootCmd.AddCommand(verifyCmd)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -14,6 +15,19 @@ import (
 type Server struct {
 	router     *gin.Engine
 	httpServer *http.Server
+
+	// healthChecks builds the component checks of the detailed health endpoint
+	healthChecks func() []componentCheck
+
+	// readinessChecks builds the component checks of the readiness probe
+	readinessChecks func() []componentCheck
+
+	// ready is set once startup completed and cleared when shutting down
+	ready atomic.Bool
+
+	// processJobs tracks the process runs started from the API, runProcess runs them
+	processJobs *processJobRegistry
+	runProcess  processRunFunc
 }
 
 // NewServer creates a new API server instance
@@ -34,7 +48,11 @@ func NewServer() *Server {
 	router.Use(errorHandlerMiddleware())
 
 	s := &Server{
-		router: router,
+		router:          router,
+		healthChecks:    defaultHealthChecks,
+		readinessChecks: defaultReadinessChecks,
+		processJobs:     newProcessJobRegistry(),
+		runProcess:      runProcess,
 	}
 
 	s.setupRoutes()
@@ -55,8 +73,16 @@ func (s *Server) Run(port int) error {
 	return s.httpServer.ListenAndServe()
 }
 
-// Shutdown gracefully shuts down the server
+// SetReady marks the server as ready, or not, to receive traffic on the readiness probe
+func (s *Server) SetReady(ready bool) {
+	s.ready.Store(ready)
+}
+
+// Shutdown gracefully shuts down the server and cancels the running process job.
+// The readiness probe fails from then on.
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.SetReady(false)
+	s.processJobs.cancelActive()
 	if s.httpServer != nil {
 		return s.httpServer.Shutdown(ctx)
 	}
@@ -66,6 +92,11 @@ func (s *Server) Shutdown(ctx context.Context) error {
 func (s *Server) setupRoutes() {
 	// Health check endpoint
 	s.router.GET("/health", s.healthCheck)
+	s.router.GET("/health/detailed", s.detailedHealthCheck)
+
+	// Kubernetes probes
+	s.router.GET("/livez", s.livenessProbe)
+	s.router.GET("/readyz", s.readinessProbe)
 
 	// API v1 routes
 	v1 := s.router.Group("/api/v1")
@@ -74,11 +105,30 @@ func (s *Server) setupRoutes() {
 		items := v1.Group("/items")
 		{
 			items.GET("", s.listItems)
+			items.DELETE("", s.deleteItems)
 			items.GET("/:id", s.getItem)
 			items.PUT("/:id", s.updateItem)
+			items.DELETE("/:id", s.deleteItem)
+			items.POST("/:id/restore", s.restoreItem)
+			items.POST("/:id/reclassify", s.reclassifyItem)
+			items.POST("/:id/download", s.downloadItem)
+			items.GET("/:id/downloads", s.listItemDownloads)
+			items.POST("/:id/tags", s.addItemTags)
+			items.DELETE("/:id/tags/:tag", s.removeItemTag)
 			items.POST("/search", s.searchItems)
 		}
 
+		// Downloads endpoints
+		downloads := v1.Group("/downloads")
+		{
+			downloads.GET("", s.listDownloads)
+			downloads.GET("/duplicates", s.listDuplicateDownloads)
+			downloads.POST("/enqueue", s.enqueueDownloads)
+			downloads.POST("/verify", s.verifyDownloads)
+			downloads.POST("/:id/retry", s.retryDownload)
+			downloads.PUT("/:id/priority", s.setDownloadPriority)
+		}
+
 		// Movies endpoints
 		movies := v1.Group("/movies")
 		{
@@ -86,13 +136,23 @@ func (s *Server) setupRoutes() {
 			movies.GET("/:id", s.getMovie)
 		}
 
+		// Collections endpoint
+		v1.GET("/collections", s.listCollections)
+
+		// Groups endpoint
+		v1.GET("/groups", s.listGroups)
+
 		// TV shows endpoints
 		tvshows := v1.Group("/tvshows")
 		{
 			tvshows.GET("", s.listTVShows)
 			tvshows.GET("/:id", s.getTVShow)
+			tvshows.GET("/:id/episodes", s.listTVShowEpisodes) // :id is the TMDB ID here
 		}
 
+		// Genres endpoint
+		v1.GET("/genres", s.listGenres)
+
 		// Filter endpoints
 		filters := v1.Group("/filters")
 		{
@@ -106,7 +166,19 @@ func (s *Server) setupRoutes() {
 		// Dry-run endpoint
 		v1.POST("/dryrun", s.executeDryRun)
 
-		// Statistics endpoint
+		// Process endpoints
+		v1.POST("/process", s.startProcess)
+		v1.GET("/process/:jobid", s.getProcessJob)
+
+		// Statistics endpoints
 		v1.GET("/stats", s.getStats)
+		v1.POST("/stats/refresh", s.refreshStats)
+		v1.GET("/stats/classification", s.getClassificationStats)
+		v1.GET("/stats/tmdb", s.getTMDBStats)
+
+		// Export endpoints
+		v1.GET("/export.m3u", s.exportM3U)
+		v1.GET("/movies.csv", s.exportMoviesCSV)
+		v1.GET("/tvshows.csv", s.exportTVShowsCSV)
 	}
 }
@@ -0,0 +1,401 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glefebvre/stalkeer/internal/apperrors"
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/downloader"
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// downloadStatuses lists the statuses accepted by the status filter of listDownloads
+var downloadStatuses = map[string]bool{
+	string(models.DownloadStatusPending):     true,
+	string(models.DownloadStatusDownloading): true,
+	string(models.DownloadStatusPaused):      true,
+	string(models.DownloadStatusCompleted):   true,
+	string(models.DownloadStatusFailed):      true,
+	string(models.DownloadStatusRetrying):    true,
+}
+
+// listDownloads returns a paginated list of download records, most recently updated
+// first, optionally filtered by status
+func (s *Server) listDownloads(c *gin.Context) {
+	db := database.GetRead()
+	limit, offset := parsePagination(c)
+
+	query := db.Model(&models.DownloadInfo{})
+	if status := c.Query("status"); status != "" {
+		if !downloadStatuses[status] {
+			respondError(c, CodeInvalidStatusFilter, fmt.Sprintf("unknown download status %q", status))
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		respondError(c, CodeDBError, "failed to count downloads")
+		return
+	}
+
+	var downloads []models.DownloadInfo
+	if err := query.Preload("ProcessedLines").
+		Order("updated_at DESC").
+		Limit(limit).Offset(offset).
+		Find(&downloads).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch downloads")
+		return
+	}
+
+	responses := make([]DownloadResponse, len(downloads))
+	for i, download := range downloads {
+		responses[i] = toDownloadResponse(download)
+	}
+
+	c.JSON(http.StatusOK, newPaginatedResponse(c, responses, total, limit, offset))
+}
+
+// listItemDownloads returns every download of an item, newest first: the current one
+// and those it superseded when the item was downloaded again
+func (s *Server) listItemDownloads(c *gin.Context) {
+	db := database.GetRead()
+	id := c.Param("id")
+
+	var item models.ProcessedLine
+	if err := db.First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(c, CodeItemNotFound, fmt.Sprintf("item with id %s not found", id))
+			return
+		}
+		respondError(c, CodeDBError, "failed to fetch item")
+		return
+	}
+
+	query := db.Where("processed_line_id = ?", item.ID)
+	if item.DownloadInfoID != nil {
+		query = query.Or("id = ?", *item.DownloadInfoID)
+	}
+
+	var downloads []models.DownloadInfo
+	if err := query.Preload("ProcessedLines").
+		Order("created_at DESC").Order("id DESC").
+		Find(&downloads).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch downloads")
+		return
+	}
+
+	responses := make([]DownloadResponse, len(downloads))
+	for i, download := range downloads {
+		responses[i] = toDownloadResponse(download)
+	}
+
+	c.JSON(http.StatusOK, ItemDownloadsResponse{
+		ItemID:            item.ID,
+		CurrentDownloadID: item.DownloadInfoID,
+		Downloads:         responses,
+	})
+}
+
+// retryDownload resets a failed download to pending and resumes it in the background.
+// The retry counts towards downloads.max_retry_attempts, a download that already
+// reached the limit is rejected.
+func (s *Server) retryDownload(c *gin.Context) {
+	db := database.Get()
+	cfg := config.Get()
+	id := c.Param("id")
+
+	var download models.DownloadInfo
+	if err := db.First(&download, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(c, CodeDownloadNotFound, fmt.Sprintf("download with id %s not found", id))
+			return
+		}
+		respondError(c, CodeDBError, "failed to fetch download")
+		return
+	}
+
+	if download.Status != string(models.DownloadStatusFailed) {
+		respondError(c, CodeDownloadNotFailed, fmt.Sprintf("download with id %s is %s, only failed downloads can be retried", id, download.Status))
+		return
+	}
+
+	maxRetries := cfg.Downloads.MaxRetryAttempts
+	if maxRetries > 0 && download.RetryCount >= maxRetries {
+		respondError(c, CodeMaxRetriesExceeded, fmt.Sprintf("download with id %s was already retried %d times (limit %d)", id, download.RetryCount, maxRetries))
+		return
+	}
+
+	dl := downloader.NewWithTimeouts(
+		downloader.NewTimeouts(cfg.Downloads),
+		cfg.Downloads.RetryAttempts,
+	)
+	stateManager := dl.GetStateManager()
+
+	if err := stateManager.ResetForRetry(c.Request.Context(), download.ID); err != nil {
+		if apperrors.IsValidationError(err) {
+			respondError(c, CodeDownloadNotFailed, fmt.Sprintf("download with id %s is no longer failed", id))
+			return
+		}
+		respondError(c, CodeDBError, "failed to reset download")
+		return
+	}
+
+	// The retry limit was checked above, the reset already counted this attempt
+	go func() {
+		helper := downloader.NewResumeHelper(stateManager, dl)
+		stats, err := helper.ResumeDownloads(context.Background(), downloader.ResumeOptions{
+			DownloadID: download.ID,
+			Parallel:   1,
+		})
+		log := logger.AppLogger()
+		if err != nil {
+			log.WithFields(map[string]interface{}{
+				"download_id": download.ID,
+				"error":       err,
+			}).Warn("API-triggered retry failed")
+			return
+		}
+		log.WithFields(map[string]interface{}{
+			"download_id": download.ID,
+			"resumed":     stats.Resumed,
+			"failed":      stats.Failed,
+			"skipped":     stats.Skipped,
+		}).Info("API-triggered retry finished")
+	}()
+
+	if err := db.Preload("ProcessedLines").First(&download, download.ID).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch download")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toDownloadResponse(download))
+}
+
+// setDownloadPriority sets the priority of a download. Incomplete downloads are
+// resumed by descending priority, so raising it moves a download to the front of
+// the queue.
+func (s *Server) setDownloadPriority(c *gin.Context) {
+	db := database.Get()
+	id := c.Param("id")
+
+	var req SetPriorityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	var download models.DownloadInfo
+	if err := db.First(&download, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(c, CodeDownloadNotFound, fmt.Sprintf("download with id %s not found", id))
+			return
+		}
+		respondError(c, CodeDBError, "failed to fetch download")
+		return
+	}
+
+	if err := db.Model(&download).Update("priority", *req.Priority).Error; err != nil {
+		respondError(c, CodeDBError, "failed to update download priority")
+		return
+	}
+
+	if err := db.Preload("ProcessedLines").First(&download, download.ID).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch download")
+		return
+	}
+
+	c.JSON(http.StatusOK, toDownloadResponse(download))
+}
+
+// enqueueDownloads creates a pending download for each item of a content type that
+// has a stream URL and is neither downloaded nor already queued. The downloads are
+// started by the next resume-downloads run.
+func (s *Server) enqueueDownloads(c *gin.Context) {
+	db := database.Get()
+	cfg := config.Get()
+
+	var req EnqueueDownloadsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, CodeInvalidRequest, err.Error())
+		return
+	}
+	contentType := models.ContentType(req.ContentType)
+	if contentType != models.ContentTypeMovies && contentType != models.ContentTypeTVShows {
+		respondError(c, CodeInvalidRequest, "content_type must be one of: movies, tvshows")
+		return
+	}
+	if req.Limit < 0 {
+		respondError(c, CodeInvalidRequest, "limit must not be negative")
+		return
+	}
+
+	// A line whose current download is not completed is already queued
+	queued := db.Model(&models.DownloadInfo{}).
+		Select("id").
+		Where("status <> ?", string(models.DownloadStatusCompleted))
+
+	var lines []models.ProcessedLine
+	if err := db.Preload("Movie").Preload("TVShow").
+		Where("content_type = ?", contentType).
+		Where("line_url IS NOT NULL AND line_url <> ''").
+		Where("state IN ?", []string{string(models.StateProcessed), string(models.StateFailed)}).
+		Where("download_info_id IS NULL OR download_info_id NOT IN (?)", queued).
+		Order("id").
+		Find(&lines).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch items to enqueue")
+		return
+	}
+
+	resp := EnqueueDownloadsResponse{}
+	for i := range lines {
+		if req.Limit > 0 && resp.Enqueued >= req.Limit {
+			break
+		}
+		line := &lines[i]
+
+		// Lines the resume worker would skip are not queued
+		if _, _, err := downloader.BaseDestPathForLine(cfg.Downloads.MoviesPath, cfg.Downloads.TVShowsPath, cfg.Downloads.MaxFilenameBytes, line); err != nil {
+			resp.Skipped++
+			continue
+		}
+		if cfg.Downloads.JoinParts {
+			following, err := downloader.HasPrecedingPart(db, line)
+			if err != nil {
+				respondError(c, CodeDBError, "failed to fetch multi-part stream")
+				return
+			}
+			if following {
+				resp.Skipped++
+				continue
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			download := models.DownloadInfo{
+				URL:             *line.LineURL,
+				Status:          string(models.DownloadStatusPending),
+				ProcessedLineID: &line.ID,
+			}
+			if err := tx.Create(&download).Error; err != nil {
+				return err
+			}
+			return tx.Model(line).Update("download_info_id", download.ID).Error
+		})
+		if err != nil {
+			respondError(c, CodeDBError, "failed to enqueue download")
+			return
+		}
+		resp.Enqueued++
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// listDuplicateDownloads groups the completed downloads whose files have the same
+// SHA-256, recorded when downloads.compute_hash is enabled
+func (s *Server) listDuplicateDownloads(c *gin.Context) {
+	db := database.GetRead()
+
+	completed := string(models.DownloadStatusCompleted)
+	duplicated := db.Model(&models.DownloadInfo{}).
+		Select("content_hash").
+		Where("status = ? AND content_hash IS NOT NULL", completed).
+		Group("content_hash").
+		Having("COUNT(*) > 1")
+
+	var downloads []models.DownloadInfo
+	if err := db.Preload("ProcessedLines").
+		Where("status = ? AND content_hash IN (?)", completed, duplicated).
+		Order("content_hash, id").
+		Find(&downloads).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch duplicate downloads")
+		return
+	}
+
+	groups := []DuplicateDownloadsResponse{}
+	for _, download := range downloads {
+		if n := len(groups); n == 0 || groups[n-1].Hash != *download.ContentHash {
+			groups = append(groups, DuplicateDownloadsResponse{Hash: *download.ContentHash, FileSize: download.FileSize})
+		}
+		group := &groups[len(groups)-1]
+		group.Downloads = append(group.Downloads, toDownloadResponse(download))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"duplicates": groups})
+}
+
+// verifyDownloads checks that completed downloads still exist on disk and moves
+// those whose file is gone back to pending (?dry_run=true only reports them)
+func (s *Server) verifyDownloads(c *gin.Context) {
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	stats, err := downloader.VerifyCompletedDownloads(database.Get(), downloader.VerifyOptions{DryRun: dryRun})
+	if err != nil {
+		respondError(c, CodeVerifyFailed, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func toDownloadResponse(download models.DownloadInfo) DownloadResponse {
+	resp := DownloadResponse{
+		ID:              download.ID,
+		URL:             download.URL,
+		Status:          download.Status,
+		ItemIDs:         make([]uint, len(download.ProcessedLines)),
+		DownloadPath:    download.DownloadPath,
+		FileSize:        download.FileSize,
+		BytesDownloaded: download.BytesDownloaded,
+		TotalBytes:      download.TotalBytes,
+		SpeedBps:        download.SpeedBps,
+		EtaSeconds:      downloadETA(download),
+		RetryCount:      download.RetryCount,
+		Priority:        download.Priority,
+		LastRetryAt:     formatTime(download.LastRetryAt),
+		ErrorMessage:    download.ErrorMessage,
+		StartedAt:       formatTime(download.StartedAt),
+		CompletedAt:     formatTime(download.CompletedAt),
+		ContentHash:     download.ContentHash,
+		CreatedAt:       download.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:       download.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	for i, line := range download.ProcessedLines {
+		resp.ItemIDs[i] = line.ID
+	}
+	return resp
+}
+
+// downloadETA estimates the seconds left for a running download from its last
+// measured speed, nil when it cannot be estimated
+func downloadETA(download models.DownloadInfo) *int64 {
+	if download.Status != string(models.DownloadStatusDownloading) || download.SpeedBps == nil || *download.SpeedBps <= 0 ||
+		download.BytesDownloaded == nil || download.TotalBytes == nil || *download.TotalBytes <= 0 {
+		return nil
+	}
+	remaining := *download.TotalBytes - *download.BytesDownloaded
+	if remaining < 0 {
+		remaining = 0
+	}
+	eta := (remaining + *download.SpeedBps - 1) / *download.SpeedBps
+	return &eta
+}
+
+// formatTime formats an optional timestamp, nil stays nil
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format("2006-01-02T15:04:05Z07:00")
+	return &formatted
+}
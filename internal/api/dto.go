@@ -1,58 +1,235 @@
 package api
 
-import "github.com/glefebvre/stalkeer/internal/models"
+import (
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/processor"
+)
 
-// ErrorResponse represents an error response
+// ErrorResponse represents an error response. Error is a machine-stable code from
+// the catalog in errors.go, RequestID echoes the X-Request-ID of the request.
 type ErrorResponse struct {
+	Error     ErrorCode `json:"error"`
+	Message   string    `json:"message"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// InvalidPatternsResponse is the error response of a filter whose patterns are not
+// all valid regular expressions
+type InvalidPatternsResponse struct {
+	ErrorResponse
+	InvalidPatterns []InvalidPattern `json:"invalid_patterns"`
+}
+
+// InvalidPattern describes a filter pattern that is rejected
+type InvalidPattern struct {
+	Field   string `json:"field"` // include_patterns or exclude_patterns
+	Pattern string `json:"pattern"`
 	Error   string `json:"error"`
-	Message string `json:"message"`
+}
+
+// DetailedHealthResponse reports the overall health and the status of each component
+type DetailedHealthResponse struct {
+	Status     string            `json:"status"` // healthy, degraded or unhealthy
+	Components []ComponentHealth `json:"components"`
+}
+
+// ComponentHealth represents the health of a single subsystem
+type ComponentHealth struct {
+	Name      string                 `json:"name"`
+	Status    string                 `json:"status"` // healthy, unhealthy or disabled
+	Critical  bool                   `json:"critical"`
+	LatencyMs float64                `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
 }
 
 // PaginatedResponse wraps paginated results with metadata
 type PaginatedResponse struct {
-	Data       interface{} `json:"data"`
-	Total      int64       `json:"total"`
-	Limit      int         `json:"limit"`
-	Offset     int         `json:"offset"`
-	TotalPages int         `json:"total_pages"`
+	Data       interface{}      `json:"data"`
+	Total      int64            `json:"total"`
+	Limit      int              `json:"limit"`
+	Offset     int              `json:"offset"`
+	TotalPages int              `json:"total_pages"`
+	Links      *PaginationLinks `json:"links,omitempty"`
+}
+
+// PaginationLinks are the relative URLs of the neighbouring pages, keeping the other query
+// parameters of the request. Prev and Next are omitted on the first and last page.
+type PaginationLinks struct {
+	First string `json:"first"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last"`
 }
 
 // ItemResponse represents a processed line response
 type ItemResponse struct {
-	ID          uint                   `json:"id"`
-	TvgName     string                 `json:"tvg_name"`
-	GroupTitle  string                 `json:"group_title"`
-	ContentType models.ContentType     `json:"content_type"`
-	State       models.ProcessingState `json:"state"`
-	Season      *int                   `json:"season,omitempty"`
-	Episode     *int                   `json:"episode,omitempty"`
-	Resolution  *string                `json:"resolution,omitempty"`
-	Movie       *MovieResponse         `json:"movie,omitempty"`
-	TVShow      *TVShowResponse        `json:"tvshow,omitempty"`
-	ProcessedAt string                 `json:"processed_at"`
-	CreatedAt   string                 `json:"created_at"`
-	UpdatedAt   string                 `json:"updated_at"`
+	ID              uint                   `json:"id"`
+	TvgName         string                 `json:"tvg_name"`
+	GroupTitle      string                 `json:"group_title"`
+	NormalizedGroup string                 `json:"normalized_group"`
+	ContentType     models.ContentType     `json:"content_type"`
+	State           models.ProcessingState `json:"state"`
+	Season          *int                   `json:"season,omitempty"`
+	Episode         *int                   `json:"episode,omitempty"`
+	Resolution      *string                `json:"resolution,omitempty"`
+	Movie           *MovieResponse         `json:"movie,omitempty"`
+	TVShow          *TVShowResponse        `json:"tvshow,omitempty"`
+	Tags            []string               `json:"tags,omitempty"`
+	ProcessedAt     string                 `json:"processed_at"`
+	CreatedAt       string                 `json:"created_at"`
+	UpdatedAt       string                 `json:"updated_at"`
+	DeletedAt       *string                `json:"deleted_at,omitempty"`
+}
+
+// AddTagsRequest represents the tags to attach to an item
+type AddTagsRequest struct {
+	Tags []string `json:"tags" binding:"required,min=1"`
+}
+
+// SetPriorityRequest represents the new priority of a download
+type SetPriorityRequest struct {
+	Priority *int `json:"priority" binding:"required"`
+}
+
+// EnqueueDownloadsRequest selects the items to queue for download
+type EnqueueDownloadsRequest struct {
+	ContentType string `json:"content_type" binding:"required"` // movies or tvshows
+	Limit       int    `json:"limit"`                           // 0 = every eligible item
+}
+
+// EnqueueDownloadsResponse reports the downloads queued by POST /downloads/enqueue
+type EnqueueDownloadsResponse struct {
+	Enqueued int `json:"enqueued"`
+	Skipped  int `json:"skipped"` // Eligible items that cannot be organized or are following parts
+}
+
+// BatchDeleteResponse represents the result of deleting the items matching filters
+type BatchDeleteResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+// ReclassifyResponse represents the result of reclassifying an item
+type ReclassifyResponse struct {
+	Item                ItemResponse       `json:"item"`
+	PreviousContentType models.ContentType `json:"previous_content_type"`
+	Changed             bool               `json:"changed"`
+}
+
+// DownloadTriggerResponse represents an accepted single-item download request
+type DownloadTriggerResponse struct {
+	ItemID          uint   `json:"item_id"`
+	DownloadInfoID  uint   `json:"download_info_id"`
+	DestinationPath string `json:"destination_path"`
+	Status          string `json:"status"`
+}
+
+// DownloadResponse represents a download record
+type DownloadResponse struct {
+	ID              uint    `json:"id"`
+	URL             string  `json:"url"`
+	Status          string  `json:"status"`
+	ItemIDs         []uint  `json:"item_ids"`
+	DownloadPath    *string `json:"download_path,omitempty"`
+	FileSize        *int64  `json:"file_size,omitempty"`
+	BytesDownloaded *int64  `json:"bytes_downloaded,omitempty"`
+	TotalBytes      *int64  `json:"total_bytes,omitempty"`
+	SpeedBps        *int64  `json:"speed_bps,omitempty"`
+	EtaSeconds      *int64  `json:"eta_seconds,omitempty"`
+	RetryCount      int     `json:"retry_count"`
+	Priority        int     `json:"priority"`
+	LastRetryAt     *string `json:"last_retry_at,omitempty"`
+	ErrorMessage    *string `json:"error_message,omitempty"`
+	StartedAt       *string `json:"started_at,omitempty"`
+	CompletedAt     *string `json:"completed_at,omitempty"`
+	ContentHash     *string `json:"content_hash,omitempty"`
+	CreatedAt       string  `json:"created_at"`
+	UpdatedAt       string  `json:"updated_at"`
+}
+
+// DuplicateDownloadsResponse lists the completed downloads sharing the same file hash
+type DuplicateDownloadsResponse struct {
+	Hash      string             `json:"hash"`
+	FileSize  *int64             `json:"file_size,omitempty"`
+	Downloads []DownloadResponse `json:"downloads"`
+}
+
+// ItemDownloadsResponse lists the download history of an item, newest first
+type ItemDownloadsResponse struct {
+	ItemID            uint               `json:"item_id"`
+	CurrentDownloadID *uint              `json:"current_download_id,omitempty"`
+	Downloads         []DownloadResponse `json:"downloads"`
 }
 
 // MovieResponse represents movie data
 type MovieResponse struct {
-	ID        uint    `json:"id"`
-	TMDBID    int     `json:"tmdb_id"`
-	TMDBTitle string  `json:"tmdb_title"`
-	TMDBYear  int     `json:"tmdb_year"`
-	Genres    *string `json:"genres,omitempty"`
-	Duration  *int    `json:"duration,omitempty"`
+	ID             uint    `json:"id"`
+	TMDBID         int     `json:"tmdb_id"`
+	TMDBTitle      string  `json:"tmdb_title"`
+	TMDBYear       int     `json:"tmdb_year"`
+	Genres         *string `json:"genres,omitempty"`
+	Duration       *int    `json:"duration,omitempty"`
+	CollectionID   *int    `json:"collection_id,omitempty"`
+	CollectionName *string `json:"collection_name,omitempty"`
+}
+
+// CollectionResponse represents a TMDB movie collection and its member count
+type CollectionResponse struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	MovieCount int64  `json:"movie_count"`
+}
+
+// GroupResponse represents the items of a normalized group-title
+type GroupResponse struct {
+	NormalizedGroup string `json:"normalized_group"`
+	ItemCount       int64  `json:"item_count"`
+	GroupTitleCount int64  `json:"group_title_count"` // Distinct raw group-titles normalized to the group
 }
 
 // TVShowResponse represents TV show data
 type TVShowResponse struct {
-	ID        uint    `json:"id"`
-	TMDBID    int     `json:"tmdb_id"`
-	TMDBTitle string  `json:"tmdb_title"`
-	TMDBYear  int     `json:"tmdb_year"`
-	Genres    *string `json:"genres,omitempty"`
-	Season    *int    `json:"season,omitempty"`
-	Episode   *int    `json:"episode,omitempty"`
+	ID           uint    `json:"id"`
+	TMDBID       int     `json:"tmdb_id"`
+	TMDBTitle    string  `json:"tmdb_title"`
+	TMDBYear     int     `json:"tmdb_year"`
+	Genres       *string `json:"genres,omitempty"`
+	Season       *int    `json:"season,omitempty"`
+	Episode      *int    `json:"episode,omitempty"`
+	EpisodeEnd   *int    `json:"episode_end,omitempty"`
+	EpisodeTitle *string `json:"episode_title,omitempty"`
+	AirDate      *string `json:"air_date,omitempty"` // YYYY-MM-DD
+}
+
+// TVShowEpisodesResponse represents the seasons and episodes available for a TV show
+type TVShowEpisodesResponse struct {
+	TMDBID    int              `json:"tmdb_id"`
+	TMDBTitle string           `json:"tmdb_title"`
+	TMDBYear  int              `json:"tmdb_year"`
+	Seasons   []SeasonResponse `json:"seasons"`
+}
+
+// SeasonResponse represents the episodes of a season, and its season pack if any
+type SeasonResponse struct {
+	Season     int               `json:"season"`
+	SeasonPack *Availability     `json:"season_pack,omitempty"`
+	Episodes   []EpisodeResponse `json:"episodes"`
+}
+
+// EpisodeResponse represents an episode and the availability of its streams
+type EpisodeResponse struct {
+	Episode      int     `json:"episode"`
+	EpisodeEnd   *int    `json:"episode_end,omitempty"`
+	EpisodeTitle *string `json:"episode_title,omitempty"`
+	AirDate      *string `json:"air_date,omitempty"` // YYYY-MM-DD
+	Availability
+}
+
+// Availability represents the streams of an episode or season pack
+type Availability struct {
+	Streams    int  `json:"streams"`    // Stored lines, with or without a stream URL
+	Available  bool `json:"available"`  // At least one line has a stream URL
+	Downloaded bool `json:"downloaded"` // At least one line was downloaded
 }
 
 // FilterResponse represents a filter configuration
@@ -75,6 +252,45 @@ type StatsResponse struct {
 	ByState             map[string]int64 `json:"by_state"`
 	TopGroups           []GroupCount     `json:"top_groups"`
 	ProcessingTimestamp string           `json:"processing_timestamp,omitempty"`
+	SnapshotAt          *string          `json:"snapshot_at,omitempty"` // When the counts were computed, absent for live counts
+}
+
+// ConfidenceDistributionResponse represents the classification confidence
+// histogram of each content type
+type ConfidenceDistributionResponse struct {
+	BucketSize    int                           `json:"bucket_size"`
+	ByContentType map[string][]ConfidenceBucket `json:"by_content_type"`
+	Unknown       int64                         `json:"unknown"` // Items classified before their confidence was stored
+}
+
+// ConfidenceBucket represents the number of items within a confidence range
+type ConfidenceBucket struct {
+	Min   int   `json:"min"`
+	Max   int   `json:"max"`
+	Count int64 `json:"count"`
+}
+
+// TMDBMatchStatsResponse represents the TMDB match rate of the last processing runs
+type TMDBMatchStatsResponse struct {
+	Runs        []TMDBRunStats `json:"runs"`         // Oldest first
+	AverageRate float64        `json:"average_rate"` // Match rate of all listed runs together, in percent
+	Change      float64        `json:"change"`       // Match rate of the latest run minus the previous one, in points
+}
+
+// TMDBRunStats represents the TMDB match counts of a processing run
+type TMDBRunStats struct {
+	RunID     uint    `json:"run_id"`
+	StartedAt string  `json:"started_at"`
+	Matched   int     `json:"matched"`
+	NotFound  int     `json:"not_found"`
+	MatchRate float64 `json:"match_rate"` // In percent
+}
+
+// GenreCount represents the number of movies and TV shows of a genre
+type GenreCount struct {
+	Genre   string `json:"genre"`
+	Movies  int64  `json:"movies"`
+	TVShows int64  `json:"tvshows"`
 }
 
 // GroupCount represents group count data
@@ -106,3 +322,16 @@ type UpdateFilterRequest struct {
 	IncludePatterns *string `json:"include_patterns,omitempty"`
 	ExcludePatterns *string `json:"exclude_patterns,omitempty"`
 }
+
+// ProcessJobResponse reports the status and statistics of a process run started
+// from the API. Stats are updated while the job is running.
+type ProcessJobResponse struct {
+	JobID      string               `json:"job_id"`
+	Status     string               `json:"status"`
+	Phase      string               `json:"phase,omitempty"` // Phase of a running job: parsing, processing or finalizing
+	FilePath   string               `json:"file_path"`
+	StartedAt  string               `json:"started_at"`
+	FinishedAt *string              `json:"finished_at,omitempty"`
+	Stats      processor.Statistics `json:"stats"`
+	Error      string               `json:"error,omitempty"`
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/models"
+)
+
+// listTVShowEpisodes returns the seasons and episodes of the TV show with TMDB ID
+// :id. A TV show is stored once per episode, so its rows are grouped by season and
+// episode, with the availability of their streams.
+func (s *Server) listTVShowEpisodes(c *gin.Context) {
+	db := database.GetRead()
+
+	tmdbID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || tmdbID <= 0 {
+		respondError(c, CodeInvalidRequest, "TMDB ID must be a positive integer")
+		return
+	}
+
+	var rows []models.TVShow
+	if err := db.Preload("ProcessedLines").
+		Where("tmdb_id = ?", tmdbID).
+		Order("season, episode, id").
+		Find(&rows).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch TV show episodes")
+		return
+	}
+	if len(rows) == 0 {
+		respondError(c, CodeTVShowNotFound, fmt.Sprintf("TV show with TMDB id %d not found", tmdbID))
+		return
+	}
+
+	c.JSON(http.StatusOK, toTVShowEpisodesResponse(rows))
+}
+
+// toTVShowEpisodesResponse groups the rows of a TV show by season and episode.
+// Rows without a season are left out.
+func toTVShowEpisodesResponse(rows []models.TVShow) TVShowEpisodesResponse {
+	resp := TVShowEpisodesResponse{
+		TMDBID:    rows[0].TMDBID,
+		TMDBTitle: rows[0].TMDBTitle,
+		TMDBYear:  rows[0].TMDBYear,
+		Seasons:   []SeasonResponse{},
+	}
+
+	seasons := make(map[int]*SeasonResponse)
+	episodes := make(map[[2]int]*EpisodeResponse)
+	var seasonNumbers []int
+	for _, row := range rows {
+		if row.Season == nil {
+			continue
+		}
+		season, ok := seasons[*row.Season]
+		if !ok {
+			season = &SeasonResponse{Season: *row.Season}
+			seasons[*row.Season] = season
+			seasonNumbers = append(seasonNumbers, *row.Season)
+		}
+
+		if row.SeasonPack || row.Episode == nil {
+			if season.SeasonPack == nil {
+				season.SeasonPack = &Availability{}
+			}
+			season.SeasonPack.add(row.ProcessedLines)
+			continue
+		}
+
+		key := [2]int{*row.Season, *row.Episode}
+		episode, ok := episodes[key]
+		if !ok {
+			episode = &EpisodeResponse{Episode: *row.Episode}
+			episodes[key] = episode
+		}
+		if episode.EpisodeEnd == nil {
+			episode.EpisodeEnd = row.EpisodeEnd
+		}
+		if episode.EpisodeTitle == nil {
+			episode.EpisodeTitle = row.EpisodeTitle
+		}
+		if episode.AirDate == nil && row.AirDate != nil {
+			airDate := row.AirDate.Format("2006-01-02")
+			episode.AirDate = &airDate
+		}
+		episode.add(row.ProcessedLines)
+	}
+
+	for key, episode := range episodes {
+		season := seasons[key[0]]
+		season.Episodes = append(season.Episodes, *episode)
+	}
+	sort.Ints(seasonNumbers)
+	for _, number := range seasonNumbers {
+		season := seasons[number]
+		if season.Episodes == nil {
+			season.Episodes = []EpisodeResponse{}
+		}
+		sort.Slice(season.Episodes, func(i, j int) bool {
+			return season.Episodes[i].Episode < season.Episodes[j].Episode
+		})
+		resp.Seasons = append(resp.Seasons, *season)
+	}
+	return resp
+}
+
+// add counts lines in the availability, removed lines are not available anymore
+func (a *Availability) add(lines []models.ProcessedLine) {
+	for _, line := range lines {
+		a.Streams++
+		if line.LineURL != nil && *line.LineURL != "" && line.State != models.StateRemoved {
+			a.Available = true
+		}
+		if line.State == models.StateDownloaded {
+			a.Downloaded = true
+		}
+	}
+}
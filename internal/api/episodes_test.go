@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createEpisode(t *testing.T, db *gorm.DB, season, episode int, url *string, state models.ProcessingState) {
+	t.Helper()
+
+	show := models.TVShow{TMDBID: 1396, TMDBTitle: "Breaking Bad", TMDBYear: 2008, Season: &season, Episode: &episode}
+	require.NoError(t, db.Create(&show).Error)
+
+	name := fmt.Sprintf("Breaking Bad S%02dE%02d", season, episode)
+	line := models.ProcessedLine{
+		LineContent: "#EXTINF:-1," + name,
+		LineURL:     url,
+		LineHash:    fmt.Sprintf("hash-%s-%s", name, state),
+		TvgName:     name,
+		GroupTitle:  "Series",
+		ContentType: models.ContentTypeTVShows,
+		TVShowID:    &show.ID,
+		State:       state,
+	}
+	require.NoError(t, db.Create(&line).Error)
+}
+
+func TestListTVShowEpisodes(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	url := "http://example.com/stream.mkv"
+	createEpisode(t, db, 2, 1, &url, models.StateProcessed)
+	createEpisode(t, db, 1, 2, &url, models.StateDownloaded)
+	createEpisode(t, db, 1, 1, &url, models.StateProcessed)
+	createEpisode(t, db, 1, 1, &url, models.StateFailed)
+	createEpisode(t, db, 2, 2, nil, models.StateNoURL)
+
+	other := models.TVShow{TMDBID: 1399, TMDBTitle: "Game of Thrones", TMDBYear: 2011}
+	require.NoError(t, db.Create(&other).Error)
+
+	w := doRequest(server, http.MethodGet, "/api/v1/tvshows/1396/episodes")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp TVShowEpisodesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 1396, resp.TMDBID)
+	assert.Equal(t, "Breaking Bad", resp.TMDBTitle)
+	require.Len(t, resp.Seasons, 2)
+
+	season1 := resp.Seasons[0]
+	assert.Equal(t, 1, season1.Season)
+	require.Len(t, season1.Episodes, 2)
+	assert.Equal(t, 1, season1.Episodes[0].Episode)
+	assert.Equal(t, Availability{Streams: 2, Available: true}, season1.Episodes[0].Availability)
+	assert.Equal(t, 2, season1.Episodes[1].Episode)
+	assert.Equal(t, Availability{Streams: 1, Available: true, Downloaded: true}, season1.Episodes[1].Availability)
+
+	season2 := resp.Seasons[1]
+	assert.Equal(t, 2, season2.Season)
+	require.Len(t, season2.Episodes, 2)
+	assert.Equal(t, Availability{Streams: 1, Available: true}, season2.Episodes[0].Availability)
+	assert.Equal(t, Availability{Streams: 1}, season2.Episodes[1].Availability)
+
+	w = doRequest(server, http.MethodGet, "/api/v1/tvshows/42/episodes")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doRequest(server, http.MethodGet, "/api/v1/tvshows/abc/episodes")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glefebvre/stalkeer/internal/logger"
+)
+
+// ErrorCode is a machine-stable error code returned in the error field of an
+// ErrorResponse. Clients should branch on the code, the message is for humans.
+type ErrorCode string
+
+const (
+	// 400 Bad Request
+	CodeInvalidRequest       ErrorCode = "INVALID_REQUEST"
+	CodeInvalidAttribute     ErrorCode = "INVALID_ATTRIBUTE"
+	CodeInvalidSortField     ErrorCode = "INVALID_SORT_FIELD"
+	CodeInvalidStatusFilter  ErrorCode = "INVALID_STATUS_FILTER"
+	CodeInvalidTimeFilter    ErrorCode = "INVALID_TIME_FILTER"
+	CodeMissingURL           ErrorCode = "MISSING_URL"
+	CodeMissingFilePath      ErrorCode = "MISSING_FILE_PATH"
+	CodeMissingFilter        ErrorCode = "MISSING_FILTER"
+	CodeConfirmationRequired ErrorCode = "CONFIRMATION_REQUIRED"
+	CodeInvalidPattern       ErrorCode = "INVALID_PATTERN"
+
+	// 404 Not Found
+	CodeItemNotFound       ErrorCode = "ITEM_NOT_FOUND"
+	CodeMovieNotFound      ErrorCode = "MOVIE_NOT_FOUND"
+	CodeTVShowNotFound     ErrorCode = "TVSHOW_NOT_FOUND"
+	CodeFilterNotFound     ErrorCode = "FILTER_NOT_FOUND"
+	CodeDownloadNotFound   ErrorCode = "DOWNLOAD_NOT_FOUND"
+	CodeProcessJobNotFound ErrorCode = "PROCESS_JOB_NOT_FOUND"
+	CodeTagNotFound        ErrorCode = "TAG_NOT_FOUND"
+
+	// 409 Conflict
+	CodeDownloadInProgress ErrorCode = "DOWNLOAD_IN_PROGRESS"
+	CodeDownloadNotFailed  ErrorCode = "DOWNLOAD_NOT_FAILED"
+	CodeProcessInProgress  ErrorCode = "PROCESS_IN_PROGRESS"
+
+	// 413 Request Entity Too Large
+	CodeFileTooLarge ErrorCode = "FILE_TOO_LARGE"
+
+	// 422 Unprocessable Entity
+	CodeMissingMetadata    ErrorCode = "MISSING_METADATA"
+	CodeNotFirstPart       ErrorCode = "NOT_FIRST_PART"
+	CodeMaxRetriesExceeded ErrorCode = "MAX_RETRIES_EXCEEDED"
+
+	// 500 Internal Server Error
+	CodeDBError         ErrorCode = "DB_ERROR"
+	CodeClassifierError ErrorCode = "CLASSIFIER_ERROR"
+	CodeDryRunFailed    ErrorCode = "DRYRUN_FAILED"
+	CodeVerifyFailed    ErrorCode = "VERIFY_FAILED"
+	CodeInternalError   ErrorCode = "INTERNAL_ERROR"
+
+	// 504 Gateway Timeout
+	CodeDryRunTimeout ErrorCode = "DRYRUN_TIMEOUT"
+)
+
+// errorStatus maps each error code to the HTTP status it is returned with
+var errorStatus = map[ErrorCode]int{
+	CodeInvalidRequest:       http.StatusBadRequest,
+	CodeInvalidAttribute:     http.StatusBadRequest,
+	CodeInvalidSortField:     http.StatusBadRequest,
+	CodeInvalidStatusFilter:  http.StatusBadRequest,
+	CodeInvalidTimeFilter:    http.StatusBadRequest,
+	CodeMissingURL:           http.StatusBadRequest,
+	CodeMissingFilePath:      http.StatusBadRequest,
+	CodeMissingFilter:        http.StatusBadRequest,
+	CodeConfirmationRequired: http.StatusBadRequest,
+	CodeInvalidPattern:       http.StatusBadRequest,
+
+	CodeItemNotFound:       http.StatusNotFound,
+	CodeMovieNotFound:      http.StatusNotFound,
+	CodeTVShowNotFound:     http.StatusNotFound,
+	CodeFilterNotFound:     http.StatusNotFound,
+	CodeDownloadNotFound:   http.StatusNotFound,
+	CodeProcessJobNotFound: http.StatusNotFound,
+	CodeTagNotFound:        http.StatusNotFound,
+
+	CodeDownloadInProgress: http.StatusConflict,
+	CodeDownloadNotFailed:  http.StatusConflict,
+	CodeProcessInProgress:  http.StatusConflict,
+
+	CodeFileTooLarge: http.StatusRequestEntityTooLarge,
+
+	CodeMissingMetadata:    http.StatusUnprocessableEntity,
+	CodeNotFirstPart:       http.StatusUnprocessableEntity,
+	CodeMaxRetriesExceeded: http.StatusUnprocessableEntity,
+
+	CodeDBError:         http.StatusInternalServerError,
+	CodeClassifierError: http.StatusInternalServerError,
+	CodeDryRunFailed:    http.StatusInternalServerError,
+	CodeVerifyFailed:    http.StatusInternalServerError,
+	CodeInternalError:   http.StatusInternalServerError,
+
+	CodeDryRunTimeout: http.StatusGatewayTimeout,
+}
+
+// Status returns the HTTP status of the code, 500 for unknown codes
+func (code ErrorCode) Status() int {
+	if status, ok := errorStatus[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// respondError writes an ErrorResponse with the status of code and the request ID
+func respondError(c *gin.Context, code ErrorCode, message string) {
+	c.JSON(code.Status(), ErrorResponse{
+		Error:     code,
+		Message:   message,
+		RequestID: c.GetString("request_id"),
+	})
+}
+
+// respondDBError logs err with the request ID and responds with a DB_ERROR carrying
+// the fixed message, so that database details are not leaked to the client
+func respondDBError(c *gin.Context, message string, err error) {
+	logger.AppLogger().WithFields(map[string]interface{}{
+		"request_id": c.GetString("request_id"),
+		"path":       c.FullPath(),
+	}).Error(message, err)
+	respondError(c, CodeDBError, message)
+}
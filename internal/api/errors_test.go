@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return resp
+}
+
+func TestErrorCodes_NotFound(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	tests := []struct {
+		method string
+		path   string
+		code   ErrorCode
+	}{
+		{http.MethodGet, "/api/v1/items/999", CodeItemNotFound},
+		{http.MethodPost, "/api/v1/items/999/download", CodeItemNotFound},
+		{http.MethodGet, "/api/v1/movies/999", CodeMovieNotFound},
+		{http.MethodGet, "/api/v1/tvshows/999", CodeTVShowNotFound},
+		{http.MethodPost, "/api/v1/downloads/999/retry", CodeDownloadNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := doRequest(server, tt.method, tt.path)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Equal(t, tt.code, decodeError(t, w).Error)
+		})
+	}
+}
+
+func TestErrorCodes_Validation(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	tests := []struct {
+		path string
+		code ErrorCode
+	}{
+		{"/api/v1/items?sort=password", CodeInvalidSortField},
+		{"/api/v1/items?order=random", CodeInvalidSortField},
+		{"/api/v1/movies?sort=tvg_name", CodeInvalidSortField},
+		{"/api/v1/items?created_after=yesterday", CodeInvalidTimeFilter},
+		{"/api/v1/downloads?status=broken", CodeInvalidStatusFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := doRequest(server, http.MethodGet, tt.path)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, tt.code, decodeError(t, w).Error)
+		})
+	}
+}
+
+func TestErrorResponse_EchoesRequestID(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/items/999", nil)
+	req.Header.Set("X-Request-ID", "req-1234")
+	server.router.ServeHTTP(w, req)
+
+	resp := decodeError(t, w)
+	assert.Equal(t, "req-1234", resp.RequestID)
+	assert.Equal(t, "req-1234", w.Header().Get("X-Request-ID"))
+
+	// A generated request ID is echoed as well
+	w = doRequest(server, http.MethodGet, "/api/v1/items/999")
+	assert.NotEmpty(t, decodeError(t, w).RequestID)
+	assert.Equal(t, w.Header().Get("X-Request-ID"), decodeError(t, w).RequestID)
+}
+
+func TestErrorCodes_DBErrorHidesDetails(t *testing.T) {
+	server, db := setupTestServer(t)
+	require.NoError(t, db.Migrator().DropTable(&models.StatsSnapshot{}))
+
+	w := doRequest(server, http.MethodGet, "/api/v1/stats")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	resp := decodeError(t, w)
+	assert.Equal(t, CodeDBError, resp.Error)
+	assert.Equal(t, "failed to load stats", resp.Message)
+	assert.NotEmpty(t, resp.RequestID)
+}
+
+func TestErrorCode_Status(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, CodeItemNotFound.Status())
+	assert.Equal(t, http.StatusBadRequest, CodeInvalidSortField.Status())
+	assert.Equal(t, http.StatusConflict, CodeDownloadInProgress.Status())
+	assert.Equal(t, http.StatusInternalServerError, CodeDBError.Status())
+	assert.Equal(t, http.StatusInternalServerError, ErrorCode("SOMETHING_ELSE").Status())
+}
@@ -0,0 +1,271 @@
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// exportBatchSize is the number of rows loaded at a time while streaming exports
+const exportBatchSize = 500
+
+// m3uAttrReplacer removes characters that would break an EXTINF attribute or line
+var m3uAttrReplacer = strings.NewReplacer(`"`, "'", "\r", " ", "\n", " ")
+
+// Values of the group_by parameter of the M3U export, selecting the group-title
+// written for each entry
+const (
+	exportGroupByOriginal    = "original"     // group-title read from the playlist
+	exportGroupByContentType = "content_type" // resolved content type, e.g. movies
+	exportGroupByNormalized  = "normalized"   // normalized group-title
+)
+
+// groupTitleAttrRegex matches the group-title attribute of an EXTINF line
+var groupTitleAttrRegex = regexp.MustCompile(`group-title="[^"]*"`)
+
+// exportM3U streams a playlist built from the stored items, honoring the listItems filters
+func (s *Server) exportM3U(c *gin.Context) {
+	db := database.GetRead()
+
+	groupBy := c.Query("group_by")
+	if groupBy == "" {
+		groupBy = exportGroupByOriginal
+	}
+	switch groupBy {
+	case exportGroupByOriginal, exportGroupByContentType, exportGroupByNormalized:
+	default:
+		respondError(c, CodeInvalidRequest, fmt.Sprintf("invalid group_by %q (must be one of: %s, %s, %s)",
+			groupBy, exportGroupByOriginal, exportGroupByContentType, exportGroupByNormalized))
+		return
+	}
+
+	query := applyItemFilters(c, db.Model(&models.ProcessedLine{})).
+		Where("line_url IS NOT NULL AND line_url <> ''").
+		Order("id")
+
+	c.Header("Content-Type", "audio/x-mpegurl; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="export.m3u"`)
+	c.Status(http.StatusOK)
+
+	w := bufio.NewWriter(c.Writer)
+	w.WriteString("#EXTM3U\n")
+
+	var batch []models.ProcessedLine
+	result := query.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, line := range batch {
+			writeM3UEntry(w, line, groupBy)
+		}
+		return w.Flush()
+	})
+	if result.Error != nil {
+		// Headers are already sent, so the error can only be logged
+		logger.AppLogger().Error("failed to export M3U playlist", result.Error)
+	}
+
+	w.Flush()
+}
+
+// writeM3UEntry writes the EXTINF and URL lines of a stored item. The EXTINF line
+// stored by the parser is written as is, apart from its group-title when groupBy
+// is not "original". It is rebuilt from the tvg-name and group-title when the
+// item has none.
+func writeM3UEntry(w *bufio.Writer, line models.ProcessedLine, groupBy string) {
+	extinf, _, _ := strings.Cut(line.LineContent, "\n")
+	extinf = strings.TrimSpace(extinf)
+	group := m3uAttrReplacer.Replace(exportGroupTitle(line, groupBy))
+	if strings.HasPrefix(extinf, "#EXTINF:") {
+		if groupBy != exportGroupByOriginal {
+			extinf = setGroupTitleAttr(extinf, group)
+		}
+		fmt.Fprintf(w, "%s\n", extinf)
+	} else {
+		name := m3uAttrReplacer.Replace(line.TvgName)
+		fmt.Fprintf(w, "#EXTINF:-1 tvg-name=\"%s\" group-title=\"%s\",%s\n", name, group, name)
+	}
+	fmt.Fprintf(w, "%s\n", strings.TrimSpace(*line.LineURL))
+}
+
+// exportGroupTitle returns the group-title of line for groupBy. Items without a
+// normalized group keep their original group-title.
+func exportGroupTitle(line models.ProcessedLine, groupBy string) string {
+	switch groupBy {
+	case exportGroupByContentType:
+		return string(line.ContentType)
+	case exportGroupByNormalized:
+		if line.NormalizedGroup != "" {
+			return line.NormalizedGroup
+		}
+	}
+	return line.GroupTitle
+}
+
+// setGroupTitleAttr sets the group-title attribute of an EXTINF line, adding it
+// before the title when the line has none
+func setGroupTitleAttr(extinf, group string) string {
+	attr := `group-title="` + group + `"`
+	if groupTitleAttrRegex.MatchString(extinf) {
+		return groupTitleAttrRegex.ReplaceAllLiteralString(extinf, attr)
+	}
+	if i := titleCommaIndex(extinf); i != -1 {
+		return extinf[:i] + " " + attr + extinf[i:]
+	}
+	return extinf + " " + attr
+}
+
+// titleCommaIndex returns the index of the comma that starts the title of an EXTINF
+// line, i.e. the first one outside the quoted attribute values, or -1
+func titleCommaIndex(extinf string) int {
+	quoted := false
+	for i, r := range extinf {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			return i
+		}
+	}
+	return -1
+}
+
+// exportStatePriority orders item states from most to least significant when
+// summarizing the lines of a movie or episode in a single CSV row
+var exportStatePriority = []models.ProcessingState{
+	models.StateDownloaded,
+	models.StateDownloading,
+	models.StateOrganizing,
+	models.StateFailed,
+	models.StateProcessed,
+	models.StatePending,
+	models.StateRemoved,
+}
+
+// exportMoviesCSV streams the movies as CSV
+func (s *Server) exportMoviesCSV(c *gin.Context) {
+	db := database.GetRead()
+	query := db.Model(&models.Movie{}).Preload("ProcessedLines").Order("id")
+
+	w := startCSVExport(c, "movies.csv", []string{"title", "year", "genres", "resolution", "state"})
+	err := findForExport(c, query, func(movies []models.Movie) error {
+		for _, movie := range movies {
+			resolution, state := summarizeLines(movie.ProcessedLines)
+			w.Write([]string{
+				movie.TMDBTitle,
+				strconv.Itoa(movie.TMDBYear),
+				valueOrEmpty(movie.TMDBGenres),
+				resolution,
+				state,
+			})
+		}
+		w.Flush()
+		return w.Error()
+	})
+	if err != nil {
+		logger.AppLogger().Error("failed to export movies CSV", err)
+	}
+}
+
+// exportTVShowsCSV streams the TV show episodes as CSV
+func (s *Server) exportTVShowsCSV(c *gin.Context) {
+	db := database.GetRead()
+	query := db.Model(&models.TVShow{}).Preload("ProcessedLines").Order("id")
+
+	w := startCSVExport(c, "tvshows.csv", []string{"title", "year", "season", "episode", "genres", "resolution", "state"})
+	err := findForExport(c, query, func(shows []models.TVShow) error {
+		for _, show := range shows {
+			resolution, state := summarizeLines(show.ProcessedLines)
+			w.Write([]string{
+				show.TMDBTitle,
+				strconv.Itoa(show.TMDBYear),
+				intOrEmpty(show.Season),
+				intOrEmpty(show.Episode),
+				valueOrEmpty(show.TMDBGenres),
+				resolution,
+				state,
+			})
+		}
+		w.Flush()
+		return w.Error()
+	})
+	if err != nil {
+		logger.AppLogger().Error("failed to export TV shows CSV", err)
+	}
+}
+
+// startCSVExport sets the download headers and writes the CSV header row
+func startCSVExport(c *gin.Context, filename string, header []string) *csv.Writer {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(header)
+	return w
+}
+
+// findForExport loads the rows of query and passes them to fn. When limit or offset
+// is given only that page is exported, otherwise all rows are loaded in batches.
+func findForExport[T any](c *gin.Context, query *gorm.DB, fn func([]T) error) error {
+	if c.Query("limit") != "" || c.Query("offset") != "" {
+		limit, offset := parsePagination(c)
+		var rows []T
+		if err := query.Limit(limit).Offset(offset).Find(&rows).Error; err != nil {
+			return err
+		}
+		return fn(rows)
+	}
+
+	var batch []T
+	return query.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// summarizeLines returns the distinct resolutions and the most significant state of lines
+func summarizeLines(lines []models.ProcessedLine) (string, string) {
+	seen := make(map[string]bool)
+	var resolutions []string
+	states := make(map[models.ProcessingState]bool)
+	for _, line := range lines {
+		if line.Resolution != nil && *line.Resolution != "" && !seen[*line.Resolution] {
+			seen[*line.Resolution] = true
+			resolutions = append(resolutions, *line.Resolution)
+		}
+		states[line.State] = true
+	}
+	sort.Strings(resolutions)
+
+	state := ""
+	for _, candidate := range exportStatePriority {
+		if states[candidate] {
+			state = string(candidate)
+			break
+		}
+	}
+
+	return strings.Join(resolutions, ", "), state
+}
+
+func valueOrEmpty(ptr *string) string {
+	if ptr == nil {
+		return ""
+	}
+	return *ptr
+}
+
+func intOrEmpty(ptr *int) string {
+	if ptr == nil {
+		return ""
+	}
+	return strconv.Itoa(*ptr)
+}
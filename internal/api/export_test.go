@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportM3U(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	movieURL := "http://example.com/movie.mp4"
+	showURL := "http://example.com/show.mp4"
+	lines := []models.ProcessedLine{
+		{LineHash: "h1", TvgName: "Inception (2010)", GroupTitle: "Movies", ContentType: models.ContentTypeMovies, LineURL: &movieURL},
+		{LineHash: "h2", TvgName: "Show S01E01", GroupTitle: "Series \"HD\"", ContentType: models.ContentTypeTVShows, LineURL: &showURL},
+		{LineHash: "h3", TvgName: "No URL", GroupTitle: "Movies", ContentType: models.ContentTypeMovies},
+	}
+	// Without a stored EXTINF line, the entries are rebuilt from their tvg-name and group-title
+	for i := range lines {
+		lines[i].LineContent = lines[i].TvgName
+		lines[i].State = models.StateProcessed
+		require.NoError(t, db.Create(&lines[i]).Error)
+	}
+
+	w := doRequest(server, http.MethodGet, "/api/v1/export.m3u")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "audio/x-mpegurl")
+
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "#EXTM3U\n"), body)
+	assert.Contains(t, body, "#EXTINF:-1 tvg-name=\"Inception (2010)\" group-title=\"Movies\",Inception (2010)\n"+movieURL+"\n")
+	assert.Contains(t, body, "group-title=\"Series 'HD'\"")
+	assert.NotContains(t, body, "No URL")
+}
+
+func TestExportM3U_RoundTrip(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	extinf := `#EXTINF:-1 tvg-id="inception.fr" tvg-name="Inception (2010)" tvg-logo="http://example.com/logo.png" group-title="FR: FILMS" catchup="default",Inception (2010) 4K`
+	url := "http://example.com/movie/123.mkv"
+	playlist := filepath.Join(t.TempDir(), "playlist.m3u")
+	require.NoError(t, os.WriteFile(playlist, []byte("#EXTM3U\n"+extinf+"\n#EXTVLCOPT:http-user-agent=VLC\n"+url+"\n"), 0644))
+
+	lines, err := parser.NewParser(playlist).Parse()
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	lines[0].State = models.StateProcessed
+	require.NoError(t, db.Create(&lines[0]).Error)
+
+	w := doRequest(server, http.MethodGet, "/api/v1/export.m3u")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "#EXTM3U\n"+extinf+"\n"+url+"\n", w.Body.String())
+}
+
+func TestExportM3U_Filtered(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	movieURL := "http://example.com/movie.mp4"
+	showURL := "http://example.com/show.mp4"
+	require.NoError(t, db.Create(&models.ProcessedLine{
+		LineContent: "movie", LineHash: "h1", TvgName: "Movie", GroupTitle: "Movies",
+		ContentType: models.ContentTypeMovies, State: models.StateProcessed, LineURL: &movieURL,
+	}).Error)
+	require.NoError(t, db.Create(&models.ProcessedLine{
+		LineContent: "show", LineHash: "h2", TvgName: "Show", GroupTitle: "Series",
+		ContentType: models.ContentTypeTVShows, State: models.StateProcessed, LineURL: &showURL,
+	}).Error)
+
+	w := doRequest(server, http.MethodGet, "/api/v1/export.m3u?content_type=tvshows")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "#EXTM3U\n"))
+	assert.Contains(t, body, showURL)
+	assert.NotContains(t, body, movieURL)
+}
+
+func TestExportM3U_GroupBy(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	movieURL := "http://example.com/movie.mkv"
+	showURL := "http://example.com/show.mkv"
+	require.NoError(t, db.Create(&models.ProcessedLine{
+		LineContent:     `#EXTINF:-1 tvg-name="Inception (2010)" group-title="FR: FILMS | 4K" tvg-logo="logo.png",Inception (2010)` + "\n" + movieURL,
+		LineHash:        "h1",
+		TvgName:         "Inception (2010)",
+		GroupTitle:      "FR: FILMS | 4K",
+		NormalizedGroup: "films 4k",
+		ContentType:     models.ContentTypeMovies,
+		State:           models.StateProcessed,
+		LineURL:         &movieURL,
+	}).Error)
+	// A stored EXTINF line without group-title gets one, before the title even when
+	// the title and attributes contain commas
+	require.NoError(t, db.Create(&models.ProcessedLine{
+		LineContent: `#EXTINF:-1 tvg-name="Show, The S01E01",Show, The S01E01` + "\n" + showURL,
+		LineHash:    "h2",
+		TvgName:     "Show S01E01",
+		GroupTitle:  "Series",
+		ContentType: models.ContentTypeTVShows,
+		State:       models.StateProcessed,
+		LineURL:     &showURL,
+	}).Error)
+
+	tests := []struct {
+		groupBy string
+		want    string
+	}{
+		{"", `#EXTINF:-1 tvg-name="Inception (2010)" group-title="FR: FILMS | 4K" tvg-logo="logo.png",Inception (2010)` + "\n" + movieURL + "\n" +
+			`#EXTINF:-1 tvg-name="Show, The S01E01",Show, The S01E01` + "\n" + showURL + "\n"},
+		{"original", `#EXTINF:-1 tvg-name="Inception (2010)" group-title="FR: FILMS | 4K" tvg-logo="logo.png",Inception (2010)` + "\n" + movieURL + "\n" +
+			`#EXTINF:-1 tvg-name="Show, The S01E01",Show, The S01E01` + "\n" + showURL + "\n"},
+		{"content_type", `#EXTINF:-1 tvg-name="Inception (2010)" group-title="movies" tvg-logo="logo.png",Inception (2010)` + "\n" + movieURL + "\n" +
+			`#EXTINF:-1 tvg-name="Show, The S01E01" group-title="tvshows",Show, The S01E01` + "\n" + showURL + "\n"},
+		{"normalized", `#EXTINF:-1 tvg-name="Inception (2010)" group-title="films 4k" tvg-logo="logo.png",Inception (2010)` + "\n" + movieURL + "\n" +
+			`#EXTINF:-1 tvg-name="Show, The S01E01" group-title="Series",Show, The S01E01` + "\n" + showURL + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			w := doRequest(server, http.MethodGet, "/api/v1/export.m3u?group_by="+tt.groupBy)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			assert.Equal(t, "#EXTM3U\n"+tt.want, w.Body.String())
+		})
+	}
+
+	w := doRequest(server, http.MethodGet, "/api/v1/export.m3u?group_by=genre")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestExportMoviesCSV(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	genres := "Action, Sci-Fi"
+	movie := models.Movie{TMDBID: 1, TMDBTitle: `Crouching Tiger, Hidden "Dragon"`, TMDBYear: 2000, TMDBGenres: &genres}
+	require.NoError(t, db.Create(&movie).Error)
+	other := models.Movie{TMDBID: 2, TMDBTitle: "Heat", TMDBYear: 1995}
+	require.NoError(t, db.Create(&other).Error)
+
+	res1080, res720 := "1080p", "720p"
+	for i, line := range []models.ProcessedLine{
+		{LineHash: "m1", TvgName: "CTHD FHD", Resolution: &res1080, State: models.StateDownloaded},
+		{LineHash: "m2", TvgName: "CTHD HD", Resolution: &res720, State: models.StateProcessed},
+	} {
+		line.LineContent = line.TvgName
+		line.GroupTitle = "Movies"
+		line.ContentType = models.ContentTypeMovies
+		line.MovieID = &movie.ID
+		require.NoError(t, db.Create(&line).Error, i)
+	}
+
+	w := doRequest(server, http.MethodGet, "/api/v1/movies.csv")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/csv")
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="movies.csv"`)
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"title", "year", "genres", "resolution", "state"}, records[0])
+	assert.Equal(t, []string{`Crouching Tiger, Hidden "Dragon"`, "2000", "Action, Sci-Fi", "1080p, 720p", "downloaded"}, records[1])
+	assert.Equal(t, []string{"Heat", "1995", "", "", ""}, records[2])
+
+	// Pagination params restrict the export to a single page
+	w = doRequest(server, http.MethodGet, "/api/v1/movies.csv?limit=1&offset=1")
+	require.Equal(t, http.StatusOK, w.Code)
+	records, err = csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "Heat", records[1][0])
+}
+
+func TestExportTVShowsCSV(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	season, episode := 1, 2
+	show := models.TVShow{TMDBID: 10, TMDBTitle: "Breaking Bad", TMDBYear: 2008, Season: &season, Episode: &episode}
+	require.NoError(t, db.Create(&show).Error)
+	require.NoError(t, db.Create(&models.ProcessedLine{
+		LineContent: "bb", LineHash: "t1", TvgName: "Breaking Bad S01E02", GroupTitle: "Series",
+		ContentType: models.ContentTypeTVShows, State: models.StateProcessed, TVShowID: &show.ID,
+	}).Error)
+
+	w := doRequest(server, http.MethodGet, "/api/v1/tvshows.csv")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="tvshows.csv"`)
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"title", "year", "season", "episode", "genres", "resolution", "state"}, records[0])
+	assert.Equal(t, []string{"Breaking Bad", "2008", "1", "2", "", "", "processed"}, records[1])
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/external/tmdb"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// listGenres lists the distinct genres of the library, normalized by
+// tmdb.NormalizeGenres, with the number of movies and TV shows of each. Genres
+// stored before normalization was enabled are normalized on the fly.
+func (s *Server) listGenres(c *gin.Context) {
+	db := database.GetRead()
+
+	movies, err := countGenres(db, &models.Movie{})
+	if err != nil {
+		respondError(c, CodeDBError, "failed to count movie genres")
+		return
+	}
+	tvShows, err := countGenres(db, &models.TVShow{})
+	if err != nil {
+		respondError(c, CodeDBError, "failed to count TV show genres")
+		return
+	}
+
+	genres := make([]GenreCount, 0, len(movies)+len(tvShows))
+	for genre, count := range movies {
+		genres = append(genres, GenreCount{Genre: genre, Movies: count, TVShows: tvShows[genre]})
+	}
+	for genre, count := range tvShows {
+		if _, ok := movies[genre]; !ok {
+			genres = append(genres, GenreCount{Genre: genre, TVShows: count})
+		}
+	}
+	sort.Slice(genres, func(i, j int) bool {
+		ti, tj := genres[i].Movies+genres[i].TVShows, genres[j].Movies+genres[j].TVShows
+		if ti != tj {
+			return ti > tj
+		}
+		return genres[i].Genre < genres[j].Genre
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"genres": genres,
+	})
+}
+
+// countGenres counts the distinct TMDB titles of model per normalized genre. A TV
+// show is stored once per episode, so titles are counted by TMDB ID.
+func countGenres(db *gorm.DB, model interface{}) (map[string]int64, error) {
+	var rows []struct {
+		TMDBID     int
+		TMDBGenres string
+	}
+	if err := db.Model(model).
+		Distinct("tmdb_id", "tmdb_genres").
+		Where("tmdb_genres IS NOT NULL AND tmdb_genres <> ''").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	titles := make(map[string]map[int]bool)
+	for _, row := range rows {
+		for _, genre := range tmdb.NormalizeGenres(strings.Split(row.TMDBGenres, ",")) {
+			if titles[genre] == nil {
+				titles[genre] = make(map[int]bool)
+			}
+			titles[genre][row.TMDBID] = true
+		}
+	}
+
+	counts := make(map[string]int64, len(titles))
+	for genre, ids := range titles {
+		counts[genre] = int64(len(ids))
+	}
+	return counts, nil
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListGenres(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	scifi, drama, raw := "Action, Science Fiction", "Drama", "Sci-Fi & Fantasy, drama"
+	season, episode1, episode2 := 1, 1, 2
+	movies := []models.Movie{
+		{TMDBID: 603, TMDBTitle: "The Matrix", TMDBYear: 1999, TMDBGenres: &scifi},
+		{TMDBID: 550, TMDBTitle: "Fight Club", TMDBYear: 1999, TMDBGenres: &drama},
+		{TMDBID: 13, TMDBTitle: "Forrest Gump", TMDBYear: 1994},
+	}
+	require.NoError(t, db.Create(&movies).Error)
+	tvShows := []models.TVShow{
+		{TMDBID: 1399, TMDBTitle: "Game of Thrones", TMDBYear: 2011, TMDBGenres: &raw, Season: &season, Episode: &episode1},
+		{TMDBID: 1399, TMDBTitle: "Game of Thrones", TMDBYear: 2011, TMDBGenres: &raw, Season: &season, Episode: &episode2},
+	}
+	require.NoError(t, db.Create(&tvShows).Error)
+
+	w := doRequest(server, http.MethodGet, "/api/v1/genres")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Genres []GenreCount `json:"genres"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	assert.Equal(t, []GenreCount{
+		{Genre: "Drama", Movies: 1, TVShows: 1},
+		{Genre: "Science Fiction", Movies: 1, TVShows: 1},
+		{Genre: "Action", Movies: 1},
+		{Genre: "Fantasy", TVShows: 1},
+	}, resp.Genres, "genres are normalized and each show is counted once across its episodes")
+}
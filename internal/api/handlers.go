@@ -1,17 +1,29 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/glefebvre/stalkeer/internal/apperrors"
+	"github.com/glefebvre/stalkeer/internal/classifier"
 	"github.com/glefebvre/stalkeer/internal/config"
 	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/downloader"
 	"github.com/glefebvre/stalkeer/internal/dryrun"
+	"github.com/glefebvre/stalkeer/internal/external/tmdb"
+	"github.com/glefebvre/stalkeer/internal/filter"
+	"github.com/glefebvre/stalkeer/internal/logger"
 	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/processor"
 	"gorm.io/gorm"
 )
 
@@ -37,69 +49,41 @@ func (s *Server) healthCheck(c *gin.Context) {
 
 // listItems returns paginated list of items with filtering and sorting
 func (s *Server) listItems(c *gin.Context) {
-	db := database.Get()
+	db := database.GetRead()
 
 	// Parse pagination params
 	limit, offset := parsePagination(c)
 
-	// Parse filters
-	contentType := c.Query("content_type")
-	state := c.Query("state")
-	groupTitle := c.Query("group_title")
-
 	// Parse sort
-	sortBy := c.DefaultQuery("sort", "created_at")
-	sortOrder := c.DefaultQuery("order", "desc")
-
-	// Validate sort field
-	validSortFields := map[string]bool{
-		"tvg_name":     true,
-		"created_at":   true,
-		"processed_at": true,
-		"group_title":  true,
-	}
-	if !validSortFields[sortBy] {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_sort_field",
-			Message: fmt.Sprintf("invalid sort field: %s", sortBy),
-		})
+	orderClause, ok := parseSort(c, itemSortFields, "created_at", "desc")
+	if !ok {
 		return
 	}
 
 	// Build query
-	query := db.Model(&models.ProcessedLine{}).Preload("Movie").Preload("TVShow")
-
-	if contentType != "" {
-		query = query.Where("content_type = ?", contentType)
+	query := db.Model(&models.ProcessedLine{}).Preload("Movie").Preload("TVShow").Preload("Tags")
+	if includeDeleted(c) {
+		query = query.Unscoped()
 	}
-	if state != "" {
-		query = query.Where("state = ?", state)
-	}
-	if groupTitle != "" {
-		query = query.Where("group_title ILIKE ?", "%"+groupTitle+"%")
+	query, ok = applyItemTimeFilters(c, applyItemFilters(c, query))
+	if !ok {
+		return
 	}
 
 	// Count total
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to count items",
-		})
+		respondError(c, CodeDBError, "failed to count items")
 		return
 	}
 
 	// Apply sorting and pagination
-	orderClause := fmt.Sprintf("%s %s", sortBy, strings.ToUpper(sortOrder))
 	query = query.Order(orderClause).Limit(limit).Offset(offset)
 
 	// Fetch items
 	var items []models.ProcessedLine
 	if err := query.Find(&items).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to fetch items",
-		})
+		respondError(c, CodeDBError, "failed to fetch items")
 		return
 	}
 
@@ -109,36 +93,21 @@ func (s *Server) listItems(c *gin.Context) {
 		responses[i] = toItemResponse(item)
 	}
 
-	// Calculate total pages
-	totalPages := int(math.Ceil(float64(total) / float64(limit)))
-
-	c.JSON(http.StatusOK, PaginatedResponse{
-		Data:       responses,
-		Total:      total,
-		Limit:      limit,
-		Offset:     offset,
-		TotalPages: totalPages,
-	})
+	c.JSON(http.StatusOK, newPaginatedResponse(c, responses, total, limit, offset))
 }
 
 // getItem returns a single item by ID
 func (s *Server) getItem(c *gin.Context) {
-	db := database.Get()
+	db := database.GetRead()
 	id := c.Param("id")
 
 	var item models.ProcessedLine
-	if err := db.Preload("Movie").Preload("TVShow").First(&item, id).Error; err != nil {
+	if err := db.Preload("Movie").Preload("TVShow").Preload("Tags").First(&item, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, ErrorResponse{
-				Error:   "not_found",
-				Message: fmt.Sprintf("item with id %s not found", id),
-			})
+			respondError(c, CodeItemNotFound, fmt.Sprintf("item with id %s not found", id))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to fetch item",
-		})
+		respondError(c, CodeDBError, "failed to fetch item")
 		return
 	}
 
@@ -152,26 +121,17 @@ func (s *Server) updateItem(c *gin.Context) {
 
 	var req UpdateItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_request",
-			Message: err.Error(),
-		})
+		respondError(c, CodeInvalidRequest, err.Error())
 		return
 	}
 
 	var item models.ProcessedLine
 	if err := db.First(&item, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, ErrorResponse{
-				Error:   "not_found",
-				Message: fmt.Sprintf("item with id %s not found", id),
-			})
+			respondError(c, CodeItemNotFound, fmt.Sprintf("item with id %s not found", id))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to fetch item",
-		})
+		respondError(c, CodeDBError, "failed to fetch item")
 		return
 	}
 
@@ -181,26 +141,241 @@ func (s *Server) updateItem(c *gin.Context) {
 	}
 
 	if err := db.Save(&item).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to update item",
-		})
+		respondError(c, CodeDBError, "failed to update item")
 		return
 	}
 
 	c.JSON(http.StatusOK, toItemResponse(item))
 }
 
+// deleteItem soft-deletes an item so that it can be restored later
+func (s *Server) deleteItem(c *gin.Context) {
+	db := database.Get()
+	id := c.Param("id")
+
+	var item models.ProcessedLine
+	if err := db.First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(c, CodeItemNotFound, fmt.Sprintf("item with id %s not found", id))
+			return
+		}
+		respondError(c, CodeDBError, "failed to fetch item")
+		return
+	}
+
+	if err := db.Delete(&item).Error; err != nil {
+		respondError(c, CodeDBError, "failed to delete item")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// deleteItems soft-deletes every item matching the list filters in one transaction.
+// It requires ?confirm=true and at least one filter, so that a bare call cannot
+// delete every item.
+func (s *Server) deleteItems(c *gin.Context) {
+	if confirm, _ := strconv.ParseBool(c.Query("confirm")); !confirm {
+		respondError(c, CodeConfirmationRequired, "batch delete requires confirm=true")
+		return
+	}
+	if !hasItemFilter(c) {
+		respondError(c, CodeMissingFilter, fmt.Sprintf("batch delete requires at least one filter: %s", strings.Join(itemFilterParams, ", ")))
+		return
+	}
+
+	var deleted int64
+	err := database.Get().Transaction(func(tx *gorm.DB) error {
+		query, ok := applyItemTimeFilters(c, applyItemFilters(c, tx.Model(&models.ProcessedLine{})))
+		if !ok {
+			return errFilterRejected
+		}
+		result := query.Delete(&models.ProcessedLine{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err == errFilterRejected {
+		return
+	}
+	if err != nil {
+		respondError(c, CodeDBError, "failed to delete items")
+		return
+	}
+
+	c.JSON(http.StatusOK, BatchDeleteResponse{Deleted: deleted})
+}
+
+// restoreItem restores a soft-deleted item
+func (s *Server) restoreItem(c *gin.Context) {
+	db := database.Get()
+	id := c.Param("id")
+
+	var item models.ProcessedLine
+	if err := db.Unscoped().First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(c, CodeItemNotFound, fmt.Sprintf("item with id %s not found", id))
+			return
+		}
+		respondError(c, CodeDBError, "failed to fetch item")
+		return
+	}
+
+	if item.DeletedAt.Valid {
+		if err := db.Unscoped().Model(&item).Update("deleted_at", nil).Error; err != nil {
+			respondError(c, CodeDBError, "failed to restore item")
+			return
+		}
+	}
+
+	if err := db.Preload("Movie").Preload("TVShow").Preload("Tags").First(&item, item.ID).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch item")
+		return
+	}
+
+	c.JSON(http.StatusOK, toItemResponse(item))
+}
+
+// reclassifyItem re-runs the classifier on the stored tvg-name and group-title of an item
+func (s *Server) reclassifyItem(c *gin.Context) {
+	db := database.Get()
+	id := c.Param("id")
+
+	var item models.ProcessedLine
+	if err := db.Preload("TVShow").First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(c, CodeItemNotFound, fmt.Sprintf("item with id %s not found", id))
+			return
+		}
+		respondError(c, CodeDBError, "failed to fetch item")
+		return
+	}
+
+	cls := classifier.New()
+	if err := cls.LoadFromConfig(); err != nil {
+		respondError(c, CodeClassifierError, err.Error())
+		return
+	}
+
+	previous := item.ContentType
+	changed, err := processor.ReclassifyLine(db, cls, &item)
+	if err != nil {
+		respondError(c, CodeDBError, "failed to reclassify item")
+		return
+	}
+
+	if err := db.Preload("Movie").Preload("TVShow").Preload("Tags").First(&item, item.ID).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch item")
+		return
+	}
+
+	c.JSON(http.StatusOK, ReclassifyResponse{
+		Item:                toItemResponse(item),
+		PreviousContentType: previous,
+		Changed:             changed,
+	})
+}
+
+// downloadItem starts an asynchronous download for a single item
+func (s *Server) downloadItem(c *gin.Context) {
+	db := database.Get()
+	cfg := config.Get()
+	id := c.Param("id")
+
+	var item models.ProcessedLine
+	if err := db.Preload("Movie").Preload("TVShow").Preload("Tags").First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(c, CodeItemNotFound, fmt.Sprintf("item with id %s not found", id))
+			return
+		}
+		respondError(c, CodeDBError, "failed to fetch item")
+		return
+	}
+
+	if item.LineURL == nil || *item.LineURL == "" {
+		respondError(c, CodeMissingURL, fmt.Sprintf("item with id %s has no stream URL", id))
+		return
+	}
+
+	baseDestPath, displayName, err := downloader.BaseDestPathForLine(cfg.Downloads.MoviesPath, cfg.Downloads.TVShowsPath, cfg.Downloads.MaxFilenameBytes, &item)
+	if err != nil {
+		respondError(c, CodeMissingMetadata, fmt.Sprintf("item with id %s cannot be organized: %v", id, err))
+		return
+	}
+
+	// Multi-part streams are downloaded as one file from their first part
+	var partURLs []string
+	if cfg.Downloads.JoinParts {
+		following, err := downloader.HasPrecedingPart(db, &item)
+		if err == nil && !following {
+			partURLs, err = downloader.FollowingPartURLs(db, &item)
+		}
+		if err != nil {
+			respondError(c, CodeDBError, "failed to fetch multi-part stream")
+			return
+		}
+		if following {
+			respondError(c, CodeNotFirstPart, fmt.Sprintf("item with id %s is a following part of a multi-part stream, download its first part instead", id))
+			return
+		}
+	}
+
+	dl := downloader.NewWithTimeouts(
+		downloader.NewTimeouts(cfg.Downloads),
+		cfg.Downloads.RetryAttempts,
+	)
+
+	// Create the DownloadInfo record and take its lock before responding, so a
+	// second trigger for the same item is rejected instead of racing the first.
+	dlInfo, err := dl.PrepareDownload(c.Request.Context(), item.ID, *item.LineURL)
+	if err != nil {
+		if apperrors.IsValidationError(err) {
+			respondError(c, CodeDownloadInProgress, fmt.Sprintf("a download for item %s is already in progress", id))
+			return
+		}
+		respondError(c, CodeDBError, "failed to prepare download")
+		return
+	}
+
+	go func() {
+		log := logger.AppLogger()
+		opts := downloader.OptionsFromConfig(cfg, &item)
+		opts.BaseDestPath = baseDestPath
+		opts.LockHeld = true
+		opts.PartURLs = partURLs
+		result, err := dl.Download(context.Background(), opts)
+		if err != nil {
+			log.WithFields(map[string]interface{}{
+				"item_id":     item.ID,
+				"download_id": dlInfo.ID,
+				"title":       displayName,
+				"error":       err,
+			}).Warn("API-triggered download failed")
+			return
+		}
+		log.WithFields(map[string]interface{}{
+			"item_id":     item.ID,
+			"download_id": dlInfo.ID,
+			"title":       displayName,
+			"file_path":   result.FilePath,
+			"file_size":   result.FileSize,
+		}).Info("API-triggered download completed")
+	}()
+
+	c.JSON(http.StatusAccepted, DownloadTriggerResponse{
+		ItemID:          item.ID,
+		DownloadInfoID:  dlInfo.ID,
+		DestinationPath: baseDestPath,
+		Status:          string(models.DownloadStatusPending),
+	})
+}
+
 // searchItems performs advanced search
 func (s *Server) searchItems(c *gin.Context) {
-	db := database.Get()
+	db := database.GetRead()
 
 	query := c.Query("q")
 	if query == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_request",
-			Message: "query parameter 'q' is required",
-		})
+		respondError(c, CodeInvalidRequest, "query parameter 'q' is required")
 		return
 	}
 
@@ -210,25 +385,23 @@ func (s *Server) searchItems(c *gin.Context) {
 	dbQuery := db.Model(&models.ProcessedLine{}).
 		Preload("Movie").
 		Preload("TVShow").
+		Preload("Tags").
 		Where("tvg_name ILIKE ? OR group_title ILIKE ?", "%"+query+"%", "%"+query+"%")
+	if includeDeleted(c) {
+		dbQuery = dbQuery.Unscoped()
+	}
 
 	// Count total
 	var total int64
 	if err := dbQuery.Count(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to count results",
-		})
+		respondError(c, CodeDBError, "failed to count results")
 		return
 	}
 
 	// Fetch results
 	var items []models.ProcessedLine
 	if err := dbQuery.Limit(limit).Offset(offset).Find(&items).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to search items",
-		})
+		respondError(c, CodeDBError, "failed to search items")
 		return
 	}
 
@@ -238,37 +411,30 @@ func (s *Server) searchItems(c *gin.Context) {
 		responses[i] = toItemResponse(item)
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(limit)))
-
-	c.JSON(http.StatusOK, PaginatedResponse{
-		Data:       responses,
-		Total:      total,
-		Limit:      limit,
-		Offset:     offset,
-		TotalPages: totalPages,
-	})
+	c.JSON(http.StatusOK, newPaginatedResponse(c, responses, total, limit, offset))
 }
 
 // listMovies returns paginated list of movies
 func (s *Server) listMovies(c *gin.Context) {
-	db := database.Get()
+	db := database.GetRead()
 	limit, offset := parsePagination(c)
 
+	orderClause, ok := parseSort(c, mediaSortFields, "id", "asc")
+	if !ok {
+		return
+	}
+
+	query := applyGenreFilter(c, db.Model(&models.Movie{}))
+
 	var total int64
-	if err := db.Model(&models.Movie{}).Count(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to count movies",
-		})
+	if err := query.Count(&total).Error; err != nil {
+		respondError(c, CodeDBError, "failed to count movies")
 		return
 	}
 
 	var movies []models.Movie
-	if err := db.Limit(limit).Offset(offset).Find(&movies).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to fetch movies",
-		})
+	if err := query.Order(orderClause).Limit(limit).Offset(offset).Find(&movies).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch movies")
 		return
 	}
 
@@ -277,35 +443,63 @@ func (s *Server) listMovies(c *gin.Context) {
 		responses[i] = toMovieResponse(movie)
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	c.JSON(http.StatusOK, newPaginatedResponse(c, responses, total, limit, offset))
+}
 
-	c.JSON(http.StatusOK, PaginatedResponse{
-		Data:       responses,
-		Total:      total,
-		Limit:      limit,
-		Offset:     offset,
-		TotalPages: totalPages,
+// listCollections returns the TMDB movie collections with their member counts
+func (s *Server) listCollections(c *gin.Context) {
+	db := database.GetRead()
+
+	collections := []CollectionResponse{}
+	if err := db.Model(&models.Movie{}).
+		Select("collection_id AS id, MAX(collection_name) AS name, COUNT(*) AS movie_count").
+		Where("collection_id IS NOT NULL").
+		Group("collection_id").
+		Order("name").
+		Scan(&collections).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch collections")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collections": collections,
+	})
+}
+
+// listGroups returns the normalized group-titles of the items with their counts,
+// largest first. It accepts the item list filters.
+func (s *Server) listGroups(c *gin.Context) {
+	db := database.GetRead()
+
+	groups := []GroupResponse{}
+	query := applyItemFilters(c, db.Model(&models.ProcessedLine{}))
+	if err := query.
+		Select("normalized_group, COUNT(*) AS item_count, COUNT(DISTINCT group_title) AS group_title_count").
+		Where("normalized_group IS NOT NULL AND normalized_group <> ''").
+		Group("normalized_group").
+		Order("item_count DESC, normalized_group").
+		Scan(&groups).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch groups")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"groups": groups,
 	})
 }
 
 // getMovie returns a single movie by ID
 func (s *Server) getMovie(c *gin.Context) {
-	db := database.Get()
+	db := database.GetRead()
 	id := c.Param("id")
 
 	var movie models.Movie
 	if err := db.Preload("ProcessedLines").First(&movie, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, ErrorResponse{
-				Error:   "not_found",
-				Message: fmt.Sprintf("movie with id %s not found", id),
-			})
+			respondError(c, CodeMovieNotFound, fmt.Sprintf("movie with id %s not found", id))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to fetch movie",
-		})
+		respondError(c, CodeDBError, "failed to fetch movie")
 		return
 	}
 
@@ -314,24 +508,25 @@ func (s *Server) getMovie(c *gin.Context) {
 
 // listTVShows returns paginated list of TV shows
 func (s *Server) listTVShows(c *gin.Context) {
-	db := database.Get()
+	db := database.GetRead()
 	limit, offset := parsePagination(c)
 
+	orderClause, ok := parseSort(c, tvShowSortFields, "id", "asc")
+	if !ok {
+		return
+	}
+
+	query := applyGenreFilter(c, db.Model(&models.TVShow{}))
+
 	var total int64
-	if err := db.Model(&models.TVShow{}).Count(&total).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to count TV shows",
-		})
+	if err := query.Count(&total).Error; err != nil {
+		respondError(c, CodeDBError, "failed to count TV shows")
 		return
 	}
 
 	var tvShows []models.TVShow
-	if err := db.Limit(limit).Offset(offset).Find(&tvShows).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to fetch TV shows",
-		})
+	if err := query.Order(orderClause).Limit(limit).Offset(offset).Find(&tvShows).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch TV shows")
 		return
 	}
 
@@ -340,35 +535,21 @@ func (s *Server) listTVShows(c *gin.Context) {
 		responses[i] = toTVShowResponse(tvShow)
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(limit)))
-
-	c.JSON(http.StatusOK, PaginatedResponse{
-		Data:       responses,
-		Total:      total,
-		Limit:      limit,
-		Offset:     offset,
-		TotalPages: totalPages,
-	})
+	c.JSON(http.StatusOK, newPaginatedResponse(c, responses, total, limit, offset))
 }
 
 // getTVShow returns a single TV show by ID
 func (s *Server) getTVShow(c *gin.Context) {
-	db := database.Get()
+	db := database.GetRead()
 	id := c.Param("id")
 
 	var tvShow models.TVShow
 	if err := db.Preload("ProcessedLines").First(&tvShow, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, ErrorResponse{
-				Error:   "not_found",
-				Message: fmt.Sprintf("TV show with id %s not found", id),
-			})
+			respondError(c, CodeTVShowNotFound, fmt.Sprintf("TV show with id %s not found", id))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to fetch TV show",
-		})
+		respondError(c, CodeDBError, "failed to fetch TV show")
 		return
 	}
 
@@ -377,14 +558,11 @@ func (s *Server) getTVShow(c *gin.Context) {
 
 // listFilters returns all filter configurations
 func (s *Server) listFilters(c *gin.Context) {
-	db := database.Get()
+	db := database.GetRead()
 
 	var filters []models.FilterConfig
 	if err := db.Find(&filters).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to fetch filters",
-		})
+		respondError(c, CodeDBError, "failed to fetch filters")
 		return
 	}
 
@@ -404,19 +582,17 @@ func (s *Server) createFilter(c *gin.Context) {
 
 	var req CreateFilterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_request",
-			Message: err.Error(),
-		})
+		respondError(c, CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Validate attribute
 	if req.Attribute != "group_title" && req.Attribute != "tvg_name" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_attribute",
-			Message: "attribute must be 'group_title' or 'tvg_name'",
-		})
+		respondError(c, CodeInvalidAttribute, "attribute must be 'group_title' or 'tvg_name'")
+		return
+	}
+
+	if !validateFilterPatterns(c, req.IncludePatterns, req.ExcludePatterns) {
 		return
 	}
 
@@ -429,16 +605,54 @@ func (s *Server) createFilter(c *gin.Context) {
 	}
 
 	if err := db.Create(&filter).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to create filter",
-		})
+		respondError(c, CodeDBError, "failed to create filter")
 		return
 	}
 
 	c.JSON(http.StatusCreated, toFilterResponse(filter))
 }
 
+// validateFilterPatterns checks that the include and exclude patterns of a filter are
+// JSON arrays of valid regular expressions, so that a stored filter cannot break
+// filter loading. It responds with every invalid pattern and returns false otherwise.
+func validateFilterPatterns(c *gin.Context, includePatterns, excludePatterns *string) bool {
+	var invalid []InvalidPattern
+	for _, field := range []struct {
+		name     string
+		patterns *string
+	}{
+		{"include_patterns", includePatterns},
+		{"exclude_patterns", excludePatterns},
+	} {
+		if field.patterns == nil {
+			continue
+		}
+		var patterns []string
+		if err := json.Unmarshal([]byte(*field.patterns), &patterns); err != nil {
+			invalid = append(invalid, InvalidPattern{Field: field.name, Pattern: *field.patterns, Error: "must be a JSON array of strings"})
+			continue
+		}
+		for _, pattern := range patterns {
+			if err := filter.ValidatePattern(pattern); err != nil {
+				invalid = append(invalid, InvalidPattern{Field: field.name, Pattern: pattern, Error: err.Error()})
+			}
+		}
+	}
+	if len(invalid) == 0 {
+		return true
+	}
+
+	c.JSON(CodeInvalidPattern.Status(), InvalidPatternsResponse{
+		ErrorResponse: ErrorResponse{
+			Error:     CodeInvalidPattern,
+			Message:   fmt.Sprintf("%d invalid filter pattern(s)", len(invalid)),
+			RequestID: c.GetString("request_id"),
+		},
+		InvalidPatterns: invalid,
+	})
+	return false
+}
+
 // updateFilter updates an existing filter
 func (s *Server) updateFilter(c *gin.Context) {
 	db := database.Get()
@@ -446,26 +660,17 @@ func (s *Server) updateFilter(c *gin.Context) {
 
 	var req UpdateFilterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_request",
-			Message: err.Error(),
-		})
+		respondError(c, CodeInvalidRequest, err.Error())
 		return
 	}
 
 	var filter models.FilterConfig
 	if err := db.First(&filter, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, ErrorResponse{
-				Error:   "not_found",
-				Message: fmt.Sprintf("filter with id %s not found", id),
-			})
+			respondError(c, CodeFilterNotFound, fmt.Sprintf("filter with id %s not found", id))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to fetch filter",
-		})
+		respondError(c, CodeDBError, "failed to fetch filter")
 		return
 	}
 
@@ -475,14 +680,14 @@ func (s *Server) updateFilter(c *gin.Context) {
 	}
 	if req.Attribute != nil {
 		if *req.Attribute != "group_title" && *req.Attribute != "tvg_name" {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error:   "invalid_attribute",
-				Message: "attribute must be 'group_title' or 'tvg_name'",
-			})
+			respondError(c, CodeInvalidAttribute, "attribute must be 'group_title' or 'tvg_name'")
 			return
 		}
 		filter.Attribute = *req.Attribute
 	}
+	if !validateFilterPatterns(c, req.IncludePatterns, req.ExcludePatterns) {
+		return
+	}
 	if req.IncludePatterns != nil {
 		filter.IncludePatterns = req.IncludePatterns
 	}
@@ -491,10 +696,7 @@ func (s *Server) updateFilter(c *gin.Context) {
 	}
 
 	if err := db.Save(&filter).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to update filter",
-		})
+		respondError(c, CodeDBError, "failed to update filter")
 		return
 	}
 
@@ -508,18 +710,12 @@ func (s *Server) deleteFilter(c *gin.Context) {
 
 	result := db.Delete(&models.FilterConfig{}, id)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to delete filter",
-		})
+		respondError(c, CodeDBError, "failed to delete filter")
 		return
 	}
 
 	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, ErrorResponse{
-			Error:   "not_found",
-			Message: fmt.Sprintf("filter with id %s not found", id),
-		})
+		respondError(c, CodeFilterNotFound, fmt.Sprintf("filter with id %s not found", id))
 		return
 	}
 
@@ -533,10 +729,7 @@ func (s *Server) clearRuntimeFilters(c *gin.Context) {
 	db := database.Get()
 
 	if err := db.Where("is_runtime = ?", true).Delete(&models.FilterConfig{}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to clear runtime filters",
-		})
+		respondError(c, CodeDBError, "failed to clear runtime filters")
 		return
 	}
 
@@ -545,63 +738,66 @@ func (s *Server) clearRuntimeFilters(c *gin.Context) {
 	})
 }
 
-// getStats returns statistics about the data
+// getStats returns statistics about the data from the stats snapshot. The counts are
+// computed live with ?fresh=true, or when no snapshot was taken yet.
 func (s *Server) getStats(c *gin.Context) {
-	db := database.Get()
+	db := database.GetRead()
+	fresh, _ := strconv.ParseBool(c.Query("fresh"))
+
+	var stats *processor.ItemStats
+	var err error
+	if !fresh {
+		stats, err = processor.LoadStatsSnapshot(db)
+		if err != nil {
+			respondDBError(c, "failed to load stats", err)
+			return
+		}
+	}
+	if stats == nil {
+		if stats, err = processor.ComputeItemStats(db); err != nil {
+			respondDBError(c, "failed to compute stats", err)
+			return
+		}
+	}
 
-	var totalItems int64
-	if err := db.Model(&models.ProcessedLine{}).Count(&totalItems).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "database_error",
-			Message: "failed to count items",
-		})
+	c.JSON(http.StatusOK, toStatsResponse(stats))
+}
+
+// refreshStats recomputes the stats snapshot and returns it
+func (s *Server) refreshStats(c *gin.Context) {
+	stats, err := processor.RefreshStatsSnapshot(database.Get())
+	if err != nil {
+		respondDBError(c, "failed to refresh stats", err)
 		return
 	}
 
-	// Count by content type
-	byContentType := make(map[string]int64)
-	contentTypes := []models.ContentType{
-		models.ContentTypeMovies,
-		models.ContentTypeTVShows,
-		models.ContentTypeChannels,
-		models.ContentTypeUncategorized,
-	}
-	for _, ct := range contentTypes {
-		var count int64
-		db.Model(&models.ProcessedLine{}).Where("content_type = ?", ct).Count(&count)
-		byContentType[string(ct)] = count
-	}
-
-	// Count by state
-	byState := make(map[string]int64)
-	states := []models.ProcessingState{
-		models.StateProcessed,
-		models.StatePending,
-		models.StateDownloading,
-		models.StateDownloaded,
-		models.StateFailed,
-	}
-	for _, state := range states {
-		var count int64
-		db.Model(&models.ProcessedLine{}).Where("state = ?", state).Count(&count)
-		byState[string(state)] = count
-	}
-
-	// Top 10 groups
-	var topGroups []GroupCount
-	db.Model(&models.ProcessedLine{}).
-		Select("group_title, COUNT(*) as count").
-		Group("group_title").
-		Order("count DESC").
-		Limit(10).
-		Scan(&topGroups)
-
-	c.JSON(http.StatusOK, StatsResponse{
-		TotalItems:    totalItems,
-		ByContentType: byContentType,
-		ByState:       byState,
-		TopGroups:     topGroups,
-	})
+	c.JSON(http.StatusOK, toStatsResponse(stats))
+}
+
+// getClassificationStats returns the classification confidence histogram of
+// each content type
+func (s *Server) getClassificationStats(c *gin.Context) {
+	dist, err := processor.ComputeConfidenceDistribution(database.GetRead())
+	if err != nil {
+		respondDBError(c, "failed to compute classification stats", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toConfidenceDistributionResponse(dist))
+}
+
+// getTMDBStats returns the TMDB match rate of the last processing runs, to spot a
+// degrading match rate such as an exhausted API key quota
+func (s *Server) getTMDBStats(c *gin.Context) {
+	limit, _ := parsePagination(c)
+
+	runs, err := processor.TMDBMatchHistory(database.GetRead(), limit)
+	if err != nil {
+		respondDBError(c, "failed to fetch TMDB match stats", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toTMDBMatchStatsResponse(runs))
 }
 
 // executeDryRun executes a dry-run analysis
@@ -615,10 +811,7 @@ func (s *Server) executeDryRun(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "invalid_request",
-			Message: err.Error(),
-		})
+		respondError(c, CodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -627,10 +820,7 @@ func (s *Server) executeDryRun(c *gin.Context) {
 	}
 
 	if filePath == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "missing_file_path",
-			Message: "M3U file path must be provided",
-		})
+		respondError(c, CodeMissingFilePath, "M3U file path must be provided")
 		return
 	}
 
@@ -642,13 +832,25 @@ func (s *Server) executeDryRun(c *gin.Context) {
 		limit = 1000
 	}
 
+	ctx := c.Request.Context()
+	if cfg.API.DryRunTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.API.DryRunTimeout)
+		defer cancel()
+	}
+
 	analyzer := dryrun.NewAnalyzer(limit)
-	result, err := analyzer.Analyze(filePath)
+	analyzer.SetMaxFileSize(cfg.API.DryRunMaxFileSizeMB * 1024 * 1024)
+	result, err := analyzer.Analyze(ctx, filePath)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "analysis_failed",
-			Message: err.Error(),
-		})
+		switch {
+		case errors.Is(err, dryrun.ErrFileTooLarge):
+			respondError(c, CodeFileTooLarge, err.Error())
+		case errors.Is(err, context.DeadlineExceeded):
+			respondError(c, CodeDryRunTimeout, fmt.Sprintf("dry-run analysis did not complete within %s", cfg.API.DryRunTimeout))
+		default:
+			respondError(c, CodeDryRunFailed, err.Error())
+		}
 		return
 	}
 
@@ -679,16 +881,239 @@ func parsePagination(c *gin.Context) (limit, offset int) {
 	return limit, offset
 }
 
+// Sort fields accepted by the sort query parameter of each list, mapped to their column
+var (
+	itemSortFields = map[string]string{
+		"id":               "id",
+		"tvg_name":         "tvg_name",
+		"created_at":       "created_at",
+		"processed_at":     "processed_at",
+		"group_title":      "group_title",
+		"normalized_group": "normalized_group",
+	}
+	mediaSortFields = map[string]string{
+		"id":         "id",
+		"title":      "tmdb_title",
+		"year":       "tmdb_year",
+		"created_at": "created_at",
+	}
+	tvShowSortFields = map[string]string{
+		"id":         "id",
+		"title":      "tmdb_title",
+		"year":       "tmdb_year",
+		"season":     "season",
+		"episode":    "episode",
+		"created_at": "created_at",
+	}
+)
+
+// parseSort returns the order clause of the sort and order query parameters, with id
+// as a tiebreaker so that pages stay stable when the sort column has equal values.
+// It responds with an error and returns false when a parameter is invalid.
+func parseSort(c *gin.Context, fields map[string]string, defaultSort, defaultOrder string) (string, bool) {
+	sortBy := c.DefaultQuery("sort", defaultSort)
+	column, ok := fields[sortBy]
+	if !ok {
+		respondError(c, CodeInvalidSortField, fmt.Sprintf("invalid sort field: %s", sortBy))
+		return "", false
+	}
+
+	sortOrder := strings.ToUpper(c.DefaultQuery("order", defaultOrder))
+	if sortOrder != "ASC" && sortOrder != "DESC" {
+		respondError(c, CodeInvalidSortField, fmt.Sprintf("invalid sort order: %s, must be asc or desc", c.Query("order")))
+		return "", false
+	}
+
+	if column == "id" {
+		return "id " + sortOrder, true
+	}
+	return fmt.Sprintf("%s %s, id %s", column, sortOrder, sortOrder), true
+}
+
+// newPaginatedResponse wraps a page of data, with the links to the other pages of the request
+func newPaginatedResponse(c *gin.Context, data interface{}, total int64, limit, offset int) PaginatedResponse {
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	return PaginatedResponse{
+		Data:       data,
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		TotalPages: totalPages,
+		Links:      paginationLinks(c, total, totalPages, limit, offset),
+	}
+}
+
+// paginationLinks builds the relative URLs (path and query) of the first, previous,
+// next and last pages from the request URL, replacing its limit and offset query
+// parameters. Links are relative so that no client-supplied header picks their scheme
+// or host.
+func paginationLinks(c *gin.Context, total int64, totalPages, limit, offset int) *PaginationLinks {
+	pageURL := func(pageOffset int) string {
+		query := c.Request.URL.Query()
+		query.Set("limit", strconv.Itoa(limit))
+		query.Set("offset", strconv.Itoa(pageOffset))
+		u := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+		return u.String()
+	}
+
+	lastOffset := 0
+	if totalPages > 0 {
+		lastOffset = (totalPages - 1) * limit
+	}
+
+	links := &PaginationLinks{First: pageURL(0), Last: pageURL(lastOffset)}
+	if offset > 0 {
+		links.Prev = pageURL(max(offset-limit, 0))
+	}
+	if int64(offset+limit) < total {
+		links.Next = pageURL(offset + limit)
+	}
+	return links
+}
+
+// itemFilterParams are the query parameters filtering the item list
+var itemFilterParams = []string{"content_type", "state", "group_title", "normalized_group", "tag", "created_after", "updated_after"}
+
+// errFilterRejected aborts a transaction whose filters were rejected, the error
+// response being already written
+var errFilterRejected = errors.New("filter rejected")
+
+// hasItemFilter reports whether any item list filter is set. Filters are checked
+// on the values the queries use, so that a group normalizing to nothing does not
+// count as a filter.
+func hasItemFilter(c *gin.Context) bool {
+	for _, param := range itemFilterParams {
+		if itemFilterValue(c, param) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// itemFilterValue returns the value an item list filter is applied with: the
+// normalized group or tag name, else the raw query parameter
+func itemFilterValue(c *gin.Context, param string) string {
+	value := c.Query(param)
+	switch param {
+	case "normalized_group":
+		return classifier.NormalizeGroupTitle(value)
+	case "tag":
+		return normalizeTagName(value)
+	}
+	return value
+}
+
+// likeEscaper escapes the LIKE wildcards of a user value, to be used with ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// applyItemTimeFilters applies the created_after and updated_after query filters. It
+// writes the error response and returns false when a value is invalid.
+func applyItemTimeFilters(c *gin.Context, query *gorm.DB) (*gorm.DB, bool) {
+	timeFilters := []struct{ param, column string }{
+		{"created_after", "created_at"},
+		{"updated_after", "updated_at"},
+	}
+	for _, filter := range timeFilters {
+		after, err := parseTimeQuery(c, filter.param)
+		if err != nil {
+			respondError(c, CodeInvalidTimeFilter, err.Error())
+			return nil, false
+		}
+		if after != nil {
+			query = query.Where(filter.column+" > ?", *after)
+		}
+	}
+	return query, true
+}
+
+// applyItemFilters applies the content_type, state, group_title, normalized_group and
+// tag query filters
+func applyItemFilters(c *gin.Context, query *gorm.DB) *gorm.DB {
+	if contentType := c.Query("content_type"); contentType != "" {
+		query = query.Where("content_type = ?", contentType)
+	}
+	if state := c.Query("state"); state != "" {
+		query = query.Where("state = ?", state)
+	}
+	if groupTitle := c.Query("group_title"); groupTitle != "" {
+		query = query.Where(`LOWER(group_title) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(strings.ToLower(groupTitle))+"%")
+	}
+	if group := itemFilterValue(c, "normalized_group"); group != "" {
+		query = query.Where("normalized_group = ?", group)
+	}
+	if tag := itemFilterValue(c, "tag"); tag != "" {
+		tagged := query.Session(&gorm.Session{NewDB: true}).
+			Table("processed_line_tags").
+			Select("processed_line_tags.processed_line_id").
+			Joins("JOIN tags ON tags.id = processed_line_tags.tag_id").
+			Where("tags.name = ?", tag)
+		query = query.Where("id IN (?)", tagged)
+	}
+	return query
+}
+
+// applyGenreFilter applies the genre query filter to a movie or TV show query.
+// Genres are stored denormalized as a comma-separated string, so the filter is a
+// case-insensitive substring match: "Action" also matches "Action & Adventure".
+// The requested genre is normalized by tmdb.NormalizeGenre and matched against
+// every name it may be stored under, since normalize_genres only applies to
+// newly enriched titles.
+func applyGenreFilter(c *gin.Context, query *gorm.DB) *gorm.DB {
+	for _, name := range tmdb.NormalizeGenre(c.Query("genre")) {
+		var clauses []string
+		var args []interface{}
+		for _, alias := range tmdb.GenreAliases(name) {
+			clauses = append(clauses, `LOWER(tmdb_genres) LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+likeEscaper.Replace(alias)+"%")
+		}
+		query = query.Where("("+strings.Join(clauses, " OR ")+")", args...)
+	}
+	return query
+}
+
+// parseTimeQuery parses a timestamp query parameter, either RFC 3339 (e.g.
+// 2024-05-01T12:00:00Z) or a date (e.g. 2024-05-01, midnight UTC). It returns nil
+// when the parameter is absent.
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid %s: %q is not an RFC 3339 timestamp or a YYYY-MM-DD date", name, value)
+}
+
+// includeDeleted reports whether soft-deleted items were requested with ?include_deleted=true
+func includeDeleted(c *gin.Context) bool {
+	include, _ := strconv.ParseBool(c.Query("include_deleted"))
+	return include
+}
+
 func toItemResponse(item models.ProcessedLine) ItemResponse {
 	resp := ItemResponse{
-		ID:          item.ID,
-		TvgName:     item.TvgName,
-		GroupTitle:  item.GroupTitle,
-		ContentType: item.ContentType,
-		State:       item.State,
-		ProcessedAt: item.ProcessedAt.Format("2006-01-02T15:04:05Z07:00"),
-		CreatedAt:   item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:   item.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		ID:              item.ID,
+		TvgName:         item.TvgName,
+		GroupTitle:      item.GroupTitle,
+		NormalizedGroup: item.NormalizedGroup,
+		ContentType:     item.ContentType,
+		State:           item.State,
+		ProcessedAt:     item.ProcessedAt.Format("2006-01-02T15:04:05Z07:00"),
+		CreatedAt:       item.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:       item.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+
+	if item.DeletedAt.Valid {
+		deletedAt := item.DeletedAt.Time.Format("2006-01-02T15:04:05Z07:00")
+		resp.DeletedAt = &deletedAt
+	}
+
+	for _, tag := range item.Tags {
+		resp.Tags = append(resp.Tags, tag.Name)
 	}
 
 	if item.Movie != nil {
@@ -708,25 +1133,98 @@ func toItemResponse(item models.ProcessedLine) ItemResponse {
 
 func toMovieResponse(movie models.Movie) MovieResponse {
 	return MovieResponse{
-		ID:        movie.ID,
-		TMDBID:    movie.TMDBID,
-		TMDBTitle: movie.TMDBTitle,
-		TMDBYear:  movie.TMDBYear,
-		Genres:    movie.TMDBGenres,
-		Duration:  movie.Duration,
+		ID:             movie.ID,
+		TMDBID:         movie.TMDBID,
+		TMDBTitle:      movie.TMDBTitle,
+		TMDBYear:       movie.TMDBYear,
+		Genres:         movie.TMDBGenres,
+		Duration:       movie.Duration,
+		CollectionID:   movie.CollectionID,
+		CollectionName: movie.CollectionName,
+	}
+}
+
+func toStatsResponse(stats *processor.ItemStats) StatsResponse {
+	resp := StatsResponse{
+		TotalItems:    stats.TotalItems,
+		ByContentType: stats.ByContentType,
+		ByResolution:  stats.ByResolution,
+		ByState:       stats.ByState,
+		TopGroups:     make([]GroupCount, len(stats.TopGroups)),
+		SnapshotAt:    formatTime(stats.RefreshedAt),
+	}
+	for i, group := range stats.TopGroups {
+		resp.TopGroups[i] = GroupCount{GroupTitle: group.GroupTitle, Count: group.Count}
 	}
+	return resp
+}
+
+func toConfidenceDistributionResponse(dist *processor.ConfidenceDistribution) ConfidenceDistributionResponse {
+	resp := ConfidenceDistributionResponse{
+		BucketSize:    processor.ConfidenceBucketSize,
+		ByContentType: make(map[string][]ConfidenceBucket, len(dist.Buckets)),
+		Unknown:       dist.Unknown,
+	}
+	for contentType, counts := range dist.Buckets {
+		buckets := make([]ConfidenceBucket, len(counts))
+		for i, count := range counts {
+			buckets[i] = ConfidenceBucket{
+				Min:   i * processor.ConfidenceBucketSize,
+				Max:   (i+1)*processor.ConfidenceBucketSize - 1,
+				Count: count,
+			}
+		}
+		buckets[len(buckets)-1].Max = 100
+		resp.ByContentType[contentType] = buckets
+	}
+	return resp
+}
+
+func toTMDBMatchStatsResponse(runs []processor.TMDBMatchRun) TMDBMatchStatsResponse {
+	resp := TMDBMatchStatsResponse{Runs: make([]TMDBRunStats, 0, len(runs))}
+
+	var total processor.TMDBMatchRun
+	for _, run := range runs {
+		resp.Runs = append(resp.Runs, TMDBRunStats{
+			RunID:     run.RunID,
+			StartedAt: run.StartedAt.Format("2006-01-02T15:04:05Z07:00"),
+			Matched:   run.Matched,
+			NotFound:  run.NotFound,
+			MatchRate: roundRate(run.MatchRate()),
+		})
+		total.Matched += run.Matched
+		total.NotFound += run.NotFound
+	}
+	resp.AverageRate = roundRate(total.MatchRate())
+
+	if n := len(runs); n >= 2 {
+		resp.Change = roundRate(runs[n-1].MatchRate() - runs[n-2].MatchRate())
+	}
+	return resp
+}
+
+// roundRate rounds a percentage to one decimal
+func roundRate(rate float64) float64 {
+	return math.Round(rate*10) / 10
 }
 
 func toTVShowResponse(tvShow models.TVShow) TVShowResponse {
-	return TVShowResponse{
-		ID:        tvShow.ID,
-		TMDBID:    tvShow.TMDBID,
-		TMDBTitle: tvShow.TMDBTitle,
-		TMDBYear:  tvShow.TMDBYear,
-		Genres:    tvShow.TMDBGenres,
-		Season:    tvShow.Season,
-		Episode:   tvShow.Episode,
+	resp := TVShowResponse{
+		ID:           tvShow.ID,
+		TMDBID:       tvShow.TMDBID,
+		TMDBTitle:    tvShow.TMDBTitle,
+		TMDBYear:     tvShow.TMDBYear,
+		Genres:       tvShow.TMDBGenres,
+		Season:       tvShow.Season,
+		Episode:      tvShow.Episode,
+		EpisodeEnd:   tvShow.EpisodeEnd,
+		EpisodeTitle: tvShow.EpisodeTitle,
 	}
+	if tvShow.AirDate != nil {
+		airDate := tvShow.AirDate.Format("2006-01-02")
+		resp.AirDate = &airDate
+	}
+	return resp
 }
 
 func toFilterResponse(filter models.FilterConfig) FilterResponse {
@@ -0,0 +1,1210 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glefebvre/stalkeer/internal/classifier"
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/downloader"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupTestServer wires an API server to a temporary sqlite database and config.
+// A file-backed database is used so the background download goroutine shares
+// the same data as the test.
+func setupTestServer(t *testing.T) (*Server, *gorm.DB) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	dsn := filepath.Join(t.TempDir(), "api.db") + "?_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.ProcessedLine{},
+		&models.Movie{},
+		&models.TVShow{},
+		&models.DownloadInfo{},
+		&models.StatsSnapshot{},
+		&models.Tag{},
+		&models.FilterConfig{},
+		&models.ProcessingLog{},
+	))
+	database.SetDB(db)
+
+	t.Setenv("STALKEER_DATABASE_USER", "test")
+	t.Setenv("STALKEER_DATABASE_DBNAME", "test")
+	t.Setenv("MOVIES_PATH", filepath.Join(t.TempDir(), "movies"))
+	t.Setenv("TEMP_DIR", filepath.Join(t.TempDir(), "tmp"))
+	require.NoError(t, config.Load())
+
+	return NewServer(), db
+}
+
+func createMovieItem(t *testing.T, db *gorm.DB, url string) models.ProcessedLine {
+	t.Helper()
+
+	movie := models.Movie{TMDBID: 603, TMDBTitle: "The Matrix", TMDBYear: 1999}
+	require.NoError(t, db.Create(&movie).Error)
+
+	item := models.ProcessedLine{
+		LineContent: "#EXTINF:-1,The Matrix",
+		LineURL:     &url,
+		LineHash:    "hash-matrix",
+		TvgName:     "The Matrix (1999)",
+		GroupTitle:  "Movies",
+		ContentType: models.ContentTypeMovies,
+		State:       models.StateProcessed,
+		MovieID:     &movie.ID,
+	}
+	require.NoError(t, db.Create(&item).Error)
+	return item
+}
+
+func TestDownloadItem_StartsDownload(t *testing.T) {
+	stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/x-matroska")
+		w.Write([]byte("fake video content"))
+	}))
+	defer stream.Close()
+
+	server, db := setupTestServer(t)
+	item := createMovieItem(t, db, stream.URL+"/movie.mkv")
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/items/%d/download", item.ID), nil)
+	server.router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var resp DownloadTriggerResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, item.ID, resp.ItemID)
+	assert.NotZero(t, resp.DownloadInfoID)
+
+	var dlInfo models.DownloadInfo
+	require.NoError(t, db.First(&dlInfo, resp.DownloadInfoID).Error)
+
+	var updated models.ProcessedLine
+	require.NoError(t, db.First(&updated, item.ID).Error)
+	require.NotNil(t, updated.DownloadInfoID)
+	assert.Equal(t, resp.DownloadInfoID, *updated.DownloadInfoID)
+
+	// Wait for the background download to finish so the temp dirs can be removed
+	require.Eventually(t, func() bool {
+		var info models.DownloadInfo
+		if err := db.First(&info, resp.DownloadInfoID).Error; err != nil {
+			return false
+		}
+		return info.Status == string(models.DownloadStatusCompleted) && info.LockedAt == nil
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestDownloadItem_Conflict(t *testing.T) {
+	server, db := setupTestServer(t)
+	item := createMovieItem(t, db, "http://example.invalid/movie.mkv")
+
+	now := time.Now()
+	owner := "other-instance"
+	dlInfo := models.DownloadInfo{
+		Status:   string(models.DownloadStatusDownloading),
+		LockedAt: &now,
+		LockedBy: &owner,
+	}
+	require.NoError(t, db.Create(&dlInfo).Error)
+	require.NoError(t, db.Model(&item).Update("download_info_id", dlInfo.ID).Error)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/items/%d/download", item.ID), nil)
+	server.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, CodeDownloadInProgress, resp.Error)
+}
+
+func TestDownloadItem_NotFound(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/items/999/download", nil)
+	server.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func createItem(t *testing.T, db *gorm.DB, tvgName string) models.ProcessedLine {
+	t.Helper()
+
+	item := models.ProcessedLine{
+		LineContent: "#EXTINF:-1," + tvgName,
+		LineHash:    "hash-" + tvgName,
+		TvgName:     tvgName,
+		GroupTitle:  "Movies",
+		ContentType: models.ContentTypeMovies,
+		State:       models.StateProcessed,
+	}
+	require.NoError(t, db.Create(&item).Error)
+	return item
+}
+
+func doRequest(server *Server, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	server.router.ServeHTTP(w, req)
+	return w
+}
+
+func listItemIDs(t *testing.T, server *Server, path string) []uint {
+	t.Helper()
+
+	w := doRequest(server, http.MethodGet, path)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Data  []ItemResponse `json:"data"`
+		Total int64          `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(len(resp.Data)), resp.Total)
+
+	ids := make([]uint, len(resp.Data))
+	for i, item := range resp.Data {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func TestDeleteItem_SoftDeletedHiddenFromListAndStats(t *testing.T) {
+	server, db := setupTestServer(t)
+	kept := createItem(t, db, "Kept Movie")
+	deleted := createItem(t, db, "Deleted Movie")
+
+	w := doRequest(server, http.MethodDelete, fmt.Sprintf("/api/v1/items/%d", deleted.ID))
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	// The row is still stored
+	var count int64
+	require.NoError(t, db.Unscoped().Model(&models.ProcessedLine{}).Where("id = ?", deleted.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	assert.ElementsMatch(t, []uint{kept.ID}, listItemIDs(t, server, "/api/v1/items"))
+	assert.ElementsMatch(t, []uint{kept.ID, deleted.ID}, listItemIDs(t, server, "/api/v1/items?include_deleted=true"))
+
+	w = doRequest(server, http.MethodGet, fmt.Sprintf("/api/v1/items/%d", deleted.ID))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doRequest(server, http.MethodGet, "/api/v1/stats")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var stats StatsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, int64(1), stats.TotalItems)
+	assert.Equal(t, int64(1), stats.ByContentType[string(models.ContentTypeMovies)])
+}
+
+func TestGetStats_ReadsSnapshot(t *testing.T) {
+	server, db := setupTestServer(t)
+	createItem(t, db, "First Movie")
+
+	getStats := func(path string) StatsResponse {
+		w := doRequest(server, http.MethodGet, path)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var stats StatsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+		return stats
+	}
+
+	// Without a snapshot the counts are live
+	live := getStats("/api/v1/stats")
+	assert.Equal(t, int64(1), live.TotalItems)
+	assert.Nil(t, live.SnapshotAt)
+
+	w := doRequest(server, http.MethodPost, "/api/v1/stats/refresh")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	createItem(t, db, "Second Movie")
+
+	stale := getStats("/api/v1/stats")
+	assert.Equal(t, int64(1), stale.TotalItems, "the snapshot predates the insert")
+	assert.NotNil(t, stale.SnapshotAt)
+
+	fresh := getStats("/api/v1/stats?fresh=true")
+	assert.Equal(t, int64(2), fresh.TotalItems)
+	assert.Nil(t, fresh.SnapshotAt)
+
+	w = doRequest(server, http.MethodPost, "/api/v1/stats/refresh")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	refreshed := getStats("/api/v1/stats")
+	assert.Equal(t, int64(2), refreshed.TotalItems)
+	assert.Equal(t, int64(2), refreshed.ByContentType[string(models.ContentTypeMovies)])
+	assert.Equal(t, int64(0), refreshed.ByContentType[string(models.ContentTypeChannels)])
+	require.Len(t, refreshed.TopGroups, 1)
+	assert.Equal(t, GroupCount{GroupTitle: "Movies", Count: 2}, refreshed.TopGroups[0])
+}
+
+func TestGetClassificationStats(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	setConfidence := func(item models.ProcessedLine, contentType models.ContentType, confidence int) {
+		require.NoError(t, db.Model(&models.ProcessedLine{}).Where("id = ?", item.ID).
+			Updates(map[string]interface{}{"content_type": contentType, "classification_confidence": confidence}).Error)
+	}
+	setConfidence(createItem(t, db, "Low Movie"), models.ContentTypeMovies, 35)
+	setConfidence(createItem(t, db, "Mid Movie"), models.ContentTypeMovies, 38)
+	setConfidence(createItem(t, db, "Sure Movie"), models.ContentTypeMovies, 100)
+	setConfidence(createItem(t, db, "Show S01E01"), models.ContentTypeTVShows, 90)
+	setConfidence(createItem(t, db, "Unknown Item"), models.ContentTypeUncategorized, 0)
+	createItem(t, db, "Legacy Movie")
+
+	w := doRequest(server, http.MethodGet, "/api/v1/stats/classification")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp ConfidenceDistributionResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 10, resp.BucketSize)
+	assert.Equal(t, int64(1), resp.Unknown, "lines without a stored confidence are counted apart")
+
+	counts := func(contentType models.ContentType) []int64 {
+		buckets := resp.ByContentType[string(contentType)]
+		require.Len(t, buckets, 10)
+		result := make([]int64, len(buckets))
+		for i, bucket := range buckets {
+			result[i] = bucket.Count
+		}
+		return result
+	}
+	assert.Equal(t, []int64{0, 0, 0, 2, 0, 0, 0, 0, 0, 1}, counts(models.ContentTypeMovies))
+	assert.Equal(t, []int64{0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, counts(models.ContentTypeTVShows))
+	assert.Equal(t, []int64{1, 0, 0, 0, 0, 0, 0, 0, 0, 0}, counts(models.ContentTypeUncategorized))
+	assert.Equal(t, make([]int64, 10), counts(models.ContentTypeChannels))
+
+	last := resp.ByContentType[string(models.ContentTypeMovies)][9]
+	assert.Equal(t, ConfidenceBucket{Min: 90, Max: 100, Count: 1}, last)
+}
+
+func TestGetTMDBStats(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	start := time.Now().Add(-2 * time.Hour)
+	createRun := func(startedAt time.Time, status string, matched, notFound int) models.ProcessingLog {
+		run := models.ProcessingLog{
+			Action:       "process_m3u",
+			Status:       status,
+			StartedAt:    startedAt,
+			TMDBMatched:  matched,
+			TMDBNotFound: notFound,
+		}
+		require.NoError(t, db.Create(&run).Error)
+		return run
+	}
+	first := createRun(start, "success", 90, 10)
+	second := createRun(start.Add(time.Hour), "completed_with_errors", 60, 40)
+	createRun(start.Add(90*time.Minute), "in_progress", 1, 0)
+	createRun(start.Add(30*time.Minute), "success", 0, 0)
+
+	w := doRequest(server, http.MethodGet, "/api/v1/stats/tmdb")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp TMDBMatchStatsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Runs, 2, "runs in progress or without TMDB lookups are left out")
+	assert.Equal(t, first.ID, resp.Runs[0].RunID)
+	assert.Equal(t, 90.0, resp.Runs[0].MatchRate)
+	assert.Equal(t, second.ID, resp.Runs[1].RunID)
+	assert.Equal(t, 60, resp.Runs[1].Matched)
+	assert.Equal(t, 40, resp.Runs[1].NotFound)
+	assert.Equal(t, 60.0, resp.Runs[1].MatchRate)
+	assert.Equal(t, 75.0, resp.AverageRate)
+	assert.Equal(t, -30.0, resp.Change, "the match rate dropped by 30 points")
+
+	w = doRequest(server, http.MethodGet, "/api/v1/stats/tmdb?limit=1")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Runs, 1)
+	assert.Equal(t, second.ID, resp.Runs[0].RunID, "the latest runs are kept")
+	assert.Equal(t, 0.0, resp.Change)
+}
+
+func TestListItems_TimeFilters(t *testing.T) {
+	server, db := setupTestServer(t)
+	old := createItem(t, db, "Old Movie")
+	recent := createItem(t, db, "Recent Movie")
+	touched := createItem(t, db, "Touched Movie")
+
+	setTimes := func(item models.ProcessedLine, created, updated time.Time) {
+		require.NoError(t, db.Model(&models.ProcessedLine{}).Where("id = ?", item.ID).
+			UpdateColumns(map[string]interface{}{"created_at": created, "updated_at": updated}).Error)
+	}
+	setTimes(old, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC))
+	setTimes(recent, time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	setTimes(touched, time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.ElementsMatch(t, []uint{recent.ID}, listItemIDs(t, server, "/api/v1/items?created_after=2024-03-01"))
+	assert.ElementsMatch(t, []uint{recent.ID, touched.ID}, listItemIDs(t, server, "/api/v1/items?updated_after=2024-03-01"))
+	assert.ElementsMatch(t, []uint{touched.ID}, listItemIDs(t, server, "/api/v1/items?updated_after=2024-06-01T12:00:00Z"))
+	assert.ElementsMatch(t, []uint{touched.ID}, listItemIDs(t, server, "/api/v1/items?created_after=2024-01-01&updated_after=2024-06-15"))
+	assert.Empty(t, listItemIDs(t, server, "/api/v1/items?created_after=2025-01-01"))
+}
+
+func TestListItems_PaginationLinks(t *testing.T) {
+	server, db := setupTestServer(t)
+	for i := 1; i <= 5; i++ {
+		createItem(t, db, fmt.Sprintf("Movie %d", i))
+	}
+
+	w := doRequest(server, http.MethodGet, "/api/v1/items?content_type=movies&limit=2&offset=2")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp PaginatedResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 3, resp.TotalPages)
+	require.NotNil(t, resp.Links)
+
+	const base = "/api/v1/items?content_type=movies&limit=2"
+	assert.Equal(t, base+"&offset=0", resp.Links.First)
+	assert.Equal(t, base+"&offset=0", resp.Links.Prev)
+	assert.Equal(t, base+"&offset=4", resp.Links.Next)
+	assert.Equal(t, base+"&offset=4", resp.Links.Last)
+
+	// The last page has no next link
+	w = doRequest(server, http.MethodGet, "/api/v1/items?content_type=movies&limit=2&offset=4")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	resp = PaginatedResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Links)
+	assert.Equal(t, base+"&offset=2", resp.Links.Prev)
+	assert.Empty(t, resp.Links.Next)
+
+	// A forwarded scheme does not leak into the links
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/items?content_type=movies&limit=2&offset=2", nil)
+	req.Header.Set("X-Forwarded-Proto", "javascript")
+	w = httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	resp = PaginatedResponse{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Links)
+	assert.Equal(t, base+"&offset=4", resp.Links.Next)
+}
+
+func TestListItems_InvalidTimeFilter(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	for _, path := range []string{
+		"/api/v1/items?created_after=yesterday",
+		"/api/v1/items?updated_after=2024-13-01",
+	} {
+		w := doRequest(server, http.MethodGet, path)
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+
+		var resp ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, CodeInvalidTimeFilter, resp.Error)
+	}
+}
+
+func TestRestoreItem(t *testing.T) {
+	server, db := setupTestServer(t)
+	item := createItem(t, db, "Restored Movie")
+	require.NoError(t, db.Delete(&item).Error)
+
+	w := doRequest(server, http.MethodPost, fmt.Sprintf("/api/v1/items/%d/restore", item.ID))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp ItemResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, item.ID, resp.ID)
+	assert.Nil(t, resp.DeletedAt)
+
+	assert.ElementsMatch(t, []uint{item.ID}, listItemIDs(t, server, "/api/v1/items"))
+
+	w = doRequest(server, http.MethodPost, "/api/v1/items/999/restore")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestReclassifyItem_AfterRuleChange(t *testing.T) {
+	server, db := setupTestServer(t)
+	item := models.ProcessedLine{
+		LineContent: "#EXTINF:-1,Doctor Who Christmas Special",
+		LineHash:    "hash-special",
+		TvgName:     "Doctor Who Christmas Special",
+		GroupTitle:  "Britbox",
+		ContentType: models.ContentTypeUncategorized,
+		State:       models.StateProcessed,
+	}
+	require.NoError(t, db.Create(&item).Error)
+	path := fmt.Sprintf("/api/v1/items/%d/reclassify", item.ID)
+
+	reclassify := func() ReclassifyResponse {
+		w := doRequest(server, http.MethodPost, path)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp ReclassifyResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	resp := reclassify()
+	assert.False(t, resp.Changed)
+	assert.Equal(t, models.ContentTypeUncategorized, resp.Item.ContentType)
+
+	config.Get().Classifier.GroupOverrides = []config.GroupOverride{{Pattern: "(?i)britbox", ContentType: "series"}}
+
+	resp = reclassify()
+	assert.True(t, resp.Changed)
+	assert.Equal(t, models.ContentTypeUncategorized, resp.PreviousContentType)
+	assert.Equal(t, models.ContentTypeTVShows, resp.Item.ContentType)
+
+	var stored models.ProcessedLine
+	require.NoError(t, db.First(&stored, item.ID).Error)
+	assert.Equal(t, models.ContentTypeTVShows, stored.ContentType)
+
+	w := doRequest(server, http.MethodPost, "/api/v1/items/999/reclassify")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetTVShow_EpisodeTitle(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	season, episode := 1, 1
+	title := "Pilot"
+	withTitle := models.TVShow{TMDBID: 1396, TMDBTitle: "Breaking Bad", TMDBYear: 2008, Season: &season, Episode: &episode, EpisodeTitle: &title}
+	require.NoError(t, db.Create(&withTitle).Error)
+	episode = 2
+	withoutTitle := models.TVShow{TMDBID: 1396, TMDBTitle: "Breaking Bad", TMDBYear: 2008, Season: &season, Episode: &episode}
+	require.NoError(t, db.Create(&withoutTitle).Error)
+
+	w := doRequest(server, http.MethodGet, fmt.Sprintf("/api/v1/tvshows/%d", withTitle.ID))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp TVShowResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.EpisodeTitle)
+	assert.Equal(t, "Pilot", *resp.EpisodeTitle)
+
+	w = doRequest(server, http.MethodGet, fmt.Sprintf("/api/v1/tvshows/%d", withoutTitle.ID))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "episode_title")
+}
+
+func TestListCollections(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	potter, matrix := 1241, 2344
+	potterName, matrixName := "Harry Potter Collection", "The Matrix Collection"
+	movies := []models.Movie{
+		{TMDBID: 671, TMDBTitle: "Harry Potter and the Philosopher's Stone", TMDBYear: 2001, CollectionID: &potter, CollectionName: &potterName},
+		{TMDBID: 672, TMDBTitle: "Harry Potter and the Chamber of Secrets", TMDBYear: 2002, CollectionID: &potter, CollectionName: &potterName},
+		{TMDBID: 603, TMDBTitle: "The Matrix", TMDBYear: 1999, CollectionID: &matrix, CollectionName: &matrixName},
+		{TMDBID: 550, TMDBTitle: "Fight Club", TMDBYear: 1999},
+	}
+	require.NoError(t, db.Create(&movies).Error)
+
+	w := doRequest(server, http.MethodGet, "/api/v1/collections")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Collections []CollectionResponse `json:"collections"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []CollectionResponse{
+		{ID: potter, Name: potterName, MovieCount: 2},
+		{ID: matrix, Name: matrixName, MovieCount: 1},
+	}, resp.Collections)
+}
+
+func TestListCollections_Empty(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	w := doRequest(server, http.MethodGet, "/api/v1/collections")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"collections":[]}`, w.Body.String())
+}
+
+func TestListGroups(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	for i, group := range []string{"FR: FILMS - Disney+", "FR | Films | Disney", "FR | Films | Disney", "EN: Series"} {
+		item := createItem(t, db, fmt.Sprintf("Entry %d", i))
+		require.NoError(t, db.Model(&item).Updates(map[string]interface{}{
+			"group_title":      group,
+			"normalized_group": classifier.NormalizeGroupTitle(group),
+		}).Error)
+	}
+	createItem(t, db, "Not normalized yet")
+
+	w := doRequest(server, http.MethodGet, "/api/v1/groups")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Groups []GroupResponse `json:"groups"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []GroupResponse{
+		{NormalizedGroup: "films disney", ItemCount: 3, GroupTitleCount: 2},
+		{NormalizedGroup: "series", ItemCount: 1, GroupTitleCount: 1},
+	}, resp.Groups)
+
+	// Items filter by the normalized form of the given group
+	w = doRequest(server, http.MethodGet, "/api/v1/items?normalized_group=Films%20-%20Disney")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var items PaginatedResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+	assert.Equal(t, int64(3), items.Total)
+}
+
+// listTitles returns the total and the TMDB titles of a paginated movie or TV show list
+func listTitles(t *testing.T, server *Server, path string) (int64, []string) {
+	t.Helper()
+
+	w := doRequest(server, http.MethodGet, path)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Data []struct {
+			TMDBTitle string `json:"tmdb_title"`
+		} `json:"data"`
+		Total int64 `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	titles := make([]string, len(resp.Data))
+	for i, entry := range resp.Data {
+		titles[i] = entry.TMDBTitle
+	}
+	return resp.Total, titles
+}
+
+func TestListMovies_GenreFilter(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	scifi, drama := "Action, Science Fiction", "Drama"
+	movies := []models.Movie{
+		{TMDBID: 603, TMDBTitle: "The Matrix", TMDBYear: 1999, TMDBGenres: &scifi},
+		{TMDBID: 550, TMDBTitle: "Fight Club", TMDBYear: 1999, TMDBGenres: &drama},
+		{TMDBID: 27205, TMDBTitle: "Inception", TMDBYear: 2010, TMDBGenres: &scifi},
+		{TMDBID: 13, TMDBTitle: "Forrest Gump", TMDBYear: 1994},
+	}
+	require.NoError(t, db.Create(&movies).Error)
+
+	total, titles := listTitles(t, server, "/api/v1/movies?genre=science%20fiction")
+	assert.Equal(t, int64(2), total)
+	assert.ElementsMatch(t, []string{"The Matrix", "Inception"}, titles)
+
+	total, titles = listTitles(t, server, "/api/v1/movies?genre=Drama")
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"Fight Club"}, titles)
+
+	total, _ = listTitles(t, server, "/api/v1/movies")
+	assert.Equal(t, int64(4), total, "no genre returns every movie")
+}
+
+func TestListMovies_SortByYear(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	movies := []models.Movie{
+		{TMDBID: 603, TMDBTitle: "The Matrix", TMDBYear: 1999},
+		{TMDBID: 27205, TMDBTitle: "Inception", TMDBYear: 2010},
+		{TMDBID: 13, TMDBTitle: "Forrest Gump", TMDBYear: 1994},
+		{TMDBID: 550, TMDBTitle: "Fight Club", TMDBYear: 1999},
+	}
+	require.NoError(t, db.Create(&movies).Error)
+
+	// Movies of the same year keep their insertion order through the id tiebreaker
+	_, titles := listTitles(t, server, "/api/v1/movies?sort=year")
+	assert.Equal(t, []string{"Forrest Gump", "The Matrix", "Fight Club", "Inception"}, titles)
+
+	_, titles = listTitles(t, server, "/api/v1/movies?sort=title&order=desc")
+	assert.Equal(t, []string{"The Matrix", "Inception", "Forrest Gump", "Fight Club"}, titles)
+}
+
+func TestDeleteItems_Filtered(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	keep := createItem(t, db, "Kept Movie")
+	var uncategorized []uint
+	for i := 1; i <= 3; i++ {
+		item := createItem(t, db, fmt.Sprintf("Unknown %d", i))
+		require.NoError(t, db.Model(&item).Update("content_type", models.ContentTypeUncategorized).Error)
+		uncategorized = append(uncategorized, item.ID)
+	}
+
+	w := doRequest(server, http.MethodDelete, "/api/v1/items?content_type=uncategorized&confirm=true")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp BatchDeleteResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(3), resp.Deleted)
+
+	var remaining []models.ProcessedLine
+	require.NoError(t, db.Find(&remaining).Error)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, keep.ID, remaining[0].ID)
+
+	// Deleted items are soft-deleted and can still be restored
+	var deleted int64
+	require.NoError(t, db.Unscoped().Model(&models.ProcessedLine{}).Where("id IN ?", uncategorized).Where("deleted_at IS NOT NULL").Count(&deleted).Error)
+	assert.Equal(t, int64(3), deleted)
+}
+
+func TestDeleteItems_SafetyGuards(t *testing.T) {
+	server, db := setupTestServer(t)
+	createItem(t, db, "The Matrix")
+
+	tests := []struct {
+		name string
+		path string
+		code ErrorCode
+	}{
+		{"missing confirmation", "/api/v1/items?content_type=movies", CodeConfirmationRequired},
+		{"confirmation refused", "/api/v1/items?content_type=movies&confirm=false", CodeConfirmationRequired},
+		{"unfiltered", "/api/v1/items?confirm=true", CodeMissingFilter},
+		{"invalid time filter", "/api/v1/items?created_after=yesterday&confirm=true", CodeInvalidTimeFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(server, http.MethodDelete, tt.path)
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+			assert.Equal(t, tt.code, decodeError(t, w).Error)
+		})
+	}
+
+	var count int64
+	require.NoError(t, db.Model(&models.ProcessedLine{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestDeleteItems_FilterBypasses(t *testing.T) {
+	server, db := setupTestServer(t)
+	createItem(t, db, "The Matrix")
+	promo := createItem(t, db, "Promo")
+	require.NoError(t, db.Model(&promo).Update("group_title", "100% Action").Error)
+
+	// A group normalizing to nothing is not a filter
+	w := doRequest(server, http.MethodDelete, "/api/v1/items?normalized_group=!!!&confirm=true")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Equal(t, CodeMissingFilter, decodeError(t, w).Error)
+
+	// A LIKE wildcard matches itself, not every group
+	w = doRequest(server, http.MethodDelete, "/api/v1/items?group_title=%25&confirm=true")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp BatchDeleteResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, int64(1), resp.Deleted)
+
+	var remaining []models.ProcessedLine
+	require.NoError(t, db.Find(&remaining).Error)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, "The Matrix", remaining[0].TvgName)
+}
+
+func TestListItems_StableOrderOnTies(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	var ids []uint
+	createdAt := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 4; i++ {
+		item := createItem(t, db, fmt.Sprintf("Movie %d", i))
+		require.NoError(t, db.Model(&models.ProcessedLine{}).Where("id = ?", item.ID).
+			UpdateColumn("created_at", createdAt).Error)
+		ids = append(ids, item.ID)
+	}
+
+	// Pages of items sharing a created_at are ordered by id, in the sort order
+	var paged []uint
+	for offset := 0; offset < 4; offset += 2 {
+		w := doRequest(server, http.MethodGet, fmt.Sprintf("/api/v1/items?limit=2&offset=%d", offset))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp struct {
+			Data []ItemResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		for _, item := range resp.Data {
+			paged = append(paged, item.ID)
+		}
+	}
+	assert.Equal(t, []uint{ids[3], ids[2], ids[1], ids[0]}, paged)
+}
+
+func TestListTVShows_GenreFilter(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	crime, comedy, scifi := "Drama, Crime", "Comedy", "Sci-Fi & Fantasy, Drama"
+	tvshows := []models.TVShow{
+		{TMDBID: 1396, TMDBTitle: "Breaking Bad", TMDBYear: 2008, TMDBGenres: &crime},
+		{TMDBID: 2316, TMDBTitle: "The Office", TMDBYear: 2005, TMDBGenres: &comedy},
+		{TMDBID: 1399, TMDBTitle: "Game of Thrones", TMDBYear: 2011, TMDBGenres: &scifi},
+	}
+	require.NoError(t, db.Create(&tvshows).Error)
+
+	total, titles := listTitles(t, server, "/api/v1/tvshows?genre=crime")
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"Breaking Bad"}, titles)
+
+	// Genres stored before normalization match their normalized names
+	total, titles = listTitles(t, server, "/api/v1/tvshows?genre=Science%20Fiction")
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"Game of Thrones"}, titles)
+
+	total, titles = listTitles(t, server, "/api/v1/tvshows?genre=Sci-Fi%20%26%20Fantasy")
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"Game of Thrones"}, titles)
+
+	total, titles = listTitles(t, server, "/api/v1/tvshows?genre=Western")
+	assert.Equal(t, int64(0), total)
+	assert.Empty(t, titles)
+}
+
+func createFailedDownload(t *testing.T, db *gorm.DB, item models.ProcessedLine, retryCount int) models.DownloadInfo {
+	t.Helper()
+
+	errMsg := "connection reset by peer"
+	download := models.DownloadInfo{
+		URL:          *item.LineURL,
+		Status:       string(models.DownloadStatusFailed),
+		RetryCount:   retryCount,
+		ErrorMessage: &errMsg,
+	}
+	require.NoError(t, db.Create(&download).Error)
+	require.NoError(t, db.Model(&item).Update("download_info_id", download.ID).Error)
+	return download
+}
+
+func TestListDownloads_StatusFilter(t *testing.T) {
+	server, db := setupTestServer(t)
+	item := createMovieItem(t, db, "http://example.invalid/movie.mkv")
+	failed := createFailedDownload(t, db, item, 2)
+	require.NoError(t, db.Create(&models.DownloadInfo{URL: "http://example.invalid/other.mkv", Status: string(models.DownloadStatusCompleted)}).Error)
+
+	w := doRequest(server, http.MethodGet, "/api/v1/downloads?status=failed")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Data  []DownloadResponse `json:"data"`
+		Total int64              `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, int64(1), resp.Total)
+	assert.Equal(t, failed.ID, resp.Data[0].ID)
+	assert.Equal(t, 2, resp.Data[0].RetryCount)
+	require.NotNil(t, resp.Data[0].ErrorMessage)
+	assert.Equal(t, "connection reset by peer", *resp.Data[0].ErrorMessage)
+	assert.Equal(t, []uint{item.ID}, resp.Data[0].ItemIDs)
+
+	w = doRequest(server, http.MethodGet, "/api/v1/downloads?status=broken")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListDuplicateDownloads(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	create := func(hash *string, status models.DownloadStatus) models.DownloadInfo {
+		download := models.DownloadInfo{URL: "http://example.invalid/movie.mkv", Status: string(status), ContentHash: hash}
+		require.NoError(t, db.Create(&download).Error)
+		return download
+	}
+	hashA, hashB, hashC := strings.Repeat("a", 64), strings.Repeat("b", 64), strings.Repeat("c", 64)
+	a1 := create(&hashA, models.DownloadStatusCompleted)
+	b1 := create(&hashB, models.DownloadStatusCompleted)
+	a2 := create(&hashA, models.DownloadStatusCompleted)
+	b2 := create(&hashB, models.DownloadStatusCompleted)
+	// Neither a single file, a failed download nor a download without hash is a duplicate
+	create(&hashC, models.DownloadStatusCompleted)
+	create(&hashC, models.DownloadStatusFailed)
+	create(nil, models.DownloadStatusCompleted)
+	create(nil, models.DownloadStatusCompleted)
+
+	w := doRequest(server, http.MethodGet, "/api/v1/downloads/duplicates")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Duplicates []DuplicateDownloadsResponse `json:"duplicates"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Duplicates, 2)
+	assert.Equal(t, hashA, resp.Duplicates[0].Hash)
+	require.Len(t, resp.Duplicates[0].Downloads, 2)
+	assert.Equal(t, a1.ID, resp.Duplicates[0].Downloads[0].ID)
+	assert.Equal(t, a2.ID, resp.Duplicates[0].Downloads[1].ID)
+	assert.Equal(t, hashB, resp.Duplicates[1].Hash)
+	require.Len(t, resp.Duplicates[1].Downloads, 2)
+	assert.Equal(t, b1.ID, resp.Duplicates[1].Downloads[0].ID)
+	assert.Equal(t, b2.ID, resp.Duplicates[1].Downloads[1].ID)
+}
+
+func TestEnqueueDownloads_OnlyEligibleLines(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	movie := models.Movie{TMDBID: 603, TMDBTitle: "The Matrix", TMDBYear: 1999}
+	require.NoError(t, db.Create(&movie).Error)
+	create := func(name string, url *string, contentType models.ContentType, state models.ProcessingState) models.ProcessedLine {
+		line := models.ProcessedLine{
+			LineContent: "#EXTINF:-1," + name,
+			LineURL:     url,
+			LineHash:    "hash-" + name,
+			TvgName:     name,
+			GroupTitle:  "Movies",
+			ContentType: contentType,
+			State:       state,
+			MovieID:     &movie.ID,
+		}
+		require.NoError(t, db.Create(&line).Error)
+		return line
+	}
+	url := "http://example.invalid/movie.mkv"
+	first := create("The Matrix FR", &url, models.ContentTypeMovies, models.StateProcessed)
+	second := create("The Matrix EN", &url, models.ContentTypeMovies, models.StateFailed)
+	downloaded := create("The Matrix 4K", &url, models.ContentTypeMovies, models.StateDownloaded)
+	noURL := create("The Matrix SD", nil, models.ContentTypeMovies, models.StateNoURL)
+	series := create("Breaking Bad S01E01", &url, models.ContentTypeTVShows, models.StateProcessed)
+	queued := create("The Matrix MULTI", &url, models.ContentTypeMovies, models.StateProcessed)
+	createFailedDownload(t, db, queued, 1)
+
+	enqueue := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/downloads/enqueue", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := enqueue(`{"content_type":"movies","limit":1}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp EnqueueDownloadsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, EnqueueDownloadsResponse{Enqueued: 1}, resp)
+
+	w = enqueue(`{"content_type":"movies"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, EnqueueDownloadsResponse{Enqueued: 1}, resp, "the line queued by the first call is not queued again")
+
+	for _, line := range []models.ProcessedLine{first, second} {
+		require.NoError(t, db.First(&line, line.ID).Error)
+		require.NotNil(t, line.DownloadInfoID, line.TvgName)
+		var download models.DownloadInfo
+		require.NoError(t, db.First(&download, *line.DownloadInfoID).Error)
+		assert.Equal(t, string(models.DownloadStatusPending), download.Status)
+		assert.Equal(t, url, download.URL)
+	}
+	for _, line := range []models.ProcessedLine{downloaded, noURL, series} {
+		require.NoError(t, db.First(&line, line.ID).Error)
+		assert.Nil(t, line.DownloadInfoID, line.TvgName)
+	}
+	var count int64
+	require.NoError(t, db.Model(&models.DownloadInfo{}).Count(&count).Error)
+	assert.Equal(t, int64(3), count, "the already queued line keeps its download")
+
+	w = enqueue(`{"content_type":"channels"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRetryDownload_ResetsAndResumes(t *testing.T) {
+	stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/x-matroska")
+		w.Write([]byte("fake video content"))
+	}))
+	defer stream.Close()
+
+	server, db := setupTestServer(t)
+	item := createMovieItem(t, db, stream.URL+"/movie.mkv")
+	download := createFailedDownload(t, db, item, 1)
+
+	w := doRequest(server, http.MethodPost, fmt.Sprintf("/api/v1/downloads/%d/retry", download.ID))
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var resp DownloadResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, download.ID, resp.ID)
+	assert.Equal(t, 2, resp.RetryCount, "the manual retry counts towards the limit")
+	assert.Nil(t, resp.ErrorMessage)
+
+	require.Eventually(t, func() bool {
+		var info models.DownloadInfo
+		if err := db.First(&info, download.ID).Error; err != nil {
+			return false
+		}
+		return info.Status == string(models.DownloadStatusCompleted) && info.LockedAt == nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// A completed download cannot be retried
+	w = doRequest(server, http.MethodPost, fmt.Sprintf("/api/v1/downloads/%d/retry", download.ID))
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+}
+
+func TestRetryDownload_MaxRetriesExceeded(t *testing.T) {
+	server, db := setupTestServer(t)
+	item := createMovieItem(t, db, "http://example.invalid/movie.mkv")
+	download := createFailedDownload(t, db, item, config.Get().Downloads.MaxRetryAttempts)
+
+	w := doRequest(server, http.MethodPost, fmt.Sprintf("/api/v1/downloads/%d/retry", download.ID))
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, CodeMaxRetriesExceeded, resp.Error)
+
+	var info models.DownloadInfo
+	require.NoError(t, db.First(&info, download.ID).Error)
+	assert.Equal(t, string(models.DownloadStatusFailed), info.Status)
+}
+
+func TestSetDownloadPriority(t *testing.T) {
+	server, db := setupTestServer(t)
+	item := createMovieItem(t, db, "http://example.invalid/movie.mkv")
+	download := createFailedDownload(t, db, item, 0)
+
+	setPriority := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := setPriority(fmt.Sprintf("/api/v1/downloads/%d/priority", download.ID), `{"priority": 10}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp DownloadResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 10, resp.Priority)
+	assert.Equal(t, []uint{item.ID}, resp.ItemIDs)
+
+	var info models.DownloadInfo
+	require.NoError(t, db.First(&info, download.ID).Error)
+	assert.Equal(t, 10, info.Priority)
+
+	w = setPriority(fmt.Sprintf("/api/v1/downloads/%d/priority", download.ID), `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "the priority is required")
+
+	w = setPriority("/api/v1/downloads/9999/priority", `{"priority": 1}`)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	assert.Equal(t, CodeDownloadNotFound, decodeError(t, w).Error)
+}
+
+func TestVerifyDownloads_ReconcilesMissingFile(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	present := filepath.Join(t.TempDir(), "present.mkv")
+	require.NoError(t, os.WriteFile(present, []byte("data"), 0644))
+	gone := filepath.Join(t.TempDir(), "gone.mkv")
+
+	kept := models.DownloadInfo{Status: string(models.DownloadStatusCompleted), DownloadPath: &present}
+	missing := models.DownloadInfo{Status: string(models.DownloadStatusCompleted), DownloadPath: &gone}
+	require.NoError(t, db.Create(&kept).Error)
+	require.NoError(t, db.Create(&missing).Error)
+
+	w := doRequest(server, http.MethodPost, "/api/v1/downloads/verify")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp downloader.VerifyStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Checked)
+	assert.Equal(t, []string{gone}, resp.Missing)
+	assert.Equal(t, 1, resp.Reconciled)
+
+	var info models.DownloadInfo
+	require.NoError(t, db.First(&info, missing.ID).Error)
+	assert.Equal(t, string(models.DownloadStatusPending), info.Status)
+}
+
+func TestListItemDownloads_History(t *testing.T) {
+	server, db := setupTestServer(t)
+	item := createMovieItem(t, db, "http://example.invalid/movie.mkv")
+
+	errMsg := "connection reset by peer"
+	first := models.DownloadInfo{
+		URL:             *item.LineURL,
+		Status:          string(models.DownloadStatusFailed),
+		ErrorMessage:    &errMsg,
+		ProcessedLineID: &item.ID,
+		CreatedAt:       time.Now().Add(-time.Hour),
+	}
+	path := "/movies/The Matrix (1999)/The Matrix (1999).mkv"
+	size := int64(1024)
+	second := models.DownloadInfo{
+		URL:             *item.LineURL,
+		Status:          string(models.DownloadStatusCompleted),
+		DownloadPath:    &path,
+		BytesDownloaded: &size,
+		ProcessedLineID: &item.ID,
+	}
+	require.NoError(t, db.Create(&first).Error)
+	require.NoError(t, db.Create(&second).Error)
+	require.NoError(t, db.Model(&item).Update("download_info_id", second.ID).Error)
+
+	// Another item's download is not part of the history
+	other := createItem(t, db, "Other Movie")
+	require.NoError(t, db.Create(&models.DownloadInfo{URL: "http://example.invalid/other.mkv", Status: string(models.DownloadStatusCompleted), ProcessedLineID: &other.ID}).Error)
+
+	w := doRequest(server, http.MethodGet, fmt.Sprintf("/api/v1/items/%d/downloads", item.ID))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp ItemDownloadsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, item.ID, resp.ItemID)
+	require.NotNil(t, resp.CurrentDownloadID)
+	assert.Equal(t, second.ID, *resp.CurrentDownloadID)
+	require.Len(t, resp.Downloads, 2)
+	assert.Equal(t, second.ID, resp.Downloads[0].ID, "newest first")
+	assert.Equal(t, string(models.DownloadStatusCompleted), resp.Downloads[0].Status)
+	assert.Equal(t, first.ID, resp.Downloads[1].ID)
+	require.NotNil(t, resp.Downloads[1].ErrorMessage)
+	assert.Equal(t, errMsg, *resp.Downloads[1].ErrorMessage)
+
+	w = doRequest(server, http.MethodGet, "/api/v1/items/999/downloads")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDownloadItem_RedownloadKeepsHistory(t *testing.T) {
+	stream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/x-matroska")
+		w.Write([]byte("fake video content"))
+	}))
+	defer stream.Close()
+
+	server, db := setupTestServer(t)
+	item := createMovieItem(t, db, stream.URL+"/movie.mkv")
+
+	previous := models.DownloadInfo{
+		URL:             *item.LineURL,
+		Status:          string(models.DownloadStatusCompleted),
+		ProcessedLineID: &item.ID,
+	}
+	require.NoError(t, db.Create(&previous).Error)
+	require.NoError(t, db.Model(&item).Update("download_info_id", previous.ID).Error)
+
+	w := doRequest(server, http.MethodPost, fmt.Sprintf("/api/v1/items/%d/download", item.ID))
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var trigger DownloadTriggerResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trigger))
+	assert.NotEqual(t, previous.ID, trigger.DownloadInfoID, "a completed download is not overwritten")
+
+	require.Eventually(t, func() bool {
+		var info models.DownloadInfo
+		if err := db.First(&info, trigger.DownloadInfoID).Error; err != nil {
+			return false
+		}
+		return info.Status == string(models.DownloadStatusCompleted) && info.LockedAt == nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	w = doRequest(server, http.MethodGet, fmt.Sprintf("/api/v1/items/%d/downloads", item.ID))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var history ItemDownloadsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	require.Len(t, history.Downloads, 2)
+	assert.Equal(t, trigger.DownloadInfoID, history.Downloads[0].ID)
+	assert.Equal(t, previous.ID, history.Downloads[1].ID)
+}
+
+func TestCreateFilter_InvalidPatterns(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	postFilter := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/filters", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := postFilter(`{"name":"movies","attribute":"group_title","include_patterns":"[\"^FR\", \"(unclosed\"]","exclude_patterns":"[\"XXX\"]"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	var resp InvalidPatternsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, CodeInvalidPattern, resp.Error)
+	require.Len(t, resp.InvalidPatterns, 1, "only the invalid pattern is reported")
+	assert.Equal(t, "include_patterns", resp.InvalidPatterns[0].Field)
+	assert.Equal(t, "(unclosed", resp.InvalidPatterns[0].Pattern)
+	assert.Contains(t, resp.InvalidPatterns[0].Error, "missing closing )")
+
+	var count int64
+	require.NoError(t, db.Model(&models.FilterConfig{}).Count(&count).Error)
+	assert.Zero(t, count, "an invalid filter is not stored")
+
+	w = postFilter(`{"name":"movies","attribute":"group_title","exclude_patterns":"XXX"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Equal(t, CodeInvalidPattern, decodeError(t, w).Error)
+
+	w = postFilter(`{"name":"movies","attribute":"group_title","include_patterns":"[\"^FR\"]"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
+
+func TestUpdateFilter_InvalidPatterns(t *testing.T) {
+	server, db := setupTestServer(t)
+	include := `["^FR"]`
+	filter := models.FilterConfig{Name: "movies", Attribute: "group_title", IncludePatterns: &include, IsRuntime: true}
+	require.NoError(t, db.Create(&filter).Error)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v1/filters/%d", filter.ID), strings.NewReader(`{"exclude_patterns":"[\"[a-\"]"}`))
+	req.Header.Set("Content-Type", "application/json")
+	server.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	var resp InvalidPatternsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.InvalidPatterns, 1)
+	assert.Equal(t, "exclude_patterns", resp.InvalidPatterns[0].Field)
+	assert.Equal(t, "[a-", resp.InvalidPatterns[0].Pattern)
+
+	var stored models.FilterConfig
+	require.NoError(t, db.First(&stored, filter.ID).Error)
+	assert.Nil(t, stored.ExcludePatterns, "the filter is left unchanged")
+}
+
+func TestExecuteDryRun_Limits(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	dir := t.TempDir()
+	m3uPath := filepath.Join(dir, "playlist.m3u")
+	require.NoError(t, os.WriteFile(m3uPath, []byte("#EXTM3U\n#EXTINF:-1 group-title=\"Movies\",The Matrix (1999)\nhttp://example.com/matrix.mkv\n"), 0o644))
+	largePath := filepath.Join(dir, "large.m3u")
+	require.NoError(t, os.WriteFile(largePath, make([]byte, 2*1024*1024), 0o644))
+
+	dryRun := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/dryrun", strings.NewReader(fmt.Sprintf(`{"file_path":%q}`, path)))
+		req.Header.Set("Content-Type", "application/json")
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := dryRun(m3uPath)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	t.Setenv("STALKEER_API_DRYRUN_MAX_FILE_SIZE_MB", "1")
+	require.NoError(t, config.Load())
+	w = dryRun(largePath)
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	assert.Equal(t, CodeFileTooLarge, decodeError(t, w).Error)
+
+	t.Setenv("STALKEER_API_DRYRUN_TIMEOUT", "1ns")
+	require.NoError(t, config.Load())
+	start := time.Now()
+	w = dryRun(m3uPath)
+	assert.Less(t, time.Since(start), time.Second)
+	require.Equal(t, http.StatusGatewayTimeout, w.Code, w.Body.String())
+	assert.Equal(t, CodeDryRunTimeout, decodeError(t, w).Error)
+}
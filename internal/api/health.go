@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/downloader"
+	"github.com/glefebvre/stalkeer/internal/external/radarr"
+	"github.com/glefebvre/stalkeer/internal/external/sonarr"
+	"github.com/glefebvre/stalkeer/internal/external/tmdb"
+)
+
+const (
+	// healthCheckTimeout bounds each component check of the detailed health endpoint
+	healthCheckTimeout = 5 * time.Second
+
+	// minFreeDiskBytes is the available space below which a download path is unhealthy
+	minFreeDiskBytes = 1 << 30
+)
+
+// Component health statuses
+const (
+	healthStatusHealthy   = "healthy"
+	healthStatusDegraded  = "degraded"
+	healthStatusUnhealthy = "unhealthy"
+	healthStatusDisabled  = "disabled"
+)
+
+// componentCheck checks a single subsystem. Details are reported even when the check fails.
+type componentCheck struct {
+	name     string
+	critical bool
+	disabled bool
+	check    func(ctx context.Context) (map[string]interface{}, error)
+}
+
+// detailedHealthCheck reports the status and latency of each subsystem. It returns
+// 200 unless a critical component is unhealthy, in which case it returns 503.
+func (s *Server) detailedHealthCheck(c *gin.Context) {
+	checks := s.healthChecks()
+	components := make([]ComponentHealth, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		if check.disabled {
+			components[i] = ComponentHealth{Name: check.name, Status: healthStatusDisabled, Critical: check.critical}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, check componentCheck) {
+			defer wg.Done()
+			components[i] = runComponentCheck(c.Request.Context(), check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	status := healthStatusHealthy
+	for _, component := range components {
+		if component.Status != healthStatusUnhealthy {
+			continue
+		}
+		if component.Critical {
+			status = healthStatusUnhealthy
+			break
+		}
+		status = healthStatusDegraded
+	}
+
+	code := http.StatusOK
+	if status == healthStatusUnhealthy {
+		code = http.StatusServiceUnavailable
+	}
+
+	c.JSON(code, DetailedHealthResponse{
+		Status:     status,
+		Components: components,
+	})
+}
+
+// livenessProbe reports that the process is running. It never checks dependencies,
+// so that a transient database outage does not get the process restarted.
+func (s *Server) livenessProbe(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+// readinessProbe reports whether the server can serve requests: startup completed,
+// the database is reachable and its migrations are applied. It returns 503 otherwise.
+func (s *Server) readinessProbe(c *gin.Context) {
+	if !s.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, DetailedHealthResponse{
+			Status: healthStatusUnhealthy,
+			Components: []ComponentHealth{{
+				Name:     "startup",
+				Status:   healthStatusUnhealthy,
+				Critical: true,
+				Error:    "server is not ready",
+			}},
+		})
+		return
+	}
+
+	checks := s.readinessChecks()
+	components := make([]ComponentHealth, len(checks))
+	status, code := healthStatusHealthy, http.StatusOK
+	for i, check := range checks {
+		components[i] = runComponentCheck(c.Request.Context(), check)
+		if components[i].Status == healthStatusUnhealthy {
+			status, code = healthStatusUnhealthy, http.StatusServiceUnavailable
+		}
+	}
+
+	c.JSON(code, DetailedHealthResponse{
+		Status:     status,
+		Components: components,
+	})
+}
+
+// runComponentCheck runs check with a timeout and measures its latency
+func runComponentCheck(ctx context.Context, check componentCheck) ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check.check(ctx)
+	latency := time.Since(start)
+
+	component := ComponentHealth{
+		Name:      check.name,
+		Status:    healthStatusHealthy,
+		Critical:  check.critical,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		component.Status = healthStatusUnhealthy
+		component.Error = err.Error()
+	}
+	return component
+}
+
+// defaultHealthChecks builds the component checks from the current configuration.
+// The database and download paths are critical, external services are not.
+func defaultHealthChecks() []componentCheck {
+	cfg := config.Get()
+
+	checks := []componentCheck{
+		{name: "database", critical: true, check: checkDatabase},
+		{
+			name:     "tmdb",
+			disabled: !cfg.TMDB.Enabled || cfg.TMDB.APIKey == "",
+			check: func(ctx context.Context) (map[string]interface{}, error) {
+				client := tmdb.NewClient(tmdb.Config{APIKey: cfg.TMDB.APIKey, Timeout: healthCheckTimeout})
+				return nil, client.Ping(ctx)
+			},
+		},
+		{
+			name:     "radarr",
+			disabled: !cfg.Radarr.Enabled || cfg.Radarr.URL == "",
+			check: func(ctx context.Context) (map[string]interface{}, error) {
+				details := map[string]interface{}{"url": cfg.Radarr.URL}
+				client, err := radarr.New(radarr.Config{BaseURL: cfg.Radarr.URL, APIKey: cfg.Radarr.APIKey, Timeout: healthCheckTimeout})
+				if err != nil {
+					return details, err
+				}
+				return details, client.Ping(ctx)
+			},
+		},
+		{
+			name:     "sonarr",
+			disabled: !cfg.Sonarr.Enabled || cfg.Sonarr.URL == "",
+			check: func(ctx context.Context) (map[string]interface{}, error) {
+				details := map[string]interface{}{"url": cfg.Sonarr.URL}
+				client, err := sonarr.New(sonarr.Config{BaseURL: cfg.Sonarr.URL, APIKey: cfg.Sonarr.APIKey, Timeout: healthCheckTimeout})
+				if err != nil {
+					return details, err
+				}
+				return details, client.Ping(ctx)
+			},
+		},
+	}
+
+	paths := []struct{ name, path string }{
+		{"disk_movies", cfg.Downloads.MoviesPath},
+		{"disk_tvshows", cfg.Downloads.TVShowsPath},
+	}
+	for _, p := range paths {
+		path := p.path
+		checks = append(checks, componentCheck{
+			name:     p.name,
+			critical: true,
+			disabled: path == "",
+			check: func(ctx context.Context) (map[string]interface{}, error) {
+				return checkDiskSpace(path)
+			},
+		})
+	}
+
+	return checks
+}
+
+// defaultReadinessChecks builds the checks of the readiness probe. Every check is critical.
+func defaultReadinessChecks() []componentCheck {
+	return []componentCheck{
+		{name: "database", critical: true, check: checkDatabase},
+		{name: "migrations", critical: true, check: checkMigrations},
+	}
+}
+
+// checkDatabase pings the primary database
+func checkDatabase(ctx context.Context) (map[string]interface{}, error) {
+	db := database.Get()
+	if db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("database ping failed: %w", err)
+	}
+
+	return map[string]interface{}{"open_connections": sqlDB.Stats().OpenConnections}, nil
+}
+
+// checkMigrations reports the schema migrations that are not applied yet
+func checkMigrations(ctx context.Context) (map[string]interface{}, error) {
+	db := database.Get()
+	if db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	pending, err := database.PendingMigrations(db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		return map[string]interface{}{"pending": len(pending)},
+			fmt.Errorf("%d pending migrations, run 'stalkeer migrate'", len(pending))
+	}
+	return map[string]interface{}{"pending": 0}, nil
+}
+
+// checkDiskSpace reports the free space of a download path
+func checkDiskSpace(path string) (map[string]interface{}, error) {
+	space, err := downloader.GetDiskSpace(path)
+	if err != nil {
+		return map[string]interface{}{"path": path}, err
+	}
+
+	details := map[string]interface{}{
+		"path":            path,
+		"available_bytes": space.Available,
+		"total_bytes":     space.Total,
+		"used_pct":        space.UsedPct,
+	}
+	if space.Available < minFreeDiskBytes {
+		return details, fmt.Errorf("low disk space: %s available", downloader.FormatBytes(space.Available))
+	}
+	return details, nil
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockCheck(name string, critical bool, err error) componentCheck {
+	return componentCheck{
+		name:     name,
+		critical: critical,
+		check: func(ctx context.Context) (map[string]interface{}, error) {
+			return map[string]interface{}{"mocked": true}, err
+		},
+	}
+}
+
+func getDetailedHealth(t *testing.T, server *Server) (int, DetailedHealthResponse) {
+	t.Helper()
+
+	w := doRequest(server, http.MethodGet, "/health/detailed")
+	var resp DetailedHealthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func TestDetailedHealthCheck_AllHealthy(t *testing.T) {
+	server, _ := setupTestServer(t)
+	server.healthChecks = func() []componentCheck {
+		return []componentCheck{
+			mockCheck("database", true, nil),
+			mockCheck("tmdb", false, nil),
+			{name: "radarr", disabled: true},
+		}
+	}
+
+	code, resp := getDetailedHealth(t, server)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "healthy", resp.Status)
+	require.Len(t, resp.Components, 3)
+	assert.Equal(t, "database", resp.Components[0].Name)
+	assert.Equal(t, "healthy", resp.Components[0].Status)
+	assert.Equal(t, true, resp.Components[0].Details["mocked"])
+	assert.Equal(t, "disabled", resp.Components[2].Status)
+}
+
+func TestDetailedHealthCheck_NonCriticalFailureIsDegraded(t *testing.T) {
+	server, _ := setupTestServer(t)
+	server.healthChecks = func() []componentCheck {
+		return []componentCheck{
+			mockCheck("database", true, nil),
+			mockCheck("sonarr", false, errors.New("connection refused")),
+		}
+	}
+
+	code, resp := getDetailedHealth(t, server)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "degraded", resp.Status)
+	assert.Equal(t, "unhealthy", resp.Components[1].Status)
+	assert.Equal(t, "connection refused", resp.Components[1].Error)
+}
+
+func TestDetailedHealthCheck_CriticalFailureIsUnhealthy(t *testing.T) {
+	server, _ := setupTestServer(t)
+	server.healthChecks = func() []componentCheck {
+		return []componentCheck{
+			mockCheck("database", true, nil),
+			mockCheck("tmdb", false, errors.New("timeout")),
+			mockCheck("disk_movies", true, errors.New("low disk space: 10.0 MB available")),
+		}
+	}
+
+	code, resp := getDetailedHealth(t, server)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unhealthy", resp.Status)
+	assert.True(t, resp.Components[2].Critical)
+	assert.Equal(t, "unhealthy", resp.Components[2].Status)
+}
+
+func TestDefaultHealthChecks(t *testing.T) {
+	server, _ := setupTestServer(t)
+	server.healthChecks = defaultHealthChecks
+
+	code, resp := getDetailedHealth(t, server)
+	assert.Equal(t, http.StatusOK, code, "database and download paths should be healthy")
+
+	statuses := make(map[string]string, len(resp.Components))
+	for _, component := range resp.Components {
+		statuses[component.Name] = component.Status
+	}
+	assert.Equal(t, "healthy", statuses["database"])
+	assert.Equal(t, "healthy", statuses["disk_movies"])
+	assert.Equal(t, "disabled", statuses["radarr"])
+	assert.Equal(t, "disabled", statuses["sonarr"])
+}
+
+func TestLivenessProbe(t *testing.T) {
+	server, db := setupTestServer(t)
+
+	// Liveness does not depend on the database
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	w := doRequest(server, http.MethodGet, "/livez")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func getReadiness(t *testing.T, server *Server) (int, DetailedHealthResponse) {
+	t.Helper()
+
+	w := doRequest(server, http.MethodGet, "/readyz")
+	var resp DetailedHealthResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return w.Code, resp
+}
+
+func TestReadinessProbe_Ready(t *testing.T) {
+	server, db := setupTestServer(t)
+	_, err := database.Migrate(db)
+	require.NoError(t, err)
+	server.SetReady(true)
+
+	code, resp := getReadiness(t, server)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "healthy", resp.Status)
+	require.Len(t, resp.Components, 2)
+	assert.Equal(t, "database", resp.Components[0].Name)
+	assert.Equal(t, "migrations", resp.Components[1].Name)
+}
+
+func TestReadinessProbe_NotStarted(t *testing.T) {
+	server, db := setupTestServer(t)
+	_, err := database.Migrate(db)
+	require.NoError(t, err)
+
+	code, resp := getReadiness(t, server)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	require.Len(t, resp.Components, 1)
+	assert.Equal(t, "startup", resp.Components[0].Name)
+}
+
+func TestReadinessProbe_PendingMigrations(t *testing.T) {
+	server, _ := setupTestServer(t)
+	server.SetReady(true)
+
+	code, resp := getReadiness(t, server)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "healthy", resp.Components[0].Status)
+	assert.Equal(t, "unhealthy", resp.Components[1].Status)
+	assert.Contains(t, resp.Components[1].Error, "pending migrations")
+}
+
+func TestReadinessProbe_DatabaseDown(t *testing.T) {
+	server, db := setupTestServer(t)
+	_, err := database.Migrate(db)
+	require.NoError(t, err)
+	server.SetReady(true)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	code, resp := getReadiness(t, server)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unhealthy", resp.Status)
+	assert.Equal(t, "database", resp.Components[0].Name)
+	assert.Equal(t, "unhealthy", resp.Components[0].Status)
+}
+
+func TestReadinessProbe_NotReadyAfterShutdown(t *testing.T) {
+	server, db := setupTestServer(t)
+	_, err := database.Migrate(db)
+	require.NoError(t, err)
+	server.SetReady(true)
+
+	require.NoError(t, server.Shutdown(context.Background()))
+
+	code, _ := getReadiness(t, server)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+}
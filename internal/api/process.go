@@ -0,0 +1,235 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/glefebvre/stalkeer/internal/processor"
+	"github.com/google/uuid"
+)
+
+// Process job statuses
+const (
+	processJobRunning   = "running"
+	processJobCompleted = "completed"
+	processJobCancelled = "cancelled"
+	processJobFailed    = "failed"
+)
+
+// processRunFunc processes an M3U file. It must call opts.OnProgress as it goes.
+type processRunFunc func(ctx context.Context, filePath string, opts processor.ProcessOptions) (*processor.Statistics, error)
+
+// runProcess processes filePath with a new processor, like the process command
+func runProcess(ctx context.Context, filePath string, opts processor.ProcessOptions) (*processor.Statistics, error) {
+	proc, err := processor.NewProcessor(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return proc.Process(ctx, opts)
+}
+
+// processJob is a process run started from the API
+type processJob struct {
+	id         string
+	filePath   string
+	status     string
+	phase      string
+	startedAt  time.Time
+	finishedAt *time.Time
+	stats      processor.Statistics
+	err        string
+	cancel     context.CancelFunc
+}
+
+// processJobRegistry keeps the process jobs in memory and ensures only one runs at a time
+type processJobRegistry struct {
+	mu     sync.Mutex
+	jobs   map[string]*processJob
+	active *processJob
+}
+
+func newProcessJobRegistry() *processJobRegistry {
+	return &processJobRegistry{jobs: make(map[string]*processJob)}
+}
+
+// start registers a running job for filePath, or returns false when a job is already running
+func (r *processJobRegistry) start(filePath string, cancel context.CancelFunc) (*processJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active != nil {
+		return r.active, false
+	}
+
+	job := &processJob{
+		id:        uuid.New().String(),
+		filePath:  filePath,
+		status:    processJobRunning,
+		startedAt: time.Now(),
+		cancel:    cancel,
+	}
+	r.jobs[job.id] = job
+	r.active = job
+	return job, true
+}
+
+// progress records the phase and a snapshot of the statistics of a running job
+func (r *processJobRegistry) progress(job *processJob, event processor.ProgressEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.phase = event.Phase
+	job.stats = event.Stats
+}
+
+// finish records the outcome of a job and releases the running slot
+func (r *processJobRegistry) finish(job *processJob, stats *processor.Statistics, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	job.finishedAt = &now
+	job.phase = ""
+	switch {
+	case err != nil:
+		job.status = processJobFailed
+		job.err = err.Error()
+	case stats.Cancelled:
+		job.status = processJobCancelled
+	default:
+		job.status = processJobCompleted
+	}
+	if stats != nil {
+		job.stats = *stats
+	}
+	if r.active == job {
+		r.active = nil
+	}
+}
+
+// get returns the response of a job, or false when it is unknown
+func (r *processJobRegistry) get(id string) (ProcessJobResponse, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return ProcessJobResponse{}, false
+	}
+	return job.response(), true
+}
+
+// cancelActive stops the running job, if any. Entries already handled are kept.
+func (r *processJobRegistry) cancelActive() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active != nil {
+		r.active.cancel()
+	}
+}
+
+// response must be called with the registry lock held
+func (job *processJob) response() ProcessJobResponse {
+	return ProcessJobResponse{
+		JobID:      job.id,
+		Status:     job.status,
+		Phase:      job.phase,
+		FilePath:   job.filePath,
+		StartedAt:  job.startedAt.Format(time.RFC3339),
+		FinishedAt: formatTime(job.finishedAt),
+		Stats:      job.stats,
+		Error:      job.err,
+	}
+}
+
+// startProcess starts processing the M3U file in the background and returns the job
+// to poll with getProcessJob. Only one job runs at a time.
+func (s *Server) startProcess(c *gin.Context) {
+	var req struct {
+		FilePath         *string `json:"file_path,omitempty"`
+		Force            bool    `json:"force"`
+		ChangedOnly      bool    `json:"changed_only"`
+		MarkRemoved      bool    `json:"mark_removed"`
+		Limit            int     `json:"limit"`
+		SkipTMDB         bool    `json:"skip_tmdb"`
+		DedupeByMetadata bool    `json:"dedupe_by_metadata"`
+	}
+
+	// An empty body processes the configured file with the default options
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, CodeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	filePath := config.Get().M3U.FilePath
+	if req.FilePath != nil {
+		filePath = *req.FilePath
+	}
+	if filePath == "" {
+		respondError(c, CodeMissingFilePath, "M3U file path must be provided")
+		return
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		respondError(c, CodeInvalidRequest, fmt.Sprintf("M3U file %s is not readable: %v", filePath, err))
+		return
+	}
+	if req.Force && req.ChangedOnly {
+		respondError(c, CodeInvalidRequest, "force and changed_only cannot be used together")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job, started := s.processJobs.start(filePath, cancel)
+	if !started {
+		cancel()
+		respondError(c, CodeProcessInProgress, fmt.Sprintf("process job %s is already running", job.id))
+		return
+	}
+
+	opts := processor.ProcessOptions{
+		Force:            req.Force,
+		ChangedOnly:      req.ChangedOnly,
+		MarkRemoved:      req.MarkRemoved,
+		Limit:            req.Limit,
+		SkipTMDB:         req.SkipTMDB,
+		DedupeByMetadata: req.DedupeByMetadata,
+		OnProgress: func(event processor.ProgressEvent) {
+			s.processJobs.progress(job, event)
+		},
+	}
+
+	go func() {
+		defer cancel()
+		stats, err := s.runProcess(ctx, filePath, opts)
+		if err != nil {
+			logger.AppLogger().WithFields(map[string]interface{}{
+				"job_id": job.id,
+				"file":   filePath,
+				"error":  err,
+			}).Warn("API-triggered process run failed")
+		}
+		s.processJobs.finish(job, stats, err)
+	}()
+
+	resp, _ := s.processJobs.get(job.id)
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// getProcessJob returns the status and current statistics of a process job
+func (s *Server) getProcessJob(c *gin.Context) {
+	resp, ok := s.processJobs.get(c.Param("jobid"))
+	if !ok {
+		respondError(c, CodeProcessJobNotFound, "process job not found")
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/processor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingRun returns a process runner that reports progress, then waits for release
+func blockingRun(progressed chan<- struct{}, release <-chan struct{}) processRunFunc {
+	return func(ctx context.Context, filePath string, opts processor.ProcessOptions) (*processor.Statistics, error) {
+		opts.OnProgress(processor.ProgressEvent{
+			Phase:     processor.PhaseProcessing,
+			Processed: 4,
+			Total:     10,
+			Stats:     processor.Statistics{TotalLines: 10, Processed: 4},
+		})
+		progressed <- struct{}{}
+		<-release
+		return &processor.Statistics{TotalLines: 10, Processed: 10, Movies: 7}, nil
+	}
+}
+
+func startProcessJob(t *testing.T, server *Server, filePath string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]interface{}{"file_path": filePath, "skip_tmdb": true})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/process", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	server.router.ServeHTTP(w, req)
+	return w
+}
+
+func getProcessJob(t *testing.T, server *Server, jobID string) ProcessJobResponse {
+	t.Helper()
+
+	w := doRequest(server, http.MethodGet, "/api/v1/process/"+jobID)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp ProcessJobResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func writePlaylist(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "playlist.m3u")
+	require.NoError(t, os.WriteFile(path, []byte("#EXTM3U\n"), 0644))
+	return path
+}
+
+func TestStartProcess_ReportsProgressAndCompletion(t *testing.T) {
+	server, _ := setupTestServer(t)
+	progressed, release := make(chan struct{}, 1), make(chan struct{})
+	server.runProcess = blockingRun(progressed, release)
+	playlist := writePlaylist(t)
+
+	w := startProcessJob(t, server, playlist)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var started ProcessJobResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &started))
+	assert.NotEmpty(t, started.JobID)
+	assert.Equal(t, processJobRunning, started.Status)
+	assert.Equal(t, playlist, started.FilePath)
+
+	<-progressed
+	running := getProcessJob(t, server, started.JobID)
+	assert.Equal(t, processJobRunning, running.Status)
+	assert.Equal(t, processor.PhaseProcessing, running.Phase)
+	assert.Equal(t, 4, running.Stats.Processed)
+	assert.Equal(t, 10, running.Stats.TotalLines)
+	assert.Nil(t, running.FinishedAt)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		return getProcessJob(t, server, started.JobID).Status == processJobCompleted
+	}, 2*time.Second, 10*time.Millisecond)
+
+	done := getProcessJob(t, server, started.JobID)
+	assert.Equal(t, 10, done.Stats.Processed)
+	assert.Equal(t, 7, done.Stats.Movies)
+	assert.NotNil(t, done.FinishedAt)
+}
+
+func TestStartProcess_ConflictWhileRunning(t *testing.T) {
+	server, _ := setupTestServer(t)
+	progressed, release := make(chan struct{}, 2), make(chan struct{})
+	server.runProcess = blockingRun(progressed, release)
+	playlist := writePlaylist(t)
+
+	w := startProcessJob(t, server, playlist)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var first ProcessJobResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+
+	w = startProcessJob(t, server, playlist)
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	assert.Equal(t, CodeProcessInProgress, decodeError(t, w).Error)
+
+	// A new job can start once the running one finished
+	close(release)
+	require.Eventually(t, func() bool {
+		return getProcessJob(t, server, first.JobID).Status == processJobCompleted
+	}, 2*time.Second, 10*time.Millisecond)
+
+	w = startProcessJob(t, server, playlist)
+	assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+}
+
+func TestStartProcess_Failure(t *testing.T) {
+	server, _ := setupTestServer(t)
+	server.runProcess = func(ctx context.Context, filePath string, opts processor.ProcessOptions) (*processor.Statistics, error) {
+		return nil, assert.AnError
+	}
+
+	w := startProcessJob(t, server, writePlaylist(t))
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var started ProcessJobResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &started))
+
+	require.Eventually(t, func() bool {
+		return getProcessJob(t, server, started.JobID).Status == processJobFailed
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, assert.AnError.Error(), getProcessJob(t, server, started.JobID).Error)
+}
+
+func TestStartProcess_MissingFile(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	w := startProcessJob(t, server, filepath.Join(t.TempDir(), "missing.m3u"))
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Equal(t, CodeInvalidRequest, decodeError(t, w).Error)
+}
+
+func TestGetProcessJob_NotFound(t *testing.T) {
+	server, _ := setupTestServer(t)
+
+	w := doRequest(server, http.MethodGet, "/api/v1/process/unknown")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, CodeProcessJobNotFound, decodeError(t, w).Error)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxTagLength is the length of the tags.name column
+const maxTagLength = 64
+
+// normalizeTagName trims and lower cases a tag, so "Keep" and "keep " are one tag
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// addItemTags attaches tags to an item, creating the tags that do not exist yet.
+// Tags already attached are left as they are.
+func (s *Server) addItemTags(c *gin.Context) {
+	db := database.Get()
+	id := c.Param("id")
+
+	var req AddTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	names := make([]string, 0, len(req.Tags))
+	for _, tag := range req.Tags {
+		name := normalizeTagName(tag)
+		if name == "" || len(name) > maxTagLength {
+			respondError(c, CodeInvalidRequest, fmt.Sprintf("tag %q must be 1 to %d characters long", tag, maxTagLength))
+			return
+		}
+		names = append(names, name)
+	}
+
+	var item models.ProcessedLine
+	if err := db.First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(c, CodeItemNotFound, fmt.Sprintf("item with id %s not found", id))
+			return
+		}
+		respondError(c, CodeDBError, "failed to fetch item")
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tags := make([]models.Tag, len(names))
+		for i, name := range names {
+			if err := tx.Where(models.Tag{Name: name}).FirstOrCreate(&tags[i]).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&item).Association("Tags").Append(tags)
+	})
+	if err != nil {
+		respondError(c, CodeDBError, "failed to tag item")
+		return
+	}
+
+	if err := db.Preload("Movie").Preload("TVShow").Preload("Tags").First(&item, item.ID).Error; err != nil {
+		respondError(c, CodeDBError, "failed to fetch item")
+		return
+	}
+
+	c.JSON(http.StatusOK, toItemResponse(item))
+}
+
+// removeItemTag detaches a tag from an item. The tag itself is kept for other items.
+func (s *Server) removeItemTag(c *gin.Context) {
+	db := database.Get()
+	id := c.Param("id")
+	name := normalizeTagName(c.Param("tag"))
+
+	var item models.ProcessedLine
+	if err := db.First(&item, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			respondError(c, CodeItemNotFound, fmt.Sprintf("item with id %s not found", id))
+			return
+		}
+		respondError(c, CodeDBError, "failed to fetch item")
+		return
+	}
+
+	result := db.Where("processed_line_id = ? AND tag_id IN (?)", item.ID,
+		db.Model(&models.Tag{}).Select("id").Where("name = ?", name)).
+		Delete(&models.ProcessedLineTag{})
+	if result.Error != nil {
+		respondError(c, CodeDBError, "failed to remove tag")
+		return
+	}
+	if result.RowsAffected == 0 {
+		respondError(c, CodeTagNotFound, fmt.Sprintf("item with id %s is not tagged %q", id, name))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addTags(t *testing.T, server *Server, itemID uint, tags ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(AddTagsRequest{Tags: tags})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/items/%d/tags", itemID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	server.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAddItemTags(t *testing.T) {
+	server, db := setupTestServer(t)
+	item := createItem(t, db, "The Matrix")
+
+	w := addTags(t, server, item.ID, "Keep", "kids ")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp ItemResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.ElementsMatch(t, []string{"keep", "kids"}, resp.Tags)
+
+	// Adding a tag again, from another item, reuses the existing tag
+	w = addTags(t, server, item.ID, "keep", "to-review")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	other := createItem(t, db, "Inception")
+	require.Equal(t, http.StatusOK, addTags(t, server, other.ID, "kids").Code)
+
+	var tags []models.Tag
+	require.NoError(t, db.Order("name").Find(&tags).Error)
+	require.Len(t, tags, 3)
+	assert.Equal(t, "keep", tags[0].Name)
+
+	w = doRequest(server, http.MethodGet, fmt.Sprintf("/api/v1/items/%d", item.ID))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.ElementsMatch(t, []string{"keep", "kids", "to-review"}, resp.Tags)
+}
+
+func TestAddItemTags_Invalid(t *testing.T) {
+	server, db := setupTestServer(t)
+	item := createItem(t, db, "The Matrix")
+
+	w := addTags(t, server, item.ID, "  ")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Equal(t, CodeInvalidRequest, decodeError(t, w).Error)
+
+	w = addTags(t, server, item.ID)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = addTags(t, server, 999, "keep")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+	assert.Equal(t, CodeItemNotFound, decodeError(t, w).Error)
+}
+
+func TestRemoveItemTag(t *testing.T) {
+	server, db := setupTestServer(t)
+	item := createItem(t, db, "The Matrix")
+	other := createItem(t, db, "Inception")
+	require.Equal(t, http.StatusOK, addTags(t, server, item.ID, "keep", "kids").Code)
+	require.Equal(t, http.StatusOK, addTags(t, server, other.ID, "keep").Code)
+
+	w := doRequest(server, http.MethodDelete, fmt.Sprintf("/api/v1/items/%d/tags/Keep", item.ID))
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	var resp ItemResponse
+	w = doRequest(server, http.MethodGet, fmt.Sprintf("/api/v1/items/%d", item.ID))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"kids"}, resp.Tags)
+
+	// The tag stays on the other item
+	w = doRequest(server, http.MethodGet, fmt.Sprintf("/api/v1/items/%d", other.ID))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"keep"}, resp.Tags)
+
+	// Removing a tag the item does not carry is a 404
+	w = doRequest(server, http.MethodDelete, fmt.Sprintf("/api/v1/items/%d/tags/keep", item.ID))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, CodeTagNotFound, decodeError(t, w).Error)
+}
+
+func TestListItems_TagFilter(t *testing.T) {
+	server, db := setupTestServer(t)
+	matrix := createItem(t, db, "The Matrix")
+	inception := createItem(t, db, "Inception")
+	createItem(t, db, "Untagged")
+	require.Equal(t, http.StatusOK, addTags(t, server, matrix.ID, "keep").Code)
+	require.Equal(t, http.StatusOK, addTags(t, server, inception.ID, "keep", "kids").Code)
+
+	tests := []struct {
+		tag      string
+		expected []uint
+	}{
+		{"keep", []uint{matrix.ID, inception.ID}},
+		{"KIDS", []uint{inception.ID}},
+		{"unknown", []uint{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			w := doRequest(server, http.MethodGet, "/api/v1/items?tag="+tt.tag)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			var resp struct {
+				Data  []ItemResponse `json:"data"`
+				Total int64          `json:"total"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, int64(len(tt.expected)), resp.Total)
+			ids := []uint{}
+			for _, item := range resp.Data {
+				ids = append(ids, item.ID)
+			}
+			assert.ElementsMatch(t, tt.expected, ids)
+		})
+	}
+}
@@ -1,9 +1,15 @@
 package classifier
 
 import (
+	"fmt"
+	"net/url"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
+
+	"github.com/glefebvre/stalkeer/internal/config"
 )
 
 // ContentType represents the type of content
@@ -12,16 +18,57 @@ type ContentType string
 const (
 	ContentTypeMovie         ContentType = "movie"
 	ContentTypeSeries        ContentType = "series"
+	ContentTypeChannel       ContentType = "channel"
 	ContentTypeUncategorized ContentType = "uncategorized"
 )
 
+// Signal names used as keys of Classification.Signals
+const (
+	SignalGroupOverride   = "group_override"
+	SignalGroupTitle      = "group_title_keyword"
+	SignalSeasonEpisode   = "season_episode_pattern"
+	SignalSeasonPack      = "season_pack"
+	SignalTitleKeyword    = "title_keyword"
+	SignalYear            = "year"
+	SignalResolution      = "resolution"
+	SignalContentType     = "http_content_type"
+	SignalLiveStream      = "live_stream"
+	SignalStreamExtension = "stream_extension"
+	SignalDefault         = "default"
+)
+
+// groupOverrideConfidence is the confidence given to a content type forced by a group override
+const groupOverrideConfidence = 95
+
+// contentTypeConfidence is the minimum confidence of an item reclassified from the
+// content type of its stream, the same as an item that is likely but not surely a movie
+const contentTypeConfidence = 40
+
+// liveStreamConfidence is the confidence given to a channel detected from a stream
+// without duration served as a playlist or transport stream
+const liveStreamConfidence = 85
+
+// streamExtensionPenalty is the confidence removed from a movie or episode whose
+// stream URL has a live extension, which is rarely a downloadable file
+const streamExtensionPenalty = 30
+
+// defaultLiveExtensions are the URL extensions of live streams, see classifier.live_extensions
+var defaultLiveExtensions = []string{".m3u8", ".ts"}
+
 // Classification represents the result of classifying a title
 type Classification struct {
-	ContentType ContentType
-	Season      *int
-	Episode     *int
-	Resolution  *string
-	Confidence  int // 0-100
+	ContentType  ContentType
+	Season       *int
+	Episode      *int
+	EpisodeEnd   *int    // Last episode of a multi-episode entry, e.g. 3 for "S01E01-E03"; equals Episode otherwise
+	EpisodeTitle *string // Text following the season/episode marker, e.g. "Pilot"
+	PartNumber   *int    // Part of a multi-part stream, e.g. 2 for "Movie Part 2" or "Movie CD2"
+	SeasonPack   bool    // Whole season in one stream, e.g. "Season 1 Complete"; Episode is nil
+	Resolution   *string
+	Language     *string        // Language tag of the stream, e.g. "VF", "MULTI" or "VOSTFR"
+	Group        string         // Normalized group-title, see NormalizeGroupTitle
+	Confidence   int            // 0-100
+	Signals      map[string]int // Contribution of each detected signal to Confidence
 }
 
 // Classifier provides content classification functionality
@@ -29,6 +76,16 @@ type Classifier struct {
 	seasonEpisodePatterns []*regexp.Regexp
 	resolutionPatterns    []*regexp.Regexp
 	yearPattern           *regexp.Regexp
+	trailingTagsPattern   *regexp.Regexp
+	groupOverrides        []groupOverride
+	liveExtensions        []string
+	streamExtensionHints  bool // Downgrade movies and episodes served from a live extension
+}
+
+// groupOverride forces a content type for matching group-titles
+type groupOverride struct {
+	pattern     *regexp.Regexp
+	contentType ContentType
 }
 
 // New creates a new Classifier with precompiled regex patterns
@@ -37,47 +94,314 @@ func New() *Classifier {
 		seasonEpisodePatterns: compileSeasonEpisodePatterns(),
 		resolutionPatterns:    compileResolutionPatterns(),
 		yearPattern:           regexp.MustCompile(`\((\d{4})\)`),
+		trailingTagsPattern:   regexp.MustCompile(`(?i)(?:\s*[\[(]?\b(?:4K|UHD|2160p|1080p|FullHD|FHD|720p|HD|480p|SD|HDTV|SDTV|MULTI|VOSTFR|VF|VO)\b[\])]?)+\s*$`),
+		liveExtensions:        defaultLiveExtensions,
+		streamExtensionHints:  true,
+	}
+}
+
+// AddGroupOverride forces contentType for every item whose group-title matches pattern.
+// Overrides are checked in the order they were added, before any heuristic.
+func (c *Classifier) AddGroupOverride(pattern string, contentType ContentType) error {
+	if contentType != ContentTypeMovie && contentType != ContentTypeSeries {
+		return fmt.Errorf("invalid content type %q for group override %q", contentType, pattern)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid group override pattern %q: %w", pattern, err)
+	}
+
+	c.groupOverrides = append(c.groupOverrides, groupOverride{pattern: re, contentType: contentType})
+	return nil
+}
+
+// SetLiveExtensions replaces the URL extensions of live streams, e.g. ".m3u8"
+func (c *Classifier) SetLiveExtensions(extensions []string) {
+	c.liveExtensions = make([]string, 0, len(extensions))
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		c.liveExtensions = append(c.liveExtensions, ext)
 	}
 }
 
+// SetStreamExtensionHints sets whether the confidence of a movie or episode whose
+// stream URL has a live extension is downgraded, see ClassifyStream
+func (c *Classifier) SetStreamExtensionHints(enabled bool) {
+	c.streamExtensionHints = enabled
+}
+
+// LoadFromConfig loads group overrides from classifier.group_overrides, the live
+// stream extensions from classifier.live_extensions and classifier.stream_extension_hints
+func (c *Classifier) LoadFromConfig() error {
+	cfg := config.Get()
+
+	if len(cfg.Classifier.LiveExtensions) > 0 {
+		c.SetLiveExtensions(cfg.Classifier.LiveExtensions)
+	}
+	c.SetStreamExtensionHints(cfg.Classifier.StreamExtensionHints)
+
+	for _, override := range cfg.Classifier.GroupOverrides {
+		if err := c.AddGroupOverride(override.Pattern, ContentType(override.ContentType)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Classify analyzes a title and returns classification information
 func (c *Classifier) Classify(title string, groupTitle string) Classification {
 	classification := Classification{
 		ContentType: ContentTypeUncategorized,
 		Confidence:  0,
+		Signals:     make(map[string]int),
 	}
 
 	// Extract season and episode
-	season, episode := c.ExtractSeasonEpisode(title)
+	season, episode, episodeEnd := c.ExtractSeasonEpisode(title)
 	classification.Season = season
 	classification.Episode = episode
+	classification.EpisodeEnd = episodeEnd
+	classification.EpisodeTitle = c.ExtractEpisodeTitle(title)
+	classification.PartNumber, _ = ExtractPart(title)
+	if season == nil {
+		if packSeason, _ := ExtractSeasonPack(title); packSeason != nil {
+			season = packSeason
+			classification.Season = season
+			classification.SeasonPack = true
+		}
+	}
 
-	// Extract resolution
+	// Extract resolution (detected but does not weigh on the content type)
 	classification.Resolution = c.ExtractResolution(title)
+	if classification.Resolution != nil {
+		classification.Signals[SignalResolution] = 0
+	}
+
+	// Extract language tag (does not weigh on the content type either)
+	classification.Language = ExtractLanguage(title)
+
+	classification.Group = NormalizeGroupTitle(groupTitle)
+
+	// Explicit group overrides take precedence over the heuristics
+	for _, override := range c.groupOverrides {
+		if override.pattern.MatchString(groupTitle) {
+			classification.ContentType = override.contentType
+			classification.Confidence = groupOverrideConfidence
+			classification.Signals[SignalGroupOverride] = groupOverrideConfidence
+			return classification
+		}
+	}
 
 	// Determine content type and confidence
-	classification.ContentType, classification.Confidence = c.determineContentType(title, groupTitle, season, episode)
+	classification.ContentType, classification.Confidence = c.determineContentType(title, groupTitle, season, episode, classification.Signals)
 
 	return classification
 }
 
-// ExtractSeasonEpisode attempts to extract season and episode numbers from a title
-func (c *Classifier) ExtractSeasonEpisode(title string) (*int, *int) {
+// ClassifyStream classifies an M3U entry like Classify, using its EXTINF duration and
+// stream URL as well: an entry without duration ("-1") served from a live extension
+// (HLS playlist or transport stream by default) is a live channel. A movie or episode
+// with a duration served from a live extension keeps its content type, with a lower
+// confidence and the stream_extension signal, unless stream extension hints are off.
+// Group overrides still take precedence.
+func (c *Classifier) ClassifyStream(title, groupTitle, duration, streamURL string) Classification {
+	classification := c.Classify(title, groupTitle)
+	if _, overridden := classification.Signals[SignalGroupOverride]; overridden {
+		return classification
+	}
+	if !c.IsLiveStream(duration, streamURL) {
+		isVOD := classification.ContentType == ContentTypeMovie || classification.ContentType == ContentTypeSeries
+		if c.streamExtensionHints && isVOD && c.hasLiveExtension(streamURL) {
+			penalty := min(streamExtensionPenalty, classification.Confidence)
+			classification.Confidence -= penalty
+			classification.Signals[SignalStreamExtension] = -penalty
+		}
+		return classification
+	}
+
+	// The heuristics on the title no longer contribute to the confidence
+	signals := map[string]int{SignalLiveStream: liveStreamConfidence}
+	if _, ok := classification.Signals[SignalResolution]; ok {
+		signals[SignalResolution] = 0
+	}
+
+	classification.ContentType = ContentTypeChannel
+	classification.Confidence = liveStreamConfidence
+	classification.Signals = signals
+	return classification
+}
+
+// IsLiveStream reports whether an entry with the given EXTINF duration and stream
+// URL is a live stream: no duration and a live extension
+func (c *Classifier) IsLiveStream(duration, streamURL string) bool {
+	return strings.TrimSpace(duration) == "-1" && c.hasLiveExtension(streamURL)
+}
+
+// hasLiveExtension reports whether the path of streamURL ends with a live extension
+func (c *Classifier) hasLiveExtension(streamURL string) bool {
+	streamPath := streamURL
+	if u, err := url.Parse(streamURL); err == nil {
+		streamPath = u.Path
+	}
+	ext := strings.ToLower(path.Ext(streamPath))
+	if ext == "" {
+		return false
+	}
+	for _, liveExt := range c.liveExtensions {
+		if ext == liveExt {
+			return true
+		}
+	}
+	return false
+}
+
+// episodeRangePattern matches the following episodes of a multi-episode marker,
+// e.g. "-E03" in "S01E01-E03" or "E02" in "S01E01E02". The last one is captured.
+var episodeRangePattern = regexp.MustCompile(`^(?:-?[Ee](\d{1,3}))+`)
+
+// ExtractSeasonEpisode attempts to extract season and episode numbers from a title.
+// episodeEnd is the last episode of a multi-episode entry such as "S01E01-E03" or
+// "S01E01E02", and equals episode for a single episode.
+func (c *Classifier) ExtractSeasonEpisode(title string) (season, episode, episodeEnd *int) {
+	s, e, end, _, ok := c.matchSeasonEpisode(title)
+	if !ok {
+		return nil, nil, nil
+	}
+	return &s, &e, &end
+}
+
+// matchSeasonEpisode finds the season/episode marker of a title, including the
+// episode range following it. end is the index in title right after the marker.
+func (c *Classifier) matchSeasonEpisode(title string) (season, episode, episodeEnd, end int, ok bool) {
 	for _, pattern := range c.seasonEpisodePatterns {
-		matches := pattern.FindStringSubmatch(title)
-		if len(matches) >= 3 {
-			season, err := strconv.Atoi(matches[1])
-			if err != nil {
+		loc := pattern.FindStringSubmatchIndex(title)
+		if loc == nil || len(loc) < 6 {
+			continue
+		}
+		season, err := strconv.Atoi(title[loc[2]:loc[3]])
+		if err != nil {
+			continue
+		}
+		episode, err := strconv.Atoi(title[loc[4]:loc[5]])
+		if err != nil {
+			continue
+		}
+
+		episodeEnd, end := episode, loc[1]
+		if rng := episodeRangePattern.FindStringSubmatchIndex(title[end:]); rng != nil {
+			if last, err := strconv.Atoi(title[end+rng[2] : end+rng[3]]); err == nil && last > episode {
+				episodeEnd = last
+			}
+			end += rng[1]
+		}
+		return season, episode, episodeEnd, end, true
+	}
+	return 0, 0, 0, 0, false
+}
+
+// ExtractEpisodeTitle extracts the episode title following the season/episode marker,
+// e.g. "Pilot" from "Breaking Bad S01E01 - Pilot". Trailing quality and language tags are ignored.
+func (c *Classifier) ExtractEpisodeTitle(title string) *string {
+	_, _, _, end, ok := c.matchSeasonEpisode(title)
+	if !ok {
+		return nil
+	}
+
+	episodeTitle := c.trailingTagsPattern.ReplaceAllString(title[end:], "")
+	episodeTitle = strings.Trim(episodeTitle, " -–:|.")
+	if episodeTitle == "" {
+		return nil
+	}
+	return &episodeTitle
+}
+
+// partPattern matches a "Part N", "Pt N", "CD N" or "Disc N" suffix, optionally
+// followed by quality tags, e.g. "The Irishman (2019) - Part 2 HD"
+var partPattern = regexp.MustCompile(`(?i)[\s\-_.]*[(\[]?\b(?:part|pt\.?|partie|cd|disc)[\s._-]*(\d{1,2})\b[)\]]?`)
+
+// ExtractPart detects a multi-part stream suffix in a title. It returns the part
+// number, or nil when the title has no part marker, and the title without the marker.
+func ExtractPart(title string) (*int, string) {
+	loc := partPattern.FindStringSubmatchIndex(title)
+	if loc == nil {
+		return nil, title
+	}
+
+	part, err := strconv.Atoi(title[loc[2]:loc[3]])
+	if err != nil || part == 0 {
+		return nil, title
+	}
+
+	base := strings.TrimSpace(title[:loc[0]] + title[loc[1]:])
+	return &part, base
+}
+
+// languagePattern matches the language tags of a title, e.g. "Movie FHD VOSTFR"
+// or "Show S01E01 HD (MULTI)"
+var languagePattern = regexp.MustCompile(`(?i)\b(MULTI|VOSTFR|VF|VO)\b`)
+
+// ExtractLanguage returns the language tag of a title in upper case, or nil when
+// it has none. Tags trail the title, so the last one wins.
+func ExtractLanguage(title string) *string {
+	matches := languagePattern.FindAllStringSubmatch(title, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	language := strings.ToUpper(matches[len(matches)-1][1])
+	return &language
+}
+
+// countryPrefixPattern matches the country code leading a lower-cased group-title,
+// e.g. "fr: ", "en | ", "|uk| " or "[de] "
+var countryPrefixPattern = regexp.MustCompile(`^\s*(?:\[[a-z]{2,3}\]|\|?[a-z]{2,3}\s*[:|\-])\s*`)
+
+// NormalizeGroupTitle reduces a group-title to its intent, so that differently
+// formatted groups compare equal: the country prefix is stripped, the title is lower
+// cased, and separators, punctuation and emoji are dropped. "FR: FILMS - Disney+"
+// and "FR | Films | Disney" both normalize to "films disney".
+func NormalizeGroupTitle(groupTitle string) string {
+	group := countryPrefixPattern.ReplaceAllString(strings.ToLower(groupTitle), "")
+	words := strings.FieldsFunc(group, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// seasonPackPatterns match a season pack marker such as "Season 1 Complete",
+// "S01 Complete", "Saison 2 Intégrale" or "Complete Season 3". The season number
+// is captured by the first non-empty group.
+var seasonPackPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)[\s\-_.]*\b(?:(?:season|saison|staffel)[\s._-]*(\d{1,2})|s(\d{1,2}))\b[\s\-_.:]*(?:complete|compl[eè]te|int[eé]grale)\b`),
+	regexp.MustCompile(`(?i)[\s\-_.]*\b(?:complete|compl[eè]te|int[eé]grale)[\s\-_.:]*(?:(?:season|saison|staffel)[\s._-]*(\d{1,2})|s(\d{1,2}))\b`),
+}
+
+// ExtractSeasonPack detects a season pack marker in a title. It returns the season
+// number, or nil when the title is not a season pack, and the title without the marker.
+func ExtractSeasonPack(title string) (*int, string) {
+	for _, pattern := range seasonPackPatterns {
+		loc := pattern.FindStringSubmatchIndex(title)
+		if loc == nil {
+			continue
+		}
+
+		for group := 1; group < len(loc)/2; group++ {
+			if loc[2*group] < 0 {
 				continue
 			}
-			episode, err := strconv.Atoi(matches[2])
+			season, err := strconv.Atoi(title[loc[2*group]:loc[2*group+1]])
 			if err != nil {
-				continue
+				break
 			}
-			return &season, &episode
+			base := strings.TrimSpace(title[:loc[0]] + title[loc[1]:])
+			return &season, base
 		}
 	}
-	return nil, nil
+	return nil, title
 }
 
 // ExtractResolution attempts to extract resolution information from a title.
@@ -93,29 +417,95 @@ func (c *Classifier) ExtractResolution(title string) *string {
 	return nil
 }
 
-// determineContentType determines if the content is a movie or series
-func (c *Classifier) determineContentType(title string, groupTitle string, season *int, episode *int) (ContentType, int) {
+// ResolutionRank orders the resolutions returned by ExtractResolution from lowest to
+// highest. Unknown or missing resolutions rank 0, below every detected one.
+func ResolutionRank(resolution *string) int {
+	if resolution == nil {
+		return 0
+	}
+	switch *resolution {
+	case "4K":
+		return 4
+	case "1080p":
+		return 3
+	case "720p":
+		return 2
+	case "480p":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsVideoContentType reports whether an HTTP Content-Type header denotes a video file.
+// Playlists (HLS) and MPEG transport streams are excluded, as live channels use them.
+func IsVideoContentType(contentType string) bool {
+	if idx := strings.Index(contentType, ";"); idx != -1 {
+		contentType = contentType[:idx]
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+
+	if contentType == "video/mp2t" {
+		return false
+	}
+	return strings.HasPrefix(contentType, "video/")
+}
+
+// ReclassifyByContentType upgrades an uncategorized classification to a movie when
+// the stream was served with a video content type. Other classifications are returned unchanged.
+func ReclassifyByContentType(classification Classification, contentType string) Classification {
+	if classification.ContentType != ContentTypeUncategorized || !IsVideoContentType(contentType) {
+		return classification
+	}
+
+	signals := make(map[string]int, len(classification.Signals)+1)
+	for name, weight := range classification.Signals {
+		signals[name] = weight
+	}
+	if classification.Confidence < contentTypeConfidence {
+		signals[SignalContentType] = contentTypeConfidence - classification.Confidence
+	}
+
+	classification.ContentType = ContentTypeMovie
+	classification.Confidence = max(classification.Confidence, contentTypeConfidence)
+	classification.Signals = signals
+	return classification
+}
+
+// determineContentType determines if the content is a movie or series.
+// The contribution of each signal to the confidence is recorded in signals.
+func (c *Classifier) determineContentType(title string, groupTitle string, season *int, episode *int, signals map[string]int) (ContentType, int) {
 	titleLower := strings.ToLower(title)
-	groupTitleLower := strings.ToLower(groupTitle)
+	group := NormalizeGroupTitle(groupTitle)
 	confidence := 0
 
 	// Check group-title first for strong indicators
-	// Series group titles typically start with "Séries" or "Series"
-	if strings.HasPrefix(groupTitleLower, "séries") || strings.HasPrefix(groupTitleLower, "series") {
+	// Series group titles typically start with "Séries" or "Series" once the country
+	// prefix is stripped
+	if strings.HasPrefix(group, "séries") || strings.HasPrefix(group, "series") {
 		confidence += 70
+		signals[SignalGroupTitle] = 70
 		return ContentTypeSeries, min(confidence, 100)
 	}
 
-	// Movies group titles typically start with patterns like "FR: FILMS", "ES: FILMS", etc.
-	// where the country code is 2-3 letters followed by ": FILMS" or "FILMS"
-	if strings.Contains(groupTitleLower, "films") || strings.Contains(groupTitleLower, "movies") {
+	// Movies group titles typically look like "FR: FILMS", "ES | Films - Disney+", etc.
+	if strings.Contains(group, "films") || strings.Contains(group, "movies") {
 		confidence += 70
+		signals[SignalGroupTitle] = 70
 		return ContentTypeMovie, min(confidence, 100)
 	}
 
 	// Strong indicators for series from season/episode
 	if season != nil && episode != nil {
 		confidence += 80
+		signals[SignalSeasonEpisode] = 80
+		return ContentTypeSeries, min(confidence, 100)
+	}
+
+	// A season without an episode comes from a season pack
+	if season != nil {
+		confidence += 80
+		signals[SignalSeasonPack] = 80
 		return ContentTypeSeries, min(confidence, 100)
 	}
 
@@ -124,6 +514,7 @@ func (c *Classifier) determineContentType(title string, groupTitle string, seaso
 	for _, keyword := range seriesKeywords {
 		if strings.Contains(titleLower, keyword) {
 			confidence += 40
+			signals[SignalTitleKeyword] = 40
 			return ContentTypeSeries, min(confidence, 100)
 		}
 	}
@@ -131,6 +522,7 @@ func (c *Classifier) determineContentType(title string, groupTitle string, seaso
 	// Year in parentheses is typical for movies
 	if c.yearPattern.MatchString(title) {
 		confidence += 60
+		signals[SignalYear] = 60
 	}
 
 	// If we found a year but no season/episode, likely a movie
@@ -148,6 +540,7 @@ func (c *Classifier) determineContentType(title string, groupTitle string, seaso
 	for _, keyword := range movieKeywords {
 		if strings.Contains(titleLower, keyword) {
 			confidence += 50
+			signals[SignalTitleKeyword] = 50
 			return ContentTypeMovie, min(confidence, 100)
 		}
 	}
@@ -159,6 +552,9 @@ func (c *Classifier) determineContentType(title string, groupTitle string, seaso
 
 	// Default to movie if we have some confidence but no series indicators
 	if season == nil && episode == nil {
+		if confidence < 40 {
+			signals[SignalDefault] = 40 - confidence
+		}
 		return ContentTypeMovie, max(confidence, 40)
 	}
 
@@ -107,7 +107,7 @@ func TestExtractSeasonEpisode(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			season, episode := c.ExtractSeasonEpisode(tt.title)
+			season, episode, _ := c.ExtractSeasonEpisode(tt.title)
 
 			if !intPtrEqual(season, tt.expectedSeason) {
 				t.Errorf("Season mismatch for '%s': got %v, want %v", tt.title, ptrToString(season), ptrToString(tt.expectedSeason))
@@ -120,6 +120,287 @@ func TestExtractSeasonEpisode(t *testing.T) {
 	}
 }
 
+func TestExtractSeasonEpisode_MultiEpisode(t *testing.T) {
+	c := New()
+
+	tests := []struct {
+		name               string
+		title              string
+		expectedEpisode    *int
+		expectedEpisodeEnd *int
+	}{
+		{"Range S01E01-E03", "Show Name S01E01-E03 720p", intPtr(1), intPtr(3)},
+		{"Consecutive S01E01E02", "Show Name S01E01E02", intPtr(1), intPtr(2)},
+		{"Three consecutive S02E04E05E06", "Show Name S02E04E05E06", intPtr(4), intPtr(6)},
+		{"Single episode", "Show Name S01E05 - Pilot", intPtr(5), intPtr(5)},
+		{"No season/episode", "Movie Title (2023)", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, episode, episodeEnd := c.ExtractSeasonEpisode(tt.title)
+
+			if !intPtrEqual(episode, tt.expectedEpisode) {
+				t.Errorf("Episode mismatch for '%s': got %v, want %v", tt.title, ptrToString(episode), ptrToString(tt.expectedEpisode))
+			}
+			if !intPtrEqual(episodeEnd, tt.expectedEpisodeEnd) {
+				t.Errorf("EpisodeEnd mismatch for '%s': got %v, want %v", tt.title, ptrToString(episodeEnd), ptrToString(tt.expectedEpisodeEnd))
+			}
+		})
+	}
+}
+
+func TestClassify_MultiEpisode(t *testing.T) {
+	c := New()
+
+	classification := c.Classify("Show Name S01E01-E03 - The Beginning", "Series")
+	if classification.ContentType != ContentTypeSeries {
+		t.Errorf("expected series, got %s", classification.ContentType)
+	}
+	if !intPtrEqual(classification.Episode, intPtr(1)) || !intPtrEqual(classification.EpisodeEnd, intPtr(3)) {
+		t.Errorf("expected episodes 1-3, got %s-%s", ptrToString(classification.Episode), ptrToString(classification.EpisodeEnd))
+	}
+	if classification.EpisodeTitle == nil || *classification.EpisodeTitle != "The Beginning" {
+		t.Errorf("expected episode title 'The Beginning', got %v", classification.EpisodeTitle)
+	}
+}
+
+func TestExtractEpisodeTitle(t *testing.T) {
+	c := New()
+
+	tests := []struct {
+		name     string
+		title    string
+		expected *string
+	}{
+		{
+			name:     "Dash separated title",
+			title:    "Breaking Bad S01E01 - Pilot",
+			expected: strPtr("Pilot"),
+		},
+		{
+			name:     "Title followed by quality tag",
+			title:    "Breaking Bad S01E01 - Pilot 1080p",
+			expected: strPtr("Pilot"),
+		},
+		{
+			name:     "Alternative marker with colon",
+			title:    "The Office 2x01: The Dundies",
+			expected: strPtr("The Dundies"),
+		},
+		{
+			name:     "Only quality tag after marker",
+			title:    "Breaking Bad S01E01 1080p",
+			expected: nil,
+		},
+		{
+			name:     "Only language tags after marker",
+			title:    "Breaking Bad S01E01 HD (MULTI)",
+			expected: nil,
+		},
+		{
+			name:     "Nothing after marker",
+			title:    "Breaking Bad S01E01",
+			expected: nil,
+		},
+		{
+			name:     "No marker",
+			title:    "The Matrix (1999) - Reloaded",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := c.ExtractEpisodeTitle(tt.title)
+
+			if tt.expected == nil {
+				if result != nil {
+					t.Errorf("Expected nil, got %v", *result)
+				}
+			} else {
+				if result == nil {
+					t.Errorf("Expected %v, got nil", *tt.expected)
+				} else if *result != *tt.expected {
+					t.Errorf("Expected %v, got %v", *tt.expected, *result)
+				}
+			}
+		})
+	}
+
+	classification := c.Classify("Breaking Bad S01E01 - Pilot", "Series")
+	if classification.EpisodeTitle == nil || *classification.EpisodeTitle != "Pilot" {
+		t.Errorf("Expected classification episode title Pilot, got %v", classification.EpisodeTitle)
+	}
+}
+
+func TestExtractPart(t *testing.T) {
+	tests := []struct {
+		name         string
+		title        string
+		expectedPart *int
+		expectedBase string
+	}{
+		{"Part suffix", "The Irishman (2019) Part 1", intPtr(1), "The Irishman (2019)"},
+		{"Dash separated part", "The Irishman (2019) - Part 2", intPtr(2), "The Irishman (2019)"},
+		{"Part before quality tag", "The Irishman (2019) Part 2 HD", intPtr(2), "The Irishman (2019) HD"},
+		{"CD suffix", "Lawrence of Arabia CD1", intPtr(1), "Lawrence of Arabia"},
+		{"Bracketed disc", "Gone with the Wind [Disc 2]", intPtr(2), "Gone with the Wind"},
+		{"French partie", "Les Misérables Partie 2", intPtr(2), "Les Misérables"},
+		{"No part", "The Matrix (1999)", nil, "The Matrix (1999)"},
+		{"Part inside a word", "The Departed (2006)", nil, "The Departed (2006)"},
+		{"Part zero ignored", "Movie Part 0", nil, "Movie Part 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			part, base := ExtractPart(tt.title)
+
+			if tt.expectedPart == nil {
+				if part != nil {
+					t.Errorf("Expected no part, got %d", *part)
+				}
+			} else if part == nil || *part != *tt.expectedPart {
+				t.Errorf("Expected part %d, got %v", *tt.expectedPart, part)
+			}
+
+			if base != tt.expectedBase {
+				t.Errorf("Expected base %q, got %q", tt.expectedBase, base)
+			}
+		})
+	}
+}
+
+func TestExtractSeasonPack(t *testing.T) {
+	tests := []struct {
+		name           string
+		title          string
+		expectedSeason *int
+		expectedBase   string
+	}{
+		{"Season complete", "Breaking Bad Season 1 Complete", intPtr(1), "Breaking Bad"},
+		{"Short season complete", "Breaking Bad S02 Complete HD", intPtr(2), "Breaking Bad HD"},
+		{"French integrale", "Kaamelott Saison 3 Intégrale", intPtr(3), "Kaamelott"},
+		{"French integrale without accent", "Kaamelott - Saison 4 Integrale", intPtr(4), "Kaamelott"},
+		{"Complete before season", "The Office Complete Season 5", intPtr(5), "The Office"},
+		{"Dash separated", "Dark - S01 - Complete", intPtr(1), "Dark"},
+		{"Episode is not a pack", "Breaking Bad S01E01", nil, "Breaking Bad S01E01"},
+		{"Season without complete", "Breaking Bad Season 1", nil, "Breaking Bad Season 1"},
+		{"Complete without season", "The Complete Works", nil, "The Complete Works"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			season, base := ExtractSeasonPack(tt.title)
+
+			if tt.expectedSeason == nil {
+				if season != nil {
+					t.Errorf("Expected no season pack, got season %d", *season)
+				}
+			} else if season == nil || *season != *tt.expectedSeason {
+				t.Errorf("Expected season %d, got %v", *tt.expectedSeason, season)
+			}
+
+			if base != tt.expectedBase {
+				t.Errorf("Expected base %q, got %q", tt.expectedBase, base)
+			}
+		})
+	}
+}
+
+func TestClassifySeasonPack(t *testing.T) {
+	c := New()
+
+	tests := []struct {
+		name       string
+		title      string
+		groupTitle string
+	}{
+		{"Series group", "Breaking Bad Saison 1 Intégrale", "Séries FR"},
+		{"Unknown group", "Breaking Bad S01 Complete", "VOD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := c.Classify(tt.title, tt.groupTitle)
+
+			if result.ContentType != ContentTypeSeries {
+				t.Errorf("Expected series, got %s", result.ContentType)
+			}
+			if !result.SeasonPack {
+				t.Error("Expected a season pack")
+			}
+			if result.Season == nil || *result.Season != 1 {
+				t.Errorf("Expected season 1, got %v", result.Season)
+			}
+			if result.Episode != nil {
+				t.Errorf("Expected no episode, got %d", *result.Episode)
+			}
+		})
+	}
+
+	if result := c.Classify("Breaking Bad S01E01", "Séries FR"); result.SeasonPack {
+		t.Error("Expected an episode not to be a season pack")
+	}
+}
+
+func TestIsVideoContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		expected    bool
+	}{
+		{"video/mp4", true},
+		{"video/x-matroska", true},
+		{"Video/MP4; charset=binary", true},
+		{"video/mp2t", false},
+		{"application/x-mpegURL", false},
+		{"application/octet-stream", false},
+		{"text/html; charset=utf-8", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if got := IsVideoContentType(tt.contentType); got != tt.expected {
+				t.Errorf("IsVideoContentType(%q) = %v, expected %v", tt.contentType, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReclassifyByContentType(t *testing.T) {
+	c := New()
+
+	// A generic title is uncategorized until its stream turns out to be a video
+	uncategorized := c.Classify("Untitled stream", "VOD")
+	if uncategorized.ContentType != ContentTypeUncategorized {
+		t.Fatalf("Expected uncategorized, got %s", uncategorized.ContentType)
+	}
+
+	result := ReclassifyByContentType(uncategorized, "video/mp4")
+	if result.ContentType != ContentTypeMovie {
+		t.Errorf("Expected movie, got %s", result.ContentType)
+	}
+	if result.Confidence != contentTypeConfidence {
+		t.Errorf("Expected confidence %d, got %d", contentTypeConfidence, result.Confidence)
+	}
+	if _, ok := result.Signals[SignalContentType]; !ok {
+		t.Errorf("Expected %s signal, got %v", SignalContentType, result.Signals)
+	}
+	if _, ok := uncategorized.Signals[SignalContentType]; ok {
+		t.Error("Expected the original classification signals to be left unchanged")
+	}
+
+	if result := ReclassifyByContentType(uncategorized, "text/html"); result.ContentType != ContentTypeUncategorized {
+		t.Errorf("Expected non-video content type to stay uncategorized, got %s", result.ContentType)
+	}
+
+	series := c.Classify("Breaking Bad S01E01", "Séries")
+	if result := ReclassifyByContentType(series, "video/mp4"); result.ContentType != ContentTypeSeries || result.Confidence != series.Confidence {
+		t.Errorf("Expected series classification unchanged, got %s (%d)", result.ContentType, result.Confidence)
+	}
+}
+
 func TestExtractResolution(t *testing.T) {
 	c := New()
 
@@ -222,6 +503,89 @@ func TestExtractResolution(t *testing.T) {
 	}
 }
 
+func TestExtractLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		expected string
+	}{
+		{"Trailing tag", "Clifford FHD VOSTFR", "VOSTFR"},
+		{"Parenthesized tag", "Breaking Bad S01E01 HD (MULTI)", "MULTI"},
+		{"Lowercase tag", "Inception (2010) vf", "VF"},
+		{"Last tag wins", "Movie VO HD VF", "VF"},
+		{"No tag", "The Matrix (1999) HD", ""},
+		{"Tag inside a word", "Vfx Artists React", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			language := ExtractLanguage(tt.title)
+			if tt.expected == "" {
+				if language != nil {
+					t.Errorf("Expected no language, got %s", *language)
+				}
+			} else if language == nil || *language != tt.expected {
+				t.Errorf("Expected language %s, got %v", tt.expected, language)
+			}
+		})
+	}
+}
+
+func TestNormalizeGroupTitle(t *testing.T) {
+	tests := []struct {
+		groupTitle string
+		expected   string
+	}{
+		{"FR: FILMS - Disney+", "films disney"},
+		{"FR | Films | Disney", "films disney"},
+		{"[UK] Movies 🎬", "movies"},
+		{"|EN| SERIES - Netflix", "series netflix"},
+		{"Séries Françaises", "séries françaises"},
+		{"Films 4K", "films 4k"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.groupTitle, func(t *testing.T) {
+			if got := NormalizeGroupTitle(tt.groupTitle); got != tt.expected {
+				t.Errorf("NormalizeGroupTitle(%q) = %q, expected %q", tt.groupTitle, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeGroupTitle_SameIntent(t *testing.T) {
+	a := NormalizeGroupTitle("FR: FILMS - Disney+")
+	b := NormalizeGroupTitle("FR | Films | Disney")
+	if a != b {
+		t.Errorf("expected both film groups to normalize alike, got %q and %q", a, b)
+	}
+}
+
+func TestClassifyNormalizedGroup(t *testing.T) {
+	c := New()
+
+	classification := c.Classify("Some Title", "FR: SÉRIES - Netflix")
+	if classification.ContentType != ContentTypeSeries {
+		t.Errorf("expected series from a prefixed series group, got %s", classification.ContentType)
+	}
+	if classification.Group != "séries netflix" {
+		t.Errorf("expected normalized group 'séries netflix', got %q", classification.Group)
+	}
+}
+
+func TestResolutionRank(t *testing.T) {
+	ordered := []*string{nil, strPtr("480p"), strPtr("720p"), strPtr("1080p"), strPtr("4K")}
+	for i := 1; i < len(ordered); i++ {
+		if ResolutionRank(ordered[i]) <= ResolutionRank(ordered[i-1]) {
+			t.Errorf("expected %s to rank above %s", ptrToString(ordered[i]), ptrToString(ordered[i-1]))
+		}
+	}
+	if rank := ResolutionRank(strPtr("8K")); rank != 0 {
+		t.Errorf("expected unknown resolution to rank 0, got %d", rank)
+	}
+}
+
 func TestClassify(t *testing.T) {
 	c := New()
 
@@ -452,6 +816,290 @@ func TestClassifyEdgeCases(t *testing.T) {
 	}
 }
 
+func TestClassifySignals(t *testing.T) {
+	c := New()
+
+	tests := []struct {
+		name            string
+		title           string
+		groupTitle      string
+		expectedType    ContentType
+		expectedSignals map[string]int
+	}{
+		{
+			name:         "Clear series",
+			title:        "Breaking Bad S01E05 1080p",
+			groupTitle:   "",
+			expectedType: ContentTypeSeries,
+			expectedSignals: map[string]int{
+				SignalSeasonEpisode: 80,
+				SignalResolution:    0,
+			},
+		},
+		{
+			name:         "Clear series from group title",
+			title:        "Breaking Bad S01E05",
+			groupTitle:   "Séries: Drama",
+			expectedType: ContentTypeSeries,
+			expectedSignals: map[string]int{
+				SignalGroupTitle: 70,
+			},
+		},
+		{
+			name:         "Clear movie",
+			title:        "The Matrix (1999) 4K",
+			groupTitle:   "",
+			expectedType: ContentTypeMovie,
+			expectedSignals: map[string]int{
+				SignalYear:       60,
+				SignalResolution: 0,
+			},
+		},
+		{
+			name:            "Uncategorized",
+			title:           "Random Content",
+			groupTitle:      "",
+			expectedType:    ContentTypeUncategorized,
+			expectedSignals: map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := c.Classify(tt.title, tt.groupTitle)
+
+			if result.ContentType != tt.expectedType {
+				t.Fatalf("Content type mismatch: got %v, want %v", result.ContentType, tt.expectedType)
+			}
+
+			if len(result.Signals) != len(tt.expectedSignals) {
+				t.Errorf("Signals mismatch: got %v, want %v", result.Signals, tt.expectedSignals)
+			}
+
+			sum := 0
+			for signal, want := range tt.expectedSignals {
+				got, ok := result.Signals[signal]
+				if !ok || got != want {
+					t.Errorf("Signal %s: got %d (present: %v), want %d", signal, got, ok, want)
+				}
+				sum += got
+			}
+
+			if sum != result.Confidence {
+				t.Errorf("Signals sum to %d, want confidence %d", sum, result.Confidence)
+			}
+		})
+	}
+}
+
+func TestClassifyGroupOverrides(t *testing.T) {
+	c := New()
+	if err := c.AddGroupOverride(`^Documentaires`, ContentTypeSeries); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.AddGroupOverride(`(?i)^vod mixed$`, ContentTypeMovie); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name               string
+		title              string
+		groupTitle         string
+		heuristicType      ContentType
+		expectedType       ContentType
+		expectedOverridden bool
+	}{
+		{
+			name:               "Year in title flipped to series",
+			title:              "Planet Earth (2006)",
+			groupTitle:         "Documentaires HD",
+			heuristicType:      ContentTypeMovie,
+			expectedType:       ContentTypeSeries,
+			expectedOverridden: true,
+		},
+		{
+			name:               "Episode pattern flipped to movie",
+			title:              "Concert Live S01E01",
+			groupTitle:         "VOD Mixed",
+			heuristicType:      ContentTypeSeries,
+			expectedType:       ContentTypeMovie,
+			expectedOverridden: true,
+		},
+		{
+			name:               "Unmatched group keeps heuristic",
+			title:              "The Matrix (1999)",
+			groupTitle:         "FR: FILMS",
+			heuristicType:      ContentTypeMovie,
+			expectedType:       ContentTypeMovie,
+			expectedOverridden: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := New().Classify(tt.title, tt.groupTitle).ContentType; got != tt.heuristicType {
+				t.Fatalf("Heuristic content type: got %v, want %v", got, tt.heuristicType)
+			}
+
+			result := c.Classify(tt.title, tt.groupTitle)
+
+			if result.ContentType != tt.expectedType {
+				t.Errorf("Content type mismatch: got %v, want %v", result.ContentType, tt.expectedType)
+			}
+
+			_, overridden := result.Signals[SignalGroupOverride]
+			if overridden != tt.expectedOverridden {
+				t.Errorf("Group override signal: got %v, want %v", overridden, tt.expectedOverridden)
+			}
+			if overridden && result.Confidence != groupOverrideConfidence {
+				t.Errorf("Confidence: got %d, want %d", result.Confidence, groupOverrideConfidence)
+			}
+		})
+	}
+}
+
+func TestAddGroupOverrideInvalid(t *testing.T) {
+	c := New()
+
+	if err := c.AddGroupOverride(`([`, ContentTypeMovie); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if err := c.AddGroupOverride(`^FILMS`, ContentTypeUncategorized); err == nil {
+		t.Error("expected error for invalid content type")
+	}
+}
+
+func TestClassifyStream(t *testing.T) {
+	tests := []struct {
+		name               string
+		title              string
+		groupTitle         string
+		duration           string
+		url                string
+		expectedType       ContentType
+		expectedConfidence int
+	}{
+		{
+			name:               "Live TS channel",
+			title:              "FR: TF1 HD",
+			groupTitle:         "FR: GENERALISTES",
+			duration:           "-1",
+			url:                "http://provider.example/live/user/pass/1234.ts",
+			expectedType:       ContentTypeChannel,
+			expectedConfidence: liveStreamConfidence,
+		},
+		{
+			name:               "Live HLS channel with query string",
+			title:              "Sport 1",
+			groupTitle:         "Sports",
+			duration:           "-1",
+			url:                "http://provider.example/hls/sport1.M3U8?token=abc",
+			expectedType:       ContentTypeChannel,
+			expectedConfidence: liveStreamConfidence,
+		},
+		{
+			name:               "VOD MKV movie",
+			title:              "The Matrix (1999)",
+			groupTitle:         "FR: FILMS",
+			duration:           "-1",
+			url:                "http://provider.example/movie/user/pass/5678.mkv",
+			expectedType:       ContentTypeMovie,
+			expectedConfidence: 70,
+		},
+		{
+			name:               "TS stream with a duration is not live",
+			title:              "The Matrix (1999)",
+			groupTitle:         "FR: FILMS",
+			duration:           "8160",
+			url:                "http://provider.example/movie/5678.ts",
+			expectedType:       ContentTypeMovie,
+			expectedConfidence: 70 - streamExtensionPenalty,
+		},
+	}
+
+	c := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := c.ClassifyStream(tt.title, tt.groupTitle, tt.duration, tt.url)
+
+			if result.ContentType != tt.expectedType {
+				t.Errorf("Content type mismatch: got %v, want %v", result.ContentType, tt.expectedType)
+			}
+			if result.Confidence != tt.expectedConfidence {
+				t.Errorf("Confidence: got %d, want %d", result.Confidence, tt.expectedConfidence)
+			}
+
+			_, live := result.Signals[SignalLiveStream]
+			if wantLive := tt.expectedType == ContentTypeChannel; live != wantLive {
+				t.Errorf("Live stream signal: got %v, want %v", live, wantLive)
+			}
+			sum := 0
+			for _, weight := range result.Signals {
+				sum += weight
+			}
+			if sum != result.Confidence {
+				t.Errorf("Signals sum to %d, want confidence %d", sum, result.Confidence)
+			}
+		})
+	}
+}
+
+func TestClassifyStream_StreamExtensionHints(t *testing.T) {
+	const (
+		title = "The Matrix (1999)"
+		group = "FR: FILMS"
+	)
+
+	c := New()
+	mkv := c.ClassifyStream(title, group, "8160", "http://provider.example/movie/5678.mkv")
+	hls := c.ClassifyStream(title, group, "8160", "http://provider.example/movie/5678.m3u8?token=abc")
+
+	if mkv.ContentType != ContentTypeMovie || hls.ContentType != ContentTypeMovie {
+		t.Fatalf("expected both entries to stay movies, got %v and %v", mkv.ContentType, hls.ContentType)
+	}
+	if _, ok := mkv.Signals[SignalStreamExtension]; ok {
+		t.Error("expected no stream_extension signal for a .mkv URL")
+	}
+	if got := hls.Signals[SignalStreamExtension]; got != -streamExtensionPenalty {
+		t.Errorf("stream_extension signal: got %d, want %d", got, -streamExtensionPenalty)
+	}
+	if hls.Confidence != mkv.Confidence-streamExtensionPenalty {
+		t.Errorf("expected the .m3u8 confidence %d to be %d points below the .mkv one %d", hls.Confidence, streamExtensionPenalty, mkv.Confidence)
+	}
+
+	c.SetStreamExtensionHints(false)
+	if got := c.ClassifyStream(title, group, "8160", "http://provider.example/movie/5678.m3u8"); got.Confidence != mkv.Confidence {
+		t.Errorf("expected confidence %d with hints disabled, got %d", mkv.Confidence, got.Confidence)
+	}
+}
+
+func TestClassifyStream_GroupOverrideWins(t *testing.T) {
+	c := New()
+	if err := c.AddGroupOverride(`^Replay`, ContentTypeSeries); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result := c.ClassifyStream("Show S01E01", "Replay TV", "-1", "http://provider.example/replay/1.m3u8")
+	if result.ContentType != ContentTypeSeries {
+		t.Errorf("Content type mismatch: got %v, want %v", result.ContentType, ContentTypeSeries)
+	}
+}
+
+func TestSetLiveExtensions(t *testing.T) {
+	c := New()
+	c.SetLiveExtensions([]string{"MPD", ".flv"})
+
+	if !c.IsLiveStream("-1", "http://provider.example/live/1.mpd") {
+		t.Error("expected .mpd stream to be live")
+	}
+	if !c.IsLiveStream("-1", "http://provider.example/live/1.flv") {
+		t.Error("expected .flv stream to be live")
+	}
+	if c.IsLiveStream("-1", "http://provider.example/live/1.ts") {
+		t.Error("expected .ts stream not to be live once the extensions are replaced")
+	}
+}
+
 func BenchmarkClassify(b *testing.B) {
 	c := New()
 	titles := []string{
@@ -1,9 +1,15 @@
 package config
 
 import (
+	"bytes"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/spf13/viper"
 )
 
 func TestLoad_WithDefaults(t *testing.T) {
@@ -216,3 +222,301 @@ func TestValidate_ModularLogLevels(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate_InvalidProxyURL(t *testing.T) {
+	os.Setenv("STALKEER_DATABASE_USER", "testuser")
+	os.Setenv("STALKEER_DATABASE_DBNAME", "testdb")
+	os.Setenv("STALKEER_NETWORK_PROXY_URL", "ftp://proxy.example.com")
+	defer func() {
+		os.Unsetenv("STALKEER_DATABASE_USER")
+		os.Unsetenv("STALKEER_DATABASE_DBNAME")
+		os.Unsetenv("STALKEER_NETWORK_PROXY_URL")
+	}()
+
+	cfg = nil
+	err := Load()
+	if err == nil {
+		t.Fatalf("expected error for invalid proxy URL, got nil")
+	}
+	if !strings.Contains(err.Error(), "network.proxy_url") {
+		t.Errorf("expected error about proxy URL, got: %s", err.Error())
+	}
+}
+
+func TestValidate_LinkModeRequiresTempDir(t *testing.T) {
+	os.Setenv("STALKEER_DATABASE_USER", "testuser")
+	os.Setenv("STALKEER_DATABASE_DBNAME", "testdb")
+	os.Setenv("STALKEER_DOWNLOADS_LINK_MODE", "symlink")
+	defer func() {
+		os.Unsetenv("STALKEER_DATABASE_USER")
+		os.Unsetenv("STALKEER_DATABASE_DBNAME")
+		os.Unsetenv("STALKEER_DOWNLOADS_LINK_MODE")
+	}()
+
+	cfg = nil
+	err := Load()
+	if err == nil {
+		t.Fatalf("expected error for symlink mode without temp_dir, got nil")
+	}
+	if !strings.Contains(err.Error(), "downloads.temp_dir") {
+		t.Errorf("expected error about temp_dir, got: %s", err.Error())
+	}
+
+	os.Setenv("STALKEER_DOWNLOADS_TEMP_DIR", t.TempDir())
+	defer os.Unsetenv("STALKEER_DOWNLOADS_TEMP_DIR")
+	cfg = nil
+	if err := Load(); err != nil {
+		t.Errorf("expected symlink mode with a temp_dir to load, got %v", err)
+	}
+}
+
+func TestLoad_MatcherMinConfidence(t *testing.T) {
+	os.Setenv("STALKEER_DATABASE_USER", "testuser")
+	os.Setenv("STALKEER_DATABASE_DBNAME", "testdb")
+	defer func() {
+		os.Unsetenv("STALKEER_DATABASE_USER")
+		os.Unsetenv("STALKEER_DATABASE_DBNAME")
+		os.Unsetenv("STALKEER_MATCHER_MIN_CONFIDENCE")
+	}()
+
+	cfg = nil
+	if err := Load(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := Get().Matcher.MinConfidence; got != 0.8 {
+		t.Errorf("expected default min_confidence 0.8, got %v", got)
+	}
+
+	os.Setenv("STALKEER_MATCHER_MIN_CONFIDENCE", "0.9")
+	cfg = nil
+	if err := Load(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := Get().Matcher.MinConfidence; got != 0.9 {
+		t.Errorf("expected min_confidence 0.9, got %v", got)
+	}
+
+	os.Setenv("STALKEER_MATCHER_MIN_CONFIDENCE", "1.5")
+	cfg = nil
+	if err := Load(); err == nil || !strings.Contains(err.Error(), "matcher.min_confidence") {
+		t.Errorf("expected error about matcher.min_confidence, got %v", err)
+	}
+}
+
+func TestGetInsecureSkipVerify_Priority(t *testing.T) {
+	enabled := true
+	disabled := false
+	config := &Config{
+		Network: NetworkConfig{InsecureSkipVerify: true},
+		Sonarr:  SonarrConfig{InsecureSkipVerify: &disabled},
+		M3U:     M3UConfig{Download: M3UDownloadConfig{InsecureSkipVerify: &enabled}},
+	}
+
+	if !config.GetInsecureSkipVerify("radarr") {
+		t.Errorf("expected radarr to inherit network.insecure_skip_verify")
+	}
+	if config.GetInsecureSkipVerify("sonarr") {
+		t.Errorf("expected sonarr override to disable skip-verify")
+	}
+	if !config.GetInsecureSkipVerify("m3u") {
+		t.Errorf("expected m3u override to enable skip-verify")
+	}
+
+	if (&Config{}).GetInsecureSkipVerify("downloads") {
+		t.Errorf("expected skip-verify to be disabled by default")
+	}
+}
+
+func TestLoad_DatabasePoolSettings(t *testing.T) {
+	os.Setenv("STALKEER_DATABASE_USER", "testuser")
+	os.Setenv("STALKEER_DATABASE_DBNAME", "testdb")
+	os.Setenv("STALKEER_DATABASE_MAX_OPEN_CONNS", "40")
+	os.Setenv("STALKEER_DATABASE_CONN_MAX_LIFETIME", "30m")
+	defer func() {
+		os.Unsetenv("STALKEER_DATABASE_USER")
+		os.Unsetenv("STALKEER_DATABASE_DBNAME")
+		os.Unsetenv("STALKEER_DATABASE_MAX_OPEN_CONNS")
+		os.Unsetenv("STALKEER_DATABASE_CONN_MAX_LIFETIME")
+	}()
+
+	cfg = nil
+	if err := Load(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	config := Get()
+	if config.Database.MaxOpenConns != 40 {
+		t.Errorf("expected max_open_conns 40, got %d", config.Database.MaxOpenConns)
+	}
+	if config.Database.MaxIdleConns != 10 {
+		t.Errorf("expected default max_idle_conns 10, got %d", config.Database.MaxIdleConns)
+	}
+	if config.Database.ConnMaxLifetime != 30*time.Minute {
+		t.Errorf("expected conn_max_lifetime 30m, got %v", config.Database.ConnMaxLifetime)
+	}
+}
+
+func TestExplain_Sources(t *testing.T) {
+	os.Setenv("STALKEER_DATABASE_USER", "testuser")
+	os.Setenv("STALKEER_DATABASE_DBNAME", "testdb")
+	os.Setenv("STALKEER_TMDB_LANGUAGE", "fr-FR")
+	os.Setenv("RADARR_API_KEY", "radarr-secret")
+	os.Setenv("STALKEER_PLEX_TOKEN", "plex-secret")
+	defer func() {
+		os.Unsetenv("STALKEER_DATABASE_USER")
+		os.Unsetenv("STALKEER_DATABASE_DBNAME")
+		os.Unsetenv("STALKEER_TMDB_LANGUAGE")
+		os.Unsetenv("RADARR_API_KEY")
+		os.Unsetenv("STALKEER_PLEX_TOKEN")
+	}()
+
+	cfg = nil
+	if err := Load(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	settings := make(map[string]Setting)
+	for _, setting := range Explain(false) {
+		settings[setting.Key] = setting
+	}
+
+	language := settings["tmdb.language"]
+	if language.Source != SourceEnv || language.Value != "fr-FR" || language.EnvVar != "STALKEER_TMDB_LANGUAGE" {
+		t.Errorf("expected tmdb.language fr-FR from env STALKEER_TMDB_LANGUAGE, got %+v", language)
+	}
+
+	apiKey := settings["radarr.api_key"]
+	if apiKey.Source != SourceEnv || apiKey.EnvVar != "RADARR_API_KEY" {
+		t.Errorf("expected radarr.api_key from env RADARR_API_KEY, got %+v", apiKey)
+	}
+	if apiKey.Value != redacted {
+		t.Errorf("expected radarr.api_key to be redacted, got %q", apiKey.Value)
+	}
+
+	if token := settings["plex.token"]; token.Value != redacted {
+		t.Errorf("expected plex.token to be redacted, got %q", token.Value)
+	}
+
+	if port := settings["api.port"]; port.Source != SourceDefault || port.Value != "8080" {
+		t.Errorf("expected api.port 8080 from default, got %+v", port)
+	}
+
+	for _, setting := range Explain(true) {
+		if setting.Key == "radarr.api_key" && setting.Value != "radarr-secret" {
+			t.Errorf("expected radarr.api_key to be shown with showSecrets, got %q", setting.Value)
+		}
+	}
+}
+
+func TestLoad_WarnsOnUnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	content := `database:
+  user: testuser
+  dbname: testdb
+downloads:
+  max_parralel: 8
+  headers:
+    Referer: http://example.com
+tmdb:
+  endpoint_timeouts:
+    search: 5
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.yml"), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	t.Chdir(dir)
+	t.Cleanup(viper.Reset)
+
+	var buf bytes.Buffer
+	previous := logger.AppLogger()
+	logger.SetAppLogger(logger.New(logger.Config{Output: &buf}))
+	t.Cleanup(func() { logger.SetAppLogger(previous) })
+
+	cfg = nil
+	if err := Load(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if unknown := UnknownKeys(); len(unknown) != 1 || unknown[0] != "downloads.max_parralel" {
+		t.Errorf("expected only downloads.max_parralel to be unknown, got %v", unknown)
+	}
+	if !strings.Contains(buf.String(), "downloads.max_parralel") || !strings.Contains(buf.String(), "unknown configuration key") {
+		t.Errorf("expected a warning for downloads.max_parralel, got %q", buf.String())
+	}
+}
+
+func TestLoad_MergesConfigFiles(t *testing.T) {
+	writeConfig := func(t *testing.T, path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+	base := `database:
+  user: baseuser
+  dbname: stalkeer
+  password: changeme
+api:
+  port: 9000
+`
+
+	t.Run("local override", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfig(t, filepath.Join(dir, "config.yml"), base)
+		writeConfig(t, filepath.Join(dir, "config.local.yml"), "database:\n  password: s3cret\n")
+		t.Chdir(dir)
+		t.Cleanup(viper.Reset)
+
+		cfg = nil
+		if err := Load(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		got := Get()
+		if got.Database.Password != "s3cret" {
+			t.Errorf("expected the override password, got %q", got.Database.Password)
+		}
+		if got.Database.User != "baseuser" || got.API.Port != 9000 {
+			t.Errorf("expected the base settings to be kept, got user %q and port %d", got.Database.User, got.API.Port)
+		}
+		if len(Files()) != 2 {
+			t.Errorf("expected 2 config files, got %v", Files())
+		}
+	})
+
+	t.Run("config dir", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfig(t, filepath.Join(dir, "10-base.yml"), base)
+		writeConfig(t, filepath.Join(dir, "20-secrets.yaml"), "database:\n  password: s3cret\n")
+		writeConfig(t, filepath.Join(dir, "README.txt"), "not a config file")
+		SetConfigDir(dir)
+		t.Cleanup(func() { SetConfigDir("") })
+		t.Chdir(t.TempDir())
+		t.Cleanup(viper.Reset)
+
+		cfg = nil
+		if err := Load(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		got := Get()
+		if got.Database.Password != "s3cret" || got.Database.User != "baseuser" {
+			t.Errorf("expected the later file to override the earlier one, got user %q and password %q", got.Database.User, got.Database.Password)
+		}
+		if len(Files()) != 2 {
+			t.Errorf("expected 2 config files, got %v", Files())
+		}
+	})
+}
+
+func TestDownloadsConfig_HostLimits(t *testing.T) {
+	downloads := DownloadsConfig{PerHostLimit: []HostLimit{
+		{Host: "Provider-A.example.com", Max: 2},
+		{Host: "provider-b.example.com:8080", Max: 6},
+	}}
+
+	limits := downloads.HostLimits()
+	if len(limits) != 2 || limits["provider-a.example.com"] != 2 || limits["provider-b.example.com:8080"] != 6 {
+		t.Errorf("unexpected host limits %v", limits)
+	}
+}
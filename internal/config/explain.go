@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Configuration value sources, in increasing order of precedence
+const (
+	SourceUnset   = "unset"
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// redacted replaces secret values in the source report
+const redacted = "********"
+
+// Setting is a resolved configuration key with the source its value came from
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	EnvVar string // environment variable that set the value, when Source is SourceEnv
+}
+
+// Explain reports every known configuration key with its resolved value and the
+// source that won. Secret values are redacted unless showSecrets is set.
+// It must be called after Load.
+func Explain(showSecrets bool) []Setting {
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+
+	settings := make([]Setting, 0, len(keys))
+	for _, key := range keys {
+		setting := Setting{Key: key, Source: SourceUnset}
+
+		if envVar, ok := envOverrides[key]; ok {
+			setting.Source, setting.EnvVar = SourceEnv, envVar
+		} else if envVar := envVarName(key); os.Getenv(envVar) != "" {
+			setting.Source, setting.EnvVar = SourceEnv, envVar
+		} else if viper.InConfig(key) {
+			setting.Source = SourceFile
+		} else if viper.IsSet(key) {
+			setting.Source = SourceDefault
+		}
+
+		if value := viper.Get(key); value != nil {
+			setting.Value = fmt.Sprintf("%v", value)
+		}
+		if !showSecrets && setting.Value != "" && isSecretKey(key) {
+			setting.Value = redacted
+		}
+
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+// envVarName returns the STALKEER_ prefixed environment variable bound to key
+func envVarName(key string) string {
+	return "STALKEER_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// isSecretKey reports whether key holds a credential
+func isSecretKey(key string) bool {
+	return strings.HasSuffix(key, "password") ||
+		strings.HasSuffix(key, "api_key") ||
+		strings.HasSuffix(key, "token") ||
+		strings.HasSuffix(key, "_dsn")
+}
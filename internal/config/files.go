@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// configFile and configDir are set from the --config and --config-dir flags.
+// When both are empty, Load looks config.yml up in the default paths.
+var (
+	configFile string
+	configDir  string
+)
+
+// loadedFiles lists the configuration files read by the last Load, in merge order
+var loadedFiles []string
+
+// SetConfigFile makes Load read path instead of looking config.yml up
+func SetConfigFile(path string) {
+	configFile = path
+}
+
+// SetConfigDir makes Load merge the *.yml and *.yaml files of dir, in name order,
+// on top of the configuration file. Without a configuration file set, dir
+// replaces the lookup of config.yml.
+func SetConfigDir(dir string) {
+	configDir = dir
+}
+
+// Files returns the configuration files read by the last Load, in merge order
+func Files() []string {
+	return loadedFiles
+}
+
+// readConfigFiles reads the configuration file, merges its local override, e.g.
+// config.local.yml next to config.yml, then the files of the configuration
+// directory. Later files override earlier ones.
+func readConfigFiles() error {
+	loadedFiles = nil
+
+	switch {
+	case configFile != "":
+		viper.SetConfigFile(configFile)
+		if err := viper.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+	case configDir == "":
+		if err := viper.ReadInConfig(); err != nil {
+			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+				return fmt.Errorf("failed to read config file: %w", err)
+			}
+		}
+	}
+
+	if base := viper.ConfigFileUsed(); base != "" {
+		loadedFiles = append(loadedFiles, base)
+		if local := localConfigFile(base); fileExists(local) {
+			if err := mergeConfigFile(local); err != nil {
+				return err
+			}
+		}
+	}
+
+	if configDir != "" {
+		files, err := dirConfigFiles(configDir)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if err := mergeConfigFile(file); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// mergeConfigFile merges path on top of the configuration read so far. The file
+// is read directly so that viper keeps the base file for the next Load.
+func mergeConfigFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	defer file.Close()
+
+	if err := viper.MergeConfig(file); err != nil {
+		return fmt.Errorf("failed to merge config file %s: %w", path, err)
+	}
+	loadedFiles = append(loadedFiles, path)
+	return nil
+}
+
+// localConfigFile returns the local override of a configuration file, e.g.
+// config.local.yml for config.yml
+func localConfigFile(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".local" + ext
+}
+
+// dirConfigFiles returns the yaml files of dir sorted by name
+func dirConfigFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yml", ".yaml":
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
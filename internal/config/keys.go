@@ -0,0 +1,74 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/spf13/viper"
+)
+
+// UnknownKeys returns the loaded configuration keys that match no setting, e.g.
+// a misspelled downloads.max_parralel, sorted. It must be called after Load.
+func UnknownKeys() []string {
+	known := map[string]bool{}
+	var freeForm []string
+	collectKeys(reflect.TypeOf(Config{}), "", known, &freeForm)
+
+	var unknown []string
+	for _, key := range viper.AllKeys() {
+		if known[key] || hasAnyPrefix(key, freeForm) {
+			continue
+		}
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// warnUnknownKeys logs a warning for each configuration key that matches no setting
+func warnUnknownKeys() {
+	for _, key := range UnknownKeys() {
+		logger.AppLogger().WithFields(map[string]interface{}{
+			"key":   key,
+			"files": loadedFiles,
+		}).Warn("unknown configuration key ignored")
+	}
+}
+
+// collectKeys adds the mapstructure keys of the fields of t to known. Maps accept
+// any sub-key, so their key is added to freeForm as a prefix instead.
+func collectKeys(t reflect.Type, prefix string, known map[string]bool, freeForm *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + name
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		switch fieldType.Kind() {
+		case reflect.Struct:
+			collectKeys(fieldType, key+".", known, freeForm)
+		case reflect.Map:
+			known[key] = true
+			*freeForm = append(*freeForm, key+".")
+		default:
+			known[key] = true
+		}
+	}
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,19 +1,35 @@
 package database
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/glefebvre/stalkeer/internal/config"
 	"github.com/glefebvre/stalkeer/internal/logger"
-	"github.com/glefebvre/stalkeer/internal/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var db *gorm.DB
 
-// InitializeWithRetry sets up the database connection with retry logic for container startup
+// readDB is an optional read replica connection, see GetRead
+var readDB *gorm.DB
+
+// Connection pool defaults used when the configuration leaves a setting unset
+const (
+	defaultMaxOpenConns    = 25
+	defaultMaxIdleConns    = 10
+	defaultConnMaxLifetime = time.Hour
+)
+
+// ErrSchemaOutdated is returned on startup when migrations are pending and
+// database.auto_migrate is disabled
+var ErrSchemaOutdated = errors.New("database schema is out of date")
+
+// InitializeWithRetry sets up the database connection with retry logic for container
+// startup. An outdated schema is returned at once.
 func InitializeWithRetry(maxRetries int, retryDelay time.Duration) error {
 	var err error
 	for i := 0; i < maxRetries; i++ {
@@ -21,6 +37,10 @@ func InitializeWithRetry(maxRetries int, retryDelay time.Duration) error {
 		if err == nil {
 			return nil
 		}
+		// Retrying does not apply pending migrations
+		if errors.Is(err, ErrSchemaOutdated) {
+			return err
+		}
 
 		if i < maxRetries-1 {
 			logger.AppLogger().WithFields(map[string]interface{}{
@@ -35,8 +55,40 @@ func InitializeWithRetry(maxRetries int, retryDelay time.Duration) error {
 	return fmt.Errorf("failed to connect to database after %d attempts: %w", maxRetries, err)
 }
 
-// Initialize sets up the database connection and runs migrations
+// Initialize sets up the database connection and applies pending migrations, or
+// only checks that none is pending when database.auto_migrate is disabled
 func Initialize() error {
+	if err := Connect(); err != nil {
+		return err
+	}
+
+	return prepareSchema(db, config.Get().Database.AutoMigrate)
+}
+
+// prepareSchema applies the pending migrations of conn when autoMigrate is set.
+// Otherwise it returns ErrSchemaOutdated when migrations are pending, so that schema
+// changes are only applied by the migrate command.
+func prepareSchema(conn *gorm.DB, autoMigrate bool) error {
+	if autoMigrate {
+		if _, err := Migrate(conn); err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
+		return nil
+	}
+
+	pending, err := PendingMigrations(conn)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %d pending migration(s) starting at version %d, run 'stalkeer migrate'",
+			ErrSchemaOutdated, len(pending), pending[0].Version)
+	}
+	return nil
+}
+
+// Connect sets up the database connection without running migrations
+func Connect() error {
 	cfg := config.Get()
 
 	dsn := fmt.Sprintf(
@@ -66,18 +118,50 @@ func Initialize() error {
 		return fmt.Errorf("failed to get database instance: %w", err)
 	}
 
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	configurePool(sqlDB, cfg.Database)
 
-	// Run auto-migrations
-	if err := runMigrations(); err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
+	// Open the read replica when configured
+	if cfg.Database.ReadDSN != "" {
+		readDB, err = gorm.Open(postgres.Open(cfg.Database.ReadDSN), &gorm.Config{
+			Logger: gormLogger,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to connect to read replica: %w", err)
+		}
+
+		readSQLDB, err := readDB.DB()
+		if err != nil {
+			return fmt.Errorf("failed to get read replica instance: %w", err)
+		}
+		configurePool(readSQLDB, cfg.Database)
 	}
 
 	return nil
 }
 
+// configurePool applies the connection pool settings, falling back to defaults for unset values
+func configurePool(sqlDB *sql.DB, cfg config.DatabaseConfig) {
+	maxOpen := cfg.MaxOpenConns
+	if maxOpen == 0 {
+		maxOpen = defaultMaxOpenConns
+	}
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle == 0 {
+		maxIdle = defaultMaxIdleConns
+	}
+	if maxIdle > maxOpen {
+		maxIdle = maxOpen
+	}
+	lifetime := cfg.ConnMaxLifetime
+	if lifetime == 0 {
+		lifetime = defaultConnMaxLifetime
+	}
+
+	sqlDB.SetMaxOpenConns(maxOpen)
+	sqlDB.SetMaxIdleConns(maxIdle)
+	sqlDB.SetConnMaxLifetime(lifetime)
+}
+
 // Get returns the database instance
 func Get() *gorm.DB {
 	return db
@@ -88,6 +172,25 @@ func GetDB() *gorm.DB {
 	return db
 }
 
+// GetRead returns the read replica connection for read-only queries,
+// falling back to the primary database when no replica is configured
+func GetRead() *gorm.DB {
+	if readDB != nil {
+		return readDB
+	}
+	return db
+}
+
+// SetDB replaces the database instance. Intended for use in tests only.
+func SetDB(conn *gorm.DB) {
+	db = conn
+}
+
+// SetReadDB replaces the read replica instance. Intended for use in tests only.
+func SetReadDB(conn *gorm.DB) {
+	readDB = conn
+}
+
 // HealthCheck verifies database connectivity
 func HealthCheck() error {
 	sqlDB, err := db.DB()
@@ -102,8 +205,15 @@ func HealthCheck() error {
 	return nil
 }
 
-// Close closes the database connection
+// Close closes the database connections
 func Close() error {
+	if readDB != nil {
+		if readSQLDB, err := readDB.DB(); err == nil {
+			readSQLDB.Close()
+		}
+		readDB = nil
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return fmt.Errorf("failed to get database instance: %w", err)
@@ -111,32 +221,3 @@ func Close() error {
 
 	return sqlDB.Close()
 }
-
-func runMigrations() error {
-	if err := db.AutoMigrate(
-		&models.Movie{},
-		&models.TVShow{},
-		&models.Channel{},
-		&models.Uncategorized{},
-		&models.FilterConfig{},
-		&models.ProcessingLog{},
-		&models.DownloadInfo{},
-		&models.ProcessedLine{},
-	); err != nil {
-		return err
-	}
-
-	// Drop legacy columns removed from ProcessedLine (overrides feature was never used).
-	// Use raw SQL with IF EXISTS so this is idempotent across environments.
-	migrations := []string{
-		"ALTER TABLE processed_lines DROP COLUMN IF EXISTS overrides_id",
-		"ALTER TABLE processed_lines DROP COLUMN IF EXISTS overrides_at",
-	}
-	for _, stmt := range migrations {
-		if err := db.Exec(stmt).Error; err != nil {
-			return fmt.Errorf("migration failed (%s): %w", stmt, err)
-		}
-	}
-
-	return nil
-}
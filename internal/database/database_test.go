@@ -0,0 +1,132 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openTestSQLDB(t *testing.T) *sql.DB {
+	t.Helper()
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite database: %v", err)
+	}
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql.DB: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return sqlDB
+}
+
+func TestConfigurePool_AppliesSettings(t *testing.T) {
+	sqlDB := openTestSQLDB(t)
+
+	configurePool(sqlDB, config.DatabaseConfig{
+		MaxOpenConns:    7,
+		MaxIdleConns:    3,
+		ConnMaxLifetime: 5 * time.Minute,
+	})
+
+	if got := sqlDB.Stats().MaxOpenConnections; got != 7 {
+		t.Errorf("expected max open connections 7, got %d", got)
+	}
+}
+
+func TestConfigurePool_DefaultsWhenUnset(t *testing.T) {
+	sqlDB := openTestSQLDB(t)
+
+	configurePool(sqlDB, config.DatabaseConfig{})
+
+	if got := sqlDB.Stats().MaxOpenConnections; got != defaultMaxOpenConns {
+		t.Errorf("expected default max open connections %d, got %d", defaultMaxOpenConns, got)
+	}
+}
+
+func TestGetRead_FallsBackToPrimary(t *testing.T) {
+	primary, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open sqlite database: %v", err)
+	}
+	SetDB(primary)
+	SetReadDB(nil)
+	defer SetDB(nil)
+
+	if GetRead() != primary {
+		t.Error("expected GetRead to return the primary when no replica is configured")
+	}
+}
+
+func TestGetRead_ReturnsReplica(t *testing.T) {
+	primary, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open primary database: %v", err)
+	}
+	replica, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open replica database: %v", err)
+	}
+	SetDB(primary)
+	SetReadDB(replica)
+	defer func() {
+		SetDB(nil)
+		SetReadDB(nil)
+	}()
+
+	if GetRead() != replica {
+		t.Error("expected GetRead to return the replica when configured")
+	}
+	if Get() != primary {
+		t.Error("expected Get to keep returning the primary")
+	}
+}
+
+func TestPrepareSchema_AutoMigrate(t *testing.T) {
+	gdb := openMigrationTestDB(t)
+
+	if err := prepareSchema(gdb, true); err != nil {
+		t.Fatalf("prepareSchema returned error: %v", err)
+	}
+
+	pending, err := PendingMigrations(gdb)
+	if err != nil {
+		t.Fatalf("PendingMigrations returned error: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected auto-migrate to apply every migration, got %d pending", len(pending))
+	}
+}
+
+func TestPrepareSchema_WithoutAutoMigrate(t *testing.T) {
+	gdb := openMigrationTestDB(t)
+
+	err := prepareSchema(gdb, false)
+	if !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("expected ErrSchemaOutdated on an empty database, got %v", err)
+	}
+	if gdb.Migrator().HasTable(&SchemaMigration{}) {
+		t.Error("expected no migration to be applied without auto-migrate")
+	}
+
+	// Once the migrate command has run, startup succeeds
+	if _, err := Migrate(gdb); err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+	if err := prepareSchema(gdb, false); err != nil {
+		t.Errorf("expected an up to date schema to pass, got %v", err)
+	}
+
+	// A rolled back migration is pending again
+	if _, err := Rollback(gdb, 1); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	if err := prepareSchema(gdb, false); !errors.Is(err, ErrSchemaOutdated) {
+		t.Errorf("expected ErrSchemaOutdated with a pending migration, got %v", err)
+	}
+}
@@ -0,0 +1,441 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// Migration is a versioned schema change. Up applies the change and Down reverts it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration records an applied migration in the schema_migrations table
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName specifies the table name for SchemaMigration
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time // nil when the migration is pending
+}
+
+// baselineModels are the models created by the baseline migration, in dependency order
+var baselineModels = []interface{}{
+	&models.Movie{},
+	&models.TVShow{},
+	&models.Channel{},
+	&models.Uncategorized{},
+	&models.FilterConfig{},
+	&models.ProcessingLog{},
+	&models.DownloadInfo{},
+	&models.ProcessedLine{},
+}
+
+// migrations lists all schema migrations in version order. New migrations are
+// appended with the next version number; applied migrations must not be edited.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "baseline",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(baselineModels...)
+		},
+		Down: func(tx *gorm.DB) error {
+			for i := len(baselineModels) - 1; i >= 0; i-- {
+				if err := tx.Migrator().DropTable(baselineModels[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		// The overrides feature was never used
+		Version: 2,
+		Name:    "drop_processed_lines_overrides",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []string{"overrides_id", "overrides_at"} {
+				if tx.Migrator().HasColumn(&models.ProcessedLine{}, column) {
+					if err := tx.Migrator().DropColumn(&models.ProcessedLine{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+		// The dropped columns held no data, there is nothing to restore
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	},
+	{
+		Version: 3,
+		Name:    "add_download_info_detected_content_type",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.DownloadInfo{}, "DetectedContentType") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.DownloadInfo{}, "DetectedContentType")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.DownloadInfo{}, "DetectedContentType")
+		},
+	},
+	{
+		// Keeps previous downloads of a line reachable once it is downloaded again
+		Version: 4,
+		Name:    "add_download_info_processed_line_id",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.DownloadInfo{}, "ProcessedLineID") {
+				if err := tx.Migrator().AddColumn(&models.DownloadInfo{}, "ProcessedLineID"); err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasIndex(&models.DownloadInfo{}, "idx_download_info_processed_line_id") {
+				if err := tx.Migrator().CreateIndex(&models.DownloadInfo{}, "idx_download_info_processed_line_id"); err != nil {
+					return err
+				}
+			}
+			return tx.Exec(`UPDATE download_info SET processed_line_id = (
+				SELECT MIN(processed_lines.id) FROM processed_lines
+				WHERE processed_lines.download_info_id = download_info.id
+			) WHERE processed_line_id IS NULL`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.DownloadInfo{}, "ProcessedLineID")
+		},
+	},
+	{
+		// Multi-episode streams such as "S01E01-E03" cover a range of episodes
+		Version: 5,
+		Name:    "add_tvshows_episode_end",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.TVShow{}, "EpisodeEnd") {
+				if err := tx.Migrator().AddColumn(&models.TVShow{}, "EpisodeEnd"); err != nil {
+					return err
+				}
+			}
+			return tx.Exec("UPDATE tvshows SET episode_end = episode WHERE episode IS NOT NULL AND episode_end IS NULL").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.TVShow{}, "EpisodeEnd")
+		},
+	},
+	{
+		Version: 6,
+		Name:    "add_download_info_speed",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []string{"SpeedBps", "ProgressUpdatedAt"} {
+				if tx.Migrator().HasColumn(&models.DownloadInfo{}, column) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&models.DownloadInfo{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"SpeedBps", "ProgressUpdatedAt"} {
+				if err := tx.Migrator().DropColumn(&models.DownloadInfo{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 7,
+		Name:    "add_processed_lines_language",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.ProcessedLine{}, "Language") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.ProcessedLine{}, "Language")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.ProcessedLine{}, "Language")
+		},
+	},
+	{
+		Version: 8,
+		Name:    "create_stats_snapshot",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.StatsSnapshot{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.StatsSnapshot{})
+		},
+	},
+	{
+		Version: 9,
+		Name:    "add_processed_lines_normalized_group",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.ProcessedLine{}, "NormalizedGroup") {
+				if err := tx.Migrator().AddColumn(&models.ProcessedLine{}, "NormalizedGroup"); err != nil {
+					return err
+				}
+			}
+			if tx.Migrator().HasIndex(&models.ProcessedLine{}, "NormalizedGroup") {
+				return nil
+			}
+			return tx.Migrator().CreateIndex(&models.ProcessedLine{}, "NormalizedGroup")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.ProcessedLine{}, "NormalizedGroup")
+		},
+	},
+	{
+		Version: 10,
+		Name:    "create_tags",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Tag{}, &models.ProcessedLineTag{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.ProcessedLineTag{}, &models.Tag{})
+		},
+	},
+	{
+		Version: 11,
+		Name:    "add_tvshows_air_date",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.TVShow{}, "AirDate") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.TVShow{}, "AirDate")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.TVShow{}, "AirDate")
+		},
+	},
+	{
+		Version: 12,
+		Name:    "add_download_info_priority",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.DownloadInfo{}, "Priority") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.DownloadInfo{}, "Priority")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.DownloadInfo{}, "Priority")
+		},
+	},
+	{
+		Version: 13,
+		Name:    "add_processed_lines_classification_confidence",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.ProcessedLine{}, "ClassificationConfidence") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.ProcessedLine{}, "ClassificationConfidence")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.ProcessedLine{}, "ClassificationConfidence")
+		},
+	},
+	{
+		Version: 14,
+		Name:    "add_download_info_content_hash",
+		Up: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasColumn(&models.DownloadInfo{}, "ContentHash") {
+				if err := tx.Migrator().AddColumn(&models.DownloadInfo{}, "ContentHash"); err != nil {
+					return err
+				}
+			}
+			if tx.Migrator().HasIndex(&models.DownloadInfo{}, "idx_download_info_content_hash") {
+				return nil
+			}
+			return tx.Migrator().CreateIndex(&models.DownloadInfo{}, "idx_download_info_content_hash")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.DownloadInfo{}, "ContentHash")
+		},
+	},
+	{
+		// Keeps the TMDB match counts of each run, to follow the match rate over time
+		Version: 15,
+		Name:    "add_processing_logs_tmdb_stats",
+		Up: func(tx *gorm.DB) error {
+			for _, column := range []string{"TMDBMatched", "TMDBNotFound"} {
+				if tx.Migrator().HasColumn(&models.ProcessingLog{}, column) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&models.ProcessingLog{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range []string{"TMDBMatched", "TMDBNotFound"} {
+				if err := tx.Migrator().DropColumn(&models.ProcessingLog{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},	{
+		// Whole season streams are stored without an episode
+		Version: 16,
+		Name:    "add_tvshows_season_pack",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasColumn(&models.TVShow{}, "SeasonPack") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&models.TVShow{}, "SeasonPack")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&models.TVShow{}, "SeasonPack")
+		},
+	},
+}
+
+// Migrate applies all pending migrations in version order, each in its own
+// transaction. It is idempotent and returns the migrations it applied.
+func Migrate(conn *gorm.DB) ([]Migration, error) {
+	return migrateUp(conn, migrations)
+}
+
+// Rollback reverts the last steps applied migrations in reverse version order
+// and returns the migrations it reverted.
+func Rollback(conn *gorm.DB, steps int) ([]Migration, error) {
+	return migrateDown(conn, migrations, steps)
+}
+
+// Status returns the state of every known migration in version order
+func Status(conn *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if record, ok := applied[m.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// PendingMigrations returns the migrations that are not applied yet, in version
+// order. Unlike Status it never creates the schema_migrations table, so it is safe
+// to call from readiness probes.
+func PendingMigrations(conn *gorm.DB) ([]Migration, error) {
+	applied := make(map[int]bool)
+	if conn.Migrator().HasTable(&SchemaMigration{}) {
+		var versions []int
+		if err := conn.Model(&SchemaMigration{}).Pluck("version", &versions).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch applied migrations: %w", err)
+		}
+		for _, version := range versions {
+			applied[version] = true
+		}
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+func migrateUp(conn *gorm.DB, list []Migration) ([]Migration, error) {
+	applied, err := appliedMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range list {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		err := conn.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+func migrateDown(conn *gorm.DB, list []Migration, steps int) ([]Migration, error) {
+	applied, err := appliedMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(list) - 1; i >= 0 && len(done) < steps; i-- {
+		m := list[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		err := conn.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("rollback of migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// appliedMigrations creates the schema_migrations table when missing and
+// returns the applied migrations keyed by version
+func appliedMigrations(conn *gorm.DB) (map[int]SchemaMigration, error) {
+	if err := conn.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	var records []SchemaMigration
+	if err := conn.Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch applied migrations: %w", err)
+	}
+
+	applied := make(map[int]SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
@@ -0,0 +1,220 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openMigrationTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open sqlite database: %v", err)
+	}
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql.DB: %v", err)
+	}
+	// Each connection to :memory: is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return gdb
+}
+
+func TestMigrate_UpThenDown(t *testing.T) {
+	gdb := openMigrationTestDB(t)
+
+	applied, err := Migrate(gdb)
+	if err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("expected %d applied migrations, got %d", len(migrations), len(applied))
+	}
+	for _, table := range []string{"processed_lines", "movies", "tvshows", "download_info"} {
+		if !gdb.Migrator().HasTable(table) {
+			t.Errorf("expected table %s after migrating up", table)
+		}
+	}
+
+	reverted, err := Rollback(gdb, len(migrations))
+	if err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	if len(reverted) != len(migrations) {
+		t.Fatalf("expected %d reverted migrations, got %d", len(migrations), len(reverted))
+	}
+	if reverted[0].Version != migrations[len(migrations)-1].Version {
+		t.Errorf("expected rollback to start from the latest version, got %d", reverted[0].Version)
+	}
+	for _, table := range []string{"processed_lines", "movies", "tvshows", "download_info"} {
+		if gdb.Migrator().HasTable(table) {
+			t.Errorf("expected table %s to be dropped after migrating down", table)
+		}
+	}
+
+	statuses, err := Status(gdb)
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			t.Errorf("expected migration %d to be pending after rollback", status.Version)
+		}
+	}
+}
+
+func TestMigrate_Idempotent(t *testing.T) {
+	gdb := openMigrationTestDB(t)
+
+	if _, err := Migrate(gdb); err != nil {
+		t.Fatalf("first Migrate returned error: %v", err)
+	}
+	applied, err := Migrate(gdb)
+	if err != nil {
+		t.Fatalf("second Migrate returned error: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("expected no migrations on second run, got %d", len(applied))
+	}
+
+	statuses, err := Status(gdb)
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if len(statuses) != len(migrations) {
+		t.Fatalf("expected %d statuses, got %d", len(migrations), len(statuses))
+	}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			t.Errorf("expected migration %d to be applied", status.Version)
+		}
+	}
+}
+
+func TestRollback_Steps(t *testing.T) {
+	gdb := openMigrationTestDB(t)
+
+	if _, err := Migrate(gdb); err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+
+	reverted, err := Rollback(gdb, 1)
+	if err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+	if len(reverted) != 1 {
+		t.Fatalf("expected 1 reverted migration, got %d", len(reverted))
+	}
+
+	statuses, err := Status(gdb)
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	last := statuses[len(statuses)-1]
+	if last.AppliedAt != nil {
+		t.Errorf("expected latest migration %d to be pending", last.Version)
+	}
+	if statuses[0].AppliedAt == nil {
+		t.Error("expected baseline migration to remain applied")
+	}
+
+	// Migrating again re-applies only the reverted migration
+	applied, err := Migrate(gdb)
+	if err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+	if len(applied) != 1 || applied[0].Version != last.Version {
+		t.Errorf("expected only migration %d to be re-applied, got %v", last.Version, applied)
+	}
+}
+
+func TestMigrateUp_FailureIsNotRecorded(t *testing.T) {
+	gdb := openMigrationTestDB(t)
+
+	list := []Migration{
+		{Version: 1, Name: "ok", Up: func(tx *gorm.DB) error { return nil }, Down: func(tx *gorm.DB) error { return nil }},
+		{Version: 2, Name: "broken", Up: func(tx *gorm.DB) error { return errors.New("boom") }, Down: func(tx *gorm.DB) error { return nil }},
+	}
+
+	applied, err := migrateUp(gdb, list)
+	if err == nil {
+		t.Fatal("expected an error from the failing migration")
+	}
+	if len(applied) != 1 || applied[0].Version != 1 {
+		t.Errorf("expected only migration 1 to be applied, got %v", applied)
+	}
+
+	var count int64
+	gdb.Model(&SchemaMigration{}).Where("version = ?", 2).Count(&count)
+	if count != 0 {
+		t.Error("expected the failing migration not to be recorded")
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	gdb := openMigrationTestDB(t)
+
+	pending, err := PendingMigrations(gdb)
+	if err != nil {
+		t.Fatalf("PendingMigrations returned error: %v", err)
+	}
+	if len(pending) != len(migrations) {
+		t.Errorf("expected all %d migrations pending on an empty database, got %d", len(migrations), len(pending))
+	}
+	if gdb.Migrator().HasTable(&SchemaMigration{}) {
+		t.Error("expected PendingMigrations not to create the schema_migrations table")
+	}
+
+	if _, err := Migrate(gdb); err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+	pending, err = PendingMigrations(gdb)
+	if err != nil {
+		t.Fatalf("PendingMigrations returned error: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected no pending migrations after Migrate, got %v", pending)
+	}
+}
+
+func TestMigrate_AddsSeasonPackToUpgradedDatabase(t *testing.T) {
+	gdb := openMigrationTestDB(t)
+
+	// A database migrated before season packs: the column and its migration are missing
+	if _, err := Migrate(gdb); err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+	if err := gdb.Migrator().DropColumn(&models.TVShow{}, "SeasonPack"); err != nil {
+		t.Fatalf("failed to drop season_pack: %v", err)
+	}
+	if err := gdb.Where("version = ?", 16).Delete(&SchemaMigration{}).Error; err != nil {
+		t.Fatalf("failed to forget migration 16: %v", err)
+	}
+	if err := gdb.Exec("INSERT INTO tvshows (tmdb_id, tmdb_title, tmdb_year, created_at, updated_at) VALUES (1399, 'Game of Thrones', 2011, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)").Error; err != nil {
+		t.Fatalf("failed to insert show: %v", err)
+	}
+
+	applied, err := Migrate(gdb)
+	if err != nil {
+		t.Fatalf("Migrate returned error: %v", err)
+	}
+	if len(applied) != 1 || applied[0].Name != "add_tvshows_season_pack" {
+		t.Fatalf("expected only add_tvshows_season_pack to be applied, got %v", applied)
+	}
+
+	var count int64
+	if err := gdb.Model(&models.TVShow{}).Where("season_pack = ?", false).Count(&count).Error; err != nil {
+		t.Fatalf("failed to query season_pack: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected the existing show to default to season_pack false, got %d", count)
+	}
+}
@@ -0,0 +1,55 @@
+// Package dedup provides an in-memory set used to skip duplicate entries
+package dedup
+
+import "sync"
+
+// Set is an in-memory set of keys, such as line hashes, that is safe for
+// concurrent use. Add checks and inserts a key atomically, so that concurrent
+// workers never both claim the same key.
+type Set struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+// New creates an empty set, sizeHint preallocates room for that many keys
+func New(sizeHint int) *Set {
+	return &Set{keys: make(map[string]struct{}, max(sizeHint, 0))}
+}
+
+// Add inserts key and reports whether it was new. It returns false when the key
+// was already in the set, i.e. the caller holds a duplicate.
+func (s *Set) Add(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.keys[key]; ok {
+		return false
+	}
+	s.keys[key] = struct{}{}
+	return true
+}
+
+// Contains reports whether key is in the set
+func (s *Set) Contains(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.keys[key]
+	return ok
+}
+
+// Remove deletes key from the set, e.g. to release a claim that could not be stored
+func (s *Set) Remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+}
+
+// Len returns the number of keys in the set
+func (s *Set) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.keys)
+}
@@ -0,0 +1,65 @@
+package dedup
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	s := New(0)
+
+	if !s.Add("a") {
+		t.Error("expected the first add to be new")
+	}
+	if s.Add("a") {
+		t.Error("expected the second add to be a duplicate")
+	}
+	if !s.Contains("a") || s.Contains("b") {
+		t.Error("unexpected contents")
+	}
+
+	s.Remove("a")
+	if s.Contains("a") || s.Len() != 0 {
+		t.Error("expected the key to be removed")
+	}
+	if !s.Add("a") {
+		t.Error("expected a removed key to be new again")
+	}
+}
+
+func TestSetConcurrentAdd(t *testing.T) {
+	const (
+		workers = 16
+		keys    = 1000
+	)
+
+	s := New(keys)
+	claims := make([]int32, keys)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// Every worker walks all keys from a different offset, so they overlap
+			for i := 0; i < keys; i++ {
+				k := (i + w*keys/workers) % keys
+				if s.Add(fmt.Sprintf("hash-%d", k)) {
+					atomic.AddInt32(&claims[k], 1)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for k, n := range claims {
+		if n != 1 {
+			t.Errorf("key %d claimed %d times, expected exactly once", k, n)
+		}
+	}
+	if s.Len() != keys {
+		t.Errorf("expected %d keys, got %d", keys, s.Len())
+	}
+}
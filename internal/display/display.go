@@ -0,0 +1,44 @@
+// Package display formats the values shown in logs, CLI output and API
+// responses, following the display settings of the configuration.
+package display
+
+import (
+	"fmt"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+)
+
+// Byte unit systems accepted by display.byte_units. The default, empty, keeps the
+// historical format: multiples of 1024 labeled KB, MB, GB.
+const (
+	ByteUnitsBinary  = "binary"  // Multiples of 1024 labeled KiB, MiB, GiB
+	ByteUnitsDecimal = "decimal" // Multiples of 1000 labeled kB, MB, GB
+)
+
+// FormatBytes converts a byte count to a human-readable string with precision
+// decimals, in the unit system configured in display.byte_units
+func FormatBytes(bytes int64, precision int) string {
+	return FormatBytesIn(bytes, config.Get().Display.ByteUnits, precision)
+}
+
+// FormatBytesIn converts a byte count to a human-readable string with precision
+// decimals, in the given unit system
+func FormatBytesIn(bytes int64, units string, precision int) string {
+	unit, prefixes, suffix := int64(1024), "KMGTPE", "B"
+	switch units {
+	case ByteUnitsBinary:
+		suffix = "iB"
+	case ByteUnitsDecimal:
+		unit, prefixes = 1000, "kMGTPE"
+	}
+
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := unit, 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.*f %c%s", precision, float64(bytes)/float64(div), prefixes[exp], suffix)
+}
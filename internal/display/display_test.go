@@ -0,0 +1,30 @@
+package display
+
+import "testing"
+
+func TestFormatBytesIn(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		units string
+		want  string
+	}{
+		{512, "", "512 B"},
+		{1536, "", "1.50 KB"},
+		{5 * 1024 * 1024, "", "5.00 MB"},
+		{1536, ByteUnitsBinary, "1.50 KiB"},
+		{5 * 1024 * 1024, ByteUnitsBinary, "5.00 MiB"},
+		{3 << 30, ByteUnitsBinary, "3.00 GiB"},
+		{999, ByteUnitsDecimal, "999 B"},
+		{1536, ByteUnitsDecimal, "1.54 kB"},
+		{5 * 1024 * 1024, ByteUnitsDecimal, "5.24 MB"},
+		{2_500_000_000_000, ByteUnitsDecimal, "2.50 TB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.units+" "+tt.want, func(t *testing.T) {
+			if got := FormatBytesIn(tt.bytes, tt.units, 2); got != tt.want {
+				t.Errorf("FormatBytesIn(%d, %q) = %q, want %q", tt.bytes, tt.units, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package downloader
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+)
+
+// StreamAuth holds the credentials of provider stream URLs that require an
+// Authorization header. The zero value sends none.
+type StreamAuth struct {
+	Username string // Basic auth, used with Password when set
+	Password string
+	Token    string // Bearer token, used when no Username is set
+
+	// Hosts the credentials are sent to besides the host of the requested stream URL,
+	// "host" or "host:port" (see scopedTo)
+	Hosts []string
+}
+
+// NewStreamAuth returns the stream credentials of the downloads settings
+func NewStreamAuth(cfg config.DownloadsConfig) StreamAuth {
+	return StreamAuth{Username: cfg.AuthUsername, Password: cfg.AuthPassword, Token: cfg.AuthToken, Hosts: cfg.AuthHosts}
+}
+
+// scopedTo returns the credentials of a request for streamURL, sent to its host and
+// to Hosts only. Other hosts reached from the stream, such as the one serving an HLS
+// variant, get no credentials.
+func (a StreamAuth) scopedTo(streamURL string) StreamAuth {
+	u, err := url.Parse(streamURL)
+	if err != nil || u.Host == "" {
+		return a
+	}
+	a.Hosts = append([]string{u.Host}, a.Hosts...)
+	return a
+}
+
+// sendsTo reports whether the credentials are sent to u, always when they are not
+// scoped to any host
+func (a StreamAuth) sendsTo(u *url.URL) bool {
+	if len(a.Hosts) == 0 {
+		return true
+	}
+	for _, host := range a.Hosts {
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply sets the Authorization header of req, like m3udownloader does for the
+// playlist, when req is sent to one of the hosts of the credentials
+func (a StreamAuth) apply(req *http.Request) {
+	if !a.sendsTo(req.URL) {
+		return
+	}
+	switch {
+	case a.Username != "":
+		req.SetBasicAuth(a.Username, a.Password)
+	case a.Token != "":
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	}
+}
@@ -0,0 +1,113 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAuthServer returns a server serving content only to requests carrying authorization
+func newAuthServer(t *testing.T, authorization string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != authorization {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write([]byte("protected video content"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownload_StreamAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		auth          StreamAuth
+		authorization string
+	}{
+		{"basic", StreamAuth{Username: "user", Password: "secret"}, "Basic dXNlcjpzZWNyZXQ="},
+		{"bearer", StreamAuth{Token: "abc123"}, "Bearer abc123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newAuthServer(t, tt.authorization)
+
+			d := New(5*time.Second, 1)
+			result, err := d.Download(context.Background(), DownloadOptions{
+				URL:           server.URL + "/stream",
+				BaseDestPath:  filepath.Join(t.TempDir(), "movie"),
+				TempDir:       t.TempDir(),
+				PreflightHead: true,
+				Auth:          tt.auth,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, int64(len("protected video content")), result.FileSize)
+		})
+	}
+}
+
+func TestDownload_StreamAuthMissing(t *testing.T) {
+	server := newAuthServer(t, "Bearer abc123")
+
+	d := New(5*time.Second, 1)
+	_, err := d.Download(context.Background(), DownloadOptions{
+		URL:          server.URL + "/stream",
+		BaseDestPath: filepath.Join(t.TempDir(), "movie"),
+		TempDir:      t.TempDir(),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestDownload_StreamAuthScopedToStreamHost(t *testing.T) {
+	var variantAuthorization []string
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		variantAuthorization = append(variantAuthorization, r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write([]byte("variant video content"))
+	}))
+	defer cdn.Close()
+
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abc123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-mpegURL")
+		w.Write([]byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=5000000\n" + cdn.URL + "/movie.mp4\n"))
+	}))
+	defer provider.Close()
+
+	download := func(auth StreamAuth) {
+		t.Helper()
+		_, err := New(5*time.Second, 1).Download(context.Background(), DownloadOptions{
+			URL:          provider.URL + "/master.m3u8",
+			BaseDestPath: filepath.Join(t.TempDir(), "movie"),
+			TempDir:      t.TempDir(),
+			Auth:         auth,
+		})
+		require.NoError(t, err)
+	}
+
+	// The variant served by another host gets no credentials
+	download(StreamAuth{Token: "abc123"})
+	require.Len(t, variantAuthorization, 1)
+	assert.Empty(t, variantAuthorization[0])
+
+	// unless its host is listed
+	cdnHost := strings.TrimPrefix(cdn.URL, "http://")
+	download(StreamAuth{Token: "abc123", Hosts: []string{cdnHost}})
+	require.Len(t, variantAuthorization, 2)
+	assert.Equal(t, "Bearer abc123", variantAuthorization[1])
+}
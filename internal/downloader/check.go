@@ -0,0 +1,54 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+)
+
+// URLCheck is the result of checking that a stream URL is alive
+type URLCheck struct {
+	URL        string
+	Reachable  bool
+	StatusCode int   // Status of the last request, 0 when it failed
+	Err        error // Request error, nil when the server answered
+}
+
+// CheckURL checks that url is alive without downloading it. It issues a HEAD
+// request and, for servers rejecting HEAD, a GET of the first byte only.
+func (d *Downloader) CheckURL(ctx context.Context, url string, auth StreamAuth) URLCheck {
+	check := URLCheck{URL: url}
+
+	status, err := d.checkRequest(ctx, http.MethodHead, url, auth)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = d.checkRequest(ctx, http.MethodGet, url, auth)
+	}
+	if err != nil {
+		check.Err = err
+		return check
+	}
+
+	check.StatusCode = status
+	check.Reachable = status == http.StatusOK || status == http.StatusPartialContent
+	return check
+}
+
+// checkRequest sends a request for url and returns its status. GET requests only
+// ask for the first byte.
+func (d *Downloader) checkRequest(ctx context.Context, method, url string, auth StreamAuth) (int, error) {
+	auth = auth.scopedTo(url)
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	auth.apply(req)
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
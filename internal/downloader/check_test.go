@@ -0,0 +1,66 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckURL(t *testing.T) {
+	var bodyRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/alive.mkv":
+			w.Header().Set("Content-Type", "video/x-matroska")
+		case "/no-head.mkv":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			bodyRequests++
+			assert.Equal(t, "bytes=0-0", r.Header.Get("Range"))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte("v"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	d := New(5*time.Second, 1)
+	tests := []struct {
+		path      string
+		reachable bool
+		status    int
+	}{
+		{"/alive.mkv", true, http.StatusOK},
+		{"/no-head.mkv", true, http.StatusPartialContent},
+		{"/dead.mkv", false, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			check := d.CheckURL(context.Background(), server.URL+tt.path, StreamAuth{})
+			require.NoError(t, check.Err)
+			assert.Equal(t, tt.reachable, check.Reachable)
+			assert.Equal(t, tt.status, check.StatusCode)
+		})
+	}
+	assert.Equal(t, 1, bodyRequests, "only servers rejecting HEAD get a ranged GET")
+}
+
+func TestCheckURL_ConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL + "/movie.mkv"
+	server.Close()
+
+	check := New(5*time.Second, 1).CheckURL(context.Background(), url, StreamAuth{})
+	assert.False(t, check.Reachable)
+	assert.Zero(t, check.StatusCode)
+	assert.Error(t, check.Err)
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
 )
 
 const (
@@ -89,3 +91,121 @@ func CleanupOrphanedTempFiles(opts CleanupOptions) error {
 	log.Info(fmt.Sprintf("Cleanup complete: %d removed, %d skipped (too recent)", removed, skipped))
 	return nil
 }
+
+// OrphanCleanupOptions holds configuration for orphaned media file cleanup
+type OrphanCleanupOptions struct {
+	Roots  []string // Media directories to scan (movies and TV shows paths)
+	Delete bool     // Remove orphaned files instead of only reporting them
+	DryRun bool     // Never remove anything, even when Delete is set
+}
+
+// OrphanCleanupStats reports the result of an orphaned media file scan
+type OrphanCleanupStats struct {
+	Scanned      int
+	Referenced   int
+	Orphans      []string
+	OrphanBytes  int64
+	Deleted      int
+	DeleteErrors int
+}
+
+// mediaExtensions are the extensions of the files a download may leave in the media
+// directories. Other files, such as NFO files, subtitles and artwork, are never orphans.
+var mediaExtensions = map[string]bool{
+	".mkv": true, ".mp4": true, ".m4v": true, ".avi": true, ".mov": true, ".flv": true,
+	".webm": true, ".mpg": true, ".mpeg": true, ".3gp": true, ".wmv": true, ".ts": true,
+	".m3u8": true,
+}
+
+// isMediaFile reports whether path is a media file, or the partial copy of one
+func isMediaFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".tmp" || ext == ".part" {
+		ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(path, filepath.Ext(path))))
+	}
+	return mediaExtensions[ext]
+}
+
+// CleanupOrphanedMediaFiles scans the media directories for media files that are not
+// the destination of a completed DownloadInfo. Such files are typically left behind
+// by failed moves or interrupted downloads. Orphans are only reported unless Delete
+// is set and DryRun is not.
+func CleanupOrphanedMediaFiles(db *gorm.DB, opts OrphanCleanupOptions) (*OrphanCleanupStats, error) {
+	log := logger.AppLogger()
+
+	var paths []string
+	if err := db.Model(&models.DownloadInfo{}).
+		Where("status = ? AND download_path IS NOT NULL", models.DownloadStatusCompleted).
+		Pluck("download_path", &paths).Error; err != nil {
+		return nil, fmt.Errorf("failed to load completed downloads: %w", err)
+	}
+
+	referenced := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		referenced[normalizePath(p)] = true
+	}
+
+	stats := &OrphanCleanupStats{}
+	for _, root := range opts.Roots {
+		if root == "" {
+			continue
+		}
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			log.Info(fmt.Sprintf("Skipping missing media directory: %s", root))
+			continue
+		}
+
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				log.Warn(fmt.Sprintf("Failed to access %s: %v", path, err))
+				return nil
+			}
+			if d.IsDir() || !isMediaFile(path) {
+				return nil
+			}
+
+			stats.Scanned++
+			if referenced[normalizePath(path)] {
+				stats.Referenced++
+				return nil
+			}
+
+			stats.Orphans = append(stats.Orphans, path)
+			if info, err := d.Info(); err == nil {
+				stats.OrphanBytes += info.Size()
+			}
+
+			if !opts.Delete || opts.DryRun {
+				log.Info(fmt.Sprintf("Orphaned media file: %s", path))
+				return nil
+			}
+
+			if err := os.Remove(path); err != nil {
+				log.Error(fmt.Sprintf("Failed to remove %s", path), err)
+				stats.DeleteErrors++
+				return nil
+			}
+			log.Info(fmt.Sprintf("Removed orphaned media file: %s", path))
+			stats.Deleted++
+			return nil
+		})
+		if err != nil {
+			return stats, fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+	}
+
+	log.Info(fmt.Sprintf("Orphan scan complete: %d scanned, %d referenced, %d orphaned, %d removed",
+		stats.Scanned, stats.Referenced, len(stats.Orphans), stats.Deleted))
+	return stats, nil
+}
+
+// normalizePath returns an absolute, cleaned path for comparison
+func normalizePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
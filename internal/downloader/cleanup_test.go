@@ -0,0 +1,130 @@
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type orphanFixture struct {
+	db         *gorm.DB
+	moviesPath string
+	tvPath     string
+	orphans    []string // Sorted paths of the orphaned media files
+}
+
+// roots returns the library roots of the media tree
+func (f *orphanFixture) roots() []string {
+	return []string{f.moviesPath, f.tvPath}
+}
+
+// setupOrphanFixture creates a media tree with one referenced movie, one referenced
+// episode and a few orphaned files, and records the completed downloads.
+func setupOrphanFixture(t *testing.T) *orphanFixture {
+	t.Helper()
+
+	db := openTestDB(t)
+	root := t.TempDir()
+	moviesPath := filepath.Join(root, "movies")
+	tvPath := filepath.Join(root, "tvshows")
+
+	write := func(path string) string {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+		return path
+	}
+
+	movie := write(filepath.Join(moviesPath, "Inception (2010)", "Inception (2010).mkv"))
+	episode := write(filepath.Join(tvPath, "Show", "Season 01", "Show - S01E01.mp4"))
+	orphans := []string{
+		write(filepath.Join(moviesPath, "Old Movie (1999)", "Old Movie (1999).mp4")),
+		write(filepath.Join(tvPath, "Show", "Season 01", "Show - S01E02.mp4.tmp")),
+	}
+	// Files written next to the media are not orphans
+	write(filepath.Join(moviesPath, "Inception (2010)", "Inception (2010).nfo"))
+	write(filepath.Join(moviesPath, "Old Movie (1999)", "Old Movie (1999).srt"))
+	write(filepath.Join(moviesPath, "Old Movie (1999)", "poster.jpg"))
+	failed := write(filepath.Join(moviesPath, "Failed (2020)", "Failed (2020).mkv"))
+	orphans = append(orphans, failed)
+
+	require.NoError(t, db.Create(&models.DownloadInfo{Status: string(models.DownloadStatusCompleted), DownloadPath: &movie}).Error)
+	require.NoError(t, db.Create(&models.DownloadInfo{Status: string(models.DownloadStatusCompleted), DownloadPath: &episode}).Error)
+	// A failed download does not reference its file
+	require.NoError(t, db.Create(&models.DownloadInfo{Status: string(models.DownloadStatusFailed), DownloadPath: &failed}).Error)
+
+	sort.Strings(orphans)
+	return &orphanFixture{db: db, moviesPath: moviesPath, tvPath: tvPath, orphans: orphans}
+}
+
+func TestCleanupOrphanedMediaFiles_ReportOnly(t *testing.T) {
+	f := setupOrphanFixture(t)
+
+	stats, err := CleanupOrphanedMediaFiles(f.db, OrphanCleanupOptions{
+		Roots: f.roots(),
+	})
+	require.NoError(t, err)
+
+	found := append([]string(nil), stats.Orphans...)
+	sort.Strings(found)
+	assert.Equal(t, f.orphans, found)
+	assert.Equal(t, 5, stats.Scanned)
+	assert.Equal(t, 2, stats.Referenced)
+	assert.Equal(t, 0, stats.Deleted)
+
+	for _, path := range f.orphans {
+		assert.FileExists(t, path)
+	}
+}
+
+func TestCleanupOrphanedMediaFiles_DryRunNeverDeletes(t *testing.T) {
+	f := setupOrphanFixture(t)
+
+	stats, err := CleanupOrphanedMediaFiles(f.db, OrphanCleanupOptions{
+		Roots:  f.roots(),
+		Delete: true,
+		DryRun: true,
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, stats.Orphans, len(f.orphans))
+	assert.Equal(t, 0, stats.Deleted)
+	for _, path := range f.orphans {
+		assert.FileExists(t, path)
+	}
+}
+
+func TestCleanupOrphanedMediaFiles_Delete(t *testing.T) {
+	f := setupOrphanFixture(t)
+
+	stats, err := CleanupOrphanedMediaFiles(f.db, OrphanCleanupOptions{
+		Roots:  f.roots(),
+		Delete: true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, len(f.orphans), stats.Deleted)
+	for _, path := range f.orphans {
+		assert.NoFileExists(t, path)
+	}
+	assert.FileExists(t, filepath.Join(f.moviesPath, "Inception (2010)", "Inception (2010).mkv"))
+	assert.FileExists(t, filepath.Join(f.tvPath, "Show", "Season 01", "Show - S01E01.mp4"))
+	assert.FileExists(t, filepath.Join(f.moviesPath, "Inception (2010)", "Inception (2010).nfo"))
+	assert.FileExists(t, filepath.Join(f.moviesPath, "Old Movie (1999)", "Old Movie (1999).srt"))
+	assert.FileExists(t, filepath.Join(f.moviesPath, "Old Movie (1999)", "poster.jpg"))
+}
+
+func TestCleanupOrphanedMediaFiles_MissingRoot(t *testing.T) {
+	f := setupOrphanFixture(t)
+
+	stats, err := CleanupOrphanedMediaFiles(f.db, OrphanCleanupOptions{
+		Roots: []string{filepath.Join(t.TempDir(), "does-not-exist")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Scanned)
+}
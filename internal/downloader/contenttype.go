@@ -0,0 +1,39 @@
+package downloader
+
+import (
+	"strings"
+)
+
+// DefaultAllowedContentTypes are the stream content types accepted by default, see
+// downloads.allowed_content_types
+var DefaultAllowedContentTypes = []string{"video/*", "application/octet-stream"}
+
+// contentTypeAllowed reports whether contentType matches one of the allowed media
+// types, where "type/*" matches any subtype. An empty allow-list or a response
+// without a Content-Type is accepted.
+func contentTypeAllowed(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	if idx := strings.Index(contentType, ";"); idx != -1 {
+		contentType = contentType[:idx]
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if contentType == "" {
+		return true
+	}
+
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(contentType, prefix+"/") {
+				return true
+			}
+			continue
+		}
+		if contentType == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentTypeAllowed(t *testing.T) {
+	tests := []struct {
+		contentType string
+		allowed     []string
+		want        bool
+	}{
+		{"video/x-matroska", DefaultAllowedContentTypes, true},
+		{"video/mp4; charset=binary", DefaultAllowedContentTypes, true},
+		{"Application/Octet-Stream", DefaultAllowedContentTypes, true},
+		{"text/html; charset=utf-8", DefaultAllowedContentTypes, false},
+		{"application/json", DefaultAllowedContentTypes, false},
+		{"", DefaultAllowedContentTypes, true},
+		{"text/html", nil, true},
+		{"audio/mpeg", []string{"audio/mpeg"}, true},
+		{"videos/mp4", []string{"video/*"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			assert.Equal(t, tt.want, contentTypeAllowed(tt.contentType, tt.allowed))
+		})
+	}
+}
+
+func TestDownloadFile_RejectsDisallowedContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><body>Stream not found</body></html>"))
+	}))
+	defer server.Close()
+
+	destPath := filepath.Join(t.TempDir(), "download.tmp")
+	d := New(5*time.Second, 1)
+
+	_, _, err := d.downloadFile(context.Background(), server.URL+"/movie.mkv", StreamAuth{}, DefaultAllowedContentTypes, destPath, 0, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "text/html")
+	assert.NoFileExists(t, destPath)
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/glefebvre/stalkeer/internal/display"
 	"golang.org/x/sys/unix"
 )
 
@@ -68,18 +69,9 @@ func HasEnoughSpace(path string, requiredBytes uint64) (bool, *DiskSpace, error)
 	return space.Available >= requiredBytes, space, nil
 }
 
-// FormatBytes formats bytes into human-readable format
+// FormatBytes formats bytes into human-readable format, in the units of display.byte_units
 func FormatBytes(bytes uint64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%d B", bytes)
-	}
-	div, exp := uint64(unit), 0
-	for n := bytes / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+	return display.FormatBytes(int64(bytes), 1)
 }
 
 // CheckDiskSpaceBeforeDownload validates there's enough space before starting a download
@@ -1,15 +1,22 @@
 package downloader
 
 import (
+	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/glefebvre/stalkeer/internal/config"
 	"github.com/glefebvre/stalkeer/internal/database"
 	apperrors "github.com/glefebvre/stalkeer/internal/apperrors"
 	"github.com/glefebvre/stalkeer/internal/logger"
@@ -26,7 +33,48 @@ type DownloadOptions struct {
 	OnProgress      func(downloaded, total int64)
 	Timeout         time.Duration
 	RetryAttempts   int
-	TempDir         string // Optional temp directory (empty = use OS temp)
+	TempDir         string   // Optional temp directory (empty = use OS temp)
+	LockHeld        bool     // Caller already holds the DownloadInfo lock (see PrepareDownload)
+	PartURLs        []string // Following parts of a multi-part stream, appended in order after URL (see FollowingPartURLs)
+	PreflightHead   bool     // Issue a HEAD request first to learn the size and content type
+	WriteNFO        bool     // Write a .nfo metadata file next to the media file (see WriteNFO)
+	LinkMode        LinkMode // How the file is placed at its destination, empty = move
+
+	ExistingFilePolicy ExistingFilePolicy // What to do when the destination already exists, empty = overwrite
+
+	PostCommand        string          // Shell command run after a successful download, {path} = file path (see RunPostCommand)
+	PostCommandTimeout time.Duration   // Timeout of PostCommand, 0 = 60s
+	LibraryRefresh     *LibraryRefresh // Media server library scanned after a successful download, nil = none (see NewPlexRefresh)
+
+	Auth StreamAuth // Credentials sent with the stream requests, zero = none
+
+	AllowedContentTypes []string // Accepted response content types, "video/*" style, empty = any
+
+	ComputeHash bool // Compute the SHA-256 of the file while it is streamed, see DownloadResult.SHA256
+}
+
+// OptionsFromConfig returns the options to download line with the downloads
+// configuration of cfg. The caller sets BaseDestPath and, as needed, PartURLs,
+// LockHeld and OnProgress.
+func OptionsFromConfig(cfg *config.Config, line *models.ProcessedLine) DownloadOptions {
+	opts := DownloadOptions{
+		TempDir:             cfg.Downloads.TempDir,
+		ProcessedLineID:     line.ID,
+		PreflightHead:       cfg.Downloads.PreflightHead,
+		WriteNFO:            cfg.Downloads.WriteNFO,
+		LinkMode:            LinkMode(cfg.Downloads.LinkMode),
+		ExistingFilePolicy:  ExistingFilePolicy(cfg.Downloads.ExistingFilePolicy),
+		PostCommand:         cfg.Downloads.PostCommand,
+		PostCommandTimeout:  time.Duration(cfg.Downloads.PostCommandTimeout) * time.Second,
+		LibraryRefresh:      NewPlexRefresh(cfg, line.ContentType),
+		Auth:                NewStreamAuth(cfg.Downloads),
+		AllowedContentTypes: cfg.Downloads.AllowedContentTypes,
+		ComputeHash:         cfg.Downloads.ComputeHash,
+	}
+	if line.LineURL != nil {
+		opts.URL = *line.LineURL
+	}
+	return opts
 }
 
 // DownloadResult contains information about a completed download
@@ -38,6 +86,9 @@ type DownloadResult struct {
 	Duration     time.Duration
 	BytesRead    int64
 	MoveDuration time.Duration
+	ContentType  string // Media type reported by the server, e.g. "video/mp4"
+	SHA256       string // Hex SHA-256 of the file when DownloadOptions.ComputeHash is set
+	KeptExisting bool   // The destination already existed and was kept, the download was discarded
 }
 
 // Downloader handles media file downloads
@@ -46,14 +97,25 @@ type Downloader struct {
 	retryConfig   retry.Config
 	stateManager  *StateManager
 	resumeSupport *ResumeSupport
+	readTimeout   time.Duration  // Stalled transfers are cancelled after this long without data, 0 = never
+	refreshes     sync.WaitGroup // Library refreshes still running, see WaitRefreshes
 }
 
-// New creates a new Downloader instance
+// New creates a new Downloader instance whose requests, body transfer included,
+// are abandoned after timeout, along with the other DefaultTimeouts
 func New(timeout time.Duration, retryAttempts int) *Downloader {
 	if timeout == 0 {
 		timeout = 600 * time.Second // 10 minutes default
 	}
 
+	timeouts := DefaultTimeouts()
+	timeouts.Total = timeout
+	return NewWithTimeouts(timeouts, retryAttempts)
+}
+
+// NewWithTimeouts creates a new Downloader instance bounding each phase of a
+// download by timeouts, see NewTimeouts
+func NewWithTimeouts(timeouts Timeouts, retryAttempts int) *Downloader {
 	if retryAttempts == 0 {
 		retryAttempts = 3
 	}
@@ -62,9 +124,7 @@ func New(timeout time.Duration, retryAttempts int) *Downloader {
 	resumeSupport := NewResumeSupport(stateManager)
 
 	return &Downloader{
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
+		httpClient: newDownloadClient(timeouts),
 		retryConfig: retry.Config{
 			MaxAttempts:       retryAttempts,
 			InitialBackoff:    2 * time.Second,
@@ -74,6 +134,7 @@ func New(timeout time.Duration, retryAttempts int) *Downloader {
 		},
 		stateManager:  stateManager,
 		resumeSupport: resumeSupport,
+		readTimeout:   timeouts.Read,
 	}
 }
 
@@ -94,6 +155,10 @@ func (d *Downloader) Download(ctx context.Context, opts DownloadOptions) (*Downl
 	if opts.BaseDestPath == "" {
 		return nil, apperrors.ValidationError("base destination path cannot be empty")
 	}
+	// Linked files stay in the temp dir, which must outlive the OS temp dir cleanups
+	if opts.LinkMode.linksFile() && opts.TempDir == "" {
+		return nil, apperrors.ConfigError("downloads.temp_dir is required", ErrKeepDirRequired)
+	}
 
 	// Create or get DownloadInfo record and acquire lock
 	var downloadInfoID uint
@@ -106,12 +171,14 @@ func (d *Downloader) Download(ctx context.Context, opts DownloadOptions) (*Downl
 		downloadInfoID = dlInfo.ID
 
 		// Acquire lock to prevent concurrent downloads
-		if err := d.stateManager.AcquireLock(ctx, downloadInfoID); err != nil {
-			log.WithFields(map[string]interface{}{
-				"download_id": downloadInfoID,
-				"error":       err,
-			}).Warn("failed to acquire download lock, skipping")
-			return nil, apperrors.ValidationError("download is locked by another process")
+		if !opts.LockHeld {
+			if err := d.stateManager.AcquireLock(ctx, downloadInfoID); err != nil {
+				log.WithFields(map[string]interface{}{
+					"download_id": downloadInfoID,
+					"error":       err,
+				}).Warn("failed to acquire download lock, skipping")
+				return nil, apperrors.ValidationError("download is locked by another process")
+			}
 		}
 		defer func() {
 			// Always release lock on exit (success or failure)
@@ -150,9 +217,24 @@ func (d *Downloader) Download(ctx context.Context, opts DownloadOptions) (*Downl
 	// Create temporary file
 	tempPath := filepath.Join(tempDownloadDir, "download.tmp")
 
+	// Some servers only report the size on HEAD. A known size is checked against the
+	// free disk space before downloading and used as the progress total.
+	var expectedSize int64
+	var preflightContentType string
+	if opts.PreflightHead {
+		expectedSize, preflightContentType = d.preflightHead(ctx, opts.URL, opts.Auth)
+		if expectedSize > 0 {
+			if err := checkFreeSpace(expectedSize, tempDownloadDir, filepath.Dir(opts.BaseDestPath)); err != nil {
+				d.markFailed(ctx, downloadInfoID, opts.ProcessedLineID, err)
+				return nil, err
+			}
+		}
+	}
+
 	// Perform download with retry
 	var result *DownloadResult
 	var contentType string
+	streamURL := opts.URL
 	var lastPersistedBytes int64
 	var lastPersistTime time.Time = time.Now()
 
@@ -170,7 +252,15 @@ func (d *Downloader) Download(ctx context.Context, opts DownloadOptions) (*Downl
 	}
 
 	err := retry.Do(ctx, retryConfig, func() error {
-		res, ct, err := d.downloadFile(ctx, opts.URL, tempPath, func(downloaded, total int64) {
+		// Each attempt downloads the whole file again, so it starts a new hash
+		var hasher hash.Hash
+		var tee io.Writer
+		if opts.ComputeHash {
+			hasher = sha256.New()
+			tee = hasher
+		}
+
+		res, ct, sourceURL, err := d.downloadStream(ctx, opts.URL, opts.Auth, opts.AllowedContentTypes, tempPath, expectedSize, tee, func(downloaded, total int64) {
 			// Call user's progress callback
 			if opts.OnProgress != nil {
 				opts.OnProgress(downloaded, total)
@@ -196,44 +286,61 @@ func (d *Downloader) Download(ctx context.Context, opts DownloadOptions) (*Downl
 		if err != nil {
 			return err
 		}
+
+		// Append the following parts of a multi-part stream
+		for i, partURL := range opts.PartURLs {
+			partPath := filepath.Join(tempDownloadDir, fmt.Sprintf("part%d.tmp", i+2))
+			partRes, _, _, err := d.downloadStream(ctx, partURL, opts.Auth, opts.AllowedContentTypes, partPath, 0, tee, opts.OnProgress)
+			if err != nil {
+				return fmt.Errorf("part %d: %w", i+2, err)
+			}
+			if err := appendFile(tempPath, partPath); err != nil {
+				return apperrors.Wrap(err, apperrors.CodeInternal, "failed to join download parts")
+			}
+			res.FileSize += partRes.FileSize
+			res.BytesRead += partRes.BytesRead
+		}
+
+		if hasher != nil {
+			res.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+		}
 		result = res
 		contentType = ct
+		streamURL = sourceURL
 		return nil
 	}, apperrors.IsRetryable)
 
 	if err != nil {
-		// Update download info on failure
-		if downloadInfoID > 0 {
-			errMsg := err.Error()
-			if updateErr := d.stateManager.UpdateState(ctx, downloadInfoID, models.DownloadStatusFailed, &errMsg); updateErr != nil {
-				log.WithFields(map[string]interface{}{
-					"error": updateErr,
-				}).Error("failed to update download state to failed", updateErr)
-			}
-
-			// Update ProcessedLine state for backward compatibility
-			if updateErr := d.updateProcessedLineState(opts.ProcessedLineID, models.StateFailed); updateErr != nil {
-				log.WithFields(map[string]interface{}{
-					"error": updateErr,
-				}).Warn("failed to update processed line state to failed")
-			}
-		}
+		d.markFailed(ctx, downloadInfoID, opts.ProcessedLineID, err)
 		return nil, apperrors.ExternalServiceError("download", "failed to download file", err)
 	}
 
-	// Detect file extension
-	ext := detectFileExtension(opts.URL, contentType)
-	result.Extension = ext
+	// Fall back to the content type reported by the preflight request
+	if contentType == "" {
+		contentType = preflightContentType
+	}
 
-	// Construct final destination path with extension
-	finalDestPath := opts.BaseDestPath + ext
+	// Detect file extension, from the variant followed for an HLS master playlist
+	ext := detectFileExtension(streamURL, contentType)
+	result.Extension = ext
+	if idx := strings.Index(contentType, ";"); idx != -1 {
+		contentType = contentType[:idx]
+	}
+	result.ContentType = strings.TrimSpace(contentType)
 
 	// Create destination directory
-	destDir := filepath.Dir(finalDestPath)
+	destDir := filepath.Dir(opts.BaseDestPath)
 	if err := os.MkdirAll(destDir, 0755); err != nil {
 		return nil, apperrors.Wrap(err, apperrors.CodeInternal, "failed to create destination directory")
 	}
 
+	// Construct final destination path with extension, according to the existing file policy
+	finalDestPath, keepExisting, err := resolveDestination(opts.BaseDestPath, ext, result.FileSize, opts.ExistingFilePolicy)
+	if err != nil {
+		d.markFailed(ctx, downloadInfoID, opts.ProcessedLineID, err)
+		return nil, apperrors.Wrap(err, apperrors.CodeInternal, "failed to resolve destination")
+	}
+
 	// Update state to organizing
 	if downloadInfoID > 0 {
 		if err := d.stateManager.UpdateState(ctx, downloadInfoID, models.DownloadStatusDownloading, nil); err != nil {
@@ -250,30 +357,30 @@ func (d *Downloader) Download(ctx context.Context, opts DownloadOptions) (*Downl
 		}
 	}
 
-	// Move file to final destination
+	// Move file to final destination, unless the existing file is kept
 	moveStart := time.Now()
-	if err := moveFile(tempPath, finalDestPath); err != nil {
-		if downloadInfoID > 0 {
-			errMsg := err.Error()
-			if updateErr := d.stateManager.UpdateState(ctx, downloadInfoID, models.DownloadStatusFailed, &errMsg); updateErr != nil {
-				log.WithFields(map[string]interface{}{
-					"error": updateErr,
-				}).Error("failed to update download state to failed", updateErr)
-			}
-
-			// Update ProcessedLine state for backward compatibility
-			if updateErr := d.updateProcessedLineState(opts.ProcessedLineID, models.StateFailed); updateErr != nil {
-				log.WithFields(map[string]interface{}{
-					"error": updateErr,
-				}).Warn("failed to update processed line state to failed")
-			}
+	if keepExisting {
+		log.WithFields(map[string]interface{}{
+			"path":          finalDestPath,
+			"download_size": result.FileSize,
+			"policy":        opts.ExistingFilePolicy,
+		}).Info("destination already exists, keeping it and discarding the download")
+
+		if info, err := os.Stat(finalDestPath); err == nil {
+			result.FileSize = info.Size()
+		}
+		result.SHA256 = "" // The hash is the one of the discarded download
+		result.KeptExisting = true
+	} else {
+		if err := placeFile(tempPath, finalDestPath, tempDir, opts.LinkMode); err != nil {
+			d.markFailed(ctx, downloadInfoID, opts.ProcessedLineID, err)
+			return nil, apperrors.Wrap(err, apperrors.CodeInternal, "failed to move file to destination")
 		}
-		return nil, apperrors.Wrap(err, apperrors.CodeInternal, "failed to move file to destination")
-	}
 
-	// Set proper file permissions
-	if err := os.Chmod(finalDestPath, 0644); err != nil {
-		return nil, apperrors.Wrap(err, apperrors.CodeInternal, "failed to set file permissions")
+		// Set proper file permissions
+		if err := os.Chmod(finalDestPath, 0644); err != nil {
+			return nil, apperrors.Wrap(err, apperrors.CodeInternal, "failed to set file permissions")
+		}
 	}
 
 	result.FilePath = finalDestPath
@@ -284,7 +391,7 @@ func (d *Downloader) Download(ctx context.Context, opts DownloadOptions) (*Downl
 	// Update state to completed
 	if downloadInfoID > 0 {
 		// Update download info with final details
-		if err := d.updateDownloadInfoCompleted(ctx, downloadInfoID, finalDestPath, result.FileSize); err != nil {
+		if err := d.updateDownloadInfoCompleted(ctx, downloadInfoID, finalDestPath, result.FileSize, result.ContentType, result.SHA256); err != nil {
 			log.WithFields(map[string]interface{}{
 				"error": err,
 			}).Error("failed to update download info to completed", err)
@@ -296,21 +403,163 @@ func (d *Downloader) Download(ctx context.Context, opts DownloadOptions) (*Downl
 				"error": err,
 			}).Warn("failed to update processed line state to downloaded")
 		}
+
+		if opts.WriteNFO {
+			d.writeLineNFO(opts.ProcessedLineID, opts.BaseDestPath)
+		}
+	}
+
+	// A kept file is unchanged, there is nothing new to process or scan
+	if result.KeptExisting {
+		return result, nil
+	}
+
+	if opts.PostCommand != "" {
+		d.runPostCommand(ctx, opts, finalDestPath)
+	}
+	if opts.LibraryRefresh != nil {
+		d.refreshLibrary(ctx, opts.LibraryRefresh, finalDestPath)
 	}
 
 	return result, nil
 }
 
-// downloadFile performs the actual HTTP download
-func (d *Downloader) downloadFile(ctx context.Context, url, destPath string, onProgress func(int64, int64)) (*DownloadResult, string, error) {
-	return d.downloadFileWithResume(ctx, url, destPath, 0, onProgress)
+// writeLineNFO writes the .nfo file of a downloaded line. A failure is logged and
+// does not fail the download.
+func (d *Downloader) writeLineNFO(processedLineID uint, basePath string) {
+	log := logger.AppLogger()
+
+	db := database.Get()
+	if db == nil {
+		return
+	}
+
+	var line models.ProcessedLine
+	if err := db.Preload("Movie").Preload("TVShow").First(&line, processedLineID).Error; err != nil {
+		log.WithFields(map[string]interface{}{
+			"processed_line_id": processedLineID,
+			"error":             err,
+		}).Warn("failed to load processed line for nfo")
+		return
+	}
+
+	if _, err := WriteNFO(&line, basePath); err != nil {
+		log.WithFields(map[string]interface{}{
+			"processed_line_id": processedLineID,
+			"error":             err,
+		}).Warn("failed to write nfo file")
+	}
 }
 
-// downloadFileWithResume performs HTTP download with optional resume support
-func (d *Downloader) downloadFileWithResume(ctx context.Context, url, destPath string, startByte int64, onProgress func(int64, int64)) (*DownloadResult, string, error) {
+// PrepareDownload creates or fetches the DownloadInfo record for a ProcessedLine and
+// acquires its lock, so the caller can report the record before running the download
+// in the background. Pass LockHeld in DownloadOptions so Download reuses the lock.
+func (d *Downloader) PrepareDownload(ctx context.Context, processedLineID uint, url string) (*models.DownloadInfo, error) {
+	dlInfo, err := d.getOrCreateDownloadInfo(ctx, processedLineID, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.stateManager.AcquireLock(ctx, dlInfo.ID); err != nil {
+		return nil, err
+	}
+
+	return dlInfo, nil
+}
+
+// markFailed records a failed download on its DownloadInfo record and ProcessedLine.
+// It does nothing for untracked downloads.
+func (d *Downloader) markFailed(ctx context.Context, downloadInfoID, processedLineID uint, err error) {
+	if downloadInfoID == 0 {
+		return
+	}
+	log := logger.AppLogger()
+
+	errMsg := err.Error()
+	if updateErr := d.stateManager.UpdateState(ctx, downloadInfoID, models.DownloadStatusFailed, &errMsg); updateErr != nil {
+		log.WithFields(map[string]interface{}{
+			"error": updateErr,
+		}).Error("failed to update download state to failed", updateErr)
+	}
+
+	// Update ProcessedLine state for backward compatibility
+	if updateErr := d.updateProcessedLineState(processedLineID, models.StateFailed); updateErr != nil {
+		log.WithFields(map[string]interface{}{
+			"error": updateErr,
+		}).Warn("failed to update processed line state to failed")
+	}
+}
+
+// preflightHead issues a HEAD request for url and returns the size and content type
+// reported by the server. Servers rejecting HEAD (e.g. 405 or 501) or omitting the
+// headers yield a zero size and an empty type, the download then proceeds without them.
+func (d *Downloader) preflightHead(ctx context.Context, url string, auth StreamAuth) (int64, string) {
+	log := logger.AppLogger()
+	auth = auth.scopedTo(url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return 0, ""
+	}
+	auth.apply(req)
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		log.WithFields(map[string]interface{}{
+			"url":   url,
+			"error": err,
+		}).Debug("preflight HEAD request failed, continuing without it")
+		return 0, ""
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		log.WithFields(map[string]interface{}{
+			"url":         url,
+			"status_code": resp.StatusCode,
+		}).Debug("preflight HEAD request not supported, continuing without it")
+		return 0, ""
+	}
+
+	size := resp.ContentLength
+	if size < 0 {
+		size = 0
+	}
+	return size, resp.Header.Get("Content-Type")
+}
+
+// checkFreeSpace fails when any of paths has less than size bytes available
+func checkFreeSpace(size int64, paths ...string) error {
+	for _, path := range paths {
+		ok, space, err := HasEnoughSpace(path, uint64(size))
+		if err != nil {
+			// Unknown free space must not block the download
+			continue
+		}
+		if !ok {
+			return apperrors.New(apperrors.CodeInternal, fmt.Sprintf("insufficient disk space in %s: %s required, %s available",
+				path, FormatBytes(uint64(size)), FormatBytes(space.Available)))
+		}
+	}
+	return nil
+}
+
+// downloadFile performs the actual HTTP download. expectedSize, when known from a
+// preflight request, is the progress total for responses without a Content-Length.
+// Responses whose content type is not in allowedTypes are rejected before writing.
+func (d *Downloader) downloadFile(ctx context.Context, url string, auth StreamAuth, allowedTypes []string, destPath string, expectedSize int64, tee io.Writer, onProgress func(int64, int64)) (*DownloadResult, string, error) {
+	return d.downloadFileWithResume(ctx, url, auth, allowedTypes, destPath, 0, expectedSize, tee, onProgress)
+}
+
+// downloadFileWithResume performs HTTP download with optional resume support. The
+// bytes written to destPath are also written to tee when it is not nil.
+func (d *Downloader) downloadFileWithResume(ctx context.Context, url string, auth StreamAuth, allowedTypes []string, destPath string, startByte, expectedSize int64, tee io.Writer, onProgress func(int64, int64)) (*DownloadResult, string, error) {
 	var req *http.Request
 	var err error
 
+	// Cancelled when the transfer stalls, see stallReader
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Create request with optional Range header
 	if startByte > 0 {
 		req, err = d.resumeSupport.BuildResumeRequest(ctx, url, startByte)
@@ -327,6 +576,7 @@ func (d *Downloader) downloadFileWithResume(ctx context.Context, url, destPath s
 			return nil, "", fmt.Errorf("failed to create request: %w", err)
 		}
 	}
+	auth.apply(req)
 
 	resp, err := d.httpClient.Do(req)
 	if err != nil {
@@ -336,11 +586,25 @@ func (d *Downloader) downloadFileWithResume(ctx context.Context, url, destPath s
 
 	// Handle resume response if we requested a range
 	if startByte > 0 {
-		if err := d.resumeSupport.HandleResumeResponse(resp, startByte); err != nil {
+		if err := d.resumeSupport.HandleResumeResponse(resp, startByte, destPath); err != nil {
+			// The file on disk already holds the whole stream, nothing left to fetch
+			if errors.Is(err, ErrAlreadyComplete) {
+				info, statErr := os.Stat(destPath)
+				if statErr != nil {
+					return nil, "", fmt.Errorf("failed to stat complete file: %w", statErr)
+				}
+				if err := copyExisting(tee, destPath, info.Size()); err != nil {
+					return nil, "", err
+				}
+				return &DownloadResult{
+					FileSize:  info.Size(),
+					BytesRead: info.Size(),
+				}, "", nil
+			}
 			// If resume not supported, we'll restart from beginning
 			if apperrors.IsValidationError(err) {
 				logger.AppLogger().Warn("resume not supported, restarting download from beginning")
-				return d.downloadFileWithResume(ctx, url, destPath, 0, onProgress)
+				return d.downloadFileWithResume(ctx, url, auth, allowedTypes, destPath, 0, expectedSize, tee, onProgress)
 			}
 			return nil, "", err
 		}
@@ -351,12 +615,35 @@ func (d *Downloader) downloadFileWithResume(ctx context.Context, url, destPath s
 		}
 	}
 
+	var stall *stallReader
+	var raw io.Reader = resp.Body
+	if d.readTimeout > 0 {
+		stall = newStallReader(resp.Body, d.readTimeout, cancel)
+		defer stall.stop()
+		raw = stall
+	}
+
+	// A playlist is never saved as the media file, see downloadStream
+	body := bufio.NewReader(raw)
+	if startByte == 0 && isHLSPlaylist(body) {
+		return nil, "", hlsPlaylistError(body, url)
+	}
+
 	// Get content type for extension detection
 	contentType := resp.Header.Get("Content-Type")
 
+	// Dead URLs may answer 200 with an HTML error page, reject it before writing it
+	if !contentTypeAllowed(contentType, allowedTypes) {
+		return nil, "", fmt.Errorf("rejected response with content type %q, not in the allowed content types", contentType)
+	}
+
 	// Open file for writing (append mode if resuming)
 	var out *os.File
 	if startByte > 0 {
+		// tee sees the whole file, not only the resumed tail
+		if err := copyExisting(tee, destPath, startByte); err != nil {
+			return nil, "", err
+		}
 		out, err = os.OpenFile(destPath, os.O_WRONLY|os.O_APPEND, 0644)
 	} else {
 		out, err = os.Create(destPath)
@@ -366,10 +653,17 @@ func (d *Downloader) downloadFileWithResume(ctx context.Context, url, destPath s
 	}
 	defer out.Close()
 
+	var dest io.Writer = out
+	if tee != nil {
+		dest = io.MultiWriter(out, tee)
+	}
+
 	// Download with progress tracking
 	var bytesRead int64
 	contentLength := resp.ContentLength
-	if startByte > 0 {
+	if contentLength <= 0 && expectedSize > 0 {
+		contentLength = expectedSize
+	} else if startByte > 0 {
 		// For resumed downloads, ContentLength is remaining bytes
 		contentLength += startByte
 	}
@@ -377,16 +671,19 @@ func (d *Downloader) downloadFileWithResume(ctx context.Context, url, destPath s
 	if onProgress != nil && contentLength > 0 {
 		// Use TeeReader to track progress
 		reader := &progressReader{
-			reader:     resp.Body,
+			reader:     body,
 			total:      contentLength,
 			downloaded: startByte, // Start from existing progress
 			onProgress: onProgress,
 		}
-		bytesRead, err = io.Copy(out, reader)
+		bytesRead, err = io.Copy(dest, reader)
 	} else {
-		bytesRead, err = io.Copy(out, resp.Body)
+		bytesRead, err = io.Copy(dest, body)
 	}
 
+	if stall != nil && stall.stalled.Load() {
+		return nil, "", fmt.Errorf("%w: no data received for %s after %d bytes", ErrTransferStalled, d.readTimeout, startByte+bytesRead)
+	}
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to write file: %w", err)
 	}
@@ -399,6 +696,23 @@ func (d *Downloader) downloadFileWithResume(ctx context.Context, url, destPath s
 	}, contentType, nil
 }
 
+// copyExisting writes the first n bytes of the file at path to tee, so that a resumed
+// transfer is hashed from the start of the file. It does nothing when tee is nil.
+func copyExisting(tee io.Writer, path string, n int64) error {
+	if tee == nil {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read partial file: %w", err)
+	}
+	defer f.Close()
+	if _, err := io.CopyN(tee, f, n); err != nil {
+		return fmt.Errorf("failed to read partial file: %w", err)
+	}
+	return nil
+}
+
 // getOrCreateDownloadInfo gets or creates a DownloadInfo record for a ProcessedLine
 func (d *Downloader) getOrCreateDownloadInfo(ctx context.Context, processedLineID uint, url string) (*models.DownloadInfo, error) {
 	db := database.Get()
@@ -412,21 +726,25 @@ func (d *Downloader) getOrCreateDownloadInfo(ctx context.Context, processedLineI
 		return nil, apperrors.DatabaseError("failed to fetch processed line", err)
 	}
 
-	// Check if DownloadInfo already exists
+	// Reuse the current DownloadInfo, unless it completed: downloading the line again
+	// starts a new record so that the previous one stays in the line's history
 	if processedLine.DownloadInfoID != nil {
 		var downloadInfo models.DownloadInfo
 		if err := db.First(&downloadInfo, *processedLine.DownloadInfoID).Error; err != nil {
 			return nil, apperrors.DatabaseError("failed to fetch download info", err)
 		}
-		return &downloadInfo, nil
+		if downloadInfo.Status != string(models.DownloadStatusCompleted) {
+			return &downloadInfo, nil
+		}
 	}
 
 	// Create new DownloadInfo
 	downloadInfo := &models.DownloadInfo{
-		URL:       url,
-		Status:    string(models.DownloadStatusPending),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		URL:             url,
+		Status:          string(models.DownloadStatusPending),
+		ProcessedLineID: &processedLineID,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
 	if err := db.Create(downloadInfo).Error; err != nil {
@@ -445,7 +763,7 @@ func (d *Downloader) getOrCreateDownloadInfo(ctx context.Context, processedLineI
 }
 
 // updateDownloadInfoCompleted updates DownloadInfo to completed status with final details
-func (d *Downloader) updateDownloadInfoCompleted(ctx context.Context, downloadInfoID uint, filePath string, fileSize int64) error {
+func (d *Downloader) updateDownloadInfoCompleted(ctx context.Context, downloadInfoID uint, filePath string, fileSize int64, contentType, contentHash string) error {
 	db := database.Get()
 	if db == nil {
 		return apperrors.New(apperrors.CodeInternal, "database not initialized")
@@ -461,6 +779,12 @@ func (d *Downloader) updateDownloadInfoCompleted(ctx context.Context, downloadIn
 		"locked_at":     nil, // Release lock
 		"locked_by":     nil,
 	}
+	if contentType != "" {
+		updates["detected_content_type"] = contentType
+	}
+	if contentHash != "" {
+		updates["content_hash"] = contentHash
+	}
 
 	// Update DownloadInfo with all completion details
 	if err := db.Model(&models.DownloadInfo{}).
@@ -637,6 +961,27 @@ func moveFile(src, dst string) error {
 	return os.Remove(src)
 }
 
+// appendFile appends the content of src to dst and removes src
+func appendFile(dst, src string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open part: %w", err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open destination: %w", err)
+	}
+	defer dstFile.Close()
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		return fmt.Errorf("failed to append part: %w", err)
+	}
+
+	return os.Remove(src)
+}
+
 // copyFile copies a file from src to dst
 func copyFile(src, dst string) error {
 	srcFile, err := os.Open(src)
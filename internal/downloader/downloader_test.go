@@ -2,6 +2,8 @@ package downloader
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/glefebvre/stalkeer/internal/config"
 	"github.com/glefebvre/stalkeer/internal/database"
 	"github.com/glefebvre/stalkeer/internal/models"
 	"github.com/stretchr/testify/assert"
@@ -20,8 +23,24 @@ import (
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
+	db := openTestDB(t)
+
+	// Set global database instance
+	database.Initialize()
+	return db
+}
+
+// openTestDB opens an in-memory database with the download models migrated, without
+// touching the global database instance. It keeps a single connection, as each
+// connection to ":memory:" opens a new empty database.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
 
 	err = db.AutoMigrate(
 		&models.ProcessedLine{},
@@ -30,9 +49,6 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		&models.DownloadInfo{},
 	)
 	require.NoError(t, err)
-
-	// Set global database instance
-	database.Initialize()
 	return db
 }
 
@@ -123,6 +139,102 @@ func TestDownload_Success(t *testing.T) {
 	assert.True(t, os.IsNotExist(err))
 }
 
+func TestDownload_ReportsContentType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4; charset=binary")
+		w.Write([]byte("fake video content"))
+	}))
+	defer server.Close()
+
+	d := New(5*time.Second, 1)
+	result, err := d.Download(context.Background(), DownloadOptions{
+		URL:          server.URL + "/stream",
+		BaseDestPath: filepath.Join(t.TempDir(), "movie"),
+		TempDir:      t.TempDir(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "video/mp4", result.ContentType)
+	assert.Equal(t, ".mp4", result.Extension)
+}
+
+func TestDownload_PreflightHeadReportsSize(t *testing.T) {
+	content := []byte("fake video content only sized on HEAD")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+			return
+		}
+		// Stream the body chunked, without Content-Length nor Content-Type
+		w.Header()["Content-Type"] = nil
+		w.(http.Flusher).Flush()
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	var lastTotal int64
+	d := New(5*time.Second, 1)
+	result, err := d.Download(context.Background(), DownloadOptions{
+		URL:           server.URL + "/stream",
+		BaseDestPath:  filepath.Join(t.TempDir(), "movie"),
+		TempDir:       t.TempDir(),
+		PreflightHead: true,
+		OnProgress: func(downloaded, total int64) {
+			lastTotal = total
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), lastTotal, "the HEAD size should be the progress total")
+	assert.Equal(t, int64(len(content)), result.FileSize)
+	assert.Equal(t, ".mp4", result.Extension, "the HEAD content type should be used for the extension")
+}
+
+func TestDownload_PreflightHeadUnsupported(t *testing.T) {
+	content := []byte("fake video content")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	d := New(5*time.Second, 1)
+	result, err := d.Download(context.Background(), DownloadOptions{
+		URL:           server.URL + "/movie.mkv",
+		BaseDestPath:  filepath.Join(t.TempDir(), "movie"),
+		TempDir:       t.TempDir(),
+		PreflightHead: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), result.FileSize)
+}
+
+func TestDownload_PreflightHeadInsufficientSpace(t *testing.T) {
+	var gets int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", fmt.Sprint(int64(1)<<60))
+			return
+		}
+		gets++
+		w.Write([]byte("never downloaded"))
+	}))
+	defer server.Close()
+
+	d := New(5*time.Second, 1)
+	_, err := d.Download(context.Background(), DownloadOptions{
+		URL:           server.URL + "/movie.mkv",
+		BaseDestPath:  filepath.Join(t.TempDir(), "movie"),
+		TempDir:       t.TempDir(),
+		PreflightHead: true,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "insufficient disk space")
+	assert.Zero(t, gets, "the download should not start")
+}
+
 func TestDownload_WithDatabaseTracking(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -564,3 +676,170 @@ func TestDownload_RetryCountIncrements(t *testing.T) {
 
 	t.Cleanup(func() { gdb.Delete(&dlInfo) })
 }
+
+func TestDownload_InsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write([]byte("self-signed content"))
+	}))
+	defer server.Close()
+
+	t.Setenv("STALKEER_DATABASE_USER", "test")
+	t.Setenv("STALKEER_DATABASE_DBNAME", "test")
+
+	download := func(t *testing.T, skipVerify string) error {
+		t.Setenv("STALKEER_DOWNLOADS_INSECURE_SKIP_VERIFY", skipVerify)
+		require.NoError(t, config.Load())
+
+		d := New(5*time.Second, 1)
+		_, err := d.Download(context.Background(), DownloadOptions{
+			URL:          server.URL + "/movie.mp4",
+			BaseDestPath: filepath.Join(t.TempDir(), "movie"),
+			TempDir:      t.TempDir(),
+		})
+		return err
+	}
+
+	t.Run("rejected by default", func(t *testing.T) {
+		assert.Error(t, download(t, "false"))
+	})
+
+	t.Run("accepted when skip-verify is enabled", func(t *testing.T) {
+		assert.NoError(t, download(t, "true"))
+	})
+
+	// Restore a secure configuration for the remaining tests
+	require.NoError(t, config.Load())
+}
+
+func TestDownload_UserAgentAndHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.UserAgent() != "VLC/3.0.20" || r.Header.Get("X-Provider-Token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write([]byte("provider content"))
+	}))
+	defer server.Close()
+
+	t.Setenv("STALKEER_DATABASE_USER", "test")
+	t.Setenv("STALKEER_DATABASE_DBNAME", "test")
+	require.NoError(t, config.Load())
+	// Restore the default configuration for the remaining tests
+	t.Cleanup(func() { config.Load() })
+
+	download := func(t *testing.T) error {
+		d := New(5*time.Second, 1)
+		_, err := d.Download(context.Background(), DownloadOptions{
+			URL:          server.URL + "/movie.mp4",
+			BaseDestPath: filepath.Join(t.TempDir(), "movie"),
+			TempDir:      t.TempDir(),
+		})
+		return err
+	}
+
+	t.Run("rejected with the default user agent", func(t *testing.T) {
+		assert.Error(t, download(t))
+	})
+
+	t.Run("accepted with the configured user agent and headers", func(t *testing.T) {
+		config.Get().Downloads.UserAgent = "VLC/3.0.20"
+		config.Get().Downloads.Headers = map[string]string{"x-provider-token": "secret"}
+		assert.NoError(t, download(t))
+	})
+}
+
+func TestDownload_ComputeHash(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "hash.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.ProcessedLine{}, &models.DownloadInfo{}))
+	database.SetDB(db)
+	t.Cleanup(func() { database.SetDB(nil) })
+
+	parts := map[string]string{"/part1.mkv": "first part ", "/part2.mkv": "second part"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/x-matroska")
+		io.WriteString(w, parts[r.URL.Path])
+	}))
+	defer server.Close()
+
+	download := func(t *testing.T, hash string, computeHash bool) *models.DownloadInfo {
+		lineURL := server.URL + "/part1.mkv"
+		line := models.ProcessedLine{
+			LineURL:     &lineURL,
+			LineContent: "#EXTINF:-1,Hash Test " + hash,
+			LineHash:    "hash-test-" + hash,
+			TvgName:     "Hash Test " + hash,
+			GroupTitle:  "Movies",
+			ContentType: models.ContentTypeMovies,
+			State:       models.StateProcessed,
+		}
+		require.NoError(t, db.Create(&line).Error)
+
+		d := New(10*time.Second, 1)
+		result, err := d.Download(context.Background(), DownloadOptions{
+			URL:             lineURL,
+			PartURLs:        []string{server.URL + "/part2.mkv"},
+			BaseDestPath:    filepath.Join(t.TempDir(), "movie"),
+			ProcessedLineID: line.ID,
+			ComputeHash:     computeHash,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, hash, result.SHA256)
+
+		require.NoError(t, db.First(&line, line.ID).Error)
+		require.NotNil(t, line.DownloadInfoID)
+		var info models.DownloadInfo
+		require.NoError(t, db.First(&info, *line.DownloadInfoID).Error)
+		return &info
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		// SHA-256 of the joined parts
+		sum := sha256.Sum256([]byte("first part second part"))
+		want := hex.EncodeToString(sum[:])
+
+		info := download(t, want, true)
+		require.NotNil(t, info.ContentHash)
+		assert.Equal(t, want, *info.ContentHash)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		info := download(t, "", false)
+		assert.Nil(t, info.ContentHash)
+	})
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Downloads.TempDir = "/var/tmp/stalkeer"
+	cfg.Downloads.PreflightHead = true
+	cfg.Downloads.LinkMode = string(LinkModeHardlink)
+	cfg.Downloads.ExistingFilePolicy = string(ExistingFileSkip)
+	cfg.Downloads.PostCommandTimeout = 30
+	cfg.Downloads.PlexRefresh = true
+	cfg.Downloads.AllowedContentTypes = []string{"video/*"}
+	cfg.Plex.URL = "http://plex.local:32400"
+	cfg.Plex.Token = "plex-token"
+	cfg.Plex.MoviesSection = 1
+
+	url := "http://example.com/movie.mkv"
+	line := &models.ProcessedLine{ID: 42, LineURL: &url, ContentType: models.ContentTypeMovies}
+
+	opts := OptionsFromConfig(cfg, line)
+	assert.Equal(t, url, opts.URL)
+	assert.Equal(t, uint(42), opts.ProcessedLineID)
+	assert.Equal(t, "/var/tmp/stalkeer", opts.TempDir)
+	assert.True(t, opts.PreflightHead)
+	assert.Equal(t, LinkModeHardlink, opts.LinkMode)
+	assert.Equal(t, ExistingFileSkip, opts.ExistingFilePolicy)
+	assert.Equal(t, 30*time.Second, opts.PostCommandTimeout)
+	assert.Equal(t, []string{"video/*"}, opts.AllowedContentTypes)
+	require.NotNil(t, opts.LibraryRefresh)
+	assert.Equal(t, 1, opts.LibraryRefresh.SectionID)
+	assert.Empty(t, opts.BaseDestPath, "the destination is left to the caller")
+
+	line.ContentType = models.ContentTypeTVShows
+	assert.Nil(t, OptionsFromConfig(cfg, line).LibraryRefresh, "no Plex section for TV shows")
+}
@@ -0,0 +1,64 @@
+package downloader
+
+import (
+	"fmt"
+	"os"
+)
+
+// ExistingFilePolicy selects what happens when the destination of a download already exists
+type ExistingFilePolicy string
+
+const (
+	// ExistingFileOverwrite replaces the existing file (default)
+	ExistingFileOverwrite ExistingFilePolicy = "overwrite"
+	// ExistingFileSkip keeps the existing file and discards the download
+	ExistingFileSkip ExistingFilePolicy = "skip"
+	// ExistingFileKeepLarger keeps whichever of the existing file and the download is larger
+	ExistingFileKeepLarger ExistingFilePolicy = "keep-larger"
+	// ExistingFileRename places the download next to the existing file, with a numeric suffix
+	ExistingFileRename ExistingFilePolicy = "rename"
+)
+
+// maxRenameSuffix bounds the numeric suffixes tried by ExistingFileRename
+const maxRenameSuffix = 1000
+
+// resolveDestination applies policy to the destination basePath+ext of a downloaded
+// file of size bytes. It returns the path the download is placed at, or keep = true
+// when the existing file is kept and the download must be discarded.
+func resolveDestination(basePath, ext string, size int64, policy ExistingFilePolicy) (path string, keep bool, err error) {
+	dst := basePath + ext
+	info, err := os.Stat(dst)
+	if os.IsNotExist(err) {
+		return dst, false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to check destination: %w", err)
+	}
+
+	switch policy {
+	case "", ExistingFileOverwrite:
+		return dst, false, nil
+	case ExistingFileSkip:
+		return dst, true, nil
+	case ExistingFileKeepLarger:
+		return dst, info.Size() >= size, nil
+	case ExistingFileRename:
+		path, err := renamedPath(basePath, ext)
+		return path, false, err
+	default:
+		return "", false, fmt.Errorf("invalid existing file policy %q", policy)
+	}
+}
+
+// renamedPath returns the first "basePath (N)ext" path that does not exist
+func renamedPath(basePath, ext string) (string, error) {
+	for n := 1; n <= maxRenameSuffix; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", basePath, n, ext)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		} else if err != nil {
+			return "", fmt.Errorf("failed to check destination: %w", err)
+		}
+	}
+	return "", fmt.Errorf("no free name for %s%s after %d attempts", basePath, ext, maxRenameSuffix)
+}
@@ -0,0 +1,127 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownload_ExistingFilePolicy(t *testing.T) {
+	const downloaded = "new video content"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Write([]byte(downloaded))
+	}))
+	defer server.Close()
+
+	d := New(10*time.Second, 1)
+
+	tests := []struct {
+		name         string
+		policy       ExistingFilePolicy
+		existing     string
+		wantPath     string // Relative to the base destination path
+		wantContent  string
+		wantExisting string // Content of the pre-existing file after the download
+		wantKept     bool
+	}{
+		{
+			name:         "empty policy overwrites",
+			existing:     "old",
+			wantPath:     ".mp4",
+			wantContent:  downloaded,
+			wantExisting: downloaded,
+		},
+		{
+			name:         "overwrite",
+			policy:       ExistingFileOverwrite,
+			existing:     "a much larger old video content",
+			wantPath:     ".mp4",
+			wantContent:  downloaded,
+			wantExisting: downloaded,
+		},
+		{
+			name:         "skip",
+			policy:       ExistingFileSkip,
+			existing:     "old",
+			wantPath:     ".mp4",
+			wantContent:  "old",
+			wantExisting: "old",
+			wantKept:     true,
+		},
+		{
+			name:         "keep-larger keeps a larger existing file",
+			policy:       ExistingFileKeepLarger,
+			existing:     "a much larger old video content",
+			wantPath:     ".mp4",
+			wantContent:  "a much larger old video content",
+			wantExisting: "a much larger old video content",
+			wantKept:     true,
+		},
+		{
+			name:         "keep-larger replaces a smaller existing file",
+			policy:       ExistingFileKeepLarger,
+			existing:     "old",
+			wantPath:     ".mp4",
+			wantContent:  downloaded,
+			wantExisting: downloaded,
+		},
+		{
+			name:         "rename",
+			policy:       ExistingFileRename,
+			existing:     "old",
+			wantPath:     " (1).mp4",
+			wantContent:  downloaded,
+			wantExisting: "old",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseDest := filepath.Join(t.TempDir(), "The Matrix (1999)")
+			require.NoError(t, os.WriteFile(baseDest+".mp4", []byte(tt.existing), 0644))
+
+			result, err := d.Download(context.Background(), DownloadOptions{
+				URL:                server.URL + "/movie.mp4",
+				BaseDestPath:       baseDest,
+				ExistingFilePolicy: tt.policy,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, baseDest+tt.wantPath, result.FilePath)
+			assert.Equal(t, tt.wantKept, result.KeptExisting)
+			assert.Equal(t, int64(len(tt.wantContent)), result.FileSize)
+
+			data, err := os.ReadFile(result.FilePath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantContent, string(data))
+
+			data, err = os.ReadFile(baseDest + ".mp4")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantExisting, string(data))
+		})
+	}
+}
+
+func TestRenamedPath(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "Dune (2021)")
+	require.NoError(t, os.WriteFile(base+".mkv", nil, 0644))
+	require.NoError(t, os.WriteFile(base+" (1).mkv", nil, 0644))
+
+	path, keep, err := resolveDestination(base, ".mkv", 10, ExistingFileRename)
+	require.NoError(t, err)
+	assert.False(t, keep)
+	assert.Equal(t, base+" (2).mkv", path)
+
+	path, keep, err = resolveDestination(base, ".mp4", 10, ExistingFileSkip)
+	require.NoError(t, err)
+	assert.False(t, keep, "a free destination is not kept")
+	assert.Equal(t, base+".mp4", path)
+}
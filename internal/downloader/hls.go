@@ -0,0 +1,127 @@
+package downloader
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/glefebvre/stalkeer/internal/logger"
+)
+
+// hlsHeader starts every HLS playlist
+const hlsHeader = "#EXTM3U"
+
+// maxPlaylistSize bounds the HLS playlist read from a stream URL
+const maxPlaylistSize = 1 << 20
+
+// ErrUnsupportedStream is returned for a stream URL that serves an HLS playlist
+// that cannot be saved as a single file: a media playlist of segments, live or not
+var ErrUnsupportedStream = errors.New("unsupported HLS stream")
+
+var hlsBandwidthRegex = regexp.MustCompile(`(?:^|,)BANDWIDTH=(\d+)`)
+
+// hlsMasterError is returned when a stream URL serves an HLS master playlist,
+// with the URL of its highest-bandwidth variant
+type hlsMasterError struct {
+	variantURL string
+	bandwidth  int64
+}
+
+func (e *hlsMasterError) Error() string {
+	return fmt.Sprintf("HLS master playlist, highest-bandwidth variant %s", e.variantURL)
+}
+
+// downloadStream downloads streamURL like downloadFile. A stream URL serving an HLS
+// master playlist is followed once to its highest-bandwidth variant. It returns the
+// URL the file was downloaded from.
+func (d *Downloader) downloadStream(ctx context.Context, streamURL string, auth StreamAuth, allowedTypes []string, destPath string, expectedSize int64, tee io.Writer, onProgress func(int64, int64)) (*DownloadResult, string, string, error) {
+	auth = auth.scopedTo(streamURL)
+	res, contentType, err := d.downloadFile(ctx, streamURL, auth, allowedTypes, destPath, expectedSize, tee, onProgress)
+	var master *hlsMasterError
+	if !errors.As(err, &master) {
+		return res, contentType, streamURL, err
+	}
+
+	logger.AppLogger().WithFields(map[string]interface{}{
+		"url":       streamURL,
+		"variant":   master.variantURL,
+		"bandwidth": master.bandwidth,
+	}).Info("following HLS master playlist to its highest-bandwidth variant")
+
+	// The size of the playlist says nothing about the size of the variant
+	res, contentType, err = d.downloadFile(ctx, master.variantURL, auth, allowedTypes, destPath, 0, tee, onProgress)
+	if errors.As(err, &master) {
+		err = fmt.Errorf("%w: variant %s is another master playlist", ErrUnsupportedStream, master.variantURL)
+	}
+	return res, contentType, master.variantURL, err
+}
+
+// isHLSPlaylist reports whether body starts with an HLS playlist, without consuming it
+func isHLSPlaylist(body *bufio.Reader) bool {
+	head, _ := body.Peek(len(hlsHeader))
+	return string(head) == hlsHeader
+}
+
+// hlsPlaylistError reads the HLS playlist served by playlistURL and returns an
+// hlsMasterError for a master playlist, ErrUnsupportedStream otherwise
+func hlsPlaylistError(body io.Reader, playlistURL string) error {
+	playlist, err := io.ReadAll(io.LimitReader(body, maxPlaylistSize))
+	if err != nil {
+		return fmt.Errorf("failed to read HLS playlist: %w", err)
+	}
+
+	variantURL, bandwidth, err := parseHLSMaster(string(playlist), playlistURL)
+	if err != nil {
+		return err
+	}
+	if variantURL == "" {
+		return fmt.Errorf("%w: %s is an HLS media playlist (live or segmented stream), not a file", ErrUnsupportedStream, playlistURL)
+	}
+	return &hlsMasterError{variantURL: variantURL, bandwidth: bandwidth}
+}
+
+// parseHLSMaster returns the URL of the highest-bandwidth variant of an HLS master
+// playlist, resolved against playlistURL, or an empty URL when the playlist lists
+// no #EXT-X-STREAM-INF variant
+func parseHLSMaster(playlist, playlistURL string) (string, int64, error) {
+	var best string
+	var bestBandwidth int64 = -1
+	var pending int64 = -1 // Bandwidth of the #EXT-X-STREAM-INF awaiting its URI
+
+	for _, line := range strings.Split(playlist, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			pending = 0
+			if m := hlsBandwidthRegex.FindStringSubmatch(strings.TrimPrefix(line, "#EXT-X-STREAM-INF:")); m != nil {
+				pending, _ = strconv.ParseInt(m[1], 10, 64)
+			}
+		case strings.HasPrefix(line, "#"):
+		case pending >= 0:
+			if pending > bestBandwidth {
+				best, bestBandwidth = line, pending
+			}
+			pending = -1
+		}
+	}
+	if best == "" {
+		return "", 0, nil
+	}
+
+	base, err := url.Parse(playlistURL)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid HLS playlist URL: %w", err)
+	}
+	variant, err := url.Parse(best)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid HLS variant URL %q: %w", best, err)
+	}
+	return base.ResolveReference(variant).String(), bestBandwidth, nil
+}
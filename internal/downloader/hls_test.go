@@ -0,0 +1,89 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMasterPlaylist = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360
+low/movie.mp4
+#EXT-X-STREAM-INF:AVERAGE-BANDWIDTH=9000000,BANDWIDTH=5000000,RESOLUTION=1920x1080
+high/movie.mp4
+#EXT-X-STREAM-INF:BANDWIDTH=2000000,RESOLUTION=1280x720
+mid/movie.mp4
+`
+
+func TestParseHLSMaster(t *testing.T) {
+	variant, bandwidth, err := parseHLSMaster(testMasterPlaylist, "http://example.com/streams/master.m3u8?token=abc")
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/streams/high/movie.mp4", variant)
+	assert.Equal(t, int64(5000000), bandwidth)
+
+	media := "#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10.0,\nsegment0.ts\n#EXTINF:10.0,\nsegment1.ts\n"
+	variant, _, err = parseHLSMaster(media, "http://example.com/live.m3u8")
+	require.NoError(t, err)
+	assert.Empty(t, variant, "a media playlist has no variant")
+}
+
+func TestDownload_HLSMasterPlaylist(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/master.m3u8":
+			w.Header().Set("Content-Type", "application/x-mpegURL")
+			w.Write([]byte(testMasterPlaylist))
+		case "/high/movie.mp4":
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write([]byte("high quality video"))
+		case "/low/movie.mp4", "/mid/movie.mp4":
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write([]byte("lower quality video"))
+		case "/live.m3u8":
+			w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+			w.Write([]byte("#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10.0,\nsegment0.ts\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	d := New(10*time.Second, 1)
+
+	t.Run("master playlist downloads the highest-bandwidth variant", func(t *testing.T) {
+		baseDest := filepath.Join(t.TempDir(), "The Matrix (1999)")
+		result, err := d.Download(context.Background(), DownloadOptions{
+			URL:                 server.URL + "/master.m3u8",
+			BaseDestPath:        baseDest,
+			AllowedContentTypes: DefaultAllowedContentTypes,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, baseDest+".mp4", result.FilePath)
+		assert.Equal(t, "video/mp4", result.ContentType)
+
+		data, err := os.ReadFile(result.FilePath)
+		require.NoError(t, err)
+		assert.Equal(t, "high quality video", string(data))
+	})
+
+	t.Run("media playlist is rejected", func(t *testing.T) {
+		baseDest := filepath.Join(t.TempDir(), "Live Channel")
+		_, err := d.Download(context.Background(), DownloadOptions{
+			URL:          server.URL + "/live.m3u8",
+			BaseDestPath: baseDest,
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrUnsupportedStream)
+
+		matches, _ := filepath.Glob(baseDest + "*")
+		assert.Empty(t, matches, "the playlist is not saved")
+	})
+}
@@ -0,0 +1,133 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/external/plex"
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/glefebvre/stalkeer/internal/models"
+)
+
+// defaultPostCommandTimeout bounds a post-download command when no timeout is set
+const defaultPostCommandTimeout = 60 * time.Second
+
+// postCommandPathPlaceholder is replaced by the quoted path of the downloaded file
+const postCommandPathPlaceholder = "{path}"
+
+// RunPostCommand runs the shell command template after a successful download, with
+// {path} replaced by the quoted path of the downloaded file. It returns the combined
+// output of the command.
+func RunPostCommand(ctx context.Context, template, path string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		timeout = defaultPostCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	command := strings.ReplaceAll(template, postCommandPathPlaceholder, shellQuote(path))
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	// Children of the shell may keep its output open after it is killed
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return string(output), fmt.Errorf("post command timed out after %s", timeout)
+	}
+	return string(output), err
+}
+
+// shellQuote quotes s as a single sh word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runPostCommand runs the post-download command of a finished download. A failure
+// is logged and does not fail the download.
+func (d *Downloader) runPostCommand(ctx context.Context, opts DownloadOptions, path string) {
+	log := logger.AppLogger()
+
+	output, err := RunPostCommand(ctx, opts.PostCommand, path, opts.PostCommandTimeout)
+	fields := map[string]interface{}{
+		"path":   path,
+		"output": strings.TrimSpace(output),
+	}
+	if err != nil {
+		fields["error"] = err
+		log.WithFields(fields).Warn("post-download command failed")
+		return
+	}
+	log.WithFields(fields).Info("post-download command completed")
+}
+
+// LibraryRefresher asks a media server to scan a directory of one of its library
+// sections, see plex.Client
+type LibraryRefresher interface {
+	RefreshPath(ctx context.Context, sectionID int, path string) error
+}
+
+// LibraryRefresh selects the library section scanned after a download
+type LibraryRefresh struct {
+	Refresher LibraryRefresher
+	SectionID int
+}
+
+// NewPlexRefresh returns the Plex refresh of a download of contentType, or nil
+// when downloads.plex_refresh is off or no Plex section is configured for the
+// content type
+func NewPlexRefresh(cfg *config.Config, contentType models.ContentType) *LibraryRefresh {
+	if !cfg.Downloads.PlexRefresh {
+		return nil
+	}
+
+	var sectionID int
+	switch contentType {
+	case models.ContentTypeMovies:
+		sectionID = cfg.Plex.MoviesSection
+	case models.ContentTypeTVShows:
+		sectionID = cfg.Plex.TVShowsSection
+	}
+	if sectionID == 0 {
+		return nil
+	}
+
+	client, err := plex.New(plex.Config{BaseURL: cfg.Plex.URL, Token: cfg.Plex.Token, Logger: logger.AppLogger()})
+	if err != nil {
+		logger.AppLogger().WithFields(map[string]interface{}{
+			"error": err,
+		}).Warn("plex refresh disabled")
+		return nil
+	}
+
+	return &LibraryRefresh{Refresher: client, SectionID: sectionID}
+}
+
+// refreshLibrary asks the media server to scan the directory of a finished
+// download in the background, so that a slow or unreachable server does not hold
+// the download. A failure is logged and does not fail the download.
+func (d *Downloader) refreshLibrary(ctx context.Context, refresh *LibraryRefresh, path string) {
+	dir := filepath.Dir(path)
+	ctx = context.WithoutCancel(ctx)
+
+	d.refreshes.Add(1)
+	go func() {
+		defer d.refreshes.Done()
+		if err := refresh.Refresher.RefreshPath(ctx, refresh.SectionID, dir); err != nil {
+			logger.AppLogger().WithFields(map[string]interface{}{
+				"section": refresh.SectionID,
+				"path":    dir,
+				"error":   err,
+			}).Warn("library refresh failed")
+		}
+	}()
+}
+
+// WaitRefreshes waits for the library refreshes of the finished downloads, so that
+// a command does not exit before they are sent
+func (d *Downloader) WaitRefreshes() {
+	d.refreshes.Wait()
+}
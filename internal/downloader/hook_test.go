@@ -0,0 +1,32 @@
+package downloader
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPostCommand_SubstitutesPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Director's Cut (2020).mkv")
+
+	output, err := RunPostCommand(context.Background(), "echo rescan {path}", path, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "rescan "+path, strings.TrimSpace(output))
+}
+
+func TestRunPostCommand_NonZeroExit(t *testing.T) {
+	output, err := RunPostCommand(context.Background(), "echo failing >&2; exit 3", "/library/movie.mkv", time.Second)
+	assert.Error(t, err)
+	assert.Equal(t, "failing", strings.TrimSpace(output))
+}
+
+func TestRunPostCommand_Timeout(t *testing.T) {
+	_, err := RunPostCommand(context.Background(), "sleep 5", "/library/movie.mkv", 50*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+}
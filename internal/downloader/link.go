@@ -0,0 +1,100 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrKeepDirRequired is returned by the hardlink and symlink modes without a temp
+// dir: the files the library links to must not be kept in the OS temp dir
+var ErrKeepDirRequired = errors.New("hardlink and symlink link modes require a temp dir")
+
+// keptDirName is the directory of the temp dir the linked files are kept in
+const keptDirName = "kept"
+
+// LinkMode selects how a downloaded file is placed at its final destination
+type LinkMode string
+
+const (
+	// LinkModeMove moves the file to its destination (default)
+	LinkModeMove LinkMode = "move"
+	// LinkModeHardlink keeps the file in the temp dir and hardlinks it to its destination
+	LinkModeHardlink LinkMode = "hardlink"
+	// LinkModeSymlink keeps the file in the temp dir and symlinks it from its destination
+	LinkModeSymlink LinkMode = "symlink"
+)
+
+// linksFile reports whether mode keeps the file in the temp dir and links it
+func (mode LinkMode) linksFile() bool {
+	return mode == LinkModeHardlink || mode == LinkModeSymlink
+}
+
+// placeFile places the downloaded file src at dst according to mode. In the hardlink
+// and symlink modes the file is first kept in keepDir at the path of dst (see
+// keptPath), so that it remains there once the temporary download directory is removed.
+func placeFile(src, dst, keepDir string, mode LinkMode) error {
+	if !mode.linksFile() {
+		return moveFile(src, dst)
+	}
+	if keepDir == "" {
+		return ErrKeepDirRequired
+	}
+
+	kept, err := keptPath(keepDir, dst)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(kept), 0755); err != nil {
+		return fmt.Errorf("failed to create keep directory: %w", err)
+	}
+	if err := moveFile(src, kept); err != nil {
+		return fmt.Errorf("failed to keep file in %s: %w", keepDir, err)
+	}
+	return linkFile(kept, dst, mode)
+}
+
+// keptPath returns the path a file linked from dst is kept at in keepDir: the
+// absolute path of dst under keepDir/kept, so that the same-named files of
+// different shows do not collide
+func keptPath(keepDir, dst string) (string, error) {
+	abs, err := filepath.Abs(dst)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve destination: %w", err)
+	}
+	rel := strings.TrimLeft(abs[len(filepath.VolumeName(abs)):], `/\`)
+	return filepath.Join(keepDir, keptDirName, rel), nil
+}
+
+// linkFile links dst to src, replacing an existing dst like a move would. When the
+// link cannot be created, e.g. across devices or on a filesystem without link
+// support, src is copied instead.
+func linkFile(src, dst string, mode LinkMode) error {
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace destination: %w", err)
+	}
+
+	var err error
+	switch mode {
+	case LinkModeHardlink:
+		err = os.Link(src, dst)
+	case LinkModeSymlink:
+		var target string
+		if target, err = filepath.Abs(src); err == nil {
+			err = os.Symlink(target, dst)
+		}
+	default:
+		return fmt.Errorf("invalid link mode %q", mode)
+	}
+	if err == nil {
+		return nil
+	}
+
+	if copyErr := copyFile(src, dst); copyErr != nil {
+		os.Remove(dst)
+		return fmt.Errorf("%s failed (%v) and copy failed: %w", mode, err, copyErr)
+	}
+	return nil
+}
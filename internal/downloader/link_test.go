@@ -0,0 +1,119 @@
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPlaceFile creates a downloaded file in a temp download dir and returns its
+// path, the keep dir and the destination path in a library dir
+func setupPlaceFile(t *testing.T) (src, keepDir, dst string) {
+	t.Helper()
+
+	keepDir = t.TempDir()
+	downloadDir := filepath.Join(keepDir, "stalkeer-download-test")
+	require.NoError(t, os.MkdirAll(downloadDir, 0755))
+	src = filepath.Join(downloadDir, "download.tmp")
+	require.NoError(t, os.WriteFile(src, []byte("video data"), 0644))
+
+	dst = filepath.Join(t.TempDir(), "Movie (2020).mkv")
+	return src, keepDir, dst
+}
+
+func TestPlaceFile_Move(t *testing.T) {
+	src, keepDir, dst := setupPlaceFile(t)
+
+	require.NoError(t, placeFile(src, dst, keepDir, LinkModeMove))
+
+	assert.NoFileExists(t, src)
+	assert.NoDirExists(t, filepath.Join(keepDir, keptDirName))
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "video data", string(data))
+}
+
+func TestPlaceFile_Hardlink(t *testing.T) {
+	src, keepDir, dst := setupPlaceFile(t)
+
+	require.NoError(t, placeFile(src, dst, keepDir, LinkModeHardlink))
+
+	kept, err := keptPath(keepDir, dst)
+	require.NoError(t, err)
+	keptInfo, err := os.Stat(kept)
+	require.NoError(t, err)
+	dstInfo, err := os.Lstat(dst)
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, src)
+	assert.Zero(t, dstInfo.Mode()&os.ModeSymlink, "destination should be a regular file")
+	assert.True(t, os.SameFile(keptInfo, dstInfo), "destination should share the inode of the kept file")
+}
+
+func TestPlaceFile_Symlink(t *testing.T) {
+	src, keepDir, dst := setupPlaceFile(t)
+
+	require.NoError(t, placeFile(src, dst, keepDir, LinkModeSymlink))
+
+	kept, err := keptPath(keepDir, dst)
+	require.NoError(t, err)
+	dstInfo, err := os.Lstat(dst)
+	require.NoError(t, err)
+	require.NotZero(t, dstInfo.Mode()&os.ModeSymlink, "destination should be a symlink")
+
+	target, err := os.Readlink(dst)
+	require.NoError(t, err)
+	assert.Equal(t, kept, target)
+
+	data, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, "video data", string(data))
+}
+
+func TestLinkFile_ReplacesExistingDestination(t *testing.T) {
+	for _, mode := range []LinkMode{LinkModeHardlink, LinkModeSymlink} {
+		t.Run(string(mode), func(t *testing.T) {
+			src, keepDir, dst := setupPlaceFile(t)
+			require.NoError(t, os.WriteFile(dst, []byte("old"), 0644))
+
+			require.NoError(t, placeFile(src, dst, keepDir, mode))
+
+			data, err := os.ReadFile(dst)
+			require.NoError(t, err)
+			assert.Equal(t, "video data", string(data))
+		})
+	}
+}
+
+func TestPlaceFile_KeepsSameNamedFilesApart(t *testing.T) {
+	keepDir, library := t.TempDir(), t.TempDir()
+
+	var dsts []string
+	for _, show := range []string{"Show A", "Show B"} {
+		src := filepath.Join(t.TempDir(), "download.tmp")
+		require.NoError(t, os.WriteFile(src, []byte(show), 0644))
+		dst := filepath.Join(library, show, "Season 01", "S01E01.mkv")
+		require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0755))
+
+		require.NoError(t, placeFile(src, dst, keepDir, LinkModeSymlink))
+		dsts = append(dsts, dst)
+	}
+
+	for i, show := range []string{"Show A", "Show B"} {
+		data, err := os.ReadFile(dsts[i])
+		require.NoError(t, err)
+		assert.Equal(t, show, string(data))
+	}
+}
+
+func TestPlaceFile_LinkModesRequireKeepDir(t *testing.T) {
+	src, _, dst := setupPlaceFile(t)
+
+	for _, mode := range []LinkMode{LinkModeHardlink, LinkModeSymlink} {
+		assert.ErrorIs(t, placeFile(src, dst, "", mode), ErrKeepDirRequired)
+	}
+	assert.FileExists(t, src)
+}
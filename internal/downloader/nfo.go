@@ -0,0 +1,121 @@
+package downloader
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+)
+
+// nfoUniqueID is a <uniqueid> element of an nfo file
+type nfoUniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr,omitempty"`
+	Value   string `xml:",chardata"`
+}
+
+// movieNFO is the Kodi/Jellyfin metadata sidecar of a movie
+type movieNFO struct {
+	XMLName   xml.Name      `xml:"movie"`
+	Title     string        `xml:"title"`
+	Year      int           `xml:"year,omitempty"`
+	Genres    []string      `xml:"genre"`
+	Set       string        `xml:"set>name,omitempty"`
+	UniqueIDs []nfoUniqueID `xml:"uniqueid"`
+}
+
+// episodeNFO is the Kodi/Jellyfin metadata sidecar of an episode
+type episodeNFO struct {
+	XMLName   xml.Name      `xml:"episodedetails"`
+	Title     string        `xml:"title"`
+	ShowTitle string        `xml:"showtitle"`
+	Season    int           `xml:"season"`
+	Episode   int           `xml:"episode"`
+	Year      int           `xml:"year,omitempty"`
+	Genres    []string      `xml:"genre"`
+	UniqueIDs []nfoUniqueID `xml:"uniqueid"`
+}
+
+// WriteNFO writes the .nfo metadata file of a downloaded line next to its media file,
+// at basePath with the .nfo extension. The line must have its Movie or TVShow
+// association loaded. It returns the path written, or an empty path when the line
+// has no TMDB metadata or is a season pack, which has no single episode to describe.
+func WriteNFO(line *models.ProcessedLine, basePath string) (string, error) {
+	var doc interface{}
+	switch {
+	case line.Movie != nil:
+		doc = buildMovieNFO(line.Movie)
+	case line.TVShow != nil && !line.TVShow.SeasonPack && line.TVShow.Season != nil && line.TVShow.Episode != nil:
+		doc = buildEpisodeNFO(line.TVShow)
+	default:
+		return "", nil
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode nfo: %w", err)
+	}
+
+	path := basePath + ".nfo"
+	content := append([]byte(xml.Header), data...)
+	content = append(content, '\n')
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write nfo: %w", err)
+	}
+	return path, nil
+}
+
+func buildMovieNFO(movie *models.Movie) movieNFO {
+	nfo := movieNFO{
+		Title:     movie.TMDBTitle,
+		Year:      movie.TMDBYear,
+		Genres:    splitGenres(movie.TMDBGenres),
+		UniqueIDs: []nfoUniqueID{{Type: "tmdb", Default: true, Value: strconv.Itoa(movie.TMDBID)}},
+	}
+	if movie.CollectionName != nil {
+		nfo.Set = *movie.CollectionName
+	}
+	if movie.TVDBID != nil {
+		nfo.UniqueIDs = append(nfo.UniqueIDs, nfoUniqueID{Type: "tvdb", Value: strconv.Itoa(*movie.TVDBID)})
+	}
+	return nfo
+}
+
+// buildEpisodeNFO describes an episode. TVShow holds the TMDB and TVDB IDs of the
+// series, not of the episode, so they are written with the tmdb_tvshow and tvdb_tvshow
+// types rather than as the episode IDs.
+func buildEpisodeNFO(show *models.TVShow) episodeNFO {
+	nfo := episodeNFO{
+		Title:     show.TMDBTitle,
+		ShowTitle: show.TMDBTitle,
+		Season:    *show.Season,
+		Episode:   *show.Episode,
+		Year:      show.TMDBYear,
+		Genres:    splitGenres(show.TMDBGenres),
+		UniqueIDs: []nfoUniqueID{{Type: "tmdb_tvshow", Value: strconv.Itoa(show.TMDBID)}},
+	}
+	if show.EpisodeTitle != nil && *show.EpisodeTitle != "" {
+		nfo.Title = *show.EpisodeTitle
+	}
+	if show.TVDBID != nil {
+		nfo.UniqueIDs = append(nfo.UniqueIDs, nfoUniqueID{Type: "tvdb_tvshow", Value: strconv.Itoa(*show.TVDBID)})
+	}
+	return nfo
+}
+
+// splitGenres splits the comma-separated TMDB genres of a movie or show
+func splitGenres(genres *string) []string {
+	if genres == nil {
+		return nil
+	}
+	var list []string
+	for _, genre := range strings.Split(*genres, ",") {
+		if genre = strings.TrimSpace(genre); genre != "" {
+			list = append(list, genre)
+		}
+	}
+	return list
+}
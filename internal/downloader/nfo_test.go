@@ -0,0 +1,99 @@
+package downloader
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteNFO_Movie(t *testing.T) {
+	genres := "Action, Science Fiction"
+	collection := "Inception Collection"
+	tvdbID := 1234
+	line := &models.ProcessedLine{Movie: &models.Movie{
+		TMDBID:         27205,
+		TVDBID:         &tvdbID,
+		TMDBTitle:      "Inception",
+		TMDBYear:       2010,
+		TMDBGenres:     &genres,
+		CollectionName: &collection,
+	}}
+
+	basePath := filepath.Join(t.TempDir(), "Inception (2010)")
+	path, err := WriteNFO(line, basePath)
+	require.NoError(t, err)
+	assert.Equal(t, basePath+".nfo", path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), xml.Header)
+
+	var nfo movieNFO
+	require.NoError(t, xml.Unmarshal(data, &nfo))
+	assert.Equal(t, "movie", nfo.XMLName.Local)
+	assert.Equal(t, "Inception", nfo.Title)
+	assert.Equal(t, 2010, nfo.Year)
+	assert.Equal(t, []string{"Action", "Science Fiction"}, nfo.Genres)
+	assert.Equal(t, "Inception Collection", nfo.Set)
+	assert.Equal(t, []nfoUniqueID{
+		{Type: "tmdb", Default: true, Value: "27205"},
+		{Type: "tvdb", Value: "1234"},
+	}, nfo.UniqueIDs)
+}
+
+func TestWriteNFO_Episode(t *testing.T) {
+	season, episode := 1, 2
+	episodeTitle := "Cat's in the Bag..."
+	line := &models.ProcessedLine{TVShow: &models.TVShow{
+		TMDBID:       1396,
+		TMDBTitle:    "Breaking Bad",
+		TMDBYear:     2008,
+		Season:       &season,
+		Episode:      &episode,
+		EpisodeTitle: &episodeTitle,
+	}}
+
+	basePath := filepath.Join(t.TempDir(), "Breaking Bad - S01E02")
+	path, err := WriteNFO(line, basePath)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var nfo episodeNFO
+	require.NoError(t, xml.Unmarshal(data, &nfo))
+	assert.Equal(t, "episodedetails", nfo.XMLName.Local)
+	assert.Equal(t, "Cat's in the Bag...", nfo.Title)
+	assert.Equal(t, "Breaking Bad", nfo.ShowTitle)
+	assert.Equal(t, 1, nfo.Season)
+	assert.Equal(t, 2, nfo.Episode)
+	assert.Equal(t, 2008, nfo.Year)
+	assert.Empty(t, nfo.Genres)
+	assert.Equal(t, []nfoUniqueID{{Type: "tmdb_tvshow", Value: "1396"}}, nfo.UniqueIDs)
+}
+
+func TestWriteNFO_SkipsLinesWithoutEpisodeMetadata(t *testing.T) {
+	season := 1
+	tests := []struct {
+		name string
+		line *models.ProcessedLine
+	}{
+		{"no metadata", &models.ProcessedLine{}},
+		{"season pack", &models.ProcessedLine{TVShow: &models.TVShow{TMDBTitle: "Show", Season: &season, SeasonPack: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			basePath := filepath.Join(t.TempDir(), "media")
+			path, err := WriteNFO(tt.line, basePath)
+			require.NoError(t, err)
+			assert.Empty(t, path)
+			assert.NoFileExists(t, basePath+".nfo")
+		})
+	}
+}
@@ -2,6 +2,8 @@ package downloader
 
 import (
 	"context"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 )
@@ -23,6 +25,7 @@ type DownloadJobResult struct {
 type ParallelDownloader struct {
 	downloader  *Downloader
 	concurrency int
+	hostLimits  map[string]int // Max concurrent downloads per URL host, on top of concurrency
 }
 
 // NewParallel creates a new parallel downloader
@@ -61,6 +64,14 @@ func (pd *ParallelDownloader) DownloadBatch(ctx context.Context, jobs []Download
 	}
 	close(jobQueue)
 
+	// Per-host semaphores, shared by the workers of this batch
+	hostSlots := make(map[string]chan struct{}, len(pd.hostLimits))
+	for host, limit := range pd.hostLimits {
+		if limit > 0 {
+			hostSlots[host] = make(chan struct{}, limit)
+		}
+	}
+
 	// Start worker goroutines
 	var wg sync.WaitGroup
 	for i := 0; i < pd.concurrency; i++ {
@@ -76,7 +87,16 @@ func (pd *ParallelDownloader) DownloadBatch(ctx context.Context, jobs []Download
 					}
 					return
 				default:
+					release, err := acquireHostSlot(ctx, hostSlots, job.Options.URL)
+					if err != nil {
+						results <- DownloadJobResult{
+							JobID: job.ID,
+							Error: err,
+						}
+						return
+					}
 					result, err := pd.downloader.Download(ctx, job.Options)
+					release()
 					results <- DownloadJobResult{
 						JobID:  job.ID,
 						Result: result,
@@ -96,6 +116,34 @@ func (pd *ParallelDownloader) DownloadBatch(ctx context.Context, jobs []Download
 	return results
 }
 
+// acquireHostSlot takes a slot of the semaphore of the host of rawURL and returns the
+// function releasing it. URLs of hosts without a limit are not restricted. The host is
+// looked up with its port first, then without.
+func acquireHostSlot(ctx context.Context, hostSlots map[string]chan struct{}, rawURL string) (func(), error) {
+	noop := func() {}
+	if len(hostSlots) == 0 {
+		return noop, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return noop, nil
+	}
+	slots, ok := hostSlots[strings.ToLower(u.Host)]
+	if !ok {
+		if slots, ok = hostSlots[strings.ToLower(u.Hostname())]; !ok {
+			return noop, nil
+		}
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return noop, ctx.Err()
+	}
+}
+
 // DownloadBatchSync downloads multiple files in parallel and waits for all to complete
 func (pd *ParallelDownloader) DownloadBatchSync(ctx context.Context, jobs []DownloadJob) []DownloadJobResult {
 	resultsChan := pd.DownloadBatch(ctx, jobs)
@@ -140,6 +188,16 @@ func (pd *ParallelDownloader) GetConcurrency() int {
 	return pd.concurrency
 }
 
+// SetHostLimits sets the maximum number of concurrent downloads per URL host
+// (host or host:port, case-insensitive), enforced in addition to the concurrency.
+// Hosts without a limit are only bound by the concurrency.
+func (pd *ParallelDownloader) SetHostLimits(limits map[string]int) {
+	pd.hostLimits = make(map[string]int, len(limits))
+	for host, limit := range limits {
+		pd.hostLimits[strings.ToLower(host)] = limit
+	}
+}
+
 // SetConcurrency updates the concurrency level
 func (pd *ParallelDownloader) SetConcurrency(concurrency int) {
 	if concurrency > 0 {
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -388,3 +389,71 @@ func TestParallelDownloader_SetConcurrency(t *testing.T) {
 	pd.SetConcurrency(-5)
 	assert.Equal(t, 10, pd.GetConcurrency())
 }
+
+func TestParallelDownloader_PerHostLimits(t *testing.T) {
+	_ = setupTestDB(t)
+
+	var mu sync.Mutex
+	active := map[string]int{}
+	peak := map[string]int{}
+	total, totalPeak := 0, 0
+
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			active[name]++
+			total++
+			if active[name] > peak[name] {
+				peak[name] = active[name]
+			}
+			if total > totalPeak {
+				totalPeak = total
+			}
+			mu.Unlock()
+
+			time.Sleep(50 * time.Millisecond)
+
+			mu.Lock()
+			active[name]--
+			total--
+			mu.Unlock()
+
+			w.Write([]byte("content"))
+		}))
+	}
+	serverA := newServer("a")
+	defer serverA.Close()
+	serverB := newServer("b")
+	defer serverB.Close()
+
+	tempDir := t.TempDir()
+	var jobs []DownloadJob
+	for i := 0; i < 8; i++ {
+		for _, server := range []*httptest.Server{serverA, serverB} {
+			jobs = append(jobs, DownloadJob{
+				ID: len(jobs),
+				Options: DownloadOptions{
+					URL:          server.URL + "/stream.mkv",
+					BaseDestPath: filepath.Join(tempDir, fmt.Sprintf("file_%d", len(jobs))),
+				},
+			})
+		}
+	}
+
+	// Both servers listen on 127.0.0.1, their hosts differ by port
+	pd := NewParallel(10*time.Second, 1, 4)
+	pd.SetHostLimits(map[string]int{
+		strings.TrimPrefix(serverA.URL, "http://"): 1,
+		strings.TrimPrefix(serverB.URL, "http://"): 3,
+	})
+
+	results := pd.DownloadBatchSync(context.Background(), jobs)
+	assert.Len(t, results, len(jobs))
+	for _, result := range results {
+		assert.NoError(t, result.Error)
+	}
+
+	assert.Equal(t, 1, peak["a"], "host a is limited to 1 concurrent download")
+	assert.LessOrEqual(t, peak["b"], 3, "host b is limited to 3 concurrent downloads")
+	assert.LessOrEqual(t, totalPeak, 4, "the global concurrency still bounds the total")
+}
@@ -0,0 +1,64 @@
+package downloader
+
+import (
+	"fmt"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// partsQuery selects the other parts of line's multi-part group. Parts are only
+// joined when they are linked to the same movie, so that distinct movies such as
+// "Deathly Hallows Part 1" and "Part 2" stay separate downloads.
+func partsQuery(db *gorm.DB, line *models.ProcessedLine) *gorm.DB {
+	query := db.Model(&models.ProcessedLine{}).
+		Where("part_group = ? AND id <> ?", *line.PartGroup, line.ID)
+	if line.MovieID != nil {
+		return query.Where("movie_id = ?", *line.MovieID)
+	}
+	return query.Where("movie_id IS NULL")
+}
+
+// FollowingPartURLs returns, in part order, the stream URLs of the parts following
+// line in its multi-part group. It returns nil when line is not part of a group.
+func FollowingPartURLs(db *gorm.DB, line *models.ProcessedLine) ([]string, error) {
+	if line.PartGroup == nil || line.PartNumber == nil {
+		return nil, nil
+	}
+
+	var parts []models.ProcessedLine
+	if err := partsQuery(db, line).
+		Where("part_number > ? AND line_url IS NOT NULL AND line_url <> ''", *line.PartNumber).
+		Order("part_number, id").
+		Find(&parts).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch following parts: %w", err)
+	}
+
+	urls := make([]string, 0, len(parts))
+	seen := make(map[int]bool, len(parts))
+	for _, part := range parts {
+		// Keep a single stream per part number
+		if seen[*part.PartNumber] {
+			continue
+		}
+		seen[*part.PartNumber] = true
+		urls = append(urls, *part.LineURL)
+	}
+	return urls, nil
+}
+
+// HasPrecedingPart reports whether line follows another part of its multi-part
+// group. Such lines are downloaded together with the first part.
+func HasPrecedingPart(db *gorm.DB, line *models.ProcessedLine) (bool, error) {
+	if line.PartGroup == nil || line.PartNumber == nil {
+		return false, nil
+	}
+
+	var count int64
+	if err := partsQuery(db, line).
+		Where("part_number < ?", *line.PartNumber).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to fetch preceding parts: %w", err)
+	}
+	return count > 0, nil
+}
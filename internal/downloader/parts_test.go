@@ -0,0 +1,125 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownload_JoinsParts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp4")
+		switch r.URL.Path {
+		case "/part1.mp4":
+			w.Write([]byte("first part|"))
+		case "/part2.mp4":
+			w.Write([]byte("second part|"))
+		case "/part3.mp4":
+			w.Write([]byte("third part"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	d := New(5*time.Second, 1)
+	result, err := d.Download(context.Background(), DownloadOptions{
+		URL:          server.URL + "/part1.mp4",
+		PartURLs:     []string{server.URL + "/part2.mp4", server.URL + "/part3.mp4"},
+		BaseDestPath: filepath.Join(t.TempDir(), "movie"),
+		TempDir:      t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(result.FilePath)
+	require.NoError(t, err)
+	assert.Equal(t, "first part|second part|third part", string(content))
+	assert.Equal(t, int64(len(content)), result.FileSize)
+}
+
+func TestDownload_JoinsPartsFailsOnMissingPart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/part1.mp4" {
+			w.Write([]byte("first part"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "movie")
+	d := New(5*time.Second, 1)
+	_, err := d.Download(context.Background(), DownloadOptions{
+		URL:          server.URL + "/part1.mp4",
+		PartURLs:     []string{server.URL + "/part2.mp4"},
+		BaseDestPath: dest,
+		TempDir:      t.TempDir(),
+	})
+	require.Error(t, err)
+
+	matches, _ := filepath.Glob(dest + "*")
+	assert.Empty(t, matches, "no partial file should reach the destination")
+}
+
+func TestFollowingPartURLs(t *testing.T) {
+	db := openTestDB(t)
+
+	movieID, otherMovieID := uint(1), uint(2)
+	group := "group-irishman"
+	create := func(name string, part int, movie *uint) *models.ProcessedLine {
+		url := "http://example.com/" + name
+		line := &models.ProcessedLine{
+			LineContent: "#EXTINF:-1," + name,
+			LineURL:     &url,
+			LineHash:    "hash-" + name,
+			TvgName:     name,
+			ContentType: models.ContentTypeMovies,
+			State:       models.StateProcessed,
+			MovieID:     movie,
+			PartNumber:  &part,
+			PartGroup:   &group,
+		}
+		require.NoError(t, db.Create(line).Error)
+		return line
+	}
+
+	// Created out of order to check the part ordering
+	part3 := create("part3", 3, &movieID)
+	part1 := create("part1", 1, &movieID)
+	part2 := create("part2", 2, &movieID)
+	create("part2-duplicate", 2, &movieID)
+	create("other-movie-part2", 2, &otherMovieID)
+
+	urls, err := FollowingPartURLs(db, part1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://example.com/part2", "http://example.com/part3"}, urls)
+
+	urls, err = FollowingPartURLs(db, part3)
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+
+	following, err := HasPrecedingPart(db, part1)
+	require.NoError(t, err)
+	assert.False(t, following)
+
+	following, err = HasPrecedingPart(db, part2)
+	require.NoError(t, err)
+	assert.True(t, following)
+
+	// A line without a part group is a standalone stream
+	single := &models.ProcessedLine{ID: 99}
+	urls, err = FollowingPartURLs(db, single)
+	require.NoError(t, err)
+	assert.Nil(t, urls)
+	following, err = HasPrecedingPart(db, single)
+	require.NoError(t, err)
+	assert.False(t, following)
+}
@@ -3,20 +3,85 @@ package downloader
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/glefebvre/stalkeer/internal/models"
 )
 
-func buildMovieBasePath(basePath, title string, year int) string {
-	dir := fmt.Sprintf("%s (%d)", sanitizeFilename(title), year)
+// DefaultMaxFilenameBytes is the file name length limit of most filesystems
+const DefaultMaxFilenameBytes = 255
+
+// extensionReserve is kept free in media file names for the extension added once
+// the download reveals the file type, e.g. ".m3u8", or for the .nfo next to them
+const extensionReserve = 8
+
+// truncationMarker ends a title shortened to fit a file name
+const truncationMarker = "…"
+
+// BaseDestPathForLine builds the destination path (without extension) and a display
+// name for a processed line from its TMDB metadata. Each path component is kept
+// within maxFilenameBytes bytes, see FitFilename. An error is returned when the
+// line lacks the metadata needed to organize the file.
+func BaseDestPathForLine(moviesPath, tvShowsPath string, maxFilenameBytes int, line *models.ProcessedLine) (string, string, error) {
+	if line.ContentType == models.ContentTypeMovies && line.Movie != nil {
+		path := buildMovieBasePath(moviesPath, line.Movie.TMDBTitle, line.Movie.TMDBYear, maxFilenameBytes)
+		return path, fmt.Sprintf("%s (%d)", line.Movie.TMDBTitle, line.Movie.TMDBYear), nil
+	}
+
+	if line.ContentType == models.ContentTypeTVShows &&
+		line.TVShow != nil && line.TVShow.Season != nil && line.TVShow.Episode != nil {
+		path := buildTVShowBasePath(tvShowsPath, line.TVShow.TMDBTitle, line.TVShow.TMDBYear, *line.TVShow.Season, *line.TVShow.Episode, maxFilenameBytes)
+		return path, fmt.Sprintf("%s (%d) - S%02dE%02d", line.TVShow.TMDBTitle, line.TVShow.TMDBYear, *line.TVShow.Season, *line.TVShow.Episode), nil
+	}
+
+	return "", "", fmt.Errorf("missing metadata for destination path")
+}
+
+func buildMovieBasePath(basePath, title string, year, maxFilenameBytes int) string {
+	// The directory is named like the file, so both leave room for the extension
+	dir := FitMediaFilename(sanitizeFilename(title), fmt.Sprintf(" (%d)", year), maxFilenameBytes)
 	return filepath.Join(basePath, dir, dir)
 }
 
-func buildTVShowBasePath(basePath, seriesTitle string, year, season, episode int) string {
-	seriesDir := fmt.Sprintf("%s (%d)", sanitizeFilename(seriesTitle), year)
+func buildTVShowBasePath(basePath, seriesTitle string, year, season, episode, maxFilenameBytes int) string {
+	title := sanitizeFilename(seriesTitle)
+	seriesDir := FitFilename(title, fmt.Sprintf(" (%d)", year), maxFilenameBytes)
 	seasonDir := fmt.Sprintf("Season %02d", season)
-	fileName := fmt.Sprintf("%s (%d) - S%02dE%02d", sanitizeFilename(seriesTitle), year, season, episode)
+	fileName := FitMediaFilename(title, fmt.Sprintf(" (%d) - S%02dE%02d", year, season, episode), maxFilenameBytes)
 	return filepath.Join(basePath, seriesDir, seasonDir, fileName)
 }
 
+// FitFilename returns title+suffix, shortening title so that the result fits in
+// maxBytes bytes. The title is cut at a character boundary and ends with an
+// ellipsis; the suffix, e.g. " (1999)" or " - S01E02", is always kept whole.
+// maxBytes <= 0 disables the limit.
+func FitFilename(title, suffix string, maxBytes int) string {
+	if maxBytes <= 0 || len(title)+len(suffix) <= maxBytes {
+		return title + suffix
+	}
+
+	budget := maxBytes - len(suffix) - len(truncationMarker)
+	cut := 0
+	for i, r := range title {
+		if i+utf8.RuneLen(r) > budget {
+			break
+		}
+		cut = i + utf8.RuneLen(r)
+	}
+	// A trailing space or dot is not allowed at the end of a Windows file name
+	return strings.TrimRight(title[:cut], " .") + truncationMarker + suffix
+}
+
+// FitMediaFilename is FitFilename for the name of a media file built without its
+// extension, keeping room for the extension added by the download
+func FitMediaFilename(title, suffix string, maxBytes int) string {
+	if maxBytes <= 0 {
+		return title + suffix
+	}
+	return FitFilename(title, suffix, maxBytes-extensionReserve)
+}
+
 func sanitizeFilename(name string) string {
 	replacer := map[rune]rune{
 		'/':  '_',
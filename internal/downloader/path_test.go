@@ -2,12 +2,14 @@ package downloader
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestBuildMovieBasePath(t *testing.T) {
 	base := "/movies"
-	path := buildMovieBasePath(base, "The/Matrix", 1999)
+	path := buildMovieBasePath(base, "The/Matrix", 1999, DefaultMaxFilenameBytes)
 	expectedDir := "The_Matrix (1999)"
 	expected := filepath.Join(base, expectedDir, expectedDir)
 	if path != expected {
@@ -17,7 +19,7 @@ func TestBuildMovieBasePath(t *testing.T) {
 
 func TestBuildTVShowBasePath(t *testing.T) {
 	base := "/tvshows"
-	path := buildTVShowBasePath(base, "Breaking:Bad", 2008, 1, 2)
+	path := buildTVShowBasePath(base, "Breaking:Bad", 2008, 1, 2, DefaultMaxFilenameBytes)
 	expected := filepath.Join(base, "Breaking_Bad (2008)", "Season 01", "Breaking_Bad (2008) - S01E02")
 	if path != expected {
 		t.Fatalf("expected %s, got %s", expected, path)
@@ -31,3 +33,57 @@ func TestSanitizeFilename(t *testing.T) {
 		t.Fatalf("expected %s, got %s", expected, sanitized)
 	}
 }
+
+func TestBuildBasePaths_LongTitle(t *testing.T) {
+	title := strings.Repeat("Un très long titre: ", 20)
+	movie := buildMovieBasePath("/movies", title, 1999, DefaultMaxFilenameBytes)
+	episode := buildTVShowBasePath("/tvshows", title, 2008, 1, 2, DefaultMaxFilenameBytes)
+
+	for _, path := range []string{movie, episode} {
+		for _, component := range strings.Split(filepath.ToSlash(path), "/") {
+			if len(component) > DefaultMaxFilenameBytes {
+				t.Errorf("component of %d bytes in %q", len(component), path)
+			}
+			if !utf8.ValidString(component) {
+				t.Errorf("component %q is not valid UTF-8", component)
+			}
+		}
+	}
+
+	// Media file names leave room for their extension
+	for _, name := range []string{filepath.Base(movie), filepath.Base(episode)} {
+		if len(name)+len(".m3u8") > DefaultMaxFilenameBytes {
+			t.Errorf("file name of %d bytes leaves no room for the extension", len(name))
+		}
+	}
+	if !strings.HasSuffix(movie, "… (1999)") {
+		t.Errorf("expected the movie to keep its year, got %q", filepath.Base(movie))
+	}
+	if !strings.HasSuffix(episode, "… (2008) - S01E02") {
+		t.Errorf("expected the episode to keep its SxxExx marker, got %q", filepath.Base(episode))
+	}
+}
+
+func TestFitFilename(t *testing.T) {
+	tests := []struct {
+		title    string
+		suffix   string
+		maxBytes int
+		expected string
+	}{
+		{"Short", " (1999)", 255, "Short (1999)"},
+		{"Long Title Here", " (1999)", 16, "Long T… (1999)"},
+		{"Éééééé", " - S01E02", 18, "Ééé… - S01E02"},
+		{"Long Title Here", " (1999)", 0, "Long Title Here (1999)"},
+	}
+
+	for _, tt := range tests {
+		got := FitFilename(tt.title, tt.suffix, tt.maxBytes)
+		if got != tt.expected {
+			t.Errorf("FitFilename(%q, %q, %d) = %q, expected %q", tt.title, tt.suffix, tt.maxBytes, got, tt.expected)
+		}
+		if tt.maxBytes > 0 && len(got) > tt.maxBytes {
+			t.Errorf("FitFilename(%q, %q, %d) = %q is %d bytes long", tt.title, tt.suffix, tt.maxBytes, got, len(got))
+		}
+	}
+}
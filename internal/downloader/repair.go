@@ -0,0 +1,237 @@
+package downloader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// RepairOptions holds configuration for download record reconciliation
+type RepairOptions struct {
+	DryRun bool // Report the changes without applying them
+	Delete bool // Delete the orphaned downloads that cannot be linked back to a line
+
+	// Library roots and path limit used to find the line of a completed download from
+	// its download_path, see BaseDestPathForLine
+	MoviesPath       string
+	TVShowsPath      string
+	MaxFilenameBytes int
+}
+
+// RepairLink is a completed download linked back to its line
+type RepairLink struct {
+	DownloadID uint   `json:"download_id"`
+	LineID     uint   `json:"line_id"`
+	Path       string `json:"path"`
+}
+
+// RepairStats reports the result of a download record reconciliation
+type RepairStats struct {
+	Relinked []RepairLink `json:"relinked"`
+	Dangling []uint       `json:"dangling"` // Lines linked to a download that no longer exists, unlinked
+	Orphaned []uint       `json:"orphaned"` // Downloads no line can be linked to
+	Deleted  int          `json:"deleted"`  // Orphaned downloads deleted with RepairOptions.Delete
+}
+
+// RepairDownloads reconciles download records and the lines that point at them:
+//   - a line linked to a download that no longer exists is unlinked, and moved back
+//     to processed when it was reported as downloaded;
+//   - a completed download that no line points at is linked back to the line that
+//     started it when that line has no download, else to the unlinked line whose
+//     destination path matches its download_path, provided that file still exists;
+//     a download whose file is missing is orphaned instead;
+//   - the remaining downloads without any line are orphaned and deleted with Delete,
+//     unless they are locked by a running download.
+//
+// Older downloads of a line that moved on to a newer download are history, not
+// orphans, and are left untouched.
+func RepairDownloads(db *gorm.DB, opts RepairOptions) (*RepairStats, error) {
+	log := logger.AppLogger()
+	stats := &RepairStats{Relinked: []RepairLink{}, Dangling: []uint{}, Orphaned: []uint{}}
+
+	var dangling []uint
+	if err := db.Model(&models.ProcessedLine{}).
+		Where("download_info_id IS NOT NULL AND download_info_id NOT IN (?)", db.Model(&models.DownloadInfo{}).Select("id")).
+		Order("id").
+		Pluck("id", &dangling).Error; err != nil {
+		return nil, fmt.Errorf("failed to find lines linked to missing downloads: %w", err)
+	}
+	stats.Dangling = append(stats.Dangling, dangling...)
+
+	var linked []uint
+	if err := db.Model(&models.ProcessedLine{}).
+		Where("download_info_id IS NOT NULL").
+		Distinct().
+		Pluck("download_info_id", &linked).Error; err != nil {
+		return nil, fmt.Errorf("failed to load linked downloads: %w", err)
+	}
+	isLinked := make(map[uint]bool, len(linked))
+	for _, id := range linked {
+		isLinked[id] = true
+	}
+
+	// Lines without a download, including the dangling ones once unlinked
+	var lines []models.ProcessedLine
+	if err := db.Preload("Movie").Preload("TVShow").
+		Where("download_info_id IS NULL OR id IN ?", stats.Dangling).
+		Order("id").
+		Find(&lines).Error; err != nil {
+		return nil, fmt.Errorf("failed to load unlinked lines: %w", err)
+	}
+	unlinked := make(map[uint]bool, len(lines))
+	byPath := make(map[string]uint)
+	for i := range lines {
+		unlinked[lines[i].ID] = true
+		base, _, err := BaseDestPathForLine(opts.MoviesPath, opts.TVShowsPath, opts.MaxFilenameBytes, &lines[i])
+		if _, taken := byPath[base]; err == nil && !taken {
+			byPath[base] = lines[i].ID
+		}
+	}
+
+	var existingLines []uint
+	if err := db.Model(&models.ProcessedLine{}).Pluck("id", &existingLines).Error; err != nil {
+		return nil, fmt.Errorf("failed to load lines: %w", err)
+	}
+	lineExists := make(map[uint]bool, len(existingLines))
+	for _, id := range existingLines {
+		lineExists[id] = true
+	}
+
+	// The latest downloads first, so that a line is linked back to its newest file
+	var downloads []models.DownloadInfo
+	if err := db.Order("id DESC").Find(&downloads).Error; err != nil {
+		return nil, fmt.Errorf("failed to load downloads: %w", err)
+	}
+
+	var orphans []models.DownloadInfo
+	for _, download := range downloads {
+		if isLinked[download.ID] {
+			continue
+		}
+
+		started := download.ProcessedLineID != nil && lineExists[*download.ProcessedLineID]
+		completed := download.Status == string(models.DownloadStatusCompleted) &&
+			download.DownloadPath != nil && *download.DownloadPath != ""
+
+		lineID, ok := uint(0), false
+		if completed {
+			lineID, ok = repairLineFor(download, started, unlinked, byPath)
+		}
+		if ok && !fileOnDisk(*download.DownloadPath) {
+			// Linking a line to a file that is gone would report it as downloaded
+			log.Warn(fmt.Sprintf("Download %d file is missing: %s", download.ID, *download.DownloadPath))
+			orphans = append(orphans, download)
+			stats.Orphaned = append(stats.Orphaned, download.ID)
+			continue
+		}
+		if ok {
+			unlinked[lineID] = false
+			stats.Relinked = append(stats.Relinked, RepairLink{DownloadID: download.ID, LineID: lineID, Path: *download.DownloadPath})
+			continue
+		}
+
+		// A download started by a line that exists is part of the history of that line
+		if !started {
+			orphans = append(orphans, download)
+			stats.Orphaned = append(stats.Orphaned, download.ID)
+		}
+	}
+
+	if opts.DryRun {
+		for _, id := range stats.Dangling {
+			log.Info(fmt.Sprintf("[DRY RUN] Line %d linked to a missing download", id))
+		}
+		for _, link := range stats.Relinked {
+			log.Info(fmt.Sprintf("[DRY RUN] Download %d would be linked to line %d: %s", link.DownloadID, link.LineID, link.Path))
+		}
+		for _, id := range stats.Orphaned {
+			log.Info(fmt.Sprintf("[DRY RUN] Orphaned download %d", id))
+		}
+		return stats, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if len(stats.Dangling) > 0 {
+			if err := unlinkLines(tx, stats.Dangling); err != nil {
+				return err
+			}
+		}
+
+		for _, link := range stats.Relinked {
+			if err := tx.Model(&models.ProcessedLine{}).
+				Where("id = ?", link.LineID).
+				Updates(map[string]interface{}{
+					"download_info_id": link.DownloadID,
+					"state":            models.StateDownloaded,
+				}).Error; err != nil {
+				return fmt.Errorf("failed to link download %d to line %d: %w", link.DownloadID, link.LineID, err)
+			}
+			if err := tx.Model(&models.DownloadInfo{}).
+				Where("id = ? AND processed_line_id IS NULL", link.DownloadID).
+				Update("processed_line_id", link.LineID).Error; err != nil {
+				return fmt.Errorf("failed to link download %d to line %d: %w", link.DownloadID, link.LineID, err)
+			}
+		}
+
+		if !opts.Delete {
+			return nil
+		}
+		for _, download := range orphans {
+			if download.LockedAt != nil {
+				log.Warn(fmt.Sprintf("Orphaned download %d is locked, not deleted", download.ID))
+				continue
+			}
+			if err := tx.Delete(&models.DownloadInfo{}, download.ID).Error; err != nil {
+				return fmt.Errorf("failed to delete download %d: %w", download.ID, err)
+			}
+			stats.Deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	log.Info(fmt.Sprintf("Download repair complete: %d dangling, %d relinked, %d orphaned, %d deleted",
+		len(stats.Dangling), len(stats.Relinked), len(stats.Orphaned), stats.Deleted))
+	return stats, nil
+}
+
+// repairLineFor returns the unlinked line a completed download belongs to: the line
+// that started it, else the line whose destination path matches its download_path
+func repairLineFor(download models.DownloadInfo, started bool, unlinked map[uint]bool, byPath map[string]uint) (uint, bool) {
+	if started {
+		id := *download.ProcessedLineID
+		return id, unlinked[id]
+	}
+
+	path := *download.DownloadPath
+	id, ok := byPath[strings.TrimSuffix(path, filepath.Ext(path))]
+	return id, ok && unlinked[id]
+}
+
+// fileOnDisk reports whether path is an existing regular file
+func fileOnDisk(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// unlinkLines clears the download of lineIDs, moving the downloaded ones back to processed
+func unlinkLines(tx *gorm.DB, lineIDs []uint) error {
+	if err := tx.Model(&models.ProcessedLine{}).
+		Where("id IN ? AND state = ?", lineIDs, models.StateDownloaded).
+		Update("state", models.StateProcessed).Error; err != nil {
+		return fmt.Errorf("failed to reset unlinked lines: %w", err)
+	}
+	if err := tx.Model(&models.ProcessedLine{}).
+		Where("id IN ?", lineIDs).
+		Update("download_info_id", nil).Error; err != nil {
+		return fmt.Errorf("failed to unlink lines: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type repairFixture struct {
+	db          *gorm.DB
+	opts        RepairOptions
+	matrix      models.ProcessedLine // Completed download exists, the line lost its link
+	matrixDL    models.DownloadInfo
+	orphan      models.DownloadInfo // Failed download of a line that no longer exists
+	dangling    models.ProcessedLine
+	history     models.DownloadInfo // Older download of a line downloaded again
+	historyLine models.ProcessedLine
+}
+
+// setupRepairFixture records a completed download whose line is missing its link,
+// an orphaned download, a line linked to a deleted download and the older download
+// of a line that was downloaded again
+func setupRepairFixture(t *testing.T) *repairFixture {
+	t.Helper()
+
+	db := openTestDB(t)
+	f := &repairFixture{db: db, opts: RepairOptions{MoviesPath: t.TempDir(), TVShowsPath: t.TempDir()}}
+
+	// The download of The Matrix completed, but its line was never linked to it
+	matrix := testutil.CreateMovie(db, func(m *models.Movie) {
+		m.TMDBID, m.TMDBTitle, m.TMDBYear = 603, "The Matrix", 1999
+	})
+	f.matrix = *testutil.CreateProcessedLine(db, testutil.WithTvgName("The Matrix (1999)"), testutil.WithMovieID(matrix.ID))
+	base, _, err := BaseDestPathForLine(f.opts.MoviesPath, f.opts.TVShowsPath, 0, &models.ProcessedLine{
+		ContentType: models.ContentTypeMovies,
+		Movie:       &models.Movie{TMDBTitle: "The Matrix", TMDBYear: 1999},
+	})
+	require.NoError(t, err)
+	matrixPath := base + ".mkv"
+	require.NoError(t, os.MkdirAll(filepath.Dir(matrixPath), 0755))
+	require.NoError(t, os.WriteFile(matrixPath, []byte("movie"), 0644))
+	f.matrixDL = models.DownloadInfo{Status: string(models.DownloadStatusCompleted), DownloadPath: &matrixPath}
+	require.NoError(t, db.Create(&f.matrixDL).Error)
+
+	missingLine := uint(9999)
+	f.orphan = models.DownloadInfo{Status: string(models.DownloadStatusFailed), ProcessedLineID: &missingLine}
+	require.NoError(t, db.Create(&f.orphan).Error)
+
+	deletedDownload := uint(8888)
+	f.dangling = *testutil.CreateProcessedLine(db, testutil.WithTvgName("Dangling"), testutil.WithDownloadInfoID(deletedDownload), testutil.WithState(models.StateDownloaded))
+
+	historyPath := filepath.Join(f.opts.MoviesPath, "Old", "Old.mkv")
+	f.history = models.DownloadInfo{Status: string(models.DownloadStatusCompleted), DownloadPath: &historyPath}
+	require.NoError(t, db.Create(&f.history).Error)
+	current := models.DownloadInfo{Status: string(models.DownloadStatusCompleted), DownloadPath: &historyPath}
+	require.NoError(t, db.Create(&current).Error)
+	f.historyLine = *testutil.CreateProcessedLine(db, testutil.WithTvgName("Downloaded Twice"), testutil.WithDownloadInfoID(current.ID), testutil.WithState(models.StateDownloaded))
+	require.NoError(t, db.Model(&f.history).Update("processed_line_id", f.historyLine.ID).Error)
+	require.NoError(t, db.Model(&current).Update("processed_line_id", f.historyLine.ID).Error)
+
+	return f
+}
+
+func TestRepairDownloads(t *testing.T) {
+	f := setupRepairFixture(t)
+
+	opts := f.opts
+	opts.Delete = true
+	stats, err := RepairDownloads(f.db, opts)
+	require.NoError(t, err)
+
+	require.Len(t, stats.Relinked, 1)
+	assert.Equal(t, RepairLink{DownloadID: f.matrixDL.ID, LineID: f.matrix.ID, Path: *f.matrixDL.DownloadPath}, stats.Relinked[0])
+	assert.Equal(t, []uint{f.dangling.ID}, stats.Dangling)
+	assert.Equal(t, []uint{f.orphan.ID}, stats.Orphaned)
+	assert.Equal(t, 1, stats.Deleted)
+
+	var matrix models.ProcessedLine
+	require.NoError(t, f.db.First(&matrix, f.matrix.ID).Error)
+	require.NotNil(t, matrix.DownloadInfoID)
+	assert.Equal(t, f.matrixDL.ID, *matrix.DownloadInfoID)
+	assert.Equal(t, models.StateDownloaded, matrix.State)
+
+	var matrixDL models.DownloadInfo
+	require.NoError(t, f.db.First(&matrixDL, f.matrixDL.ID).Error)
+	require.NotNil(t, matrixDL.ProcessedLineID)
+	assert.Equal(t, f.matrix.ID, *matrixDL.ProcessedLineID)
+
+	var dangling models.ProcessedLine
+	require.NoError(t, f.db.First(&dangling, f.dangling.ID).Error)
+	assert.Nil(t, dangling.DownloadInfoID)
+	assert.Equal(t, models.StateProcessed, dangling.State)
+
+	var count int64
+	require.NoError(t, f.db.Model(&models.DownloadInfo{}).Where("id = ?", f.orphan.ID).Count(&count).Error)
+	assert.Zero(t, count, "the orphaned download is deleted")
+	require.NoError(t, f.db.Model(&models.DownloadInfo{}).Where("id = ?", f.history.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "the older download of a line is kept")
+
+	// A repaired database has nothing left to repair
+	stats, err = RepairDownloads(f.db, opts)
+	require.NoError(t, err)
+	assert.Empty(t, stats.Relinked)
+	assert.Empty(t, stats.Dangling)
+	assert.Empty(t, stats.Orphaned)
+}
+
+func TestRepairDownloads_DryRun(t *testing.T) {
+	f := setupRepairFixture(t)
+
+	opts := f.opts
+	opts.DryRun = true
+	opts.Delete = true
+	stats, err := RepairDownloads(f.db, opts)
+	require.NoError(t, err)
+	assert.Len(t, stats.Relinked, 1)
+	assert.Len(t, stats.Dangling, 1)
+	assert.Len(t, stats.Orphaned, 1)
+	assert.Zero(t, stats.Deleted)
+
+	var matrix models.ProcessedLine
+	require.NoError(t, f.db.First(&matrix, f.matrix.ID).Error)
+	assert.Nil(t, matrix.DownloadInfoID, "a dry run links nothing")
+
+	var dangling models.ProcessedLine
+	require.NoError(t, f.db.First(&dangling, f.dangling.ID).Error)
+	assert.NotNil(t, dangling.DownloadInfoID, "a dry run unlinks nothing")
+
+	var count int64
+	require.NoError(t, f.db.Model(&models.DownloadInfo{}).Count(&count).Error)
+	assert.Equal(t, int64(4), count, "a dry run deletes nothing")
+}
+
+func TestRepairDownloads_KeepsOrphansWithoutDelete(t *testing.T) {
+	f := setupRepairFixture(t)
+
+	stats, err := RepairDownloads(f.db, f.opts)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{f.orphan.ID}, stats.Orphaned)
+	assert.Zero(t, stats.Deleted)
+
+	var count int64
+	require.NoError(t, f.db.Model(&models.DownloadInfo{}).Where("id = ?", f.orphan.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestRepairDownloads_OrphansMissingFiles(t *testing.T) {
+	f := setupRepairFixture(t)
+	require.NoError(t, os.Remove(*f.matrixDL.DownloadPath))
+
+	opts := f.opts
+	opts.Delete = true
+	stats, err := RepairDownloads(f.db, opts)
+	require.NoError(t, err)
+	assert.Empty(t, stats.Relinked)
+	assert.ElementsMatch(t, []uint{f.orphan.ID, f.matrixDL.ID}, stats.Orphaned)
+	assert.Equal(t, 2, stats.Deleted)
+
+	var matrix models.ProcessedLine
+	require.NoError(t, f.db.First(&matrix, f.matrix.ID).Error)
+	assert.Nil(t, matrix.DownloadInfoID, "a line is not linked to a missing file")
+	assert.Equal(t, models.StateProcessed, matrix.State)
+}
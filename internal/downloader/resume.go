@@ -2,13 +2,16 @@ package downloader
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	apperrors "github.com/glefebvre/stalkeer/internal/apperrors"
+	"github.com/glefebvre/stalkeer/internal/httpclient"
 	"github.com/glefebvre/stalkeer/internal/logger"
 	"github.com/glefebvre/stalkeer/internal/models"
 )
@@ -32,9 +35,7 @@ func (rs *ResumeSupport) CheckServerSupport(ctx context.Context, url string) (bo
 		return false, fmt.Errorf("failed to create HEAD request: %w", err)
 	}
 
-	client := &http.Client{
-		Timeout: 10 * 1000000000, // 10 seconds
-	}
+	client := httpclient.New(httpclient.ServiceDownloads, 10*time.Second)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -121,8 +122,19 @@ func (rs *ResumeSupport) BuildResumeRequest(ctx context.Context, url string, sta
 	return req, nil
 }
 
-// HandleResumeResponse processes HTTP response for resumed download
-func (rs *ResumeSupport) HandleResumeResponse(resp *http.Response, expectedStartByte int64) error {
+// ErrAlreadyComplete is returned by HandleResumeResponse when the server rejected the
+// range because the partial file already holds the whole stream
+var ErrAlreadyComplete = errors.New("download already complete")
+
+// HandleResumeResponse processes HTTP response for resumed download. A 416 Range Not
+// Satisfiable response yields ErrAlreadyComplete when the partial file at partialPath
+// has the total size reported by the server, and a validation error restarting the
+// download otherwise.
+func (rs *ResumeSupport) HandleResumeResponse(resp *http.Response, expectedStartByte int64, partialPath string) error {
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return rs.handleRangeNotSatisfiable(resp, partialPath)
+	}
+
 	// Check status code
 	// 206 Partial Content = server honors range request
 	// 200 OK = server ignores range request (full download)
@@ -163,6 +175,40 @@ func (rs *ResumeSupport) HandleResumeResponse(resp *http.Response, expectedStart
 	return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 }
 
+// handleRangeNotSatisfiable checks a partial file against the total of a 416
+// response, whose Content-Range is "bytes */TOTAL"
+func (rs *ResumeSupport) handleRangeNotSatisfiable(resp *http.Response, partialPath string) error {
+	log := logger.AppLogger()
+
+	var total int64
+	if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes */%d", &total); err != nil {
+		log.WithFields(map[string]interface{}{
+			"content_range": resp.Header.Get("Content-Range"),
+		}).Warn("range not satisfiable without a total size, restarting download")
+		return apperrors.ValidationError("range not satisfiable and total size unknown")
+	}
+
+	info, err := os.Stat(partialPath)
+	if err != nil {
+		return apperrors.ValidationError(fmt.Sprintf("range not satisfiable and partial file unreadable: %v", err))
+	}
+
+	if info.Size() != total {
+		log.WithFields(map[string]interface{}{
+			"path":        partialPath,
+			"file_bytes":  info.Size(),
+			"total_bytes": total,
+		}).Warn("range not satisfiable and partial file size differs from total, restarting download")
+		return apperrors.ValidationError("partial file size does not match total size")
+	}
+
+	log.WithFields(map[string]interface{}{
+		"path":        partialPath,
+		"total_bytes": total,
+	}).Info("range not satisfiable, partial file is already complete")
+	return ErrAlreadyComplete
+}
+
 // ShouldAttemptResume determines if we should try to resume a download
 func (rs *ResumeSupport) ShouldAttemptResume(download *models.DownloadInfo, partialPath string) bool {
 	// Must have partial download info
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/database"
 	"github.com/glefebvre/stalkeer/internal/logger"
 	"github.com/glefebvre/stalkeer/internal/models"
 )
@@ -32,12 +33,14 @@ func (rs *ResumeStats) Duration() time.Duration {
 
 // ResumeOptions holds options for resuming downloads
 type ResumeOptions struct {
-	MaxRetries  int
-	Limit       int
-	Parallel    int
-	DryRun      bool
-	ContentType *string // Filter by content type (movies, tvshows)
-	Verbose     bool
+	MaxRetries       int
+	MinRetryInterval time.Duration // Backoff after the first retry, doubled with each retry (0 = retry immediately)
+	Limit            int
+	Parallel         int
+	DryRun           bool
+	ContentType      *string // Filter by content type (movies, tvshows)
+	DownloadID       uint    // Only resume this download (0 = all)
+	Verbose          bool
 }
 
 // ResumeHelper provides shared functionality for resuming downloads
@@ -64,6 +67,16 @@ func (rh *ResumeHelper) GetIncompleteDownloads(ctx context.Context, opts ResumeO
 		return nil, err
 	}
 
+	if opts.DownloadID != 0 {
+		var filtered []models.DownloadInfo
+		for _, download := range downloads {
+			if download.ID == opts.DownloadID {
+				filtered = append(filtered, download)
+			}
+		}
+		downloads = filtered
+	}
+
 	// Filter by content type if specified
 	if opts.ContentType != nil {
 		normalized := normalizeContentType(*opts.ContentType)
@@ -78,6 +91,24 @@ func (rh *ResumeHelper) GetIncompleteDownloads(ctx context.Context, opts ResumeO
 		}
 	}
 
+	// Skip failing downloads whose retry backoff has not elapsed yet
+	if opts.MinRetryInterval > 0 {
+		now := time.Now()
+		var due []models.DownloadInfo
+		for _, download := range downloads {
+			if rh.stateManager.IsDueForRetry(&download, opts.MinRetryInterval, now) {
+				due = append(due, download)
+			} else if opts.Verbose {
+				log.WithFields(map[string]interface{}{
+					"download_id": download.ID,
+					"retry_count": download.RetryCount,
+					"retry_after": download.LastRetryAt.Add(RetryBackoff(download.RetryCount, opts.MinRetryInterval)),
+				}).Info("skipping download in retry backoff")
+			}
+		}
+		downloads = due
+	}
+
 	if opts.Verbose {
 		log.WithFields(map[string]interface{}{
 			"count":       len(downloads),
@@ -134,7 +165,7 @@ func (rh *ResumeHelper) ResumeDownloads(ctx context.Context, opts ResumeOptions)
 		return stats, nil
 	}
 
-	jobs, jobInfo, skipped := rh.buildDownloadJobs(downloads, cfg, opts)
+	jobs, jobInfo, skipped := rh.buildDownloadJobs(ctx, downloads, cfg, opts)
 	stats.Skipped += skipped
 	if len(jobs) == 0 {
 		log.Info("no eligible downloads to resume")
@@ -150,6 +181,7 @@ func (rh *ResumeHelper) ResumeDownloads(ctx context.Context, opts ResumeOptions)
 	}).Info("starting resume downloads")
 
 	parallelDownloader := NewParallelWithDownloader(rh.downloader, parallel)
+	parallelDownloader.SetHostLimits(cfg.Downloads.HostLimits())
 	results := parallelDownloader.DownloadBatch(ctx, jobs)
 
 	for result := range results {
@@ -190,7 +222,7 @@ type resumeJobInfo struct {
 	displayName string
 }
 
-func (rh *ResumeHelper) buildDownloadJobs(downloads []models.DownloadInfo, cfg *config.Config, opts ResumeOptions) ([]DownloadJob, map[int]resumeJobInfo, int) {
+func (rh *ResumeHelper) buildDownloadJobs(ctx context.Context, downloads []models.DownloadInfo, cfg *config.Config, opts ResumeOptions) ([]DownloadJob, map[int]resumeJobInfo, int) {
 	log := logger.AppLogger()
 	jobs := make([]DownloadJob, 0, len(downloads))
 	jobInfo := make(map[int]resumeJobInfo, len(downloads))
@@ -234,16 +266,44 @@ func (rh *ResumeHelper) buildDownloadJobs(downloads []models.DownloadInfo, cfg *
 			continue
 		}
 
+		// A resumed first part rejoins the following parts of its multi-part stream
+		var partURLs []string
+		if cfg.Downloads.JoinParts {
+			if db := database.Get(); db != nil {
+				partURLs, err = FollowingPartURLs(db, processedLine)
+				if err != nil {
+					if opts.Verbose {
+						log.WithFields(map[string]interface{}{
+							"download_id": download.ID,
+							"line_id":     processedLine.ID,
+							"error":       err,
+						}).Warn("skipping download due to multi-part lookup error")
+					}
+					skipped++
+					continue
+				}
+			}
+		}
+
+		// Resuming a failed download counts as a retry, so that it backs off if it fails again
+		if download.Status == string(models.DownloadStatusFailed) || download.Status == string(models.DownloadStatusRetrying) {
+			if err := rh.stateManager.UpdateState(ctx, download.ID, models.DownloadStatusRetrying, nil); err != nil {
+				log.WithFields(map[string]interface{}{
+					"download_id": download.ID,
+					"error":       err,
+				}).Warn("failed to record resume attempt")
+			}
+		}
+
+		options := OptionsFromConfig(cfg, processedLine)
+		options.BaseDestPath = baseDestPath
+		options.PartURLs = partURLs
+		options.OnProgress = rh.buildProgressLogger(download.ID, displayName, opts.Verbose)
+
 		jobID := len(jobs) + 1
 		jobs = append(jobs, DownloadJob{
-			ID: jobID,
-			Options: DownloadOptions{
-				URL:             *processedLine.LineURL,
-				BaseDestPath:    baseDestPath,
-				TempDir:         cfg.Downloads.TempDir,
-				ProcessedLineID: processedLine.ID,
-				OnProgress:      rh.buildProgressLogger(download.ID, displayName, opts.Verbose),
-			},
+			ID:      jobID,
+			Options: options,
 		})
 		jobInfo[jobID] = resumeJobInfo{
 			downloadID:  download.ID,
@@ -255,18 +315,8 @@ func (rh *ResumeHelper) buildDownloadJobs(downloads []models.DownloadInfo, cfg *
 }
 
 func (rh *ResumeHelper) buildBaseDestPath(cfg *config.Config, line *models.ProcessedLine, download *models.DownloadInfo) (string, string, error) {
-	if line.ContentType == models.ContentTypeMovies {
-		if line.Movie != nil {
-			path := buildMovieBasePath(cfg.Downloads.MoviesPath, line.Movie.TMDBTitle, line.Movie.TMDBYear)
-			return path, fmt.Sprintf("%s (%d)", line.Movie.TMDBTitle, line.Movie.TMDBYear), nil
-		}
-	}
-
-	if line.ContentType == models.ContentTypeTVShows {
-		if line.TVShow != nil && line.TVShow.Season != nil && line.TVShow.Episode != nil {
-			path := buildTVShowBasePath(cfg.Downloads.TVShowsPath, line.TVShow.TMDBTitle, line.TVShow.TMDBYear, *line.TVShow.Season, *line.TVShow.Episode)
-			return path, fmt.Sprintf("%s (%d) - S%02dE%02d", line.TVShow.TMDBTitle, line.TVShow.TMDBYear, *line.TVShow.Season, *line.TVShow.Episode), nil
-		}
+	if path, displayName, err := BaseDestPathForLine(cfg.Downloads.MoviesPath, cfg.Downloads.TVShowsPath, cfg.Downloads.MaxFilenameBytes, line); err == nil {
+		return path, displayName, nil
 	}
 
 	if download.DownloadPath != nil && *download.DownloadPath != "" {
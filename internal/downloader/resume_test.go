@@ -0,0 +1,123 @@
+package downloader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rangeServer serves content, answering 416 to ranges starting at or past its end
+func rangeServer(t *testing.T, content string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var start int
+		if r.Header.Get("Range") != "" {
+			_, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start)
+			require.NoError(t, err)
+		}
+		if start >= len(content) {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(content)))
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if start > 0 {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		w.Write([]byte(content[start:]))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloadFileWithResume_RangeNotSatisfiable(t *testing.T) {
+	const content = "complete video data"
+
+	tests := []struct {
+		name      string
+		onDisk    string
+		startByte int64
+	}{
+		{"file already complete", content, int64(len(content))},
+		{"offset past the end of a complete file", content, int64(len(content)) + 10},
+		{"offset past the end of a corrupt file", strings.Repeat("x", len(content)+5), int64(len(content)) + 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rangeServer(t, content)
+			destPath := filepath.Join(t.TempDir(), "movie.partial")
+			require.NoError(t, os.WriteFile(destPath, []byte(tt.onDisk), 0644))
+
+			d := New(5*time.Second, 1)
+			result, _, err := d.downloadFileWithResume(context.Background(), server.URL+"/movie.mkv", StreamAuth{}, nil, destPath, tt.startByte, 0, nil, nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(len(content)), result.FileSize)
+			data, err := os.ReadFile(destPath)
+			require.NoError(t, err)
+			assert.Equal(t, content, string(data))
+		})
+	}
+}
+
+func TestDownloadFileWithResume_HashesWholeFile(t *testing.T) {
+	const content = "complete video data"
+	want := sha256.Sum256([]byte(content))
+
+	tests := []struct {
+		name      string
+		startByte int64
+	}{
+		{"resumed transfer", 8},
+		{"file already complete", int64(len(content))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := rangeServer(t, content)
+			destPath := filepath.Join(t.TempDir(), "movie.partial")
+			require.NoError(t, os.WriteFile(destPath, []byte(content[:tt.startByte]), 0644))
+
+			hasher := sha256.New()
+			d := New(5*time.Second, 1)
+			_, _, err := d.downloadFileWithResume(context.Background(), server.URL+"/movie.mkv", StreamAuth{}, nil, destPath, tt.startByte, 0, hasher, nil)
+			require.NoError(t, err)
+			assert.Equal(t, hex.EncodeToString(want[:]), hex.EncodeToString(hasher.Sum(nil)))
+		})
+	}
+}
+
+func TestHandleResumeResponse_RangeNotSatisfiable(t *testing.T) {
+	partialPath := filepath.Join(t.TempDir(), "movie.partial")
+	require.NoError(t, os.WriteFile(partialPath, []byte("0123456789"), 0644))
+	rs := NewResumeSupport(nil)
+
+	resp := &http.Response{
+		StatusCode: http.StatusRequestedRangeNotSatisfiable,
+		Header:     http.Header{"Content-Range": []string{"bytes */10"}},
+	}
+	assert.ErrorIs(t, rs.HandleResumeResponse(resp, 10, partialPath), ErrAlreadyComplete)
+
+	resp.Header.Set("Content-Range", "bytes */20")
+	err := rs.HandleResumeResponse(resp, 10, partialPath)
+	assert.NotErrorIs(t, err, ErrAlreadyComplete)
+	assert.Error(t, err)
+
+	resp.Header.Del("Content-Range")
+	err = rs.HandleResumeResponse(resp, 10, partialPath)
+	assert.NotErrorIs(t, err, ErrAlreadyComplete)
+	assert.Error(t, err)
+}
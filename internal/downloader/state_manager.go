@@ -164,13 +164,18 @@ func (sm *StateManager) UpdateState(ctx context.Context, downloadID uint, newSta
 	switch newStatus {
 	case models.DownloadStatusDownloading:
 		updates["started_at"] = now
+		// Measure the speed from the start of this attempt
+		updates["progress_updated_at"] = now
+		updates["speed_bps"] = nil
 	case models.DownloadStatusCompleted:
 		updates["completed_at"] = now
+		updates["speed_bps"] = nil
 		// Release lock on completion
 		updates["locked_at"] = nil
 		updates["locked_by"] = nil
 	case models.DownloadStatusFailed:
 		updates["completed_at"] = now
+		updates["speed_bps"] = nil
 		// Release lock on failure
 		updates["locked_at"] = nil
 		updates["locked_by"] = nil
@@ -199,11 +204,56 @@ func (sm *StateManager) UpdateState(ctx context.Context, downloadID uint, newSta
 	return nil
 }
 
-// UpdateProgress updates download progress (bytes downloaded)
+// ResetForRetry moves a failed download back to pending so that it can be resumed,
+// clearing its error. The manual retry counts towards the retry limit.
+func (sm *StateManager) ResetForRetry(ctx context.Context, downloadID uint) error {
+	result := sm.db.WithContext(ctx).
+		Model(&models.DownloadInfo{}).
+		Where("id = ? AND status = ?", downloadID, string(models.DownloadStatusFailed)).
+		Updates(map[string]interface{}{
+			"status":        string(models.DownloadStatusPending),
+			"error_message": nil,
+			"completed_at":  nil,
+			"locked_at":     nil,
+			"locked_by":     nil,
+			"retry_count":   gorm.Expr("retry_count + 1"),
+			"last_retry_at": time.Now(),
+		})
+
+	if result.Error != nil {
+		return apperrors.Wrap(result.Error, apperrors.CodeInternal, "failed to reset download for retry")
+	}
+
+	if result.RowsAffected == 0 {
+		return apperrors.ValidationError("download is not in failed state")
+	}
+
+	logger.AppLogger().WithFields(map[string]interface{}{
+		"download_id": downloadID,
+	}).Debug("reset download for retry")
+
+	return nil
+}
+
+// UpdateProgress updates download progress (bytes downloaded) and the download
+// speed measured since the previous update
 func (sm *StateManager) UpdateProgress(ctx context.Context, downloadID uint, bytesDownloaded, totalBytes int64) error {
+	now := time.Now()
 	updates := map[string]interface{}{
-		"bytes_downloaded": bytesDownloaded,
-		"total_bytes":      totalBytes,
+		"bytes_downloaded":    bytesDownloaded,
+		"total_bytes":         totalBytes,
+		"progress_updated_at": now,
+	}
+
+	var previous models.DownloadInfo
+	err := sm.db.WithContext(ctx).
+		Select("bytes_downloaded", "progress_updated_at").
+		Where("id = ?", downloadID).
+		Take(&previous).Error
+	if err == nil {
+		if speed, ok := progressSpeed(previous.BytesDownloaded, previous.ProgressUpdatedAt, bytesDownloaded, now); ok {
+			updates["speed_bps"] = speed
+		}
 	}
 
 	result := sm.db.WithContext(ctx).
@@ -224,6 +274,20 @@ func (sm *StateManager) UpdateProgress(ctx context.Context, downloadID uint, byt
 	return nil
 }
 
+// progressSpeed returns the speed in bytes per second between the previous progress
+// update and now. It reports false when there is no previous update or the download
+// restarted from a lower offset.
+func progressSpeed(previousBytes *int64, previousAt *time.Time, bytesDownloaded int64, now time.Time) (int64, bool) {
+	if previousBytes == nil || previousAt == nil || bytesDownloaded < *previousBytes {
+		return 0, false
+	}
+	elapsed := now.Sub(*previousAt)
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return int64(float64(bytesDownloaded-*previousBytes) / elapsed.Seconds()), true
+}
+
 // ShouldPersistProgress determines if progress should be persisted based on interval
 func (sm *StateManager) ShouldPersistProgress(bytesSinceLastPersist int64, timeSinceLastPersist time.Duration) bool {
 	return bytesSinceLastPersist >= sm.progressInterval.bytes ||
@@ -258,8 +322,9 @@ func (sm *StateManager) GetIncompleteDownloads(ctx context.Context, maxRetries i
 	cutoffTime := time.Now().Add(-sm.lockTimeout)
 	query = query.Where("locked_at IS NULL OR locked_at < ?", cutoffTime)
 
-	// Order by priority: failed recently, then oldest first
-	query = query.Order("CASE WHEN status = 'failed' THEN 0 ELSE 1 END").
+	// Order by priority: highest priority, then failed recently, then oldest first
+	query = query.Order("priority DESC").
+		Order("CASE WHEN status = 'failed' THEN 0 ELSE 1 END").
 		Order("updated_at ASC")
 
 	// Apply limit if specified
@@ -279,6 +344,36 @@ func (sm *StateManager) GetIncompleteDownloads(ctx context.Context, maxRetries i
 	return downloads, nil
 }
 
+// maxRetryBackoff caps the delay between two resume attempts of a failing download
+const maxRetryBackoff = 24 * time.Hour
+
+// RetryBackoff returns the delay to wait after the last retry of a download that
+// already failed retryCount times. The delay starts at minInterval and doubles
+// with each retry, up to maxRetryBackoff. A zero minInterval disables the backoff.
+func RetryBackoff(retryCount int, minInterval time.Duration) time.Duration {
+	if retryCount <= 0 || minInterval <= 0 {
+		return 0
+	}
+
+	backoff := minInterval
+	for i := 1; i < retryCount && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return backoff
+}
+
+// IsDueForRetry reports whether the backoff of a previously retried download has
+// elapsed at now. Downloads that were never retried are always due.
+func (sm *StateManager) IsDueForRetry(download *models.DownloadInfo, minInterval time.Duration, now time.Time) bool {
+	if download.LastRetryAt == nil {
+		return true
+	}
+	return !now.Before(download.LastRetryAt.Add(RetryBackoff(download.RetryCount, minInterval)))
+}
+
 // GetDownloadByID retrieves a download record by ID
 func (sm *StateManager) GetDownloadByID(ctx context.Context, downloadID uint) (*models.DownloadInfo, error) {
 	var download models.DownloadInfo
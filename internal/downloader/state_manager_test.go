@@ -0,0 +1,169 @@
+package downloader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name        string
+		retryCount  int
+		minInterval time.Duration
+		expected    time.Duration
+	}{
+		{"never retried", 0, 5 * time.Minute, 0},
+		{"first retry", 1, 5 * time.Minute, 5 * time.Minute},
+		{"second retry doubles", 2, 5 * time.Minute, 10 * time.Minute},
+		{"fifth retry", 5, 5 * time.Minute, 80 * time.Minute},
+		{"capped", 20, 5 * time.Minute, maxRetryBackoff},
+		{"interval above cap", 1, 48 * time.Hour, maxRetryBackoff},
+		{"backoff disabled", 3, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RetryBackoff(tt.retryCount, tt.minInterval))
+		})
+	}
+}
+
+func TestIsDueForRetry(t *testing.T) {
+	sm := &StateManager{lockTimeout: 5 * time.Minute}
+	now := time.Now()
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name     string
+		download models.DownloadInfo
+		expected bool
+	}{
+		{"never retried", models.DownloadInfo{}, true},
+		{"first retry within backoff", models.DownloadInfo{RetryCount: 1, LastRetryAt: ago(time.Minute)}, false},
+		{"first retry after backoff", models.DownloadInfo{RetryCount: 1, LastRetryAt: ago(6 * time.Minute)}, true},
+		{"third retry within doubled backoff", models.DownloadInfo{RetryCount: 3, LastRetryAt: ago(15 * time.Minute)}, false},
+		{"third retry after doubled backoff", models.DownloadInfo{RetryCount: 3, LastRetryAt: ago(21 * time.Minute)}, true},
+		{"exactly at the backoff boundary", models.DownloadInfo{RetryCount: 1, LastRetryAt: ago(5 * time.Minute)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sm.IsDueForRetry(&tt.download, 5*time.Minute, now))
+		})
+	}
+}
+
+func TestResumeHelper_SkipsDownloadsInBackoff(t *testing.T) {
+	db := openTestDB(t)
+
+	recent := time.Now().Add(-time.Minute)
+	old := time.Now().Add(-2 * time.Hour)
+	downloads := []models.DownloadInfo{
+		{URL: "http://example.com/fresh", Status: string(models.DownloadStatusFailed)},
+		{URL: "http://example.com/backing-off", Status: string(models.DownloadStatusFailed), RetryCount: 2, LastRetryAt: &recent},
+		{URL: "http://example.com/due", Status: string(models.DownloadStatusFailed), RetryCount: 2, LastRetryAt: &old},
+	}
+	require.NoError(t, db.Create(&downloads).Error)
+
+	sm := &StateManager{db: db, lockTimeout: 5 * time.Minute}
+	helper := NewResumeHelper(sm, nil)
+
+	due, err := helper.GetIncompleteDownloads(context.Background(), ResumeOptions{MinRetryInterval: 5 * time.Minute})
+	require.NoError(t, err)
+
+	urls := make([]string, 0, len(due))
+	for _, download := range due {
+		urls = append(urls, download.URL)
+	}
+	assert.ElementsMatch(t, []string{"http://example.com/fresh", "http://example.com/due"}, urls)
+
+	all, err := helper.GetIncompleteDownloads(context.Background(), ResumeOptions{})
+	require.NoError(t, err)
+	assert.Len(t, all, 3, "a zero interval disables the backoff")
+}
+
+func TestGetIncompleteDownloads_OrdersByPriority(t *testing.T) {
+	db := openTestDB(t)
+
+	old := time.Now().Add(-2 * time.Hour)
+	downloads := []models.DownloadInfo{
+		{URL: "http://example.com/failed", Status: string(models.DownloadStatusFailed), UpdatedAt: old},
+		{URL: "http://example.com/pending", Status: string(models.DownloadStatusPending), UpdatedAt: old},
+		{URL: "http://example.com/bumped", Status: string(models.DownloadStatusPending), Priority: 10},
+		{URL: "http://example.com/lowered", Status: string(models.DownloadStatusFailed), Priority: -1},
+		{URL: "http://example.com/urgent", Status: string(models.DownloadStatusPaused), Priority: 20},
+	}
+	require.NoError(t, db.Create(&downloads).Error)
+
+	sm := &StateManager{db: db, lockTimeout: 5 * time.Minute}
+	incomplete, err := sm.GetIncompleteDownloads(context.Background(), 0, 0)
+	require.NoError(t, err)
+
+	urls := make([]string, 0, len(incomplete))
+	for _, download := range incomplete {
+		urls = append(urls, download.URL)
+	}
+	assert.Equal(t, []string{
+		"http://example.com/urgent",
+		"http://example.com/bumped",
+		"http://example.com/failed",
+		"http://example.com/pending",
+		"http://example.com/lowered",
+	}, urls)
+
+	limited, err := sm.GetIncompleteDownloads(context.Background(), 0, 1)
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+	assert.Equal(t, "http://example.com/urgent", limited[0].URL, "the limit keeps the highest priority")
+}
+
+func TestUpdateProgress_RecordsSpeed(t *testing.T) {
+	db := openTestDB(t)
+
+	download := models.DownloadInfo{URL: "http://example.com/movie.mkv", Status: string(models.DownloadStatusDownloading)}
+	require.NoError(t, db.Create(&download).Error)
+
+	sm := &StateManager{db: db, lockTimeout: 5 * time.Minute}
+	ctx := context.Background()
+
+	require.NoError(t, sm.UpdateProgress(ctx, download.ID, 1000, 100000))
+	var first models.DownloadInfo
+	require.NoError(t, db.First(&first, download.ID).Error)
+	assert.Nil(t, first.SpeedBps, "the first update has no interval to measure")
+	require.NotNil(t, first.ProgressUpdatedAt)
+
+	gap := 100 * time.Millisecond
+	time.Sleep(gap)
+	require.NoError(t, sm.UpdateProgress(ctx, download.ID, 11000, 100000))
+
+	var second models.DownloadInfo
+	require.NoError(t, db.First(&second, download.ID).Error)
+	require.NotNil(t, second.SpeedBps)
+	assert.Greater(t, *second.SpeedBps, int64(0))
+	assert.LessOrEqual(t, *second.SpeedBps, int64(10000/gap.Seconds()), "10000 bytes took at least the gap")
+	assert.Equal(t, int64(11000), *second.BytesDownloaded)
+}
+
+func TestProgressSpeed(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-2 * time.Second)
+	bytes := int64(1000)
+
+	speed, ok := progressSpeed(&bytes, &earlier, 5000, now)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2000), speed)
+
+	_, ok = progressSpeed(nil, nil, 5000, now)
+	assert.False(t, ok, "no previous update")
+
+	_, ok = progressSpeed(&bytes, &earlier, 500, now)
+	assert.False(t, ok, "download restarted from a lower offset")
+}
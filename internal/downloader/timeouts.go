@@ -0,0 +1,91 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/httpclient"
+)
+
+// ErrTransferStalled is returned when a download receives no data for the read timeout
+var ErrTransferStalled = errors.New("download stalled")
+
+// Timeouts bounds each phase of a download separately, so that a dead connection
+// is detected quickly without limiting how long a large transfer may take. A zero
+// value disables the bound.
+type Timeouts struct {
+	Connect        time.Duration // Establishing the connection
+	ResponseHeader time.Duration // Waiting for the response headers once the request is sent
+	Read           time.Duration // Waiting for the next bytes of the body
+	Total          time.Duration // Whole request, body transfer included
+}
+
+// DefaultTimeouts returns the timeouts of a download without a total limit
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Connect:        30 * time.Second,
+		ResponseHeader: 60 * time.Second,
+		Read:           120 * time.Second,
+	}
+}
+
+// NewTimeouts returns the download timeouts of the downloads settings
+func NewTimeouts(cfg config.DownloadsConfig) Timeouts {
+	return Timeouts{
+		Connect:        time.Duration(cfg.ConnectTimeout) * time.Second,
+		ResponseHeader: time.Duration(cfg.ResponseHeaderTimeout) * time.Second,
+		Read:           time.Duration(cfg.ReadTimeout) * time.Second,
+		Total:          time.Duration(cfg.Timeout) * time.Second,
+	}
+}
+
+// newDownloadClient creates the HTTP client of the downloads with the connect and
+// response header timeouts set on its transport and Total as the client timeout
+func newDownloadClient(timeouts Timeouts) *http.Client {
+	transport := httpclient.NewTransport(httpclient.ServiceDownloads)
+	transport.DialContext = (&net.Dialer{
+		Timeout:   timeouts.Connect,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.ResponseHeaderTimeout = timeouts.ResponseHeader
+
+	return httpclient.NewWithTransport(httpclient.ServiceDownloads, transport, timeouts.Total)
+}
+
+// stallReader cancels a transfer that receives no data for timeout
+type stallReader struct {
+	reader  io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+	stalled atomic.Bool
+}
+
+// newStallReader wraps reader, calling cancel once no data was read for timeout
+func newStallReader(reader io.Reader, timeout time.Duration, cancel context.CancelFunc) *stallReader {
+	s := &stallReader{reader: reader, timeout: timeout}
+	s.timer = time.AfterFunc(timeout, func() {
+		s.stalled.Store(true)
+		cancel()
+	})
+	return s
+}
+
+// Read implements io.Reader
+func (s *stallReader) Read(p []byte) (int, error) {
+	n, err := s.reader.Read(p)
+	if n > 0 {
+		s.timer.Reset(s.timeout)
+	}
+	return n, err
+}
+
+// stop releases the timer once the transfer is over
+func (s *stallReader) stop() {
+	s.timer.Stop()
+}
@@ -0,0 +1,91 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownload_Timeouts(t *testing.T) {
+	chunk := bytes.Repeat([]byte("x"), 32*1024)
+	const chunks = 20
+
+	release := make(chan struct{})
+	defer close(release)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow-headers.mp4":
+			// The connection is accepted but the response never starts
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		case "/steady.mp4":
+			w.Header().Set("Content-Type", "video/mp4")
+			for i := 0; i < chunks; i++ {
+				w.Write(chunk)
+				w.(http.Flusher).Flush()
+				time.Sleep(20 * time.Millisecond)
+			}
+		case "/stalled.mp4":
+			w.Header().Set("Content-Type", "video/mp4")
+			w.Write(chunk)
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+	}))
+	defer server.Close()
+
+	// The steady transfer takes about 400ms: longer than every phase timeout, with no
+	// total limit
+	d := NewWithTimeouts(Timeouts{
+		Connect:        time.Second,
+		ResponseHeader: 100 * time.Millisecond,
+		Read:           150 * time.Millisecond,
+	}, 1)
+
+	t.Run("slow to respond server is cut by the header timeout", func(t *testing.T) {
+		start := time.Now()
+		_, err := d.Download(context.Background(), DownloadOptions{
+			URL:          server.URL + "/slow-headers.mp4",
+			BaseDestPath: filepath.Join(t.TempDir(), "Slow"),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "timeout awaiting response headers")
+		assert.Less(t, time.Since(start), 2*time.Second)
+	})
+
+	t.Run("slow but steady transfer succeeds", func(t *testing.T) {
+		result, err := d.Download(context.Background(), DownloadOptions{
+			URL:          server.URL + "/steady.mp4",
+			BaseDestPath: filepath.Join(t.TempDir(), "Steady"),
+		})
+		require.NoError(t, err)
+		assert.Greater(t, result.Duration, 150*time.Millisecond)
+
+		info, err := os.Stat(result.FilePath)
+		require.NoError(t, err)
+		assert.Equal(t, int64(chunks*len(chunk)), info.Size())
+	})
+
+	t.Run("stalled transfer is cut by the read timeout", func(t *testing.T) {
+		_, err := d.Download(context.Background(), DownloadOptions{
+			URL:          server.URL + "/stalled.mp4",
+			BaseDestPath: filepath.Join(t.TempDir(), "Stalled"),
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrTransferStalled)
+	})
+}
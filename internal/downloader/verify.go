@@ -0,0 +1,101 @@
+package downloader
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// VerifyOptions holds configuration for completed download verification
+type VerifyOptions struct {
+	DryRun bool // Report missing files without updating the records
+}
+
+// VerifyStats reports the result of a completed download verification
+type VerifyStats struct {
+	Checked    int      `json:"checked"`
+	Missing    []string `json:"missing"`    // destination paths that no longer exist
+	Reconciled int      `json:"reconciled"` // records moved back to pending
+	Errors     int      `json:"errors"`     // paths that could not be checked
+}
+
+// VerifyCompletedDownloads checks that the file of every completed download still
+// exists at its destination. A download whose file was deleted out-of-band is moved
+// back to pending, so that resume-downloads fetches it again, and its items are no
+// longer reported as downloaded. Paths that cannot be checked (e.g. an unmounted
+// share reporting a permission error) are left untouched.
+func VerifyCompletedDownloads(db *gorm.DB, opts VerifyOptions) (*VerifyStats, error) {
+	log := logger.AppLogger()
+
+	var downloads []models.DownloadInfo
+	if err := db.Where("status = ? AND download_path IS NOT NULL", models.DownloadStatusCompleted).
+		Order("id").
+		Find(&downloads).Error; err != nil {
+		return nil, fmt.Errorf("failed to load completed downloads: %w", err)
+	}
+
+	stats := &VerifyStats{Missing: []string{}}
+	for _, download := range downloads {
+		path := *download.DownloadPath
+		if path == "" {
+			continue
+		}
+		stats.Checked++
+
+		_, err := os.Stat(path)
+		if err == nil {
+			continue
+		}
+		if !os.IsNotExist(err) {
+			log.Warn(fmt.Sprintf("Failed to check %s: %v", path, err))
+			stats.Errors++
+			continue
+		}
+
+		stats.Missing = append(stats.Missing, path)
+		if opts.DryRun {
+			log.Info(fmt.Sprintf("[DRY RUN] Missing downloaded file: %s", path))
+			continue
+		}
+
+		if err := resetMissingDownload(db, download.ID, path); err != nil {
+			return stats, err
+		}
+		log.Info(fmt.Sprintf("Missing downloaded file, marked for re-download: %s", path))
+		stats.Reconciled++
+	}
+
+	log.Info(fmt.Sprintf("Verification complete: %d checked, %d missing, %d reconciled",
+		stats.Checked, len(stats.Missing), stats.Reconciled))
+	return stats, nil
+}
+
+// resetMissingDownload moves a completed download back to pending and its items
+// back to processed. The destination path is kept as a fallback for resume.
+func resetMissingDownload(db *gorm.DB, downloadID uint, path string) error {
+	errMsg := fmt.Sprintf("downloaded file missing from destination: %s", path)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.DownloadInfo{}).
+			Where("id = ?", downloadID).
+			Updates(map[string]interface{}{
+				"status":           string(models.DownloadStatusPending),
+				"completed_at":     nil,
+				"bytes_downloaded": 0,
+				"retry_count":      0,
+				"last_retry_at":    nil,
+				"error_message":    errMsg,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to reset download %d: %w", downloadID, err)
+		}
+
+		if err := tx.Model(&models.ProcessedLine{}).
+			Where("download_info_id = ? AND state = ?", downloadID, models.StateDownloaded).
+			Update("state", models.StateProcessed).Error; err != nil {
+			return fmt.Errorf("failed to reset items of download %d: %w", downloadID, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,87 @@
+package downloader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupVerifyFixture records two completed downloads, one whose file exists and one
+// whose file was deleted, each linked to a downloaded item
+func setupVerifyFixture(t *testing.T) (*gorm.DB, models.DownloadInfo, models.DownloadInfo) {
+	t.Helper()
+
+	db := openTestDB(t)
+
+	root := t.TempDir()
+	present := filepath.Join(root, "Inception (2010)", "Inception (2010).mkv")
+	require.NoError(t, os.MkdirAll(filepath.Dir(present), 0755))
+	require.NoError(t, os.WriteFile(present, []byte("data"), 0644))
+	gone := filepath.Join(root, "Deleted (2020)", "Deleted (2020).mkv")
+
+	kept := models.DownloadInfo{Status: string(models.DownloadStatusCompleted), DownloadPath: &present}
+	missing := models.DownloadInfo{Status: string(models.DownloadStatusCompleted), DownloadPath: &gone, RetryCount: 2}
+	require.NoError(t, db.Create(&kept).Error)
+	require.NoError(t, db.Create(&missing).Error)
+
+	for _, download := range []models.DownloadInfo{kept, missing} {
+		testutil.CreateProcessedLine(db, testutil.WithTvgName(fmt.Sprintf("Movie %d", download.ID)),
+			testutil.WithDownloadInfoID(download.ID), testutil.WithState(models.StateDownloaded))
+	}
+
+	return db, kept, missing
+}
+
+func TestVerifyCompletedDownloads_ResetsMissingFiles(t *testing.T) {
+	db, kept, missing := setupVerifyFixture(t)
+
+	stats, err := VerifyCompletedDownloads(db, VerifyOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Checked)
+	assert.Equal(t, []string{*missing.DownloadPath}, stats.Missing)
+	assert.Equal(t, 1, stats.Reconciled)
+
+	var present models.DownloadInfo
+	require.NoError(t, db.First(&present, kept.ID).Error)
+	assert.Equal(t, string(models.DownloadStatusCompleted), present.Status)
+
+	var info models.DownloadInfo
+	require.NoError(t, db.First(&info, missing.ID).Error)
+	assert.Equal(t, string(models.DownloadStatusPending), info.Status)
+	assert.Equal(t, 0, info.RetryCount)
+	assert.Nil(t, info.CompletedAt)
+	require.NotNil(t, info.ErrorMessage)
+	assert.Contains(t, *info.ErrorMessage, "missing")
+
+	var keptLine, missingLine models.ProcessedLine
+	require.NoError(t, db.Where("download_info_id = ?", kept.ID).First(&keptLine).Error)
+	assert.Equal(t, models.StateDownloaded, keptLine.State)
+	require.NoError(t, db.Where("download_info_id = ?", missing.ID).First(&missingLine).Error)
+	assert.Equal(t, models.StateProcessed, missingLine.State)
+
+	// A second pass finds nothing left to reconcile
+	stats, err = VerifyCompletedDownloads(db, VerifyOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Checked)
+	assert.Empty(t, stats.Missing)
+}
+
+func TestVerifyCompletedDownloads_DryRun(t *testing.T) {
+	db, _, missing := setupVerifyFixture(t)
+
+	stats, err := VerifyCompletedDownloads(db, VerifyOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{*missing.DownloadPath}, stats.Missing)
+	assert.Equal(t, 0, stats.Reconciled)
+
+	var info models.DownloadInfo
+	require.NoError(t, db.First(&info, missing.ID).Error)
+	assert.Equal(t, string(models.DownloadStatusCompleted), info.Status)
+}
@@ -1,10 +1,17 @@
 package dryrun
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/glefebvre/stalkeer/internal/classifier"
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/dedup"
 	"github.com/glefebvre/stalkeer/internal/filter"
 	"github.com/glefebvre/stalkeer/internal/models"
 	"github.com/glefebvre/stalkeer/internal/parser"
@@ -16,6 +23,11 @@ type Issue struct {
 	GroupTitle string   `json:"group_title"`
 	Issues     []string `json:"issues"`
 	Severity   string   `json:"severity"` // "info", "warning", "error"
+	// Classification confidence and its breakdown by signal, set for classifier issues
+	Confidence int            `json:"confidence,omitempty"`
+	Signals    map[string]int `json:"signals,omitempty"`
+	// Outcome of the group_title and tvg_name filters, with the pattern that decided
+	Filters []filter.Decision `json:"filters,omitempty"`
 }
 
 // Result represents the result of a dry-run analysis
@@ -37,12 +49,17 @@ type Summary struct {
 	ByContentType map[string]int `json:"by_content_type"`
 }
 
+// ErrFileTooLarge is returned by Analyze when the M3U file exceeds the size set
+// with SetMaxFileSize
+var ErrFileTooLarge = errors.New("M3U file too large")
+
 // Analyzer performs dry-run analysis
 type Analyzer struct {
 	classifier    *classifier.Classifier
 	filterManager *filter.Manager
 	limit         int
-	seenHashes    map[string]bool
+	maxFileSize   int64
+	seenHashes    *dedup.Set
 }
 
 // NewAnalyzer creates a new dry-run analyzer
@@ -51,21 +68,51 @@ func NewAnalyzer(limit int) *Analyzer {
 		classifier:    classifier.New(),
 		filterManager: filter.NewManager(),
 		limit:         limit,
-		seenHashes:    make(map[string]bool),
+		seenHashes:    dedup.New(0),
 	}
 }
 
-// Analyze performs dry-run analysis on an M3U file
-func (a *Analyzer) Analyze(filePath string) (*Result, error) {
+// SetMaxFileSize sets the size in bytes above which the M3U file is not read, 0 = no limit
+func (a *Analyzer) SetMaxFileSize(bytes int64) {
+	a.maxFileSize = bytes
+}
+
+// Analyze performs dry-run analysis on an M3U file. It stops with the context
+// error when ctx is done, including in the middle of parsing the file.
+func (a *Analyzer) Analyze(ctx context.Context, filePath string) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if a.maxFileSize > 0 {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read M3U file: %w", err)
+		}
+		if info.Size() > a.maxFileSize {
+			return nil, fmt.Errorf("%w: %d bytes, the limit is %d", ErrFileTooLarge, info.Size(), a.maxFileSize)
+		}
+	}
+
 	// Load filters from config
 	if err := a.filterManager.LoadFromConfig(); err != nil {
 		return nil, fmt.Errorf("failed to load filters: %w", err)
 	}
+	if err := a.classifier.LoadFromConfig(); err != nil {
+		return nil, fmt.Errorf("failed to load classifier group overrides: %w", err)
+	}
 
 	// Parse M3U file
 	p := parser.NewParser(filePath)
-	lines, err := p.Parse()
+	cfg := config.Get()
+	if err := p.ConfigureURLs(cfg.M3U.BaseURL, cfg.M3U.URLVariables); err != nil {
+		return nil, err
+	}
+	p.SetKeepURLless(cfg.M3U.KeepURLless)
+	lines, err := p.ParseContext(ctx)
 	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("failed to parse M3U file: %w", err)
 	}
 
@@ -90,6 +137,9 @@ func (a *Analyzer) Analyze(filePath string) (*Result, error) {
 
 	// Analyze each item
 	for _, line := range lines {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		a.analyzeItem(line, result)
 	}
 
@@ -108,7 +158,7 @@ func (a *Analyzer) analyzeItem(line models.ProcessedLine, result *Result) {
 	severity := "info"
 
 	// Check for duplicates
-	if a.seenHashes[line.LineHash] {
+	if !a.seenHashes.Add(line.LineHash) {
 		result.Duplicates = append(result.Duplicates, Issue{
 			TvgName:    line.TvgName,
 			GroupTitle: line.GroupTitle,
@@ -118,22 +168,27 @@ func (a *Analyzer) analyzeItem(line models.ProcessedLine, result *Result) {
 		result.Summary.BySeverity["warning"]++
 		return
 	}
-	a.seenHashes[line.LineHash] = true
 
 	// Check if item passes filters
-	if !a.filterManager.MatchesItem(line) {
+	decisions := a.filterManager.ExplainItem(line)
+	if !matchesAll(decisions) {
 		result.FilteredOut = append(result.FilteredOut, Issue{
 			TvgName:    line.TvgName,
 			GroupTitle: line.GroupTitle,
 			Issues:     []string{"filtered_out_by_rules"},
 			Severity:   "info",
+			Filters:    decisions,
 		})
 		result.Summary.BySeverity["info"]++
 		return
 	}
 
 	// Classify content
-	classification := a.classifier.Classify(line.TvgName, line.GroupTitle)
+	lineURL := ""
+	if line.LineURL != nil {
+		lineURL = *line.LineURL
+	}
+	classification := a.classifier.ClassifyStream(line.TvgName, line.GroupTitle, line.Duration, lineURL)
 
 	// Track content type
 	result.Summary.ByContentType[string(classification.ContentType)]++
@@ -172,6 +227,9 @@ func (a *Analyzer) analyzeItem(line models.ProcessedLine, result *Result) {
 			GroupTitle: line.GroupTitle,
 			Issues:     issues,
 			Severity:   severity,
+			Confidence: classification.Confidence,
+			Signals:    classification.Signals,
+			Filters:    decisions,
 		}
 
 		if classification.ContentType == classifier.ContentTypeUncategorized || classification.Confidence < 50 {
@@ -184,6 +242,16 @@ func (a *Analyzer) analyzeItem(line models.ProcessedLine, result *Result) {
 	}
 }
 
+// matchesAll reports whether the line passed every filter
+func matchesAll(decisions []filter.Decision) bool {
+	for _, decision := range decisions {
+		if !decision.Matched {
+			return false
+		}
+	}
+	return true
+}
+
 // PrintSummary prints a human-readable summary of the dry-run results
 func PrintSummary(result *Result) {
 	fmt.Println("\n=== Dry-Run Analysis Summary ===")
@@ -223,6 +291,8 @@ func PrintSummary(result *Result) {
 			fmt.Printf("   Group: %s\n", issue.GroupTitle)
 			fmt.Printf("   Issues: %v\n", issue.Issues)
 			fmt.Printf("   Severity: %s\n", issue.Severity)
+			fmt.Printf("   Confidence: %d\n", issue.Confidence)
+			printReasons(issue)
 		}
 	}
 
@@ -236,14 +306,54 @@ func PrintSummary(result *Result) {
 			fmt.Printf("   Group: %s\n", issue.GroupTitle)
 			fmt.Printf("   Issues: %v\n", issue.Issues)
 			fmt.Printf("   Severity: %s\n", issue.Severity)
+			printReasons(issue)
 		}
 	}
 
 	if len(result.FilteredOut) > 0 {
-		fmt.Printf("\n=== Filtered Out: %d items ===\n", len(result.FilteredOut))
+		fmt.Printf("\n=== Filtered Out: %d items (first 5) ===\n", len(result.FilteredOut))
+		for i, issue := range result.FilteredOut {
+			if i >= 5 {
+				break
+			}
+			fmt.Printf("\n%d. %s\n", i+1, issue.TvgName)
+			fmt.Printf("   Group: %s\n", issue.GroupTitle)
+			printReasons(issue)
+		}
 	}
 
 	if len(result.Duplicates) > 0 {
 		fmt.Printf("\n=== Duplicates: %d items ===\n", len(result.Duplicates))
 	}
 }
+
+// printReasons prints the classification signals and filter decisions of an issue
+func printReasons(issue Issue) {
+	if issue.Signals != nil {
+		if len(issue.Signals) == 0 {
+			fmt.Println("   Signals: none")
+		} else {
+			names := make([]string, 0, len(issue.Signals))
+			for name := range issue.Signals {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			signals := make([]string, len(names))
+			for i, name := range names {
+				signals[i] = fmt.Sprintf("%s=%d", name, issue.Signals[name])
+			}
+			fmt.Printf("   Signals: %s\n", strings.Join(signals, ", "))
+		}
+	}
+
+	for _, decision := range issue.Filters {
+		switch decision.Reason {
+		case filter.ReasonExcluded, filter.ReasonIncluded:
+			fmt.Printf("   Filter %s: %s by %q\n", decision.Attribute, decision.Reason, decision.Pattern)
+		case filter.ReasonNotIncluded:
+			fmt.Printf("   Filter %s: %s, tried %q\n", decision.Attribute, decision.Reason, decision.Unmatched)
+		case filter.ReasonNotExcluded:
+			fmt.Printf("   Filter %s: %s\n", decision.Attribute, decision.Reason)
+		}
+	}
+}
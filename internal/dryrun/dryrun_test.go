@@ -0,0 +1,70 @@
+package dryrun
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/classifier"
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/filter"
+)
+
+func TestAnalyze_Reasons(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yml")
+	configContent := `database:
+  user: test
+  dbname: test
+filter:
+  group_title:
+    exclude_patterns: ["^XXX"]
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	config.SetConfigFile(configPath)
+	t.Cleanup(func() { config.SetConfigFile("") })
+	if err := config.Load(); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	m3uPath := filepath.Join(dir, "playlist.m3u")
+	m3uContent := `#EXTM3U
+#EXTINF:-1 group-title="Misc",Random Channel 1080p
+http://example.com/random.mkv
+#EXTINF:-1 group-title="XXX Adult",Hidden Channel
+http://example.com/hidden.ts
+`
+	if err := os.WriteFile(m3uPath, []byte(m3uContent), 0644); err != nil {
+		t.Fatalf("failed to write playlist: %v", err)
+	}
+
+	result, err := NewAnalyzer(0).Analyze(context.Background(), m3uPath)
+	if err != nil {
+		t.Fatalf("analysis failed: %v", err)
+	}
+
+	if len(result.Unclassified) != 1 {
+		t.Fatalf("expected 1 unclassified item, got %d", len(result.Unclassified))
+	}
+	unclassified := result.Unclassified[0]
+	if _, ok := unclassified.Signals[classifier.SignalResolution]; !ok {
+		t.Errorf("expected the resolution signal in %v", unclassified.Signals)
+	}
+	if len(unclassified.Filters) != 2 {
+		t.Fatalf("expected 2 filter decisions, got %d", len(unclassified.Filters))
+	}
+	if got := unclassified.Filters[0]; !got.Matched || got.Reason != filter.ReasonNotExcluded {
+		t.Errorf("expected the group_title filter to pass as not excluded, got %+v", got)
+	}
+
+	if len(result.FilteredOut) != 1 {
+		t.Fatalf("expected 1 filtered out item, got %d", len(result.FilteredOut))
+	}
+	excluded := result.FilteredOut[0].Filters[0]
+	if excluded.Matched || excluded.Reason != filter.ReasonExcluded || excluded.Pattern != "^XXX" {
+		t.Errorf("expected the group_title to be excluded by ^XXX, got %+v", excluded)
+	}
+}
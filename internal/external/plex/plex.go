@@ -0,0 +1,134 @@
+package plex
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	apperrors "github.com/glefebvre/stalkeer/internal/apperrors"
+	"github.com/glefebvre/stalkeer/internal/circuitbreaker"
+	"github.com/glefebvre/stalkeer/internal/httpclient"
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/glefebvre/stalkeer/internal/retry"
+)
+
+// Client represents a Plex Media Server API client
+type Client struct {
+	baseURL     string
+	token       string
+	httpClient  *http.Client
+	retryConfig retry.Config
+	circuitBrk  *circuitbreaker.CircuitBreaker
+	logger      *logger.Logger
+}
+
+// Config holds Plex client configuration
+type Config struct {
+	BaseURL     string
+	Token       string // X-Plex-Token of the server
+	Timeout     time.Duration
+	RetryConfig retry.Config
+	Logger      *logger.Logger
+}
+
+// New creates a new Plex client. It returns an error when BaseURL is not a valid
+// http or https URL or when the token is missing.
+func New(cfg Config) (*Client, error) {
+	baseURL, err := httpclient.NormalizeBaseURL(cfg.BaseURL)
+	if err != nil {
+		return nil, apperrors.ConfigError("invalid plex URL", err)
+	}
+	if cfg.Token == "" {
+		return nil, apperrors.ConfigError("plex token is required", nil)
+	}
+
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	if cfg.RetryConfig.MaxAttempts == 0 {
+		cfg.RetryConfig = retry.DefaultConfig()
+	}
+
+	// Refreshes are fire-and-forget, so a Plex server that keeps failing is not
+	// asked again until the breaker closes
+	cb := circuitbreaker.New(circuitbreaker.Config{
+		MaxFailures: 3,
+		Timeout:     60 * time.Second,
+	})
+
+	return &Client{
+		baseURL:     baseURL,
+		token:       cfg.Token,
+		httpClient:  httpclient.New(httpclient.ServicePlex, cfg.Timeout),
+		retryConfig: cfg.RetryConfig,
+		circuitBrk:  cb,
+		logger:      cfg.Logger,
+	}, nil
+}
+
+// RefreshLibrary asks Plex to scan the whole library section sectionID
+func (c *Client) RefreshLibrary(ctx context.Context, sectionID int) error {
+	return c.refresh(ctx, sectionID, "")
+}
+
+// RefreshPath asks Plex for a partial scan of path, a directory of the library
+// section sectionID, which is much faster than scanning the whole section
+func (c *Client) RefreshPath(ctx context.Context, sectionID int, path string) error {
+	return c.refresh(ctx, sectionID, path)
+}
+
+func (c *Client) refresh(ctx context.Context, sectionID int, path string) error {
+	endpoint := fmt.Sprintf("/library/sections/%d/refresh", sectionID)
+	if path != "" {
+		endpoint += "?" + url.Values{"path": {path}}.Encode()
+	}
+
+	err := c.circuitBrk.Execute(func() error {
+		return retry.Do(ctx, c.retryConfig, func() error {
+			return c.get(ctx, endpoint)
+		}, apperrors.IsRetryable)
+	})
+	if err != nil {
+		return apperrors.ExternalServiceError("plex", "failed to refresh library", err)
+	}
+
+	if c.logger != nil {
+		c.logger.WithFields(map[string]interface{}{
+			"section": sectionID,
+			"path":    path,
+		}).Info("plex library refresh requested")
+	}
+	return nil
+}
+
+// get sends a GET request to endpoint. Network errors and 5xx responses are
+// retryable.
+func (c *Client) get(ctx context.Context, endpoint string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Plex-Token", c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.CodeServiceUnavailable, "plex request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		err := fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return apperrors.Wrap(err, apperrors.CodeServiceUnavailable, "plex unavailable")
+		}
+		return err
+	}
+
+	return nil
+}
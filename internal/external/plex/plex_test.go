@@ -0,0 +1,144 @@
+package plex
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/retry"
+)
+
+// newTestClient creates a client for server, failing the test on an invalid configuration
+func newTestClient(t *testing.T, serverURL string) *Client {
+	t.Helper()
+
+	client, err := New(Config{
+		BaseURL:     serverURL,
+		Token:       "test-token",
+		Timeout:     5 * time.Second,
+		RetryConfig: retry.Config{MaxAttempts: 3, InitialBackoff: time.Millisecond, BackoffMultiplier: 1},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{BaseURL: "http://plex.local:32400/", Token: "token"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := New(Config{BaseURL: "plex.local:32400", Token: "token"}); err == nil {
+		t.Error("expected an error for a URL without scheme")
+	}
+	if _, err := New(Config{BaseURL: "http://plex.local:32400"}); err == nil {
+		t.Error("expected an error without token")
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	tests := []struct {
+		name      string
+		refresh   func(c *Client) error
+		wantPath  string
+		wantQuery string
+	}{
+		{
+			name:     "whole section",
+			refresh:  func(c *Client) error { return c.RefreshLibrary(context.Background(), 2) },
+			wantPath: "/library/sections/2/refresh",
+		},
+		{
+			name:      "partial scan",
+			refresh:   func(c *Client) error { return c.RefreshPath(context.Background(), 1, "/media/movies/Dune (2021)") },
+			wantPath:  "/library/sections/1/refresh",
+			wantQuery: "/media/movies/Dune (2021)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				if r.Method != http.MethodGet {
+					t.Errorf("expected GET, got %s", r.Method)
+				}
+				if r.URL.Path != tt.wantPath {
+					t.Errorf("expected path %q, got %q", tt.wantPath, r.URL.Path)
+				}
+				if got := r.URL.Query().Get("path"); got != tt.wantQuery {
+					t.Errorf("expected path parameter %q, got %q", tt.wantQuery, got)
+				}
+				if got := r.Header.Get("X-Plex-Token"); got != "test-token" {
+					t.Errorf("expected the Plex token, got %q", got)
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			if err := tt.refresh(newTestClient(t, server.URL)); err != nil {
+				t.Fatalf("refresh failed: %v", err)
+			}
+			if calls != 1 {
+				t.Errorf("expected 1 refresh request, got %d", calls)
+			}
+		})
+	}
+}
+
+func TestRefreshRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "server error is retried", status: http.StatusServiceUnavailable, wantCalls: 2},
+		{name: "unauthorized is not retried", status: http.StatusUnauthorized, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			err := newTestClient(t, server.URL).RefreshLibrary(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d requests, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestRefreshCircuitBreaker(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	for i := 0; i < 5; i++ {
+		if err := client.RefreshLibrary(context.Background(), 1); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if calls != 3 {
+		t.Errorf("expected the circuit breaker to stop after 3 failed requests, got %d", calls)
+	}
+}
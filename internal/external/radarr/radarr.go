@@ -10,6 +10,7 @@ import (
 	"time"
 
 	apperrors "github.com/glefebvre/stalkeer/internal/apperrors"
+	"github.com/glefebvre/stalkeer/internal/httpclient"
 	"github.com/glefebvre/stalkeer/internal/logger"
 	"github.com/glefebvre/stalkeer/internal/retry"
 )
@@ -52,8 +53,14 @@ type FetchOptions struct {
 	Limit int
 }
 
-// New creates a new Radarr client
-func New(cfg Config) *Client {
+// New creates a new Radarr client. It returns an error when BaseURL is not a valid
+// http or https URL; trailing slashes are trimmed from it.
+func New(cfg Config) (*Client, error) {
+	baseURL, err := httpclient.NormalizeBaseURL(cfg.BaseURL)
+	if err != nil {
+		return nil, apperrors.ConfigError("invalid radarr URL", err)
+	}
+
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 30 * time.Second
 	}
@@ -63,14 +70,12 @@ func New(cfg Config) *Client {
 	}
 
 	return &Client{
-		baseURL: cfg.BaseURL,
-		apiKey:  cfg.APIKey,
-		httpClient: &http.Client{
-			Timeout: cfg.Timeout,
-		},
+		baseURL:     baseURL,
+		apiKey:      cfg.APIKey,
+		httpClient:  httpclient.New(httpclient.ServiceRadarr, cfg.Timeout),
 		retryConfig: cfg.RetryConfig,
 		logger:      cfg.Logger,
-	}
+	}, nil
 }
 
 // GetMissingMovies retrieves all monitored movies that are not downloaded by paginating
@@ -153,6 +158,50 @@ func (c *Client) UpdateMovie(ctx context.Context, movie *Movie) error {
 	return nil
 }
 
+// Ping checks that Radarr is reachable and accepts the API key, without retries
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := c.newRequest(ctx, "GET", "/api/v3/system/status", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// WaitUntilReachable pings Radarr until it answers, retrying any failure with the
+// backoff of cfg, so that a Radarr still starting up is waited for. MaxAttempts 0
+// skips the check.
+func (c *Client) WaitUntilReachable(ctx context.Context, cfg retry.Config) error {
+	if c.logger != nil {
+		cfg.OnRetry = func(attempt int, err error) {
+			c.logger.WithFields(map[string]interface{}{
+				"attempt":      attempt,
+				"max_attempts": cfg.MaxAttempts,
+				"error":        err.Error(),
+			}).Warn("radarr not reachable yet, retrying")
+		}
+	}
+
+	err := retry.Do(ctx, cfg, func() error {
+		return c.Ping(ctx)
+	}, func(error) bool { return true })
+	if err != nil {
+		return apperrors.ExternalServiceError("radarr", fmt.Sprintf("not reachable after %d attempts", cfg.MaxAttempts), err)
+	}
+
+	return nil
+}
+
 func (c *Client) getPagedMovies(ctx context.Context, endpoint string) ([]Movie, int, error) {
 	req, err := c.newRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
@@ -12,22 +12,54 @@ import (
 )
 
 func TestNew(t *testing.T) {
-	cfg := Config{
-		BaseURL: "http://localhost:7878",
-		APIKey:  "test-key",
+	tests := []struct {
+		name        string
+		baseURL     string
+		wantBaseURL string
+		wantErr     bool
+	}{
+		{name: "valid", baseURL: "http://localhost:7878", wantBaseURL: "http://localhost:7878"},
+		{name: "https with path", baseURL: "https://example.com/radarr", wantBaseURL: "https://example.com/radarr"},
+		{name: "trailing slash", baseURL: "http://localhost:7878/", wantBaseURL: "http://localhost:7878"},
+		{name: "trailing slash after path", baseURL: "https://example.com/radarr//", wantBaseURL: "https://example.com/radarr"},
+		{name: "missing scheme", baseURL: "localhost:7878", wantErr: true},
+		{name: "missing scheme and port", baseURL: "example.com", wantErr: true},
+		{name: "unsupported scheme", baseURL: "ftp://localhost:7878", wantErr: true},
+		{name: "missing host", baseURL: "http://", wantErr: true},
+		{name: "empty", baseURL: "", wantErr: true},
 	}
 
-	client := New(cfg)
-
-	if client == nil {
-		t.Fatal("expected non-nil client")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(Config{BaseURL: tt.baseURL, APIKey: "test-key"})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error for %q", tt.baseURL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if client.baseURL != tt.wantBaseURL {
+				t.Errorf("expected baseURL %s, got %s", tt.wantBaseURL, client.baseURL)
+			}
+			if client.apiKey != "test-key" {
+				t.Errorf("expected apiKey test-key, got %s", client.apiKey)
+			}
+		})
 	}
-	if client.baseURL != cfg.BaseURL {
-		t.Errorf("expected baseURL %s, got %s", cfg.BaseURL, client.baseURL)
-	}
-	if client.apiKey != cfg.APIKey {
-		t.Errorf("expected apiKey %s, got %s", cfg.APIKey, client.apiKey)
+}
+
+// newTestClient creates a client, failing the test on an invalid configuration
+func newTestClient(t *testing.T, cfg Config) *Client {
+	t.Helper()
+
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
 	}
+	return client
 }
 
 func TestGetMissingMovies(t *testing.T) {
@@ -55,7 +87,7 @@ func TestGetMissingMovies(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL: server.URL,
 		APIKey:  "test-key",
 		Timeout: 5 * time.Second,
@@ -98,7 +130,7 @@ func TestGetMovieDetails(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL: server.URL,
 		APIKey:  "test-key",
 		Timeout: 5 * time.Second,
@@ -121,6 +153,32 @@ func TestGetMovieDetails(t *testing.T) {
 	}
 }
 
+func TestPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/system/status" {
+			t.Errorf("expected path /api/v3/system/status, got %s", r.URL.Path)
+		}
+		if r.Header.Get("X-Api-Key") != "test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"version":"5.0.0"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := newTestClient(t, Config{BaseURL: server.URL, APIKey: "test-key", Timeout: 5 * time.Second})
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client = newTestClient(t, Config{BaseURL: server.URL, APIKey: "wrong-key", Timeout: 5 * time.Second})
+	if err := client.Ping(ctx); err == nil {
+		t.Error("expected an error for a rejected API key")
+	}
+}
+
 func TestUpdateMovie(t *testing.T) {
 	movie := &Movie{
 		ID:        1,
@@ -153,7 +211,7 @@ func TestUpdateMovie(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL: server.URL,
 		APIKey:  "test-key",
 		Timeout: 5 * time.Second,
@@ -206,7 +264,7 @@ func TestClientRetry(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL: server.URL,
 		APIKey:  "test-key",
 		Timeout: 5 * time.Second,
@@ -268,7 +326,7 @@ func TestGetMissingMoviesMultiPage(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL: server.URL,
 		APIKey:  "test-key",
 		Timeout: 5 * time.Second,
@@ -301,7 +359,7 @@ func TestGetMissingMoviesEmpty(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL: server.URL,
 		APIKey:  "test-key",
 		Timeout: 5 * time.Second,
@@ -351,7 +409,7 @@ func TestGetMissingMoviesWithLimit(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL:     server.URL,
 		APIKey:      "test-key",
 		Timeout:     5 * time.Second,
@@ -411,3 +469,57 @@ func TestGetMissingMoviesWithLimit(t *testing.T) {
 		}
 	})
 }
+
+func TestWaitUntilReachable(t *testing.T) {
+	startupRetry := retry.Config{
+		MaxAttempts:       4,
+		InitialBackoff:    10 * time.Millisecond,
+		MaxBackoff:        50 * time.Millisecond,
+		BackoffMultiplier: 2.0,
+	}
+
+	tests := []struct {
+		name         string
+		failures     int
+		wantErr      bool
+		wantRequests int
+	}{
+		{name: "up at once", failures: 0, wantRequests: 1},
+		{name: "up after starting", failures: 2, wantRequests: 3},
+		{name: "never up", failures: 10, wantErr: true, wantRequests: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Write([]byte(`{"version":"4.0.0"}`))
+			}))
+			defer server.Close()
+
+			client := newTestClient(t, Config{BaseURL: server.URL, APIKey: "test-key", Timeout: 5 * time.Second})
+			err := client.WaitUntilReachable(context.Background(), startupRetry)
+			if tt.wantErr && err == nil {
+				t.Error("expected an error when Radarr never answers")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("expected %d requests, got %d", tt.wantRequests, requests)
+			}
+		})
+	}
+}
+
+func TestWaitUntilReachableDisabled(t *testing.T) {
+	client := newTestClient(t, Config{BaseURL: "http://127.0.0.1:1", APIKey: "test-key"})
+	if err := client.WaitUntilReachable(context.Background(), retry.Config{}); err != nil {
+		t.Errorf("expected no check with 0 attempts, got %v", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	apperrors "github.com/glefebvre/stalkeer/internal/apperrors"
+	"github.com/glefebvre/stalkeer/internal/httpclient"
 	"github.com/glefebvre/stalkeer/internal/logger"
 	"github.com/glefebvre/stalkeer/internal/retry"
 )
@@ -45,6 +46,22 @@ type Series struct {
 	TotalEpisodeCount int       `json:"totalEpisodeCount"`
 	Added             time.Time `json:"added"`
 	QualityProfileID  int       `json:"qualityProfileId"`
+	Seasons           []Season  `json:"seasons"`
+}
+
+// Season represents a season of a Sonarr series
+type Season struct {
+	SeasonNumber int               `json:"seasonNumber"`
+	Monitored    bool              `json:"monitored"`
+	Statistics   *SeasonStatistics `json:"statistics,omitempty"`
+}
+
+// SeasonStatistics holds the episode counts of a season. EpisodeCount counts the
+// aired episodes, TotalEpisodeCount includes the announced ones.
+type SeasonStatistics struct {
+	EpisodeFileCount  int `json:"episodeFileCount"`
+	EpisodeCount      int `json:"episodeCount"`
+	TotalEpisodeCount int `json:"totalEpisodeCount"`
 }
 
 // Episode represents a Sonarr episode
@@ -65,8 +82,14 @@ type FetchOptions struct {
 	Limit int
 }
 
-// New creates a new Sonarr client
-func New(cfg Config) *Client {
+// New creates a new Sonarr client. It returns an error when BaseURL is not a valid
+// http or https URL; trailing slashes are trimmed from it.
+func New(cfg Config) (*Client, error) {
+	baseURL, err := httpclient.NormalizeBaseURL(cfg.BaseURL)
+	if err != nil {
+		return nil, apperrors.ConfigError("invalid sonarr URL", err)
+	}
+
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 30 * time.Second
 	}
@@ -76,14 +99,12 @@ func New(cfg Config) *Client {
 	}
 
 	return &Client{
-		baseURL: cfg.BaseURL,
-		apiKey:  cfg.APIKey,
-		httpClient: &http.Client{
-			Timeout: cfg.Timeout,
-		},
+		baseURL:     baseURL,
+		apiKey:      cfg.APIKey,
+		httpClient:  httpclient.New(httpclient.ServiceSonarr, cfg.Timeout),
 		retryConfig: cfg.RetryConfig,
 		logger:      cfg.Logger,
-	}
+	}, nil
 }
 
 // GetMissingSeries retrieves all monitored series with missing episodes
@@ -214,6 +235,50 @@ func (c *Client) UpdateEpisode(ctx context.Context, episode *Episode) error {
 	return nil
 }
 
+// Ping checks that Sonarr is reachable and accepts the API key, without retries
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := c.newRequest(ctx, "GET", "/api/v3/system/status", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// WaitUntilReachable pings Sonarr until it answers, retrying any failure with the
+// backoff of cfg, so that a Sonarr still starting up is waited for. MaxAttempts 0
+// skips the check.
+func (c *Client) WaitUntilReachable(ctx context.Context, cfg retry.Config) error {
+	if c.logger != nil {
+		cfg.OnRetry = func(attempt int, err error) {
+			c.logger.WithFields(map[string]interface{}{
+				"attempt":      attempt,
+				"max_attempts": cfg.MaxAttempts,
+				"error":        err.Error(),
+			}).Warn("sonarr not reachable yet, retrying")
+		}
+	}
+
+	err := retry.Do(ctx, cfg, func() error {
+		return c.Ping(ctx)
+	}, func(error) bool { return true })
+	if err != nil {
+		return apperrors.ExternalServiceError("sonarr", fmt.Sprintf("not reachable after %d attempts", cfg.MaxAttempts), err)
+	}
+
+	return nil
+}
+
 func (c *Client) getSeries(ctx context.Context, endpoint string) ([]Series, error) {
 	req, err := c.newRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
@@ -12,22 +12,54 @@ import (
 )
 
 func TestNew(t *testing.T) {
-	cfg := Config{
-		BaseURL: "http://localhost:8989",
-		APIKey:  "test-key",
+	tests := []struct {
+		name        string
+		baseURL     string
+		wantBaseURL string
+		wantErr     bool
+	}{
+		{name: "valid", baseURL: "http://localhost:8989", wantBaseURL: "http://localhost:8989"},
+		{name: "https with path", baseURL: "https://example.com/sonarr", wantBaseURL: "https://example.com/sonarr"},
+		{name: "trailing slash", baseURL: "http://localhost:8989/", wantBaseURL: "http://localhost:8989"},
+		{name: "trailing slash after path", baseURL: "https://example.com/sonarr//", wantBaseURL: "https://example.com/sonarr"},
+		{name: "missing scheme", baseURL: "localhost:8989", wantErr: true},
+		{name: "missing scheme and port", baseURL: "example.com", wantErr: true},
+		{name: "unsupported scheme", baseURL: "ftp://localhost:8989", wantErr: true},
+		{name: "missing host", baseURL: "http://", wantErr: true},
+		{name: "empty", baseURL: "", wantErr: true},
 	}
 
-	client := New(cfg)
-
-	if client == nil {
-		t.Fatal("expected non-nil client")
-	}
-	if client.baseURL != cfg.BaseURL {
-		t.Errorf("expected baseURL %s, got %s", cfg.BaseURL, client.baseURL)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := New(Config{BaseURL: tt.baseURL, APIKey: "test-key"})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error for %q", tt.baseURL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if client.baseURL != tt.wantBaseURL {
+				t.Errorf("expected baseURL %s, got %s", tt.wantBaseURL, client.baseURL)
+			}
+			if client.apiKey != "test-key" {
+				t.Errorf("expected apiKey test-key, got %s", client.apiKey)
+			}
+		})
 	}
-	if client.apiKey != cfg.APIKey {
-		t.Errorf("expected apiKey %s, got %s", cfg.APIKey, client.apiKey)
+}
+
+// newTestClient creates a client, failing the test on an invalid configuration
+func newTestClient(t *testing.T, cfg Config) *Client {
+	t.Helper()
+
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
 	}
+	return client
 }
 
 func TestGetMissingSeries(t *testing.T) {
@@ -50,7 +82,7 @@ func TestGetMissingSeries(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL: server.URL,
 		APIKey:  "test-key",
 		Timeout: 5 * time.Second,
@@ -97,7 +129,7 @@ func TestGetMissingEpisodes(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL: server.URL,
 		APIKey:  "test-key",
 		Timeout: 5 * time.Second,
@@ -138,7 +170,7 @@ func TestGetEpisodeDetails(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL: server.URL,
 		APIKey:  "test-key",
 		Timeout: 5 * time.Second,
@@ -194,7 +226,7 @@ func TestUpdateEpisode(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL: server.URL,
 		APIKey:  "test-key",
 		Timeout: 5 * time.Second,
@@ -247,7 +279,7 @@ func TestGetMissingEpisodesMultiPage(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL: server.URL,
 		APIKey:  "test-key",
 		Timeout: 5 * time.Second,
@@ -280,7 +312,7 @@ func TestGetMissingEpisodesEmpty(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL: server.URL,
 		APIKey:  "test-key",
 		Timeout: 5 * time.Second,
@@ -330,7 +362,7 @@ func TestGetMissingEpisodesWithLimit(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := New(Config{
+	client := newTestClient(t, Config{
 		BaseURL:     server.URL,
 		APIKey:      "test-key",
 		Timeout:     5 * time.Second,
@@ -390,3 +422,57 @@ func TestGetMissingEpisodesWithLimit(t *testing.T) {
 		}
 	})
 }
+
+func TestWaitUntilReachable(t *testing.T) {
+	startupRetry := retry.Config{
+		MaxAttempts:       4,
+		InitialBackoff:    10 * time.Millisecond,
+		MaxBackoff:        50 * time.Millisecond,
+		BackoffMultiplier: 2.0,
+	}
+
+	tests := []struct {
+		name         string
+		failures     int
+		wantErr      bool
+		wantRequests int
+	}{
+		{name: "up at once", failures: 0, wantRequests: 1},
+		{name: "up after starting", failures: 2, wantRequests: 3},
+		{name: "never up", failures: 10, wantErr: true, wantRequests: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Write([]byte(`{"version":"4.0.0"}`))
+			}))
+			defer server.Close()
+
+			client := newTestClient(t, Config{BaseURL: server.URL, APIKey: "test-key", Timeout: 5 * time.Second})
+			err := client.WaitUntilReachable(context.Background(), startupRetry)
+			if tt.wantErr && err == nil {
+				t.Error("expected an error when Sonarr never answers")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("expected %d requests, got %d", tt.wantRequests, requests)
+			}
+		})
+	}
+}
+
+func TestWaitUntilReachableDisabled(t *testing.T) {
+	client := newTestClient(t, Config{BaseURL: "http://127.0.0.1:1", APIKey: "test-key"})
+	if err := client.WaitUntilReachable(context.Background(), retry.Config{}); err != nil {
+		t.Errorf("expected no check with 0 attempts, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package tmdb
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket refilled at rate tokens per second, holding at
+// most burst tokens. Wait blocks until a token is available.
+type rateLimiter struct {
+	rate   float64
+	burst  float64
+	mu     sync.Mutex
+	tokens float64   // may go negative: waiters reserve tokens ahead of time
+	last   time.Time // when tokens was last refilled
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait takes a token, sleeping until the bucket has refilled enough for it
+func (l *rateLimiter) Wait() {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// sharedLimiters holds one limiter per rate and burst, so every client built
+// with the same settings draws from the same bucket
+var (
+	sharedLimiters   = make(map[limiterKey]*rateLimiter)
+	sharedLimitersMu sync.Mutex
+)
+
+type limiterKey struct {
+	rps   float64
+	burst int
+}
+
+// sharedLimiter returns the process-wide limiter for rps and burst, or nil when
+// rps disables limiting
+func sharedLimiter(rps float64, burst int) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+
+	sharedLimitersMu.Lock()
+	defer sharedLimitersMu.Unlock()
+
+	key := limiterKey{rps: rps, burst: burst}
+	if l, ok := sharedLimiters[key]; ok {
+		return l
+	}
+	l := newRateLimiter(rps, burst)
+	sharedLimiters[key] = l
+	return l
+}
@@ -3,22 +3,35 @@ package tmdb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/glefebvre/stalkeer/internal/circuitbreaker"
+	"github.com/glefebvre/stalkeer/internal/httpclient"
 	"github.com/glefebvre/stalkeer/internal/logger"
 	"github.com/glefebvre/stalkeer/internal/retry"
 )
 
 const defaultTimeout = 30 * time.Second
 
+// validateTimeout bounds the API key check of Validate
+const validateTimeout = 10 * time.Second
+
+// ErrInvalidAPIKey is returned by Validate when TMDB rejects the API key
+var ErrInvalidAPIKey = errors.New("TMDB rejected the API key")
+
+// ErrNoResults is returned by the searches when TMDB has no match for the title
+var ErrNoResults = errors.New("no results found")
+
 // baseURL is a var so tests can override it with an httptest server address.
 var baseURL = "https://api.themoviedb.org/3"
 
@@ -34,18 +47,30 @@ type Client struct {
 	httpClient      *http.Client
 	logger          *logger.Logger
 	circuitBrk      *circuitbreaker.CircuitBreaker
-	requestInterval time.Duration     // minimum gap between HTTP requests; 0 = no limiting
-	lastRequestAt   time.Time         // when the last HTTP request was initiated
-	cache           map[string][]byte // URL → raw JSON response (scoped to client lifetime)
-	cacheMu         sync.RWMutex      // protects cache
+	limiter         *rateLimiter             // shared token bucket; nil = no limiting
+	timeout         time.Duration            // per-request timeout when no endpoint timeout is set
+	endpointTimeout map[string]time.Duration // per-request timeout by endpoint kind
+	cache           map[string][]byte        // URL → raw JSON response (scoped to client lifetime)
+	cacheMu         sync.RWMutex             // protects cache
 }
 
+// Endpoint kinds accepted as keys of Config.EndpointTimeouts
+const (
+	EndpointSearch      = "search"       // /search/movie, /search/tv
+	EndpointDetails     = "details"      // /movie/{id}, /tv/{id}, /tv/{id}/season/{n}
+	EndpointExternalIDs = "external_ids" // /movie/{id}/external_ids, /tv/{id}/external_ids
+)
+
 // Config holds TMDB client configuration
 type Config struct {
 	APIKey            string
 	Language          string // e.g., "en-US", "fr-FR,fr;q=0.9,en-US;q=0.5,en;q=0.5"
 	Timeout           time.Duration
 	RequestsPerSecond float64 // max outbound requests per second; 0 = no limit (default: 4.0)
+	Burst             int     // requests allowed back to back before the rate applies (default: 1)
+
+	// EndpointTimeouts overrides Timeout for an endpoint kind (EndpointSearch, ...)
+	EndpointTimeouts map[string]time.Duration
 }
 
 // MovieResult represents a movie search result from TMDB
@@ -105,6 +130,8 @@ type MovieDetails struct {
 	Popularity    float64 `json:"popularity"`
 	Runtime       *int    `json:"runtime"`
 	Genres        []Genre `json:"genres"`
+
+	BelongsToCollection *Collection `json:"belongs_to_collection"`
 }
 
 // TVShowDetails represents detailed TV show information
@@ -121,6 +148,44 @@ type TVShowDetails struct {
 	Genres       []Genre `json:"genres"`
 }
 
+// SeasonDetails represents a TV show season with its episodes
+type SeasonDetails struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	SeasonNumber int       `json:"season_number"`
+	AirDate      string    `json:"air_date"`
+	Episodes     []Episode `json:"episodes"`
+}
+
+// Episode represents an episode of a TV show season
+type Episode struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	SeasonNumber  int    `json:"season_number"`
+	EpisodeNumber int    `json:"episode_number"`
+	AirDate       string `json:"air_date"` // YYYY-MM-DD, empty when not announced
+	Overview      string `json:"overview"`
+	Runtime       *int   `json:"runtime"`
+}
+
+// Episode returns the episode numbered number, or nil when the season has none
+func (s *SeasonDetails) Episode(number int) *Episode {
+	for i := range s.Episodes {
+		if s.Episodes[i].EpisodeNumber == number {
+			return &s.Episodes[i]
+		}
+	}
+	return nil
+}
+
+// Collection represents a TMDB movie collection (franchise)
+type Collection struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	PosterPath   *string `json:"poster_path"`
+	BackdropPath *string `json:"backdrop_path"`
+}
+
 // Genre represents a TMDB genre
 type Genre struct {
 	ID   int    `json:"id"`
@@ -150,20 +215,24 @@ func NewClient(cfg Config) *Client {
 		Timeout:     60 * time.Second,
 	})
 
-	var requestInterval time.Duration
-	if cfg.RequestsPerSecond > 0 {
-		requestInterval = time.Duration(float64(time.Second) / cfg.RequestsPerSecond)
+	// Requests are bounded by their own context, so the HTTP client only needs
+	// to outlast the longest of them
+	clientTimeout := cfg.Timeout
+	for _, timeout := range cfg.EndpointTimeouts {
+		if timeout > clientTimeout {
+			clientTimeout = timeout
+		}
 	}
 
 	return &Client{
-		apiKey:   cfg.APIKey,
-		language: cfg.Language,
-		httpClient: &http.Client{
-			Timeout: cfg.Timeout,
-		},
+		apiKey:          cfg.APIKey,
+		language:        cfg.Language,
+		httpClient:      httpclient.New(httpclient.ServiceTMDB, clientTimeout),
 		logger:          logger.AppLogger(),
 		circuitBrk:      cb,
-		requestInterval: requestInterval,
+		limiter:         sharedLimiter(cfg.RequestsPerSecond, cfg.Burst),
+		timeout:         cfg.Timeout,
+		endpointTimeout: cfg.EndpointTimeouts,
 		cache:           make(map[string][]byte),
 	}
 }
@@ -182,25 +251,37 @@ func (c *Client) SearchMovie(title string, year *int) (*MovieResult, error) {
 	}
 
 	if len(response.Results) == 0 {
-		return nil, fmt.Errorf("no results found for movie: %s", title)
+		return nil, fmt.Errorf("%w for movie: %s", ErrNoResults, title)
 	}
 
 	// Return the first (most relevant) result
 	return &response.Results[0], nil
 }
 
-// SearchTVShow searches for TV shows by title
-func (c *Client) SearchTVShow(title string) (*TVShowResult, error) {
+// SearchTVShow searches for TV shows by title and optional first air year.
+// When a year is given but yields no results, the search is retried without it.
+func (c *Client) SearchTVShow(title string, year *int) (*TVShowResult, error) {
 	params := url.Values{}
 	params.Set("query", title)
+	if year != nil && *year > 0 {
+		params.Set("first_air_date_year", fmt.Sprintf("%d", *year))
+	}
 
 	var response TVShowSearchResponse
 	if err := c.makeRequest("/search/tv", params, &response); err != nil {
 		return nil, err
 	}
 
+	if len(response.Results) == 0 && params.Has("first_air_date_year") {
+		c.logger.WithFields(map[string]interface{}{
+			"title": title,
+			"year":  *year,
+		}).Debug("no TV show found for year, retrying without year")
+		return c.SearchTVShow(title, nil)
+	}
+
 	if len(response.Results) == 0 {
-		return nil, fmt.Errorf("no results found for TV show: %s", title)
+		return nil, fmt.Errorf("%w for TV show: %s", ErrNoResults, title)
 	}
 
 	// Return the first (most relevant) result
@@ -227,6 +308,17 @@ func (c *Client) GetTVShowDetails(tvShowID int) (*TVShowDetails, error) {
 	return &details, nil
 }
 
+// GetSeasonDetails retrieves a season of a TV show with the names and air dates
+// of its episodes
+func (c *Client) GetSeasonDetails(tvShowID, season int) (*SeasonDetails, error) {
+	var details SeasonDetails
+	endpoint := fmt.Sprintf("/tv/%d/season/%d", tvShowID, season)
+	if err := c.makeRequest(endpoint, url.Values{}, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
 // GetMovieExternalIDs retrieves external IDs for a specific movie
 func (c *Client) GetMovieExternalIDs(movieID int) (*ExternalIDs, error) {
 	var externalIDs ExternalIDs
@@ -247,6 +339,51 @@ func (c *Client) GetTVShowExternalIDs(tvShowID int) (*ExternalIDs, error) {
 	return &externalIDs, nil
 }
 
+// Ping checks that the TMDB API is reachable and accepts the API key. It
+// bypasses the cache, rate limiter, circuit breaker and retries.
+func (c *Client) Ping(ctx context.Context) error {
+	params := url.Values{}
+	params.Set("api_key", c.apiKey)
+	requestURL := fmt.Sprintf("%s/configuration?%s", baseURL, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrInvalidAPIKey
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Validate checks the API key with a single /configuration call, so that a wrong
+// key can be reported once up front instead of failing every request. It returns
+// ErrInvalidAPIKey when TMDB answers 401.
+func (c *Client) Validate(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, validateTimeout)
+	defer cancel()
+
+	if err := c.Ping(ctx); err != nil {
+		if errors.Is(err, ErrInvalidAPIKey) {
+			return err
+		}
+		return fmt.Errorf("failed to validate TMDB API key: %w", err)
+	}
+	return nil
+}
+
 // makeRequest performs an HTTP request to the TMDB API with caching, rate limiting,
 // circuit breaker, and retry.
 func (c *Client) makeRequest(endpoint string, params url.Values, result interface{}) error {
@@ -264,15 +401,8 @@ func (c *Client) makeRequest(endpoint string, params url.Values, result interfac
 	}
 	c.cacheMu.RUnlock()
 
-	// Rate-limit: sleep until the minimum interval has elapsed since the last request.
-	if c.requestInterval > 0 {
-		if gap := c.requestInterval - time.Since(c.lastRequestAt); gap > 0 {
-			time.Sleep(gap)
-		}
-		c.lastRequestAt = time.Now()
-	}
-
 	ctx := context.Background()
+	timeout := c.timeoutFor(endpoint)
 	retryCfg := retry.Config{
 		MaxAttempts:       3,
 		InitialBackoff:    1 * time.Second,
@@ -285,9 +415,17 @@ func (c *Client) makeRequest(endpoint string, params url.Values, result interfac
 	var rawBody []byte
 
 	operation := func() error {
+		// Every attempt, retries included, takes a token from the shared bucket
+		if c.limiter != nil {
+			c.limiter.Wait()
+		}
+
 		// Execute through circuit breaker
 		return c.circuitBrk.Execute(func() error {
-			req, err := http.NewRequest("GET", requestURL, nil)
+			reqCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(reqCtx, "GET", requestURL, nil)
 			if err != nil {
 				return err
 			}
@@ -366,6 +504,21 @@ func (c *Client) makeRequest(endpoint string, params url.Values, result interfac
 	return nil
 }
 
+// timeoutFor returns the request timeout of an endpoint
+func (c *Client) timeoutFor(endpoint string) time.Duration {
+	kind := EndpointDetails
+	switch {
+	case strings.HasPrefix(endpoint, "/search/"):
+		kind = EndpointSearch
+	case strings.HasSuffix(endpoint, "/external_ids"):
+		kind = EndpointExternalIDs
+	}
+	if timeout, ok := c.endpointTimeout[kind]; ok && timeout > 0 {
+		return timeout
+	}
+	return c.timeout
+}
+
 // ExtractYear extracts year from TMDB date string (YYYY-MM-DD)
 func ExtractYear(dateStr string) int {
 	if dateStr == "" {
@@ -391,3 +544,89 @@ func FormatGenres(genres []Genre) string {
 	}
 	return strings.Join(names, ", ")
 }
+
+// genreSynonyms maps lowercased genre names to their normalized genres. TMDB TV
+// genres that combine two movie genres, such as "Sci-Fi & Fantasy", are split.
+var genreSynonyms = map[string][]string{
+	"sci-fi":                        {"Science Fiction"},
+	"scifi":                         {"Science Fiction"},
+	"science-fiction":               {"Science Fiction"},
+	"sci-fi & fantasy":              {"Science Fiction", "Fantasy"},
+	"science-fiction & fantastique": {"Science Fiction", "Fantastique"},
+	"action & adventure":            {"Action", "Adventure"},
+	"action & aventure":             {"Action", "Aventure"},
+	"war & politics":                {"War", "Politics"},
+	"guerre & politique":            {"Guerre", "Politique"},
+	"tv movie":                      {"TV Movie"},
+}
+
+// NormalizeGenre trims and title-cases a genre name, mapping known synonyms to
+// their normalized genres. It returns nil for an empty name.
+func NormalizeGenre(name string) []string {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return nil
+	}
+	if genres, ok := genreSynonyms[strings.ToLower(name)]; ok {
+		return genres
+	}
+
+	words := strings.Split(name, " ")
+	for i, word := range words {
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return []string{strings.Join(words, " ")}
+}
+
+// NormalizeGenres normalizes genre names with NormalizeGenre and drops the
+// duplicates, keeping the first occurrence
+func NormalizeGenres(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var normalized []string
+	for _, name := range names {
+		for _, genre := range NormalizeGenre(name) {
+			if !seen[genre] {
+				seen[genre] = true
+				normalized = append(normalized, genre)
+			}
+		}
+	}
+	return normalized
+}
+
+// HasGenre reports whether the genres formatted by FormatGenres contain genre.
+// Both sides are normalized by NormalizeGenres, so that "science fiction" matches
+// genres stored as "Sci-Fi & Fantasy" whether or not they were normalized.
+func HasGenre(genres, genre string) bool {
+	wanted := NormalizeGenre(genre)
+	if len(wanted) == 0 {
+		return false
+	}
+	stored := make(map[string]bool)
+	for _, name := range NormalizeGenres(strings.Split(genres, ",")) {
+		stored[name] = true
+	}
+	for _, name := range wanted {
+		if !stored[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// GenreAliases returns the lowercased names a normalized genre may be stored
+// under before normalization: the genre itself and the synonyms that map to it
+func GenreAliases(genre string) []string {
+	aliases := []string{strings.ToLower(genre)}
+	for synonym, genres := range genreSynonyms {
+		for _, name := range genres {
+			if name == genre && synonym != aliases[0] {
+				aliases = append(aliases, synonym)
+			}
+		}
+	}
+	sort.Strings(aliases[1:])
+	return aliases
+}
@@ -1,9 +1,13 @@
 package tmdb
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -90,6 +94,157 @@ func TestSearchMovieNotFound(t *testing.T) {
 	}
 }
 
+func TestSearchTVShowWithYear(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search/tv" {
+			t.Errorf("expected path '/search/tv', got '%s'", r.URL.Path)
+		}
+		query := r.URL.Query()
+		if query.Get("query") != "One Day at a Time" {
+			t.Errorf("expected query 'One Day at a Time', got '%s'", query.Get("query"))
+		}
+		if query.Get("first_air_date_year") != "2017" {
+			t.Errorf("expected first_air_date_year '2017', got '%s'", query.Get("first_air_date_year"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"page":1,"results":[{"id":67167,"name":"One Day at a Time","original_name":"One Day at a Time","first_air_date":"2017-01-06"}],"total_pages":1,"total_results":1}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, 0)
+
+	year := 2017
+	result, err := client.SearchTVShow("One Day at a Time", &year)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.ID != 67167 {
+		t.Errorf("expected ID 67167, got %d", result.ID)
+	}
+}
+
+func TestSearchTVShowWithoutYear(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("first_air_date_year") {
+			t.Errorf("expected no first_air_date_year, got '%s'", r.URL.Query().Get("first_air_date_year"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"page":1,"results":[{"id":1396,"name":"Breaking Bad","first_air_date":"2008-01-20"}],"total_pages":1,"total_results":1}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, 0)
+
+	if _, err := client.SearchTVShow("Breaking Bad", nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestSearchTVShowYearFallback(t *testing.T) {
+	var years []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		year := r.URL.Query().Get("first_air_date_year")
+		years = append(years, year)
+		w.Header().Set("Content-Type", "application/json")
+		if year != "" {
+			w.Write([]byte(`{"page":1,"results":[],"total_pages":0,"total_results":0}`))
+			return
+		}
+		w.Write([]byte(`{"page":1,"results":[{"id":1396,"name":"Breaking Bad","first_air_date":"2008-01-20"}],"total_pages":1,"total_results":1}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, 0)
+
+	year := 2010
+	result, err := client.SearchTVShow("Breaking Bad", &year)
+	if err != nil {
+		t.Fatalf("expected fallback result, got %v", err)
+	}
+	if result.ID != 1396 {
+		t.Errorf("expected ID 1396, got %d", result.ID)
+	}
+	if len(years) != 2 || years[0] != "2010" || years[1] != "" {
+		t.Errorf("expected a year search then a yearless search, got %v", years)
+	}
+}
+
+func TestGetMovieDetailsCollection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/movie/671":
+			w.Write([]byte(`{"id":671,"title":"Harry Potter and the Philosopher's Stone","release_date":"2001-11-16","belongs_to_collection":{"id":1241,"name":"Harry Potter Collection","poster_path":"/poster.jpg","backdrop_path":null}}`))
+		case "/movie/550":
+			w.Write([]byte(`{"id":550,"title":"Fight Club","release_date":"1999-10-15","belongs_to_collection":null}`))
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, 0)
+
+	details, err := client.GetMovieDetails(671)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if details.BelongsToCollection == nil {
+		t.Fatal("expected collection to be decoded")
+	}
+	if details.BelongsToCollection.ID != 1241 || details.BelongsToCollection.Name != "Harry Potter Collection" {
+		t.Errorf("unexpected collection: %+v", *details.BelongsToCollection)
+	}
+
+	details, err = client.GetMovieDetails(550)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if details.BelongsToCollection != nil {
+		t.Errorf("expected no collection, got %+v", *details.BelongsToCollection)
+	}
+}
+
+func TestGetSeasonDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tv/1396/season/1" {
+			t.Errorf("expected path /tv/1396/season/1, got %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":3572,"name":"Season 1","season_number":1,"air_date":"2008-01-20","episodes":[` +
+			`{"id":62085,"name":"Pilot","season_number":1,"episode_number":1,"air_date":"2008-01-20","runtime":58},` +
+			`{"id":62086,"name":"Cat's in the Bag...","season_number":1,"episode_number":2,"air_date":"2008-01-27","runtime":48},` +
+			`{"id":62087,"name":"TBA","season_number":1,"episode_number":3,"air_date":null,"runtime":null}]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, 0)
+
+	season, err := client.GetSeasonDetails(1396, 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if season.SeasonNumber != 1 || len(season.Episodes) != 3 {
+		t.Fatalf("expected season 1 with 3 episodes, got %+v", season)
+	}
+
+	episode := season.Episode(2)
+	if episode == nil {
+		t.Fatal("expected episode 2 to be found")
+	}
+	if episode.Name != "Cat's in the Bag..." || episode.AirDate != "2008-01-27" {
+		t.Errorf("unexpected episode 2: %+v", *episode)
+	}
+	if episode.Runtime == nil || *episode.Runtime != 48 {
+		t.Errorf("expected runtime 48, got %v", episode.Runtime)
+	}
+
+	if unaired := season.Episode(3); unaired == nil || unaired.AirDate != "" || unaired.Runtime != nil {
+		t.Errorf("expected episode 3 without air date nor runtime, got %+v", unaired)
+	}
+	if season.Episode(10) != nil {
+		t.Error("expected no episode 10")
+	}
+}
+
 func TestExtractYear(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -178,6 +333,133 @@ func TestRateLimitingDisabledWhenZero(t *testing.T) {
 	}
 }
 
+func TestRateLimitingSpacesRequests(t *testing.T) {
+	var mu sync.Mutex
+	var requestTimes []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestTimes = append(requestTimes, time.Now())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, movieJSON)
+	}))
+	defer server.Close()
+
+	// Two clients with the same settings draw from one shared bucket
+	first := newTestClient(server.URL, 5)
+	second := newTestClient(server.URL, 5)
+
+	year := 2020
+	for i := 0; i < 4; i++ {
+		client := first
+		if i%2 == 1 {
+			client = second
+		}
+		if _, err := client.SearchMovie(fmt.Sprintf("Spaced%d", i), &year); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+
+	// At 5 rps with a burst of 1, requests must be at least ~200ms apart
+	for i := 1; i < len(requestTimes); i++ {
+		if gap := requestTimes[i].Sub(requestTimes[i-1]); gap < 180*time.Millisecond {
+			t.Errorf("request %d followed the previous one after %v, expected ~200ms", i, gap)
+		}
+	}
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	limiter := newRateLimiter(2, 3)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.Wait()
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("expected the burst to pass without waiting, took %v", elapsed)
+	}
+
+	limiter.Wait()
+	if elapsed := time.Since(start); elapsed < 450*time.Millisecond {
+		t.Errorf("expected the request after the burst to wait ~500ms, took %v", elapsed)
+	}
+}
+
+func TestEndpointTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, movieJSON)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		APIKey:           "test-key",
+		Timeout:          5 * time.Second,
+		EndpointTimeouts: map[string]time.Duration{EndpointDetails: 50 * time.Millisecond},
+	})
+	baseURL = server.URL
+
+	if got := client.timeoutFor("/search/movie"); got != 5*time.Second {
+		t.Errorf("expected search to use the default timeout, got %v", got)
+	}
+	if got := client.timeoutFor("/movie/42/external_ids"); got != 5*time.Second {
+		t.Errorf("expected external ids to use the default timeout, got %v", got)
+	}
+	if _, err := client.GetMovieDetails(42); err == nil {
+		t.Error("expected the details request to time out")
+	}
+}
+
+func TestValidate_InvalidKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/configuration" {
+			t.Errorf("expected a /configuration request, got %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"status_code":7,"status_message":"Invalid API key: You must be granted a valid key.","success":false}`)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, 0)
+
+	err := client.Validate(context.Background())
+	if !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey, got %v", err)
+	}
+}
+
+func TestValidate_ValidKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != "test-key" {
+			t.Errorf("expected the API key to be sent, got %q", r.URL.Query().Get("api_key"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"images":{"base_url":"http://image.tmdb.org/t/p/","secure_base_url":"https://image.tmdb.org/t/p/"},"change_keys":[]}`)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, 0)
+
+	if err := client.Validate(context.Background()); err != nil {
+		t.Errorf("expected a valid key, got %v", err)
+	}
+}
+
+func TestValidate_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, 0)
+
+	err := client.Validate(context.Background())
+	if err == nil || errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expected an error other than ErrInvalidAPIKey, got %v", err)
+	}
+}
+
 func TestRetryAfterSecondsFormat(t *testing.T) {
 	attempt := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -283,3 +565,60 @@ func TestFormatGenres(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeGenres(t *testing.T) {
+	tests := []struct {
+		names    []string
+		expected []string
+	}{
+		{[]string{"Action", "Science Fiction"}, []string{"Action", "Science Fiction"}},
+		{[]string{"Sci-Fi & Fantasy", "Drama"}, []string{"Science Fiction", "Fantasy", "Drama"}},
+		{[]string{"Science-Fiction", "science fiction"}, []string{"Science Fiction"}},
+		{[]string{"Action & Adventure", "Action"}, []string{"Action", "Adventure"}},
+		{[]string{"  crime ", "DRAMA", "tv movie"}, []string{"Crime", "Drama", "TV Movie"}},
+		{[]string{"", " "}, nil},
+	}
+
+	for _, tt := range tests {
+		got := NormalizeGenres(tt.names)
+		if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+			t.Errorf("NormalizeGenres(%q) = %q, expected %q", tt.names, got, tt.expected)
+		}
+	}
+}
+
+func TestGenreAliases(t *testing.T) {
+	got := GenreAliases("Science Fiction")
+	expected := []string{"science fiction", "sci-fi", "sci-fi & fantasy", "science-fiction", "science-fiction & fantastique", "scifi"}
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("GenreAliases = %q, expected %q", got, expected)
+	}
+	if got := GenreAliases("Drama"); strings.Join(got, "|") != "drama" {
+		t.Errorf("GenreAliases(Drama) = %q, expected only the genre", got)
+	}
+}
+
+func TestHasGenre(t *testing.T) {
+	tests := []struct {
+		genres   string
+		genre    string
+		expected bool
+	}{
+		{"Action, Science Fiction", "Action", true},
+		{"Action, Science Fiction", "science fiction", true},
+		{"Action & Adventure", "Action", true},
+		{"Sci-Fi & Fantasy, Drama", "Science Fiction", true},
+		{"Science Fiction, Fantasy", "sci-fi & fantasy", true},
+		{"Science Fiction", "sci-fi & fantasy", false},
+		{"Action & Adventure", "Adventure Time", false},
+		{"Drama", "Comedy", false},
+		{"Drama", " ", false},
+		{"", "Drama", false},
+	}
+
+	for _, tt := range tests {
+		if got := HasGenre(tt.genres, tt.genre); got != tt.expected {
+			t.Errorf("HasGenre(%q, %q) = %v, expected %v", tt.genres, tt.genre, got, tt.expected)
+		}
+	}
+}
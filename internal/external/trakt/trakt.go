@@ -0,0 +1,205 @@
+package trakt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	apperrors "github.com/glefebvre/stalkeer/internal/apperrors"
+	"github.com/glefebvre/stalkeer/internal/httpclient"
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/glefebvre/stalkeer/internal/retry"
+)
+
+// apiVersion is the Trakt API version sent with every request
+const apiVersion = "2"
+
+// WatchlistList is the list name that selects the watchlist of a user
+const WatchlistList = "watchlist"
+
+// List item types
+const (
+	ItemTypeMovie   = "movie"
+	ItemTypeEpisode = "episode"
+)
+
+// Client represents a Trakt API client
+type Client struct {
+	baseURL     string
+	clientID    string
+	accessToken string
+	httpClient  *http.Client
+	retryConfig retry.Config
+	logger      *logger.Logger
+}
+
+// Config holds Trakt client configuration
+type Config struct {
+	BaseURL     string
+	ClientID    string
+	AccessToken string
+	Timeout     time.Duration
+	RetryConfig retry.Config
+	Logger      *logger.Logger
+}
+
+// IDs holds the identifiers of a Trakt movie, show or episode
+type IDs struct {
+	Trakt int    `json:"trakt"`
+	Slug  string `json:"slug"`
+	IMDB  string `json:"imdb"`
+	TMDB  int    `json:"tmdb"`
+	TVDB  int    `json:"tvdb"`
+}
+
+// Movie represents a Trakt movie
+type Movie struct {
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+	IDs   IDs    `json:"ids"`
+}
+
+// Show represents a Trakt show
+type Show struct {
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+	IDs   IDs    `json:"ids"`
+}
+
+// Episode represents a Trakt episode
+type Episode struct {
+	Season int    `json:"season"`
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	IDs    IDs    `json:"ids"`
+}
+
+// ListItem is an entry of a watchlist or list. Movie is set for movie items,
+// Show and Episode for episode items.
+type ListItem struct {
+	Rank     int       `json:"rank"`
+	ListedAt time.Time `json:"listed_at"`
+	Type     string    `json:"type"`
+	Movie    *Movie    `json:"movie,omitempty"`
+	Show     *Show     `json:"show,omitempty"`
+	Episode  *Episode  `json:"episode,omitempty"`
+}
+
+// New creates a new Trakt client. It returns an error when BaseURL is not a valid
+// http or https URL or when the client ID is missing.
+func New(cfg Config) (*Client, error) {
+	baseURL, err := httpclient.NormalizeBaseURL(cfg.BaseURL)
+	if err != nil {
+		return nil, apperrors.ConfigError("invalid trakt URL", err)
+	}
+	if cfg.ClientID == "" {
+		return nil, apperrors.ConfigError("trakt client ID is required", nil)
+	}
+
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	if cfg.RetryConfig.MaxAttempts == 0 {
+		cfg.RetryConfig = retry.DefaultConfig()
+	}
+
+	return &Client{
+		baseURL:     baseURL,
+		clientID:    cfg.ClientID,
+		accessToken: cfg.AccessToken,
+		httpClient:  httpclient.New(httpclient.ServiceTrakt, cfg.Timeout),
+		retryConfig: cfg.RetryConfig,
+		logger:      cfg.Logger,
+	}, nil
+}
+
+// GetWatchlist retrieves the movies and episodes of the watchlist of user.
+// Use "me" for the user owning the access token.
+func (c *Client) GetWatchlist(ctx context.Context, user string) ([]ListItem, error) {
+	endpoint := fmt.Sprintf("/users/%s/watchlist/movies,episodes", url.PathEscape(user))
+	return c.fetchItems(ctx, endpoint, "failed to get watchlist")
+}
+
+// GetList retrieves the movies and episodes of a personal list of user, identified
+// by its slug or Trakt ID
+func (c *Client) GetList(ctx context.Context, user, list string) ([]ListItem, error) {
+	endpoint := fmt.Sprintf("/users/%s/lists/%s/items/movie,episode", url.PathEscape(user), url.PathEscape(list))
+	return c.fetchItems(ctx, endpoint, "failed to get list")
+}
+
+// GetItems retrieves the watchlist when list is WatchlistList or empty, the named list otherwise
+func (c *Client) GetItems(ctx context.Context, user, list string) ([]ListItem, error) {
+	if list == "" || list == WatchlistList {
+		return c.GetWatchlist(ctx, user)
+	}
+	return c.GetList(ctx, user, list)
+}
+
+func (c *Client) fetchItems(ctx context.Context, endpoint, failure string) ([]ListItem, error) {
+	var items []ListItem
+	err := retry.Do(ctx, c.retryConfig, func() error {
+		i, err := c.getItems(ctx, endpoint)
+		if err != nil {
+			return err
+		}
+		items = i
+		return nil
+	}, apperrors.IsRetryable)
+
+	if err != nil {
+		return nil, apperrors.ExternalServiceError("trakt", failure, err)
+	}
+
+	if c.logger != nil {
+		c.logger.Info(fmt.Sprintf("trakt: fetched %d items", len(items)))
+	}
+
+	return items, nil
+}
+
+func (c *Client) getItems(ctx context.Context, endpoint string) ([]ListItem, error) {
+	req, err := c.newRequest(ctx, "GET", endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var items []ListItem
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return items, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, endpoint string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("trakt-api-version", apiVersion)
+	req.Header.Set("trakt-api-key", c.clientID)
+	if c.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	}
+
+	return req, nil
+}
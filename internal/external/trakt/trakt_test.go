@@ -0,0 +1,130 @@
+package trakt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/retry"
+)
+
+const listResponse = `[
+	{
+		"rank": 1,
+		"listed_at": "2026-01-10T20:00:00.000Z",
+		"type": "movie",
+		"movie": {"title": "Inception", "year": 2010, "ids": {"trakt": 16662, "slug": "inception-2010", "imdb": "tt1375666", "tmdb": 27205}}
+	},
+	{
+		"rank": 2,
+		"listed_at": "2026-01-11T20:00:00.000Z",
+		"type": "episode",
+		"episode": {"season": 1, "number": 2, "title": "Cat's in the Bag...", "ids": {"trakt": 62086, "tvdb": 349236, "tmdb": 62086}},
+		"show": {"title": "Breaking Bad", "year": 2008, "ids": {"trakt": 1388, "slug": "breaking-bad", "tvdb": 81189, "tmdb": 1396}}
+	}
+]`
+
+// newTestClient creates a client for server, failing the test on an invalid configuration
+func newTestClient(t *testing.T, serverURL string) *Client {
+	t.Helper()
+
+	client, err := New(Config{
+		BaseURL:     serverURL,
+		ClientID:    "test-client",
+		AccessToken: "test-token",
+		Timeout:     5 * time.Second,
+		RetryConfig: retry.Config{MaxAttempts: 1},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{BaseURL: "https://api.trakt.tv/", ClientID: "id"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := New(Config{BaseURL: "api.trakt.tv", ClientID: "id"}); err == nil {
+		t.Error("expected an error for a URL without scheme")
+	}
+	if _, err := New(Config{BaseURL: "https://api.trakt.tv"}); err == nil {
+		t.Error("expected an error without client ID")
+	}
+}
+
+func TestGetItems(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		list     string
+		wantPath string
+	}{
+		{name: "watchlist", user: "me", list: WatchlistList, wantPath: "/users/me/watchlist/movies,episodes"},
+		{name: "empty list is the watchlist", user: "me", list: "", wantPath: "/users/me/watchlist/movies,episodes"},
+		{name: "personal list", user: "jane", list: "to-grab", wantPath: "/users/jane/lists/to-grab/items/movie,episode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.wantPath {
+					t.Errorf("expected path %s, got %s", tt.wantPath, r.URL.Path)
+				}
+				if r.Header.Get("trakt-api-version") != "2" {
+					t.Errorf("expected trakt-api-version 2, got %q", r.Header.Get("trakt-api-version"))
+				}
+				if r.Header.Get("trakt-api-key") != "test-client" {
+					t.Errorf("expected trakt-api-key header, got %q", r.Header.Get("trakt-api-key"))
+				}
+				if r.Header.Get("Authorization") != "Bearer test-token" {
+					t.Errorf("expected bearer token, got %q", r.Header.Get("Authorization"))
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(listResponse))
+			}))
+			defer server.Close()
+
+			items, err := newTestClient(t, server.URL).GetItems(context.Background(), tt.user, tt.list)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(items) != 2 {
+				t.Fatalf("expected 2 items, got %d", len(items))
+			}
+
+			movie := items[0]
+			if movie.Type != ItemTypeMovie || movie.Movie == nil {
+				t.Fatalf("expected a movie item, got %+v", movie)
+			}
+			if movie.Movie.IDs.TMDB != 27205 || movie.Movie.Year != 2010 {
+				t.Errorf("unexpected movie %+v", movie.Movie)
+			}
+
+			episode := items[1]
+			if episode.Type != ItemTypeEpisode || episode.Episode == nil || episode.Show == nil {
+				t.Fatalf("expected an episode item, got %+v", episode)
+			}
+			if episode.Show.IDs.TMDB != 1396 || episode.Show.IDs.TVDB != 81189 {
+				t.Errorf("unexpected show %+v", episode.Show)
+			}
+			if episode.Episode.Season != 1 || episode.Episode.Number != 2 {
+				t.Errorf("unexpected episode %+v", episode.Episode)
+			}
+		})
+	}
+}
+
+func TestGetItems_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	if _, err := newTestClient(t, server.URL).GetWatchlist(context.Background(), "me"); err == nil {
+		t.Fatal("expected an error for an unauthorized request")
+	}
+}
@@ -99,8 +99,35 @@ func (m *Manager) LoadAll() error {
 	return nil
 }
 
+// Reasons of a Decision
+const (
+	ReasonNoFilter    = "no_filter"    // No filter applies to the attribute
+	ReasonExcluded    = "excluded"     // An exclude pattern matched
+	ReasonIncluded    = "included"     // An include pattern matched
+	ReasonNotIncluded = "not_included" // None of the include patterns matched
+	ReasonNotExcluded = "not_excluded" // Only exclude patterns apply and none matched
+)
+
+// Decision explains the outcome of the filters of an attribute for a value
+type Decision struct {
+	Attribute string   `json:"attribute"`
+	Value     string   `json:"value"`
+	Matched   bool     `json:"matched"`
+	Reason    string   `json:"reason"`
+	Pattern   string   `json:"pattern,omitempty"`   // Pattern that decided, for excluded and included
+	Unmatched []string `json:"unmatched,omitempty"` // Include patterns tried, for not_included
+}
+
 // Matches checks if an item matches the filters
 func (m *Manager) Matches(attribute, value string) bool {
+	return m.Explain(attribute, value).Matched
+}
+
+// Explain applies the filters of attribute to value and reports which pattern
+// decided the outcome
+func (m *Manager) Explain(attribute, value string) Decision {
+	decision := Decision{Attribute: attribute, Value: value, Matched: true, Reason: ReasonNoFilter}
+
 	// Find applicable filters
 	var applicableFilters []Filter
 	for _, filter := range m.filters {
@@ -111,7 +138,7 @@ func (m *Manager) Matches(attribute, value string) bool {
 
 	if len(applicableFilters) == 0 {
 		// No filters for this attribute, allow all
-		return true
+		return decision
 	}
 
 	// Runtime filters take precedence
@@ -132,30 +159,44 @@ func (m *Manager) Matches(attribute, value string) bool {
 	}
 
 	// Apply filters
+	decision.Reason = ReasonNotExcluded
 	for _, filter := range filtersToApply {
 		// Check exclude patterns first
 		for _, excludePattern := range filter.ExcludePatterns {
 			if excludePattern.MatchString(value) {
-				return false // Excluded
+				// Excluded
+				decision.Matched = false
+				decision.Reason = ReasonExcluded
+				decision.Pattern = excludePattern.String()
+				return decision
 			}
 		}
 
 		// If there are include patterns, at least one must match
 		if len(filter.IncludePatterns) > 0 {
-			matched := false
+			included, matched := false, ""
 			for _, includePattern := range filter.IncludePatterns {
 				if includePattern.MatchString(value) {
-					matched = true
+					included, matched = true, includePattern.String()
 					break
 				}
 			}
-			if !matched {
-				return false // Didn't match any include pattern
+			if !included {
+				// Didn't match any include pattern
+				decision.Matched = false
+				decision.Reason = ReasonNotIncluded
+				decision.Pattern = ""
+				for _, includePattern := range filter.IncludePatterns {
+					decision.Unmatched = append(decision.Unmatched, includePattern.String())
+				}
+				return decision
 			}
+			decision.Reason = ReasonIncluded
+			decision.Pattern = matched
 		}
 	}
 
-	return true
+	return decision
 }
 
 // ShouldProcess checks if an entry should be processed based on group-title and tvg-name
@@ -188,6 +229,15 @@ func (m *Manager) MatchesItem(item models.ProcessedLine) bool {
 	return true
 }
 
+// ExplainItem explains the group_title and tvg_name filters of a processed line.
+// The line matches when every decision matched.
+func (m *Manager) ExplainItem(item models.ProcessedLine) []Decision {
+	return []Decision{
+		m.Explain("group_title", item.GroupTitle),
+		m.Explain("tvg_name", item.TvgName),
+	}
+}
+
 // loadFilterSet loads and compiles a set of filter patterns
 func (m *Manager) loadFilterSet(attribute string, includePatterns, excludePatterns []string, isRuntime bool) error {
 	filter := Filter{
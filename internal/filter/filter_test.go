@@ -340,3 +340,45 @@ func BenchmarkMatchesItem(b *testing.B) {
 		m.MatchesItem(item)
 	}
 }
+
+func TestManager_Explain(t *testing.T) {
+	m := NewManager()
+	if err := m.loadFilterSet("group_title", []string{"^Movies", "^Films"}, []string{"XXX"}, false); err != nil {
+		t.Fatalf("Failed to load filter: %v", err)
+	}
+
+	tests := []struct {
+		value       string
+		wantMatched bool
+		wantReason  string
+		wantPattern string
+	}{
+		{"Movies HD", true, ReasonIncluded, "^Movies"},
+		{"Movies XXX", false, ReasonExcluded, "XXX"},
+		{"Series", false, ReasonNotIncluded, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got := m.Explain("group_title", tt.value)
+			if got.Matched != tt.wantMatched || got.Reason != tt.wantReason || got.Pattern != tt.wantPattern {
+				t.Errorf("Explain() = %+v, want matched %v, reason %s, pattern %q", got, tt.wantMatched, tt.wantReason, tt.wantPattern)
+			}
+		})
+	}
+
+	if got := m.Explain("group_title", "Series"); len(got.Unmatched) != 2 {
+		t.Errorf("expected the 2 include patterns tried, got %v", got.Unmatched)
+	}
+	if got := m.Explain("tvg_name", "The Matrix"); !got.Matched || got.Reason != ReasonNoFilter {
+		t.Errorf("expected no_filter for tvg_name, got %+v", got)
+	}
+
+	empty := NewManager()
+	if err := empty.loadFilterSet("group_title", []string{""}, nil, false); err != nil {
+		t.Fatalf("Failed to load filter: %v", err)
+	}
+	if got := empty.Explain("group_title", "Series"); !got.Matched || got.Reason != ReasonIncluded {
+		t.Errorf("expected the empty include pattern to match, got %+v", got)
+	}
+}
@@ -0,0 +1,152 @@
+// Package httpclient builds the HTTP clients used for all outbound requests,
+// so proxy and TLS settings are applied consistently across integrations.
+package httpclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Service identifies the integration a client is built for, so that
+// per-integration network overrides can be applied
+type Service string
+
+const (
+	ServiceTMDB      Service = "tmdb"
+	ServiceRadarr    Service = "radarr"
+	ServiceSonarr    Service = "sonarr"
+	ServiceTrakt     Service = "trakt"
+	ServicePlex      Service = "plex"
+	ServiceM3U       Service = "m3u"
+	ServiceDownloads Service = "downloads"
+)
+
+// DefaultUserAgent is sent to media and playlist providers when no user agent is configured
+const DefaultUserAgent = "Stalkeer/0.1.0"
+
+// insecureWarned records services for which the skip-verify warning was logged
+var insecureWarned sync.Map
+
+// New creates an HTTP client for the given service with a proxy-aware transport.
+// Requests to media and playlist providers carry the user agent and headers
+// configured for the service.
+func New(service Service, timeout time.Duration) *http.Client {
+	return NewWithTransport(service, NewTransport(service), timeout)
+}
+
+// NewWithTransport creates an HTTP client for the given service like New, on a
+// transport tuned by the caller, e.g. with its own dial or response header timeouts
+func NewWithTransport(service Service, transport *http.Transport, timeout time.Duration) *http.Client {
+	var rt http.RoundTripper = transport
+	if userAgent, headers, ok := config.Get().GetRequestHeaders(string(service)); ok {
+		rt = WithHeaders(rt, userAgent, headers)
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: rt,
+	}
+}
+
+// headerTransport adds default headers to the requests it sends
+type headerTransport struct {
+	base   http.RoundTripper
+	header http.Header
+}
+
+// WithHeaders wraps base so that every request carries userAgent, or DefaultUserAgent
+// when empty, and headers. Headers already set on a request take precedence.
+func WithHeaders(base http.RoundTripper, userAgent string, headers map[string]string) http.RoundTripper {
+	header := make(http.Header, len(headers)+1)
+	for name, value := range headers {
+		header.Set(name, value)
+	}
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	header.Set("User-Agent", userAgent)
+
+	return &headerTransport{base: base, header: header}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(req.Context())
+	for name, values := range t.header {
+		if _, ok := req.Header[name]; !ok {
+			req.Header[name] = values
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// NewTransport creates a transport based on http.DefaultTransport that uses
+// the proxy configured in network.proxy_url, or the environment otherwise.
+// TLS certificate verification is disabled when insecure_skip_verify is
+// enabled for the service.
+func NewTransport(service Service) *http.Transport {
+	cfg := config.Get()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = ProxyFunc(cfg.Network.ProxyURL)
+
+	if cfg.GetInsecureSkipVerify(string(service)) {
+		if _, warned := insecureWarned.LoadOrStore(service, true); !warned {
+			logger.AppLogger().WithFields(map[string]interface{}{
+				"service": service,
+			}).Warn("TLS certificate verification is DISABLED; connections are vulnerable to interception")
+		}
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return transport
+}
+
+// ProxyFunc returns the proxy selection function for the given proxy URL.
+// An empty proxyURL falls back to HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+// An explicit proxyURL is used for both HTTP and HTTPS, and NO_PROXY is still honored.
+func ProxyFunc(proxyURL string) func(*http.Request) (*url.URL, error) {
+	if proxyURL == "" {
+		return http.ProxyFromEnvironment
+	}
+
+	proxyCfg := &httpproxy.Config{
+		HTTPProxy:  proxyURL,
+		HTTPSProxy: proxyURL,
+		NoProxy:    httpproxy.FromEnvironment().NoProxy,
+	}
+	fn := proxyCfg.ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return fn(req.URL)
+	}
+}
+
+// NormalizeBaseURL validates the base URL of an API and trims its trailing slashes,
+// so that endpoint paths can be appended without producing double slashes.
+// The URL must be absolute with an http or https scheme and a host.
+func NormalizeBaseURL(rawURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("malformed URL %q: %w", rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("URL %q must start with http:// or https://", rawURL)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("URL %q has no host", rawURL)
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u.String(), nil
+}
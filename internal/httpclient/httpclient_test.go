@@ -0,0 +1,105 @@
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/config"
+)
+
+func TestNew_UsesConfiguredProxy(t *testing.T) {
+	t.Setenv("STALKEER_DATABASE_USER", "test")
+	t.Setenv("STALKEER_DATABASE_DBNAME", "test")
+	t.Setenv("STALKEER_NETWORK_PROXY_URL", "http://proxy.example.com:3128")
+	if err := config.Load(); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	client := New(ServiceTMDB, 5*time.Second)
+	if client.Timeout != 5*time.Second {
+		t.Errorf("expected timeout 5s, got %v", client.Timeout)
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected *http.Transport, got %T", client.Transport)
+	}
+
+	for _, target := range []string{"http://api.example.org/movies", "https://api.themoviedb.org/3/search/movie"} {
+		req, _ := http.NewRequest(http.MethodGet, target, nil)
+		proxyURL, err := transport.Proxy(req)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", target, err)
+		}
+		if proxyURL == nil || proxyURL.Host != "proxy.example.com:3128" {
+			t.Errorf("expected request to %s to use proxy.example.com:3128, got %v", target, proxyURL)
+		}
+	}
+}
+
+func TestProxyFunc_HonorsNoProxy(t *testing.T) {
+	t.Setenv("NO_PROXY", "internal.example.org")
+
+	proxy := ProxyFunc("http://proxy.example.com:3128")
+
+	req, _ := http.NewRequest(http.MethodGet, "http://internal.example.org/api", nil)
+	proxyURL, err := proxy(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if proxyURL != nil {
+		t.Errorf("expected no proxy for NO_PROXY host, got %v", proxyURL)
+	}
+}
+
+func TestProxyFunc_EmptyUsesEnvironment(t *testing.T) {
+	proxy := ProxyFunc("")
+	if proxy == nil {
+		t.Fatal("expected a proxy function")
+	}
+}
+
+func TestWithHeaders(t *testing.T) {
+	var got http.Header
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req.Header
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+
+	client := &http.Client{Transport: WithHeaders(base, "", map[string]string{"referer": "http://provider.example.com/"})}
+	req, _ := http.NewRequest(http.MethodGet, "http://provider.example.com/movie.mkv", nil)
+	req.Header.Set("Range", "bytes=10-")
+	if _, err := client.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ua := got.Get("User-Agent"); ua != DefaultUserAgent {
+		t.Errorf("expected default user agent %q, got %q", DefaultUserAgent, ua)
+	}
+	if referer := got.Get("Referer"); referer != "http://provider.example.com/" {
+		t.Errorf("expected configured Referer header, got %q", referer)
+	}
+	if rng := got.Get("Range"); rng != "bytes=10-" {
+		t.Errorf("expected request header to be kept, got Range %q", rng)
+	}
+	if req.Header.Get("User-Agent") != "" {
+		t.Error("the caller's request must not be modified")
+	}
+
+	client.Transport = WithHeaders(base, "VLC/3.0.20", nil)
+	req, _ = http.NewRequest(http.MethodGet, "http://provider.example.com/movie.mkv", nil)
+	if _, err := client.Do(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ua := got.Get("User-Agent"); ua != "VLC/3.0.20" {
+		t.Errorf("expected configured user agent, got %q", ua)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
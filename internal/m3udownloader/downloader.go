@@ -13,6 +13,7 @@ import (
 
 	"github.com/glefebvre/stalkeer/internal/circuitbreaker"
 	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/httpclient"
 	"github.com/glefebvre/stalkeer/internal/logger"
 	"github.com/glefebvre/stalkeer/internal/retry"
 )
@@ -42,7 +43,8 @@ type Downloader struct {
 func NewDownloader(cfg *config.M3UDownloadConfig, log *logger.Logger) *Downloader {
 	// Create HTTP client with timeout
 	httpClient := &http.Client{
-		Timeout: time.Duration(cfg.TimeoutSeconds) * time.Second,
+		Timeout:   time.Duration(cfg.TimeoutSeconds) * time.Second,
+		Transport: httpclient.WithHeaders(httpclient.NewTransport(httpclient.ServiceM3U), cfg.UserAgent, cfg.Headers),
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			// Limit redirects to 10
 			if len(via) >= 10 {
@@ -228,6 +228,40 @@ http://example.com/stream.m3u8
 	}
 }
 
+func TestDownload_UserAgentAndHeaders(t *testing.T) {
+	_, cfg := setupTestDownloader(t)
+	cfg.RetryAttempts = 1
+
+	m3uContent := `#EXTM3U
+#EXTINF:-1,Test Channel
+http://example.com/stream.m3u8
+`
+
+	// Create mock HTTP server that only serves the expected client
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.UserAgent() != "TiviMate/4.7.0" || r.Header.Get("Referer") != "http://portal.example.com/" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(m3uContent))
+	}))
+	defer server.Close()
+
+	log := logger.NewWithLevelAndFormat("info", "text")
+	destPath := filepath.Join(t.TempDir(), "playlist.m3u")
+
+	if err := NewDownloader(cfg, log).Download(context.Background(), server.URL, destPath); err == nil {
+		t.Fatal("expected download with the default user agent to be rejected")
+	}
+
+	cfg.UserAgent = "TiviMate/4.7.0"
+	cfg.Headers = map[string]string{"referer": "http://portal.example.com/"}
+	if err := NewDownloader(cfg, log).Download(context.Background(), server.URL, destPath); err != nil {
+		t.Fatalf("Download with configured headers failed: %v", err)
+	}
+}
+
 func TestDownloadAndArchive(t *testing.T) {
 	downloader, _ := setupTestDownloader(t)
 
@@ -3,6 +3,7 @@ package matcher
 import (
 	"errors"
 	"regexp"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -14,13 +15,15 @@ import (
 
 // Config holds matcher configuration
 type Config struct {
-	MinConfidence float64
+	MinConfidence  float64 // Confidence below which MatchMovie, MatchEpisode and the Match*By* functions reject a match
+	FuzzyThreshold float64 // Title similarity below which the fuzzy fallback of the Match*By* functions rejects a match
 }
 
 // DefaultConfig returns sensible defaults for matcher
 func DefaultConfig() Config {
 	return Config{
-		MinConfidence: 0.8,
+		MinConfidence:  0.8,
+		FuzzyThreshold: 0.7,
 	}
 }
 
@@ -101,7 +104,7 @@ func (m *Matcher) MatchEpisode(line *models.ProcessedLine, series *sonarr.Series
 	// Calculate season/episode match
 	seasonEpisodeScore := 0.0
 	if line.TVShow != nil && line.TVShow.Season != nil && line.TVShow.Episode != nil {
-		if *line.TVShow.Season == episode.SeasonNumber && *line.TVShow.Episode == episode.EpisodeNumber {
+		if *line.TVShow.Season == episode.SeasonNumber && coversEpisode(line.TVShow, episode.EpisodeNumber) {
 			seasonEpisodeScore = 1.0
 		}
 	}
@@ -171,9 +174,48 @@ func FindTVShowDownloadCandidates(db *gorm.DB, tvshowID uint) ([]models.Processe
 	return candidates, err
 }
 
-// MatchMovieByTVDB finds a movie in the database by TVDB ID with fallback to TMDB ID
+// PreferLanguages reorders download candidates by the rank of their language tag in
+// priority, e.g. ["VF", "MULTI", "VOSTFR"]. Candidates tagged with a language missing
+// from priority are dropped, untagged ones come after every listed language. The
+// order within a rank is kept. An empty priority returns candidates unchanged.
+func PreferLanguages(candidates []models.ProcessedLine, priority []string) []models.ProcessedLine {
+	if len(priority) == 0 {
+		return candidates
+	}
+
+	ranks := make(map[string]int, len(priority))
+	for i, language := range priority {
+		ranks[strings.ToUpper(language)] = i
+	}
+	untagged := len(priority)
+
+	preferred := make([]models.ProcessedLine, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.Language == nil {
+			preferred = append(preferred, candidate)
+			continue
+		}
+		if _, ok := ranks[strings.ToUpper(*candidate.Language)]; ok {
+			preferred = append(preferred, candidate)
+		}
+	}
+
+	rank := func(line models.ProcessedLine) int {
+		if line.Language == nil {
+			return untagged
+		}
+		return ranks[strings.ToUpper(*line.Language)]
+	}
+	sort.SliceStable(preferred, func(i, j int) bool {
+		return rank(preferred[i]) < rank(preferred[j])
+	})
+	return preferred
+}
+
+// MatchMovieByTVDB finds a movie in the database by TVDB ID with fallback to TMDB ID.
+// cfg is the same as for MatchMovieByTMDB.
 // Returns (movie, processedLine, confidence, error)
-func MatchMovieByTVDB(db *gorm.DB, tvdbID int, tmdbID int, title string, year int) (*models.Movie, *models.ProcessedLine, int, error) {
+func MatchMovieByTVDB(db *gorm.DB, tvdbID int, tmdbID int, title string, year int, cfg Config) (*models.Movie, *models.ProcessedLine, int, error) {
 	// Primary match: exact TVDB ID
 	if tvdbID > 0 {
 		var movie models.Movie
@@ -196,12 +238,14 @@ func MatchMovieByTVDB(db *gorm.DB, tvdbID int, tmdbID int, title string, year in
 	}
 
 	// Fallback to TMDB matching
-	return MatchMovieByTMDB(db, tmdbID, title, year)
+	return MatchMovieByTMDB(db, tmdbID, title, year, cfg)
 }
 
-// MatchMovieByTMDB finds a movie in the database by TMDB ID with fallback to title/year matching
+// MatchMovieByTMDB finds a movie in the database by TMDB ID with fallback to title/year matching.
+// The fallback only accepts a movie whose score reaches both cfg.FuzzyThreshold and
+// cfg.MinConfidence, see matcher.fuzzy_threshold and matcher.min_confidence.
 // Returns (movie, processedLine, confidence, error)
-func MatchMovieByTMDB(db *gorm.DB, tmdbID int, title string, year int) (*models.Movie, *models.ProcessedLine, int, error) {
+func MatchMovieByTMDB(db *gorm.DB, tmdbID int, title string, year int, cfg Config) (*models.Movie, *models.ProcessedLine, int, error) {
 	// Primary match: exact TMDB ID
 	var movie models.Movie
 	err := db.Where("tmdb_id = ?", tmdbID).Take(&movie).Error
@@ -232,7 +276,7 @@ func MatchMovieByTMDB(db *gorm.DB, tmdbID int, title string, year int) (*models.
 		return nil, nil, 0, err
 	}
 
-	matcher := New(DefaultConfig())
+	matcher := New(cfg)
 	var bestMovie *models.Movie
 	var bestScore float64
 
@@ -247,7 +291,7 @@ func MatchMovieByTMDB(db *gorm.DB, tmdbID int, title string, year int) (*models.
 			score = score*0.8 + 0.2
 		}
 
-		if score > bestScore && score >= 0.7 {
+		if score > bestScore && score >= cfg.FuzzyThreshold && score >= cfg.MinConfidence {
 			bestScore = score
 			bestMovie = &movies[i]
 		}
@@ -271,13 +315,17 @@ func MatchMovieByTMDB(db *gorm.DB, tmdbID int, title string, year int) (*models.
 	return bestMovie, &processedLine, confidence, nil
 }
 
-// MatchTVShowByTVDB finds a TV show episode in the database by TVDB ID with fallback to TMDB ID
+// MatchTVShowByTVDB finds a TV show episode in the database by TVDB ID with fallback to TMDB ID.
+// With exactEpisode, see matcher.require_exact_episode, season and episode must match even
+// when they are 0 (specials); otherwise a 0 season or episode matches any. The title
+// fallback only accepts a show whose score reaches both cfg.FuzzyThreshold and
+// cfg.MinConfidence, see matcher.fuzzy_threshold and matcher.min_confidence.
 // Returns (tvshow, processedLine, confidence, error)
-func MatchTVShowByTVDB(db *gorm.DB, tvdbID int, tmdbID int, title string, season, episode int) (*models.TVShow, *models.ProcessedLine, int, error) {
+func MatchTVShowByTVDB(db *gorm.DB, tvdbID int, tmdbID int, title string, season, episode int, exactEpisode bool, cfg Config) (*models.TVShow, *models.ProcessedLine, int, error) {
 	// Primary match: exact TVDB ID + season + episode
 	if tvdbID > 0 {
 		var tvshow models.TVShow
-		query := applyTVShowEpisodeFilters(db.Where("tvdb_id = ?", tvdbID), season, episode)
+		query := applyTVShowEpisodeFilters(db.Where("tvdb_id = ?", tvdbID), season, episode, exactEpisode)
 		err := query.Take(&tvshow).Error
 		if err == nil {
 			// Found exact TVDB match, get processed line
@@ -297,15 +345,16 @@ func MatchTVShowByTVDB(db *gorm.DB, tvdbID int, tmdbID int, title string, season
 	}
 
 	// Fallback to TMDB matching
-	return MatchTVShowByTMDB(db, tmdbID, title, season, episode)
+	return MatchTVShowByTMDB(db, tmdbID, title, season, episode, exactEpisode, cfg)
 }
 
-// MatchTVShowByTMDB finds a TV show episode in the database by TMDB ID, season, and episode
+// MatchTVShowByTMDB finds a TV show episode in the database by TMDB ID, season, and episode.
+// exactEpisode and cfg are the same as for MatchTVShowByTVDB.
 // Returns (tvshow, processedLine, confidence, error)
-func MatchTVShowByTMDB(db *gorm.DB, tmdbID int, title string, season, episode int) (*models.TVShow, *models.ProcessedLine, int, error) {
+func MatchTVShowByTMDB(db *gorm.DB, tmdbID int, title string, season, episode int, exactEpisode bool, cfg Config) (*models.TVShow, *models.ProcessedLine, int, error) {
 	// Primary match: exact TMDB ID + season + episode
 	var tvshow models.TVShow
-	query := applyTVShowEpisodeFilters(db.Where("tmdb_id = ?", tmdbID), season, episode)
+	query := applyTVShowEpisodeFilters(db.Where("tmdb_id = ?", tmdbID), season, episode, exactEpisode)
 	err := query.Take(&tvshow).Error
 	if err == nil {
 		// Found exact match, get processed line
@@ -329,19 +378,13 @@ func MatchTVShowByTMDB(db *gorm.DB, tmdbID int, title string, season, episode in
 	}
 
 	var tvshows []models.TVShow
-	query = db.Model(&models.TVShow{})
-	if season > 0 {
-		query = query.Where("season = ?", season)
-	}
-	if episode > 0 {
-		query = query.Where("episode = ?", episode)
-	}
+	query = applyTVShowEpisodeFilters(db.Model(&models.TVShow{}), season, episode, exactEpisode)
 	err = query.Find(&tvshows).Error
 	if err != nil {
 		return nil, nil, 0, err
 	}
 
-	matcher := New(DefaultConfig())
+	matcher := New(cfg)
 	var bestShow *models.TVShow
 	var bestScore float64
 
@@ -355,11 +398,11 @@ func MatchTVShowByTMDB(db *gorm.DB, tmdbID int, title string, season, episode in
 		if tvshows[i].Season != nil && season > 0 && *tvshows[i].Season == season {
 			score = score*0.7 + 0.15
 		}
-		if tvshows[i].Episode != nil && episode > 0 && *tvshows[i].Episode == episode {
+		if episode > 0 && coversEpisode(&tvshows[i], episode) {
 			score = score*0.7 + 0.15
 		}
 
-		if score > bestScore && score >= 0.7 {
+		if score > bestScore && score >= cfg.FuzzyThreshold && score >= cfg.MinConfidence {
 			bestScore = score
 			bestShow = &tvshows[i]
 		}
@@ -383,6 +426,47 @@ func MatchTVShowByTMDB(db *gorm.DB, tmdbID int, title string, season, episode in
 	return bestShow, &processedLine, confidence, nil
 }
 
+// FindSeasonPack finds a season pack of a TV show in the database by TVDB ID or
+// TMDB ID. It returns gorm.ErrRecordNotFound when the season has no pack.
+func FindSeasonPack(db *gorm.DB, tvdbID, tmdbID, season int) (*models.TVShow, error) {
+	query := db.Where("season = ? AND season_pack = ?", season, true)
+
+	switch {
+	case tvdbID > 0 && tmdbID > 0:
+		query = query.Where("tvdb_id = ? OR tmdb_id = ?", tvdbID, tmdbID)
+	case tvdbID > 0:
+		query = query.Where("tvdb_id = ?", tvdbID)
+	case tmdbID > 0:
+		query = query.Where("tmdb_id = ?", tmdbID)
+	default:
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var pack models.TVShow
+	if err := query.Take(&pack).Error; err != nil {
+		return nil, err
+	}
+	return &pack, nil
+}
+
+// PreferSeasonPack reports whether a season should be downloaded as a pack rather
+// than episode by episode: the season has no episode file in Sonarr and all of its
+// aired episodes are in the missing list.
+func PreferSeasonPack(series *sonarr.Series, season, missingEpisodes int) bool {
+	if series == nil {
+		return false
+	}
+
+	for _, s := range series.Seasons {
+		if s.SeasonNumber != season || s.Statistics == nil {
+			continue
+		}
+		stats := s.Statistics
+		return stats.EpisodeFileCount == 0 && stats.EpisodeCount > 0 && missingEpisodes >= stats.EpisodeCount
+	}
+	return false
+}
+
 // normalizeTitle normalizes a title for comparison
 func (m *Matcher) normalizeTitle(title string) string {
 	// Convert to lowercase
@@ -421,17 +505,34 @@ func (m *Matcher) normalizeTitle(title string) string {
 	return strings.TrimSpace(title)
 }
 
-func applyTVShowEpisodeFilters(query *gorm.DB, season, episode int) *gorm.DB {
-	if season > 0 {
+// applyTVShowEpisodeFilters restricts query to the season and episode. A 0 season or
+// episode is not filtered on, unless exact is set.
+func applyTVShowEpisodeFilters(query *gorm.DB, season, episode int, exact bool) *gorm.DB {
+	if season > 0 || exact {
 		query = query.Where("season = ?", season)
 	}
-	if episode > 0 {
-		query = query.Where("episode = ?", episode)
+	if episode > 0 || exact {
+		// Multi-episode streams cover every episode of their range, a single
+		// episode stream is preferred over a range
+		query = query.Where("episode <= ? AND COALESCE(episode_end, episode) >= ?", episode, episode).
+			Order("COALESCE(episode_end, episode) - episode")
 	}
 
 	return query
 }
 
+// coversEpisode reports whether show is episode, or a multi-episode stream whose range includes it
+func coversEpisode(show *models.TVShow, episode int) bool {
+	if show.Episode == nil {
+		return false
+	}
+	last := *show.Episode
+	if show.EpisodeEnd != nil {
+		last = *show.EpisodeEnd
+	}
+	return *show.Episode <= episode && episode <= last
+}
+
 // calculateStringSimilarity calculates similarity between two strings using Levenshtein distance
 func (m *Matcher) calculateStringSimilarity(s1, s2 string) float64 {
 	if s1 == s2 {
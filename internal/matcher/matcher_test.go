@@ -2,6 +2,7 @@ package matcher
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/glefebvre/stalkeer/internal/external/radarr"
@@ -336,6 +337,10 @@ func TestLevenshteinDistance(t *testing.T) {
 	}
 }
 
+// fuzzyOnlyConfig accepts fuzzy matches down to the default fuzzy threshold, below
+// the default minimum confidence, see TestMatchByTVDB_MinConfidence
+var fuzzyOnlyConfig = Config{FuzzyThreshold: DefaultConfig().FuzzyThreshold}
+
 func TestMatchMovieByTMDB(t *testing.T) {
 	// Setup in-memory database
 	db := setupTestDB(t)
@@ -437,7 +442,7 @@ func TestMatchMovieByTMDB(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			movie, processedLine, confidence, err := MatchMovieByTMDB(db, tt.tmdbID, tt.title, tt.year)
+			movie, processedLine, confidence, err := MatchMovieByTMDB(db, tt.tmdbID, tt.title, tt.year, fuzzyOnlyConfig)
 
 			if tt.expectMatch {
 				if err != nil {
@@ -592,7 +597,7 @@ func TestMatchTVShowByTMDB(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tvshow, processedLine, confidence, err := MatchTVShowByTMDB(db, tt.tmdbID, tt.title, tt.season, tt.episode)
+			tvshow, processedLine, confidence, err := MatchTVShowByTMDB(db, tt.tmdbID, tt.title, tt.season, tt.episode, false, fuzzyOnlyConfig)
 
 			if tt.expectMatch {
 				if err != nil {
@@ -667,7 +672,7 @@ func TestMatchTVShowByTVDB(t *testing.T) {
 		t.Fatalf("failed to create processed line: %v", err)
 	}
 
-	matchedShow, matchedLine, confidence, err := MatchTVShowByTVDB(db, tvdbID, 0, "Malcolm in the Middle", season, episode)
+	matchedShow, matchedLine, confidence, err := MatchTVShowByTVDB(db, tvdbID, 0, "Malcolm in the Middle", season, episode, false, DefaultConfig())
 	if err != nil {
 		t.Fatalf("expected TVDB match, got error: %v", err)
 	}
@@ -688,6 +693,171 @@ func TestMatchTVShowByTVDB(t *testing.T) {
 	}
 }
 
+func TestMatchTVShowByTMDB_MultiEpisode(t *testing.T) {
+	db := setupTestDB(t)
+
+	season, first, last, single := 1, 1, 3, 3
+	shows := []models.TVShow{
+		{TMDBID: 1396, TMDBTitle: "Breaking Bad", Season: &season, Episode: &first, EpisodeEnd: &last},
+		{TMDBID: 1396, TMDBTitle: "Breaking Bad", Season: &season, Episode: &single, EpisodeEnd: &single},
+	}
+	if err := db.Create(&shows).Error; err != nil {
+		t.Fatalf("failed to create test tvshows: %v", err)
+	}
+	for i, show := range shows {
+		lineURL := fmt.Sprintf("http://example.com/bb-%d.mkv", i)
+		line := models.ProcessedLine{
+			TVShowID:    &show.ID,
+			TvgName:     show.TMDBTitle,
+			LineURL:     &lineURL,
+			LineContent: "#EXTINF:-1," + show.TMDBTitle,
+			LineHash:    fmt.Sprintf("multi-episode-hash-%d", i),
+			GroupTitle:  "TV Shows",
+			ContentType: models.ContentTypeTVShows,
+			State:       models.StateProcessed,
+		}
+		if err := db.Create(&line).Error; err != nil {
+			t.Fatalf("failed to create processed line: %v", err)
+		}
+	}
+
+	tests := []struct {
+		episode  int
+		expected uint
+	}{
+		{1, shows[0].ID},
+		{2, shows[0].ID},
+		{3, shows[1].ID}, // the single episode stream is preferred over the range
+	}
+	for _, tt := range tests {
+		matched, _, _, err := MatchTVShowByTMDB(db, 1396, "", season, tt.episode, false, DefaultConfig())
+		if err != nil {
+			t.Fatalf("episode %d: expected a match, got error: %v", tt.episode, err)
+		}
+		if matched.ID != tt.expected {
+			t.Errorf("episode %d: expected tvshow %d, got %d", tt.episode, tt.expected, matched.ID)
+		}
+	}
+
+	if _, _, _, err := MatchTVShowByTMDB(db, 1396, "", season, 4, false, DefaultConfig()); err == nil {
+		t.Error("expected no match for an episode outside the range")
+	}
+}
+
+func TestMatchTVShowByTVDB_ExactEpisode(t *testing.T) {
+	db := setupTestDB(t)
+
+	tvdbID, season, episode := 81189, 1, 2
+	show := models.TVShow{TMDBID: 1396, TVDBID: &tvdbID, TMDBTitle: "Breaking Bad", Season: &season, Episode: &episode}
+	if err := db.Create(&show).Error; err != nil {
+		t.Fatalf("failed to create test tvshow: %v", err)
+	}
+	lineURL := "http://example.com/bb-s01e02.mkv"
+	line := models.ProcessedLine{
+		TVShowID:    &show.ID,
+		TvgName:     show.TMDBTitle,
+		LineURL:     &lineURL,
+		LineContent: "#EXTINF:-1," + show.TMDBTitle,
+		LineHash:    "exact-episode-hash",
+		GroupTitle:  "TV Shows",
+		ContentType: models.ContentTypeTVShows,
+		State:       models.StateProcessed,
+	}
+	if err := db.Create(&line).Error; err != nil {
+		t.Fatalf("failed to create processed line: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		season, episode int
+		lenientMatches  bool
+	}{
+		{"same episode", 1, 2, true},
+		{"episode 0 of the season", 1, 0, true},
+		{"special of season 0", 0, 2, true},
+		{"other episode", 1, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := MatchTVShowByTVDB(db, tvdbID, 1396, "Breaking Bad", tt.season, tt.episode, false, DefaultConfig())
+			if matched := err == nil; matched != tt.lenientMatches {
+				t.Errorf("lenient mode: matched = %v, want %v (err: %v)", matched, tt.lenientMatches, err)
+			}
+
+			exactMatches := tt.season == season && tt.episode == episode
+			_, _, _, err = MatchTVShowByTVDB(db, tvdbID, 1396, "Breaking Bad", tt.season, tt.episode, true, DefaultConfig())
+			if matched := err == nil; matched != exactMatches {
+				t.Errorf("exact mode: matched = %v, want %v (err: %v)", matched, exactMatches, err)
+			}
+		})
+	}
+}
+
+func TestFindSeasonPack(t *testing.T) {
+	db := setupTestDB(t)
+
+	season1, season2, episode := 1, 2, 1
+	tvdbID := 81189
+	shows := []models.TVShow{
+		{TMDBID: 1396, TVDBID: &tvdbID, TMDBTitle: "Breaking Bad", Season: &season1, SeasonPack: true},
+		{TMDBID: 1396, TVDBID: &tvdbID, TMDBTitle: "Breaking Bad", Season: &season2, Episode: &episode},
+	}
+	if err := db.Create(&shows).Error; err != nil {
+		t.Fatalf("failed to create test tvshows: %v", err)
+	}
+
+	pack, err := FindSeasonPack(db, tvdbID, 0, 1)
+	if err != nil {
+		t.Fatalf("expected season 1 pack, got error: %v", err)
+	}
+	if pack.ID != shows[0].ID {
+		t.Errorf("expected tvshow ID %d, got %d", shows[0].ID, pack.ID)
+	}
+
+	if _, err := FindSeasonPack(db, 0, 1396, 1); err != nil {
+		t.Errorf("expected season 1 pack by TMDB ID, got error: %v", err)
+	}
+
+	if _, err := FindSeasonPack(db, tvdbID, 0, 2); err != gorm.ErrRecordNotFound {
+		t.Errorf("expected no pack for season 2 which only has episodes, got %v", err)
+	}
+}
+
+func TestPreferSeasonPack(t *testing.T) {
+	series := &sonarr.Series{
+		Seasons: []sonarr.Season{
+			{SeasonNumber: 1, Statistics: &sonarr.SeasonStatistics{EpisodeFileCount: 0, EpisodeCount: 7, TotalEpisodeCount: 7}},
+			{SeasonNumber: 2, Statistics: &sonarr.SeasonStatistics{EpisodeFileCount: 3, EpisodeCount: 13, TotalEpisodeCount: 13}},
+			{SeasonNumber: 3, Statistics: &sonarr.SeasonStatistics{EpisodeFileCount: 0, EpisodeCount: 0, TotalEpisodeCount: 13}},
+			{SeasonNumber: 4},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		series          *sonarr.Series
+		season          int
+		missingEpisodes int
+		expected        bool
+	}{
+		{"whole season missing", series, 1, 7, true},
+		{"some episodes missing from the list", series, 1, 5, false},
+		{"season partly downloaded", series, 2, 10, false},
+		{"season not aired yet", series, 3, 0, false},
+		{"no statistics", series, 4, 10, false},
+		{"unknown season", series, 5, 10, false},
+		{"no series", nil, 1, 7, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PreferSeasonPack(tt.series, tt.season, tt.missingEpisodes); got != tt.expected {
+				t.Errorf("PreferSeasonPack() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestFindMovieDownloadCandidates(t *testing.T) {
 	db := setupTestDB(t)
 
@@ -834,6 +1004,48 @@ func TestFindMovieDownloadCandidatesExcludesDownloaded(t *testing.T) {
 }
 
 // setupTestDB creates an in-memory SQLite database for testing
+func TestPreferLanguages(t *testing.T) {
+	line := func(id uint, language string) models.ProcessedLine {
+		l := models.ProcessedLine{ID: id}
+		if language != "" {
+			l.Language = &language
+		}
+		return l
+	}
+	// Ordered by resolution, as returned by FindMovieDownloadCandidates
+	candidates := []models.ProcessedLine{
+		line(1, "VOSTFR"),
+		line(2, "MULTI"),
+		line(3, ""),
+		line(4, "VO"),
+		line(5, "VF"),
+		line(6, "MULTI"),
+	}
+
+	tests := []struct {
+		name     string
+		priority []string
+		expected []uint
+	}{
+		{"VF then MULTI then VOSTFR", []string{"VF", "MULTI", "VOSTFR"}, []uint{5, 2, 6, 1, 3}},
+		{"case insensitive", []string{"vostfr"}, []uint{1, 3}},
+		{"no priority", nil, []uint{1, 2, 3, 4, 5, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preferred := PreferLanguages(candidates, tt.priority)
+			ids := make([]uint, len(preferred))
+			for i, candidate := range preferred {
+				ids[i] = candidate.ID
+			}
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("expected candidates %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+}
+
 func setupTestDB(t *testing.T) *gorm.DB {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	if err != nil {
@@ -852,3 +1064,117 @@ func setupTestDB(t *testing.T) *gorm.DB {
 
 	return db
 }
+
+func TestMatchByTMDB_FuzzyThreshold(t *testing.T) {
+	db := setupTestDB(t)
+
+	season, episode := 1, 1
+	movie := models.Movie{TMDBID: 603, TMDBTitle: "The Matrix", TMDBYear: 1999}
+	show := models.TVShow{TMDBID: 1396, TMDBTitle: "Breaking Bad", Season: &season, Episode: &episode}
+	if err := db.Create(&movie).Error; err != nil {
+		t.Fatalf("failed to create test movie: %v", err)
+	}
+	if err := db.Create(&show).Error; err != nil {
+		t.Fatalf("failed to create test tvshow: %v", err)
+	}
+	lineURL := "http://example.com/stream.mkv"
+	lines := []models.ProcessedLine{
+		{MovieID: &movie.ID, TvgName: "The Matrix", LineURL: &lineURL, LineContent: "#EXTINF:-1,The Matrix", LineHash: "threshold-movie", GroupTitle: "Movies", ContentType: models.ContentTypeMovies, State: models.StateProcessed},
+		{TVShowID: &show.ID, TvgName: "Breaking Bad S01E01", LineURL: &lineURL, LineContent: "#EXTINF:-1,Breaking Bad S01E01", LineHash: "threshold-show", GroupTitle: "Series", ContentType: models.ContentTypeTVShows, State: models.StateProcessed},
+	}
+	if err := db.Create(&lines).Error; err != nil {
+		t.Fatalf("failed to create processed lines: %v", err)
+	}
+
+	// "the matriks" scores 0.81 against "the matrix", "braking bad" 0.70 against
+	// "breaking bad" once weighted by the season and episode boosts
+	tests := []struct {
+		name      string
+		threshold float64
+		wantMovie bool
+		wantShow  bool
+	}{
+		{name: "lenient", threshold: 0.6, wantMovie: true, wantShow: true},
+		{name: "default", threshold: DefaultConfig().FuzzyThreshold, wantMovie: true, wantShow: true},
+		{name: "strict", threshold: 0.75, wantMovie: true, wantShow: false},
+		{name: "stricter", threshold: 0.85, wantMovie: false, wantShow: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := MatchMovieByTMDB(db, 0, "The Matriks", 2000, Config{FuzzyThreshold: tt.threshold})
+			if (err == nil) != tt.wantMovie {
+				t.Errorf("movie match = %v, want %v (err: %v)", err == nil, tt.wantMovie, err)
+			}
+
+			_, _, _, err = MatchTVShowByTMDB(db, 0, "Braking Bad", season, episode, false, Config{FuzzyThreshold: tt.threshold})
+			if (err == nil) != tt.wantShow {
+				t.Errorf("tvshow match = %v, want %v (err: %v)", err == nil, tt.wantShow, err)
+			}
+		})
+	}
+}
+
+func TestMatchByTVDB_MinConfidence(t *testing.T) {
+	db := setupTestDB(t)
+
+	season, episode := 1, 1
+	movie := models.Movie{TMDBID: 603, TMDBTitle: "The Matrix", TMDBYear: 1999}
+	show := models.TVShow{TMDBID: 1396, TMDBTitle: "Breaking Bad", Season: &season, Episode: &episode}
+	if err := db.Create(&movie).Error; err != nil {
+		t.Fatalf("failed to create test movie: %v", err)
+	}
+	if err := db.Create(&show).Error; err != nil {
+		t.Fatalf("failed to create test tvshow: %v", err)
+	}
+	lineURL := "http://example.com/stream.mkv"
+	lines := []models.ProcessedLine{
+		{MovieID: &movie.ID, TvgName: "The Matrix", LineURL: &lineURL, LineContent: "#EXTINF:-1,The Matrix", LineHash: "confidence-movie", GroupTitle: "Movies", ContentType: models.ContentTypeMovies, State: models.StateProcessed},
+		{TVShowID: &show.ID, TvgName: "Breaking Bad S01E01", LineURL: &lineURL, LineContent: "#EXTINF:-1,Breaking Bad S01E01", LineHash: "confidence-show", GroupTitle: "Series", ContentType: models.ContentTypeTVShows, State: models.StateProcessed},
+	}
+	if err := db.Create(&lines).Error; err != nil {
+		t.Fatalf("failed to create processed lines: %v", err)
+	}
+
+	// Same scores as in TestMatchByTMDB_FuzzyThreshold: 0.81 for the movie, 0.70 for
+	// the show, both above a lenient fuzzy threshold
+	tests := []struct {
+		name          string
+		minConfidence float64
+		wantMovie     bool
+		wantShow      bool
+	}{
+		{name: "lenient", minConfidence: 0.6, wantMovie: true, wantShow: true},
+		{name: "default", minConfidence: DefaultConfig().MinConfidence, wantMovie: true, wantShow: false},
+		{name: "strict", minConfidence: 0.85, wantMovie: false, wantShow: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MinConfidence: tt.minConfidence, FuzzyThreshold: 0.5}
+
+			// The TVDB matchers fall back to the same title matching
+			_, _, _, err := MatchMovieByTVDB(db, 0, 0, "The Matriks", 2000, cfg)
+			if (err == nil) != tt.wantMovie {
+				t.Errorf("movie match = %v, want %v (err: %v)", err == nil, tt.wantMovie, err)
+			}
+
+			_, _, _, err = MatchTVShowByTVDB(db, 0, 0, "Braking Bad", season, episode, false, cfg)
+			if (err == nil) != tt.wantShow {
+				t.Errorf("tvshow match = %v, want %v (err: %v)", err == nil, tt.wantShow, err)
+			}
+		})
+	}
+}
+
+func TestMatchMovie_MinConfidence(t *testing.T) {
+	// A year off by one scores 0.85, a stricter minimum confidence rejects it
+	line := &models.ProcessedLine{TvgName: "The Matrix", Movie: &models.Movie{TMDBYear: 1998}}
+	movie := &radarr.Movie{ID: 1, Title: "The Matrix", Year: 1999}
+	if New(DefaultConfig()).MatchMovie(line, movie) == nil {
+		t.Fatal("expected a match with the default minimum confidence")
+	}
+	if New(Config{MinConfidence: 0.9}).MatchMovie(line, movie) != nil {
+		t.Error("expected no match with a 0.9 minimum confidence")
+	}
+}
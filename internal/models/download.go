@@ -22,23 +22,29 @@ const (
 
 // DownloadInfo represents download tracking information
 type DownloadInfo struct {
-	ID              uint       `gorm:"primaryKey" json:"id"`
-	URL             string     `gorm:"type:text;index:idx_download_info_url" json:"url"`                       // Source URL of the download
-	Status          string     `gorm:"type:varchar(50);not null;index:idx_download_info_status" json:"status"` // "pending", "downloading", "paused", "completed", "failed", "retrying"
-	DownloadPath    *string    `gorm:"type:text" json:"download_path,omitempty"`
-	FileSize        *int64     `json:"file_size,omitempty"`
-	BytesDownloaded *int64     `gorm:"default:0" json:"bytes_downloaded,omitempty"`                  // Track partial download progress
-	TotalBytes      *int64     `json:"total_bytes,omitempty"`                                        // Expected total file size
-	ResumeToken     *string    `gorm:"type:varchar(255)" json:"resume_token,omitempty"`              // Server-specific resume identifier (ETag, etc.)
-	RetryCount      int        `gorm:"default:0;not null" json:"retry_count"`                        // Number of retry attempts
-	LastRetryAt     *time.Time `json:"last_retry_at,omitempty"`                                      // Timestamp of last retry attempt
-	LockedAt        *time.Time `gorm:"index:idx_download_info_locked_at" json:"locked_at,omitempty"` // Lock timestamp to prevent concurrent downloads
-	LockedBy        *string    `gorm:"type:varchar(100)" json:"locked_by,omitempty"`                 // Instance/process that acquired lock
-	StartedAt       *time.Time `json:"started_at,omitempty"`
-	CompletedAt     *time.Time `json:"completed_at,omitempty"`
-	ErrorMessage    *string    `gorm:"type:text" json:"error_message,omitempty"`
-	CreatedAt       time.Time  `gorm:"not null" json:"created_at"`
-	UpdatedAt       time.Time  `gorm:"not null;index:idx_download_info_updated_at" json:"updated_at"`
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	URL                 string     `gorm:"type:text;index:idx_download_info_url" json:"url"`                       // Source URL of the download
+	Status              string     `gorm:"type:varchar(50);not null;index:idx_download_info_status" json:"status"` // "pending", "downloading", "paused", "completed", "failed", "retrying"
+	DownloadPath        *string    `gorm:"type:text" json:"download_path,omitempty"`
+	FileSize            *int64     `json:"file_size,omitempty"`
+	DetectedContentType *string    `gorm:"type:varchar(100)" json:"detected_content_type,omitempty"`                            // Content-Type reported by the server for the stream
+	ContentHash         *string    `gorm:"type:varchar(64);index:idx_download_info_content_hash" json:"content_hash,omitempty"` // SHA-256 of the file, see downloads.compute_hash
+	BytesDownloaded     *int64     `gorm:"default:0" json:"bytes_downloaded,omitempty"`                                         // Track partial download progress
+	TotalBytes          *int64     `json:"total_bytes,omitempty"`                                                               // Expected total file size
+	SpeedBps            *int64     `json:"speed_bps,omitempty"`                                                                 // Download speed over the last progress interval, in bytes per second
+	ProgressUpdatedAt   *time.Time `json:"progress_updated_at,omitempty"`                                                       // Time of the last progress update, used to compute the speed
+	ResumeToken         *string    `gorm:"type:varchar(255)" json:"resume_token,omitempty"`                                     // Server-specific resume identifier (ETag, etc.)
+	RetryCount          int        `gorm:"default:0;not null" json:"retry_count"`                                               // Number of retry attempts
+	Priority            int        `gorm:"default:0;not null" json:"priority"`                                                  // Higher priorities are resumed first
+	LastRetryAt         *time.Time `json:"last_retry_at,omitempty"`                                                             // Timestamp of last retry attempt
+	LockedAt            *time.Time `gorm:"index:idx_download_info_locked_at" json:"locked_at,omitempty"`                        // Lock timestamp to prevent concurrent downloads
+	LockedBy            *string    `gorm:"type:varchar(100)" json:"locked_by,omitempty"`                                        // Instance/process that acquired lock
+	StartedAt           *time.Time `json:"started_at,omitempty"`
+	CompletedAt         *time.Time `json:"completed_at,omitempty"`
+	ErrorMessage        *string    `gorm:"type:text" json:"error_message,omitempty"`
+	ProcessedLineID     *uint      `gorm:"index:idx_download_info_processed_line_id" json:"processed_line_id,omitempty"` // Line that started the download, kept when the line moves on to a newer download
+	CreatedAt           time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt           time.Time  `gorm:"not null;index:idx_download_info_updated_at" json:"updated_at"`
 
 	// Associations
 	ProcessedLines []ProcessedLine `gorm:"foreignKey:DownloadInfoID" json:"processed_lines,omitempty"`
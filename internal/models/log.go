@@ -11,6 +11,8 @@ type ProcessingLog struct {
 	StartedAt    time.Time  `gorm:"not null" json:"started_at"`
 	CompletedAt  *time.Time `json:"completed_at,omitempty"`
 	ErrorMessage *string    `gorm:"type:text" json:"error_message,omitempty"`
+	TMDBMatched  int        `gorm:"not null;default:0" json:"tmdb_matched"`   // Items matched on TMDB during the run
+	TMDBNotFound int        `gorm:"not null;default:0" json:"tmdb_not_found"` // Items looked up on TMDB without a match
 	CreatedAt    time.Time  `gorm:"not null" json:"created_at"`
 	UpdatedAt    time.Time  `gorm:"not null" json:"updated_at"`
 }
@@ -4,15 +4,17 @@ import "time"
 
 // Movie represents movie metadata from TMDB
 type Movie struct {
-	ID         uint      `gorm:"primaryKey" json:"id"`
-	TMDBID     int       `gorm:"not null;index:idx_movies_tmdb" json:"tmdb_id"`
-	TVDBID     *int      `gorm:"index:idx_movies_tvdb" json:"tvdb_id,omitempty"`
-	TMDBTitle  string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_movies_unique,composite:tmdb_title_year" json:"tmdb_title"`
-	TMDBYear   int       `gorm:"not null;uniqueIndex:idx_movies_unique,composite:tmdb_title_year" json:"tmdb_year"`
-	TMDBGenres *string   `gorm:"type:text" json:"tmdb_genres,omitempty"`
-	Duration   *int      `json:"duration,omitempty"`
-	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
-	UpdatedAt  time.Time `gorm:"not null" json:"updated_at"`
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	TMDBID         int       `gorm:"not null;index:idx_movies_tmdb" json:"tmdb_id"`
+	TVDBID         *int      `gorm:"index:idx_movies_tvdb" json:"tvdb_id,omitempty"`
+	TMDBTitle      string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_movies_unique,composite:tmdb_title_year" json:"tmdb_title"`
+	TMDBYear       int       `gorm:"not null;uniqueIndex:idx_movies_unique,composite:tmdb_title_year" json:"tmdb_year"`
+	TMDBGenres     *string   `gorm:"type:text" json:"tmdb_genres,omitempty"`
+	Duration       *int      `json:"duration,omitempty"`
+	CollectionID   *int      `gorm:"index:idx_movies_collection" json:"collection_id,omitempty"`
+	CollectionName *string   `gorm:"type:varchar(255)" json:"collection_name,omitempty"`
+	CreatedAt      time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"not null" json:"updated_at"`
 
 	// Associations
 	ProcessedLines []ProcessedLine `gorm:"foreignKey:MovieID" json:"processed_lines,omitempty"`
@@ -25,16 +27,20 @@ func (Movie) TableName() string {
 
 // TVShow represents TV show metadata from TMDB with season/episode information
 type TVShow struct {
-	ID         uint      `gorm:"primaryKey" json:"id"`
-	TMDBID     int       `gorm:"not null;index:idx_tvshows_tmdb" json:"tmdb_id"`
-	TVDBID     *int      `gorm:"index:idx_tvshows_tvdb" json:"tvdb_id,omitempty"`
-	TMDBTitle  string    `gorm:"type:varchar(255);not null" json:"tmdb_title"`
-	TMDBYear   int       `gorm:"not null" json:"tmdb_year"`
-	TMDBGenres *string   `gorm:"type:text" json:"tmdb_genres,omitempty"`
-	Season     *int      `gorm:"index:idx_tvshows_season_episode" json:"season,omitempty"`
-	Episode    *int      `gorm:"index:idx_tvshows_season_episode" json:"episode,omitempty"`
-	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
-	UpdatedAt  time.Time `gorm:"not null" json:"updated_at"`
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	TMDBID       int        `gorm:"not null;index:idx_tvshows_tmdb" json:"tmdb_id"`
+	TVDBID       *int       `gorm:"index:idx_tvshows_tvdb" json:"tvdb_id,omitempty"`
+	TMDBTitle    string     `gorm:"type:varchar(255);not null" json:"tmdb_title"`
+	TMDBYear     int        `gorm:"not null" json:"tmdb_year"`
+	TMDBGenres   *string    `gorm:"type:text" json:"tmdb_genres,omitempty"`
+	Season       *int       `gorm:"index:idx_tvshows_season_episode" json:"season,omitempty"`
+	Episode      *int       `gorm:"index:idx_tvshows_season_episode" json:"episode,omitempty"`
+	EpisodeEnd   *int       `json:"episode_end,omitempty"` // Last episode of a multi-episode stream, equals Episode otherwise
+	EpisodeTitle *string    `gorm:"type:varchar(255)" json:"episode_title,omitempty"`
+	AirDate      *time.Time `gorm:"type:date" json:"air_date,omitempty"`       // First air date of the episode, from TMDB
+	SeasonPack   bool       `gorm:"not null;default:false" json:"season_pack"` // Whole season in one stream, Episode is nil
+	CreatedAt    time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"not null" json:"updated_at"`
 
 	// Associations
 	ProcessedLines []ProcessedLine `gorm:"foreignKey:TVShowID" json:"processed_lines,omitempty"`
@@ -57,6 +57,7 @@ func TestProcessingState_Constants(t *testing.T) {
 		{StateDownloading, "downloading"},
 		{StateDownloaded, "downloaded"},
 		{StateFailed, "failed"},
+		{StateRemoved, "removed"},
 	}
 
 	for _, tc := range tests {
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // ContentType represents the type of media content
 type ContentType string
@@ -22,31 +26,49 @@ const (
 	StateOrganizing  ProcessingState = "organizing"
 	StateDownloaded  ProcessingState = "downloaded"
 	StateFailed      ProcessingState = "failed"
+	StateRemoved     ProcessingState = "removed"
+	StateNoURL       ProcessingState = "no_url" // Entry kept without a stream URL, not downloadable
 )
 
 // ProcessedLine represents an M3U playlist line with polymorphic relationships
 type ProcessedLine struct {
-	ID              uint            `gorm:"primaryKey" json:"id"`
-	LineContent     string          `gorm:"type:text;not null" json:"line_content"`
-	LineURL         *string         `gorm:"type:text" json:"line_url,omitempty"`
-	LineHash        string          `gorm:"type:varchar(64);not null;uniqueIndex" json:"line_hash"`
-	TvgName         string          `gorm:"type:varchar(255);not null;index:idx_processed_lines_m3u" json:"tvg_name"`
-	GroupTitle      string          `gorm:"type:varchar(255);not null;index:idx_processed_lines_m3u" json:"group_title"`
-	ProcessedAt     time.Time       `gorm:"not null" json:"processed_at"`
-	ContentType     ContentType     `gorm:"type:varchar(20);not null;index:idx_processed_lines_content" json:"content_type"`
-	Resolution      *string         `gorm:"type:varchar(10)" json:"resolution,omitempty"`
-	ChannelID       *uint           `gorm:"index" json:"channel_id,omitempty"`
-	MovieID         *uint           `gorm:"index" json:"movie_id,omitempty"`
-	TVShowID        *uint           `gorm:"index" json:"tvshow_id,omitempty"`
-	UncategorizedID *uint           `gorm:"index" json:"uncategorized_id,omitempty"`
-	DownloadInfoID  *uint           `gorm:"index:idx_processed_lines_download" json:"download_info_id,omitempty"`
-	State           ProcessingState `gorm:"type:varchar(50);not null;default:processed;index:idx_processed_lines_content" json:"state"`
-	CreatedAt       time.Time       `gorm:"not null" json:"created_at"`
-	UpdatedAt       time.Time       `gorm:"not null" json:"updated_at"`
+	ID                       uint            `gorm:"primaryKey" json:"id"`
+	LineContent              string          `gorm:"type:text;not null" json:"line_content"`
+	LineURL                  *string         `gorm:"type:text" json:"line_url,omitempty"`
+	LineHash                 string          `gorm:"type:varchar(64);not null;uniqueIndex" json:"line_hash"`
+	TvgName                  string          `gorm:"type:varchar(255);not null;index:idx_processed_lines_m3u" json:"tvg_name"`
+	GroupTitle               string          `gorm:"type:varchar(255);not null;index:idx_processed_lines_m3u" json:"group_title"`
+	NormalizedGroup          string          `gorm:"type:varchar(255);index" json:"normalized_group"` // Group-title reduced by classifier.NormalizeGroupTitle
+	ProcessedAt              time.Time       `gorm:"not null" json:"processed_at"`
+	LastSeenAt               *time.Time      `gorm:"index:idx_processed_lines_last_seen" json:"last_seen_at,omitempty"` // Last processing run that found the entry in the playlist
+	ContentType              ContentType     `gorm:"type:varchar(20);not null;index:idx_processed_lines_content" json:"content_type"`
+	Resolution               *string         `gorm:"type:varchar(10)" json:"resolution,omitempty"`
+	Language                 *string         `gorm:"type:varchar(10)" json:"language,omitempty"` // Language tag of the stream, e.g. "VF" or "MULTI"
+	ClassificationConfidence *int            `json:"classification_confidence,omitempty"`        // Classifier confidence (0-100), nil for lines classified before it was stored
+	ChannelID                *uint           `gorm:"index" json:"channel_id,omitempty"`
+	MovieID                  *uint           `gorm:"index" json:"movie_id,omitempty"`
+	TVShowID                 *uint           `gorm:"index" json:"tvshow_id,omitempty"`
+	UncategorizedID          *uint           `gorm:"index" json:"uncategorized_id,omitempty"`
+	DownloadInfoID           *uint           `gorm:"index:idx_processed_lines_download" json:"download_info_id,omitempty"`
+	PartNumber               *int            `json:"part_number,omitempty"`                              // Part of a multi-part stream
+	PartGroup                *string         `gorm:"type:varchar(64);index" json:"part_group,omitempty"` // Shared by all parts of a multi-part stream
+	State                    ProcessingState `gorm:"type:varchar(50);not null;default:processed;index:idx_processed_lines_content" json:"state"`
+	CreatedAt                time.Time       `gorm:"not null" json:"created_at"`
+	UpdatedAt                time.Time       `gorm:"not null" json:"updated_at"`
+	DeletedAt                gorm.DeletedAt  `gorm:"index" json:"deleted_at,omitempty"` // Soft-delete marker, see Unscoped() to include deleted rows
+
+	// ContentKey identifies the logical entry (tvg-name + group-title) independently
+	// of its URL. It is computed by the parser and is not persisted.
+	ContentKey string `gorm:"-" json:"-"`
+
+	// Duration is the EXTINF duration of the entry, "-1" for streams without a
+	// length. It is read by the parser and is not persisted either.
+	Duration string `gorm:"-" json:"-"`
 
 	// Associations
 	Movie  *Movie  `gorm:"foreignKey:MovieID;constraint:OnDelete=CASCADE" json:"movie,omitempty"`
 	TVShow *TVShow `gorm:"foreignKey:TVShowID;constraint:OnDelete=CASCADE" json:"tvshow,omitempty"`
+	Tags   []Tag   `gorm:"many2many:processed_line_tags" json:"tags,omitempty"`
 }
 
 // TableName specifies the table name for ProcessedLine
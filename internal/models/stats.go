@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Stats snapshot dimensions
+const (
+	StatsDimensionTotal       = "total"
+	StatsDimensionContentType = "content_type"
+	StatsDimensionResolution  = "resolution"
+	StatsDimensionGroup       = "group"
+)
+
+// StatsSnapshot is one precomputed item count of the stats endpoint, e.g. the
+// number of items in the "movies" key of the "content_type" dimension. The whole
+// table is rewritten on each refresh.
+type StatsSnapshot struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Dimension   string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_stats_snapshot_key" json:"dimension"`
+	Key         string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_stats_snapshot_key" json:"key"`
+	Count       int64     `gorm:"not null;default:0" json:"count"`
+	RefreshedAt time.Time `gorm:"not null" json:"refreshed_at"`
+}
+
+// TableName specifies the table name for StatsSnapshot
+func (StatsSnapshot) TableName() string {
+	return "stats_snapshot"
+}
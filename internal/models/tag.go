@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Tag is a user-defined label attached to items independently of their content
+// type, e.g. "keep", "to-review" or "kids"
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"name"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+}
+
+// TableName specifies the table name for Tag
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// ProcessedLineTag is a row of the join table between processed lines and tags
+type ProcessedLineTag struct {
+	ProcessedLineID uint `gorm:"primaryKey"`
+	TagID           uint `gorm:"primaryKey;index"`
+}
+
+// TableName specifies the table name for ProcessedLineTag
+func (ProcessedLineTag) TableName() string {
+	return "processed_line_tags"
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"bufio"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/glefebvre/stalkeer/internal/apperrors"
+	"github.com/glefebvre/stalkeer/internal/dedup"
 	"github.com/glefebvre/stalkeer/internal/logger"
 	"github.com/glefebvre/stalkeer/internal/models"
 )
@@ -24,23 +26,54 @@ type M3UEntry struct {
 	Duration   string
 	Title      string
 	URL        string
+	Raw        string // EXTINF line as read from the playlist
+}
+
+// URLDuplicatePolicy decides what happens to entries that share a stream URL under
+// different titles, e.g. placeholder URLs reused by a provider for dead entries.
+// Entries with the same title and URL are always skipped as duplicates.
+type URLDuplicatePolicy string
+
+const (
+	// URLDuplicatesKeepAll keeps every entry (default)
+	URLDuplicatesKeepAll URLDuplicatePolicy = "keep-all"
+	// URLDuplicatesKeepFirst keeps the first entry of each URL
+	URLDuplicatesKeepFirst URLDuplicatePolicy = "keep-first"
+	// URLDuplicatesDropAll drops every entry of a URL shared by several titles
+	URLDuplicatesDropAll URLDuplicatePolicy = "drop-all"
+)
+
+// ParseURLDuplicatePolicy validates a policy name. An empty name is keep-all.
+func ParseURLDuplicatePolicy(name string) (URLDuplicatePolicy, error) {
+	switch policy := URLDuplicatePolicy(name); policy {
+	case "":
+		return URLDuplicatesKeepAll, nil
+	case URLDuplicatesKeepAll, URLDuplicatesKeepFirst, URLDuplicatesDropAll:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid URL duplicate policy %q (must be keep-all, keep-first or drop-all)", name)
+	}
 }
 
 // ParseStats tracks parsing statistics
 type ParseStats struct {
-	ParsedEntries     int
-	SkippedDuplicates int
-	MalformedEntries  int
-	TotalLines        int
-	Duration          time.Duration
-	ErrorsByType      map[string]int
+	ParsedEntries        int
+	SkippedDuplicates    int
+	SkippedURLDuplicates int // entries skipped by the URL duplicate policy
+	MalformedEntries     int
+	TotalLines           int
+	Duration             time.Duration
+	ErrorsByType         map[string]int
 }
 
 // Parser handles M3U playlist parsing
 type Parser struct {
 	filePath   string
 	logger     *logger.Logger
-	seenHashes map[string]bool
+	seenHashes *dedup.Set
+	urlPolicy  URLDuplicatePolicy
+	resolver   *URLResolver // nil keeps the URLs as read
+	keepNoURL  bool         // keep entries without a URL line instead of counting them as malformed
 	stats      ParseStats
 }
 
@@ -49,7 +82,8 @@ func NewParser(filePath string) *Parser {
 	return &Parser{
 		filePath:   filePath,
 		logger:     logger.AppLogger(),
-		seenHashes: make(map[string]bool),
+		seenHashes: dedup.New(0),
+		urlPolicy:  URLDuplicatesKeepAll,
 		stats: ParseStats{
 			ErrorsByType: make(map[string]int),
 		},
@@ -61,15 +95,56 @@ func NewParserWithLogger(filePath string, log *logger.Logger) *Parser {
 	return &Parser{
 		filePath:   filePath,
 		logger:     log,
-		seenHashes: make(map[string]bool),
+		seenHashes: dedup.New(0),
+		urlPolicy:  URLDuplicatesKeepAll,
 		stats: ParseStats{
 			ErrorsByType: make(map[string]int),
 		},
 	}
 }
 
+// SetURLDuplicatePolicy sets how entries sharing a URL under different titles are handled
+func (p *Parser) SetURLDuplicatePolicy(policy URLDuplicatePolicy) {
+	p.urlPolicy = policy
+}
+
+// SetURLResolver sets the resolver of relative and templated stream URLs. Entries
+// whose URL cannot be resolved are counted as malformed.
+func (p *Parser) SetURLResolver(resolver *URLResolver) {
+	p.resolver = resolver
+}
+
+// ConfigureURLs installs a resolver for baseURL and the template variables vars.
+// When neither is set the URLs are kept as read, so that playlists with relative
+// URLs keep parsing as before.
+func (p *Parser) ConfigureURLs(baseURL string, vars map[string]string) error {
+	if baseURL == "" && len(vars) == 0 {
+		p.resolver = nil
+		return nil
+	}
+
+	resolver, err := NewURLResolver(baseURL, vars)
+	if err != nil {
+		return err
+	}
+	p.resolver = resolver
+	return nil
+}
+
+// SetKeepURLless makes the parser keep entries that have metadata but no URL line,
+// e.g. "coming soon" items, with a nil URL and the no_url state. By default they
+// are counted as malformed.
+func (p *Parser) SetKeepURLless(keep bool) {
+	p.keepNoURL = keep
+}
+
 // Parse reads and parses an M3U playlist file
 func (p *Parser) Parse() ([]models.ProcessedLine, error) {
+	return p.ParseContext(context.Background())
+}
+
+// ParseContext is Parse that stops with the context error as soon as ctx is done
+func (p *Parser) ParseContext(ctx context.Context) ([]models.ProcessedLine, error) {
 	startTime := time.Now()
 
 	p.logger.WithFields(map[string]interface{}{
@@ -83,12 +158,16 @@ func (p *Parser) Parse() ([]models.ProcessedLine, error) {
 	defer file.Close()
 
 	var lines []models.ProcessedLine
+	seenURLs := make(map[string]bool)
 	scanner := bufio.NewScanner(file)
 	lineNumber := 0
 	var currentEntry *M3UEntry
 	hasHeader := false
 
 	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		lineNumber++
 		p.stats.TotalLines++
 		line := strings.TrimSpace(scanner.Text())
@@ -106,14 +185,9 @@ func (p *Parser) Parse() ([]models.ProcessedLine, error) {
 
 		// Parse EXTINF line
 		if strings.HasPrefix(line, "#EXTINF") {
-			// If we have a pending entry without URL, it's malformed
+			// A pending entry has no URL
 			if currentEntry != nil && currentEntry.URL == "" {
-				p.stats.MalformedEntries++
-				p.stats.ErrorsByType["missing_url"]++
-				p.logger.WithFields(map[string]interface{}{
-					"line_number": lineNumber - 1,
-					"tvg_name":    currentEntry.TvgName,
-				}).Warn("EXTINF entry without URL")
+				lines = p.addURLless(lines, currentEntry, lineNumber-1)
 			}
 
 			currentEntry = p.parseExtinf(line, lineNumber)
@@ -128,6 +202,21 @@ func (p *Parser) Parse() ([]models.ProcessedLine, error) {
 		// This is a URL line
 		if currentEntry != nil {
 			currentEntry.URL = line
+			if p.resolver != nil {
+				resolved, err := p.resolver.Resolve(line)
+				if err != nil {
+					p.stats.MalformedEntries++
+					p.stats.ErrorsByType["invalid_url"]++
+					p.logger.WithFields(map[string]interface{}{
+						"line_number": lineNumber,
+						"tvg_name":    currentEntry.TvgName,
+						"error":       err,
+					}).Warn("failed to resolve stream URL")
+					currentEntry = nil
+					continue
+				}
+				currentEntry.URL = resolved
+			}
 
 			// Create ProcessedLine from entry
 			processedLine, err := p.createProcessedLine(currentEntry)
@@ -143,13 +232,19 @@ func (p *Parser) Parse() ([]models.ProcessedLine, error) {
 			}
 
 			// Check for duplicates
-			if p.seenHashes[processedLine.LineHash] {
+			if !p.seenHashes.Add(processedLine.LineHash) {
 				p.stats.SkippedDuplicates++
 				currentEntry = nil
 				continue
 			}
 
-			p.seenHashes[processedLine.LineHash] = true
+			if p.urlPolicy == URLDuplicatesKeepFirst && seenURLs[currentEntry.URL] {
+				p.stats.SkippedURLDuplicates++
+				currentEntry = nil
+				continue
+			}
+			seenURLs[currentEntry.URL] = true
+
 			lines = append(lines, *processedLine)
 			p.stats.ParsedEntries++
 			currentEntry = nil
@@ -165,14 +260,17 @@ func (p *Parser) Parse() ([]models.ProcessedLine, error) {
 
 	// Check for final entry without URL
 	if currentEntry != nil && currentEntry.URL == "" {
-		p.stats.MalformedEntries++
-		p.stats.ErrorsByType["missing_url"]++
+		lines = p.addURLless(lines, currentEntry, lineNumber)
 	}
 
 	if err := scanner.Err(); err != nil {
 		return nil, apperrors.ParseError("error reading playlist file", err)
 	}
 
+	if p.urlPolicy == URLDuplicatesDropAll {
+		lines = p.dropSharedURLs(lines)
+	}
+
 	// Warn if missing header
 	if !hasHeader {
 		p.stats.ErrorsByType["missing_header"]++
@@ -185,6 +283,7 @@ func (p *Parser) Parse() ([]models.ProcessedLine, error) {
 		"total_lines":      p.stats.TotalLines,
 		"parsed":           p.stats.ParsedEntries,
 		"duplicates":       p.stats.SkippedDuplicates,
+		"url_duplicates":   p.stats.SkippedURLDuplicates,
 		"malformed":        p.stats.MalformedEntries,
 		"duration_seconds": p.stats.Duration.Seconds(),
 	}).Info("parsing complete")
@@ -192,9 +291,57 @@ func (p *Parser) Parse() ([]models.ProcessedLine, error) {
 	return lines, nil
 }
 
+// addURLless handles an entry without a URL line. It is appended to lines when
+// URL-less entries are kept, and counted as malformed otherwise.
+func (p *Parser) addURLless(lines []models.ProcessedLine, entry *M3UEntry, lineNumber int) []models.ProcessedLine {
+	if p.keepNoURL {
+		if processedLine, err := p.createProcessedLine(entry); err == nil {
+			if p.seenHashes.Add(processedLine.LineHash) {
+				lines = append(lines, *processedLine)
+				p.stats.ParsedEntries++
+			} else {
+				p.stats.SkippedDuplicates++
+			}
+			return lines
+		}
+	}
+
+	p.stats.MalformedEntries++
+	p.stats.ErrorsByType["missing_url"]++
+	p.logger.WithFields(map[string]interface{}{
+		"line_number": lineNumber,
+		"tvg_name":    entry.TvgName,
+	}).Warn("EXTINF entry without URL")
+	return lines
+}
+
+// dropSharedURLs removes every line whose URL is shared with another line
+func (p *Parser) dropSharedURLs(lines []models.ProcessedLine) []models.ProcessedLine {
+	counts := make(map[string]int, len(lines))
+	for _, line := range lines {
+		if line.LineURL != nil {
+			counts[*line.LineURL]++
+		}
+	}
+
+	kept := lines[:0]
+	for _, line := range lines {
+		if line.LineURL != nil && counts[*line.LineURL] > 1 {
+			p.stats.SkippedURLDuplicates++
+			p.stats.ParsedEntries--
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
+
+// extinfDurationRegex matches the duration of an EXTINF line
+var extinfDurationRegex = regexp.MustCompile(`^#EXTINF:\s*(-?\d+(?:\.\d+)?)`)
+
 // parseExtinf parses an EXTINF line and extracts metadata
 func (p *Parser) parseExtinf(line string, lineNumber int) *M3UEntry {
-	entry := &M3UEntry{}
+	entry := &M3UEntry{Raw: line}
 
 	// Extract attributes using regex
 	tvgIDRegex := regexp.MustCompile(`tvg-id="([^"]*)"`)
@@ -215,6 +362,11 @@ func (p *Parser) parseExtinf(line string, lineNumber int) *M3UEntry {
 		entry.GroupTitle = matches[1]
 	}
 
+	// Extract duration (number following "#EXTINF:", -1 for live streams)
+	if matches := extinfDurationRegex.FindStringSubmatch(line); len(matches) > 1 {
+		entry.Duration = matches[1]
+	}
+
 	// Extract title (text after last comma)
 	if commaIdx := strings.LastIndex(line, ","); commaIdx != -1 {
 		entry.Title = strings.TrimSpace(line[commaIdx+1:])
@@ -236,26 +388,40 @@ func (p *Parser) createProcessedLine(entry *M3UEntry) (*models.ProcessedLine, er
 	if entry.TvgName == "" {
 		return nil, fmt.Errorf("missing tvg-name")
 	}
-	if entry.URL == "" {
+	if entry.URL == "" && !p.keepNoURL {
 		return nil, fmt.Errorf("missing URL")
 	}
 
-	// Create line content (EXTINF + URL)
-	lineContent := fmt.Sprintf("#EXTINF:-1 tvg-name=\"%s\" group-title=\"%s\",%s\n%s",
-		entry.TvgName, entry.GroupTitle, entry.Title, entry.URL)
+	// Create line content (EXTINF + URL). The EXTINF line is kept as read, with all its
+	// attributes in their order, so that the entry can be exported unchanged.
+	extinf := entry.Raw
+	if extinf == "" {
+		extinf = fmt.Sprintf("#EXTINF:-1 tvg-name=\"%s\" group-title=\"%s\",%s",
+			entry.TvgName, entry.GroupTitle, entry.Title)
+	}
+	lineContent := extinf + "\n" + entry.URL
 
 	// Calculate hash
 	hash := p.calculateHash(entry.TvgName, entry.URL)
 
-	return &models.ProcessedLine{
+	line := &models.ProcessedLine{
 		LineContent: lineContent,
 		LineURL:     &entry.URL,
 		LineHash:    hash,
+		ContentKey:  ContentKey(entry.TvgName, entry.GroupTitle),
+		Duration:    entry.Duration,
 		TvgName:     entry.TvgName,
 		GroupTitle:  entry.GroupTitle,
 		State:       models.StatePending,
 		ContentType: models.ContentTypeUncategorized,
-	}, nil
+	}
+	if entry.URL == "" {
+		// Kept URL-less entry, listed but not downloadable
+		line.LineContent = extinf
+		line.LineURL = nil
+		line.State = models.StateNoURL
+	}
+	return line, nil
 }
 
 // calculateHash generates a SHA-256 hash for a title and URL combination
@@ -265,6 +431,13 @@ func (p *Parser) calculateHash(tvgName, url string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// ContentKey returns the identity key of an entry, independent of its stream URL.
+// Two entries with the same key describe the same item, even if the provider
+// changed the URL between playlist refreshes.
+func ContentKey(tvgName, groupTitle string) string {
+	return strings.TrimSpace(tvgName) + "\x00" + strings.TrimSpace(groupTitle)
+}
+
 // GetStats returns the current parsing statistics
 func (p *Parser) GetStats() ParseStats {
 	return p.stats
@@ -1,8 +1,11 @@
 package parser
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -65,6 +68,11 @@ http://example.com/movie2.mp4`
 	if lines[0].ContentType != models.ContentTypeUncategorized {
 		t.Errorf("expected ContentType to be 'uncategorized', got '%s'", lines[0].ContentType)
 	}
+	wantContent := `#EXTINF:-1 tvg-id="movie1" tvg-name="Test Movie" tvg-logo="http://example.com/logo.jpg" group-title="Movies",Test Movie` +
+		"\nhttp://example.com/movie.mkv"
+	if lines[0].LineContent != wantContent {
+		t.Errorf("expected LineContent to keep the EXTINF line as read, got '%s'", lines[0].LineContent)
+	}
 
 	// Verify stats
 	stats := parser.GetStats()
@@ -76,6 +84,30 @@ http://example.com/movie2.mp4`
 	}
 }
 
+func TestParseContextCancelled(t *testing.T) {
+	content := `#EXTM3U
+#EXTINF:-1 tvg-name="Test Movie" group-title="Movies",Test Movie
+http://example.com/movie.mkv`
+
+	tempFile := createTempM3U(t, content)
+	defer os.Remove(tempFile)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	parser := NewParser(tempFile)
+	lines, err := parser.ParseContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if lines != nil {
+		t.Errorf("expected no lines, got %d", len(lines))
+	}
+	if parser.stats.TotalLines != 0 {
+		t.Errorf("expected parsing to stop before the first line, read %d", parser.stats.TotalLines)
+	}
+}
+
 func TestParseMissingHeader(t *testing.T) {
 	content := `#EXTINF:-1 tvg-name="Test Movie" group-title="Movies",Test Movie
 http://example.com/movie.mkv`
@@ -133,6 +165,82 @@ http://example.com/movie.mkv`
 	}
 }
 
+func TestParseURLDuplicatePolicies(t *testing.T) {
+	content := `#EXTM3U
+#EXTINF:-1 tvg-name="Dead Movie A" group-title="Movies",Dead Movie A
+http://example.com/placeholder.mkv
+#EXTINF:-1 tvg-name="Real Movie" group-title="Movies",Real Movie
+http://example.com/real.mkv
+#EXTINF:-1 tvg-name="Dead Movie B" group-title="Movies",Dead Movie B
+http://example.com/placeholder.mkv
+#EXTINF:-1 tvg-name="Dead Movie A" group-title="Movies",Dead Movie A
+http://example.com/placeholder.mkv
+#EXTINF:-1 tvg-name="Dead Movie C" group-title="Movies",Dead Movie C
+http://example.com/placeholder.mkv`
+
+	tests := []struct {
+		policy        URLDuplicatePolicy
+		expected      []string
+		urlDuplicates int
+	}{
+		{URLDuplicatesKeepAll, []string{"Dead Movie A", "Real Movie", "Dead Movie B", "Dead Movie C"}, 0},
+		{URLDuplicatesKeepFirst, []string{"Dead Movie A", "Real Movie"}, 2},
+		{URLDuplicatesDropAll, []string{"Real Movie"}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			parser := NewParser(createTempM3U(t, content))
+			parser.SetURLDuplicatePolicy(tt.policy)
+
+			lines, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+
+			var names []string
+			for _, line := range lines {
+				names = append(names, line.TvgName)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected entries %v, got %v", tt.expected, names)
+			}
+
+			stats := parser.GetStats()
+			if stats.SkippedDuplicates != 1 {
+				t.Errorf("expected 1 title+URL duplicate, got %d", stats.SkippedDuplicates)
+			}
+			if stats.SkippedURLDuplicates != tt.urlDuplicates {
+				t.Errorf("expected %d URL duplicates, got %d", tt.urlDuplicates, stats.SkippedURLDuplicates)
+			}
+			if stats.ParsedEntries != len(tt.expected) {
+				t.Errorf("expected %d parsed entries, got %d", len(tt.expected), stats.ParsedEntries)
+			}
+		})
+	}
+}
+
+func TestParseURLDuplicatePolicy(t *testing.T) {
+	for name, expected := range map[string]URLDuplicatePolicy{
+		"":           URLDuplicatesKeepAll,
+		"keep-all":   URLDuplicatesKeepAll,
+		"keep-first": URLDuplicatesKeepFirst,
+		"drop-all":   URLDuplicatesDropAll,
+	} {
+		policy, err := ParseURLDuplicatePolicy(name)
+		if err != nil {
+			t.Errorf("ParseURLDuplicatePolicy(%q) returned error: %v", name, err)
+		}
+		if policy != expected {
+			t.Errorf("ParseURLDuplicatePolicy(%q) = %q, expected %q", name, policy, expected)
+		}
+	}
+
+	if _, err := ParseURLDuplicatePolicy("keep-last"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
 func TestParseMalformedEntries(t *testing.T) {
 	content := `#EXTM3U
 #EXTINF:-1 tvg-name="Movie Without URL" group-title="Movies",Movie Without URL
@@ -169,6 +277,65 @@ http://example.com/another.mkv`
 	}
 }
 
+func TestParseKeepURLless(t *testing.T) {
+	content := `#EXTM3U
+#EXTINF:-1 tvg-id="dune3" tvg-name="Dune Part Three" tvg-logo="http://example.com/dune3.jpg" group-title="Coming Soon",Dune Part Three
+#EXTINF:-1 tvg-name="Valid Movie" group-title="Movies",Valid Movie
+http://example.com/valid.mkv
+#EXTINF:-1 tvg-name="Avatar 4" group-title="Coming Soon",Avatar 4`
+
+	tests := []struct {
+		name          string
+		keep          bool
+		wantLines     int
+		wantMalformed int
+	}{
+		{name: "dropped by default", keep: false, wantLines: 1, wantMalformed: 2},
+		{name: "kept", keep: true, wantLines: 3, wantMalformed: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser(createTempM3U(t, content))
+			parser.SetKeepURLless(tt.keep)
+
+			lines, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if len(lines) != tt.wantLines {
+				t.Fatalf("expected %d lines, got %d", tt.wantLines, len(lines))
+			}
+
+			stats := parser.GetStats()
+			if stats.MalformedEntries != tt.wantMalformed {
+				t.Errorf("expected %d malformed entries, got %d", tt.wantMalformed, stats.MalformedEntries)
+			}
+			if stats.ErrorsByType["missing_url"] != tt.wantMalformed {
+				t.Errorf("expected %d missing_url errors, got %d", tt.wantMalformed, stats.ErrorsByType["missing_url"])
+			}
+
+			for _, line := range lines {
+				if line.TvgName == "Valid Movie" {
+					if line.LineURL == nil || line.State != models.StatePending {
+						t.Errorf("expected the valid movie to keep its URL and state, got %+v", line)
+					}
+					continue
+				}
+				if line.LineURL != nil {
+					t.Errorf("expected %q to have no URL, got %q", line.TvgName, *line.LineURL)
+				}
+				if line.State != models.StateNoURL {
+					t.Errorf("expected %q to have state %q, got %q", line.TvgName, models.StateNoURL, line.State)
+				}
+				if line.GroupTitle != "Coming Soon" || line.LineHash == "" {
+					t.Errorf("expected %q to keep its metadata, got %+v", line.TvgName, line)
+				}
+			}
+		})
+	}
+}
+
 func TestParseUTF8Content(t *testing.T) {
 	content := `#EXTM3U
 #EXTINF:-1 tvg-name="فيلم عربي" group-title="أفلام",فيلم عربي
@@ -290,6 +457,7 @@ func TestParseExtinf(t *testing.T) {
 		wantLogo  string
 		wantGroup string
 		wantTitle string
+		wantDur   string
 	}{
 		{
 			name:      "full attributes",
@@ -299,6 +467,7 @@ func TestParseExtinf(t *testing.T) {
 			wantLogo:  "http://example.com/logo.jpg",
 			wantGroup: "Movies",
 			wantTitle: "Test Movie",
+			wantDur:   "-1",
 		},
 		{
 			name:      "minimal attributes",
@@ -308,6 +477,7 @@ func TestParseExtinf(t *testing.T) {
 			wantLogo:  "",
 			wantGroup: "",
 			wantTitle: "Simple Title",
+			wantDur:   "-1",
 		},
 		{
 			name:      "no title fallback",
@@ -317,6 +487,15 @@ func TestParseExtinf(t *testing.T) {
 			wantLogo:  "",
 			wantGroup: "Group",
 			wantTitle: "",
+			wantDur:   "-1",
+		},
+		{
+			name:      "vod duration",
+			line:      `#EXTINF:5400 tvg-name="Movie" group-title="VOD",Movie`,
+			wantName:  "Movie",
+			wantGroup: "VOD",
+			wantTitle: "Movie",
+			wantDur:   "5400",
 		},
 	}
 
@@ -340,6 +519,9 @@ func TestParseExtinf(t *testing.T) {
 			if entry.Title != tt.wantTitle {
 				t.Errorf("Title: got '%s', want '%s'", entry.Title, tt.wantTitle)
 			}
+			if entry.Duration != tt.wantDur {
+				t.Errorf("Duration: got '%s', want '%s'", entry.Duration, tt.wantDur)
+			}
 		})
 	}
 }
@@ -374,12 +556,38 @@ func TestCreateProcessedLine(t *testing.T) {
 	if line.LineHash == "" {
 		t.Error("LineHash should not be empty")
 	}
+	if line.ContentKey != ContentKey("Test Movie", "Movies") {
+		t.Errorf("ContentKey: got %q, want key for 'Test Movie'/'Movies'", line.ContentKey)
+	}
 	if line.State != models.StatePending {
 		t.Errorf("State: got '%s', want 'pending'", line.State)
 	}
 	if line.ContentType != models.ContentTypeUncategorized {
 		t.Errorf("ContentType: got '%s', want 'uncategorized'", line.ContentType)
 	}
+	if line.Duration != "-1" {
+		t.Errorf("Duration: got '%s', want '-1'", line.Duration)
+	}
+}
+
+func TestContentKey(t *testing.T) {
+	key1 := ContentKey("Movie Title", "Movies")
+	key2 := ContentKey(" Movie Title ", "Movies")
+	key3 := ContentKey("Movie Title", "Films")
+
+	if key1 != key2 {
+		t.Error("surrounding whitespace should not change the content key")
+	}
+	if key1 == key3 {
+		t.Error("different group-title should produce a different content key")
+	}
+
+	parser := NewParser("")
+	hash1 := parser.calculateHash("Movie Title", "http://example.com/a.mkv")
+	hash2 := parser.calculateHash("Movie Title", "http://example.com/b.mkv")
+	if hash1 == hash2 {
+		t.Error("a changed URL should produce a different line hash for the same content key")
+	}
 }
 
 func TestCreateProcessedLineErrors(t *testing.T) {
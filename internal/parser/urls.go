@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// templateVarPattern matches a {name} template variable of a stream URL
+var templateVarPattern = regexp.MustCompile(`\{([A-Za-z0-9_.-]+)\}`)
+
+// URLResolver turns the stream URLs of a playlist into absolute URLs. Template
+// variables such as {provider} are substituted first, then relative URLs are
+// resolved against the base URL.
+type URLResolver struct {
+	base *url.URL
+	vars map[string]string
+}
+
+// NewURLResolver creates a resolver for baseURL, which may be empty, and the
+// template variables vars. Variable names are matched case-insensitively.
+func NewURLResolver(baseURL string, vars map[string]string) (*URLResolver, error) {
+	r := &URLResolver{vars: make(map[string]string, len(vars))}
+	for name, value := range vars {
+		r.vars[strings.ToLower(name)] = value
+	}
+
+	if baseURL != "" {
+		base, err := url.Parse(baseURL)
+		if err != nil || base.Scheme == "" || base.Host == "" {
+			return nil, fmt.Errorf("invalid base URL %q: must be an absolute URL", baseURL)
+		}
+		r.base = base
+	}
+	return r, nil
+}
+
+// Resolve returns the absolute URL of raw. It fails on unknown template
+// variables and on relative URLs when no base URL is configured.
+func (r *URLResolver) Resolve(raw string) (string, error) {
+	var unknown []string
+	resolved := templateVarPattern.ReplaceAllStringFunc(raw, func(match string) string {
+		name := strings.ToLower(match[1 : len(match)-1])
+		value, ok := r.vars[name]
+		if !ok {
+			unknown = append(unknown, match)
+			return match
+		}
+		return value
+	})
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("unknown template variable %s", strings.Join(unknown, ", "))
+	}
+
+	u, err := url.Parse(resolved)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", resolved, err)
+	}
+	if u.IsAbs() {
+		return resolved, nil
+	}
+	if r.base == nil {
+		return "", fmt.Errorf("relative URL %q without a base URL", resolved)
+	}
+
+	return r.base.ResolveReference(u).String(), nil
+}
@@ -0,0 +1,122 @@
+package parser
+
+import "testing"
+
+func TestURLResolverResolve(t *testing.T) {
+	resolver, err := NewURLResolver("http://provider.example.com/streams/", map[string]string{
+		"Provider": "http://cdn.example.com",
+		"token":    "abc123",
+	})
+	if err != nil {
+		t.Fatalf("NewURLResolver failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		raw      string
+		expected string
+		wantErr  bool
+	}{
+		{"absolute URL", "http://example.com/movie.mkv", "http://example.com/movie.mkv", false},
+		{"relative URL", "movie/42.mkv", "http://provider.example.com/streams/movie/42.mkv", false},
+		{"root-relative URL", "/vod/42.mkv", "http://provider.example.com/vod/42.mkv", false},
+		{"template variables", "{provider}/movie/42.mkv?token={TOKEN}", "http://cdn.example.com/movie/42.mkv?token=abc123", false},
+		{"unknown template variable", "{mirror}/movie/42.mkv", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := resolver.Resolve(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", resolved)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve(%q) failed: %v", tt.raw, err)
+			}
+			if resolved != tt.expected {
+				t.Errorf("Resolve(%q) = %q, expected %q", tt.raw, resolved, tt.expected)
+			}
+		})
+	}
+}
+
+func TestURLResolverWithoutBaseURL(t *testing.T) {
+	resolver, err := NewURLResolver("", nil)
+	if err != nil {
+		t.Fatalf("NewURLResolver failed: %v", err)
+	}
+	if _, err := resolver.Resolve("movie/42.mkv"); err == nil {
+		t.Error("expected an error for a relative URL without a base URL")
+	}
+
+	if _, err := NewURLResolver("provider.example.com/streams", nil); err == nil {
+		t.Error("expected an error for a base URL without a scheme")
+	}
+}
+
+func TestParseResolvesURLs(t *testing.T) {
+	content := `#EXTM3U
+#EXTINF:-1 tvg-name="Relative Movie" group-title="Movies",Relative Movie
+movie/1.mkv
+#EXTINF:-1 tvg-name="Templated Movie" group-title="Movies",Templated Movie
+{provider}/movie/2.mkv
+#EXTINF:-1 tvg-name="Broken Movie" group-title="Movies",Broken Movie
+{mirror}/movie/3.mkv`
+
+	resolver, err := NewURLResolver("http://provider.example.com/", map[string]string{"provider": "http://cdn.example.com"})
+	if err != nil {
+		t.Fatalf("NewURLResolver failed: %v", err)
+	}
+	parser := NewParser(createTempM3U(t, content))
+	parser.SetURLResolver(resolver)
+
+	lines, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+
+	for i, expected := range []string{"http://provider.example.com/movie/1.mkv", "http://cdn.example.com/movie/2.mkv"} {
+		if *lines[i].LineURL != expected {
+			t.Errorf("expected URL %q, got %q", expected, *lines[i].LineURL)
+		}
+	}
+
+	stats := parser.GetStats()
+	if stats.ErrorsByType["invalid_url"] != 1 {
+		t.Errorf("expected 1 invalid_url error, got %d", stats.ErrorsByType["invalid_url"])
+	}
+	if stats.MalformedEntries != 1 {
+		t.Errorf("expected 1 malformed entry, got %d", stats.MalformedEntries)
+	}
+}
+
+func TestConfigureURLsWithoutBaseURL(t *testing.T) {
+	content := `#EXTM3U
+#EXTINF:-1 tvg-name="Relative Movie" group-title="Movies",Relative Movie
+movie/1.mkv`
+
+	parser := NewParser(createTempM3U(t, content))
+	if err := parser.ConfigureURLs("", nil); err != nil {
+		t.Fatalf("ConfigureURLs failed: %v", err)
+	}
+
+	lines, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(lines) != 1 {
+		t.Fatalf("expected the relative URL entry to be kept, got %d lines", len(lines))
+	}
+	if *lines[0].LineURL != "movie/1.mkv" {
+		t.Errorf("expected the URL to be kept as read, got %q", *lines[0].LineURL)
+	}
+	if stats := parser.GetStats(); stats.ErrorsByType["invalid_url"] != 0 {
+		t.Errorf("expected no invalid_url error, got %d", stats.ErrorsByType["invalid_url"])
+	}
+}
@@ -0,0 +1,104 @@
+package processor
+
+import (
+	"fmt"
+
+	"github.com/glefebvre/stalkeer/internal/classifier"
+	"github.com/glefebvre/stalkeer/internal/models"
+)
+
+// metadataKey identifies the title a line was enriched to: the TMDB movie (and part
+// for multi-part streams) or the TMDB episode. Lines without a TMDB match have no key.
+func metadataKey(line *models.ProcessedLine) (string, bool) {
+	switch {
+	case line.MovieID != nil:
+		part := 0
+		if line.PartNumber != nil {
+			part = *line.PartNumber
+		}
+		return fmt.Sprintf("movie:%d:%d", *line.MovieID, part), true
+	case line.TVShowID != nil:
+		return fmt.Sprintf("tvshow:%d", *line.TVShowID), true
+	default:
+		return "", false
+	}
+}
+
+// dedupeByMetadata compares line with the best entry already known for the same
+// title, either seen earlier in this run or stored by a previous one, and keeps the
+// one with the highest resolution. On a tie the known entry is kept. A superseded
+// entry still waiting in batch is removed from it, a stored one is soft-deleted.
+// It reports whether line should be saved.
+func (p *Processor) dedupeByMetadata(line *models.ProcessedLine, seen map[string]*models.ProcessedLine, batch *[]*models.ProcessedLine, stats *Statistics) (bool, error) {
+	key, ok := metadataKey(line)
+	if !ok {
+		return true, nil
+	}
+
+	best, ok := seen[key]
+	if !ok {
+		stored, err := p.bestStoredLine(line)
+		if err != nil {
+			return false, err
+		}
+		if stored == nil {
+			seen[key] = line
+			return true, nil
+		}
+		best = stored
+	}
+
+	if classifier.ResolutionRank(line.Resolution) <= classifier.ResolutionRank(best.Resolution) {
+		seen[key] = best
+		stats.MetadataDuplicates++
+		return false, nil
+	}
+
+	// line supersedes best
+	for i, pending := range *batch {
+		if pending == best {
+			*batch = append((*batch)[:i], (*batch)[i+1:]...)
+			break
+		}
+	}
+	if best.ID != 0 && best.ID != line.ID {
+		if err := p.db.Delete(&models.ProcessedLine{}, best.ID).Error; err != nil {
+			return false, fmt.Errorf("failed to delete superseded line %d: %w", best.ID, err)
+		}
+	}
+	seen[key] = line
+	stats.MetadataDuplicates++
+	return true, nil
+}
+
+// bestStoredLine returns the highest resolution stored line enriched to the same
+// title as line, other than line itself, or nil when there is none
+func (p *Processor) bestStoredLine(line *models.ProcessedLine) (*models.ProcessedLine, error) {
+	query := p.db.Where("line_hash <> ?", line.LineHash)
+	if line.ID != 0 {
+		query = query.Where("id <> ?", line.ID)
+	}
+	if line.MovieID != nil {
+		query = query.Where("movie_id = ?", *line.MovieID)
+		if line.PartNumber != nil {
+			query = query.Where("part_number = ?", *line.PartNumber)
+		} else {
+			query = query.Where("part_number IS NULL")
+		}
+	} else {
+		query = query.Where("tv_show_id = ?", *line.TVShowID)
+	}
+
+	var stored []models.ProcessedLine
+	if err := query.Order("id").Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to query lines with the same metadata: %w", err)
+	}
+
+	var best *models.ProcessedLine
+	for i := range stored {
+		if best == nil || classifier.ResolutionRank(stored[i].Resolution) > classifier.ResolutionRank(best.Resolution) {
+			best = &stored[i]
+		}
+	}
+	return best, nil
+}
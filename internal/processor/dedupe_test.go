@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/parser"
+)
+
+func TestProcessDedupeByMetadata_KeepsHighestResolution(t *testing.T) {
+	srv := newTMDBTestServer(t, newMockTMDBHandler)
+	file := createTestM3U(t, `#EXTM3U
+#EXTINF:-1 tvg-name="The Matrix (1999) 720p" group-title="Movies",The Matrix
+http://example.com/matrix-720.mkv
+#EXTINF:-1 tvg-name="The Matrix (1999) 4K" group-title="Films UHD",The Matrix
+http://example.com/matrix-4k.mkv
+#EXTINF:-1 tvg-name="The Matrix (1999) 1080p" group-title="Films",The Matrix
+http://example.com/matrix-1080.mkv
+#EXTINF:-1 tvg-name="Breaking Bad S01E01" group-title="Séries",Breaking Bad
+http://example.com/bb-sd.mkv
+#EXTINF:-1 tvg-name="Breaking Bad S01E01 1080p" group-title="Séries HD",Breaking Bad
+http://example.com/bb-hd.mkv
+`)
+	p, db := newSQLiteProcessor(t, file, srv.URL)
+
+	stats, err := p.Process(context.Background(), ProcessOptions{BatchSize: 2, TMDBLanguage: "en-US", DedupeByMetadata: true})
+	if err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+
+	if stats.MetadataDuplicates != 3 {
+		t.Errorf("expected 3 metadata duplicates, got %d", stats.MetadataDuplicates)
+	}
+
+	var lines []models.ProcessedLine
+	db.Order("id").Find(&lines)
+	urls := make([]string, 0, len(lines))
+	for _, line := range lines {
+		urls = append(urls, *line.LineURL)
+	}
+	if len(urls) != 2 || urls[0] != "http://example.com/matrix-4k.mkv" || urls[1] != "http://example.com/bb-hd.mkv" {
+		t.Errorf("expected only the 4K movie and the 1080p episode to be stored, got %v", urls)
+	}
+}
+
+func TestProcessDedupeByMetadata_SupersedesStoredLine(t *testing.T) {
+	srv := newTMDBTestServer(t, newMockTMDBHandler)
+	first := createTestM3U(t, `#EXTM3U
+#EXTINF:-1 tvg-name="The Matrix (1999) 720p" group-title="Movies",The Matrix
+http://example.com/matrix-720.mkv
+`)
+	p, db := newSQLiteProcessor(t, first, srv.URL)
+	opts := ProcessOptions{TMDBLanguage: "en-US", DedupeByMetadata: true}
+	if _, err := p.Process(context.Background(), opts); err != nil {
+		t.Fatalf("first Process() error: %v", err)
+	}
+
+	second := createTestM3U(t, `#EXTM3U
+#EXTINF:-1 tvg-name="The Matrix (1999) 480p" group-title="Films SD",The Matrix
+http://example.com/matrix-480.mkv
+#EXTINF:-1 tvg-name="The Matrix (1999) 1080p" group-title="Films",The Matrix
+http://example.com/matrix-1080.mkv
+`)
+	p.filePath = second
+	p.parser = parser.NewParserWithLogger(second, logger.AppLogger())
+	stats, err := p.Process(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("second Process() error: %v", err)
+	}
+
+	if stats.MetadataDuplicates != 2 {
+		t.Errorf("expected 2 metadata duplicates, got %d", stats.MetadataDuplicates)
+	}
+
+	var kept []models.ProcessedLine
+	db.Find(&kept)
+	if len(kept) != 1 || *kept[0].LineURL != "http://example.com/matrix-1080.mkv" {
+		t.Errorf("expected only the 1080p line to be kept, got %+v", kept)
+	}
+
+	var deleted int64
+	db.Unscoped().Model(&models.ProcessedLine{}).Where("line_url = ? AND deleted_at IS NOT NULL", "http://example.com/matrix-720.mkv").Count(&deleted)
+	if deleted != 1 {
+		t.Error("expected the stored 720p line to be soft-deleted")
+	}
+}
@@ -0,0 +1,105 @@
+package processor
+
+import (
+	"fmt"
+
+	"github.com/glefebvre/stalkeer/internal/classifier"
+	"github.com/glefebvre/stalkeer/internal/external/tmdb"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// EnrichTMDBOptions holds configuration for the TMDB backfill operation.
+type EnrichTMDBOptions struct {
+	Limit           int
+	ContentType     models.ContentType // movies or tvshows; empty means both
+	Language        string
+	Verbose         bool
+	EpisodeDetails  bool // fetch episode names and air dates from the season endpoint
+	NormalizeGenres bool // store genres normalized by tmdb.NormalizeGenres
+}
+
+// EnrichTMDBStats holds the results of a TMDB backfill run.
+type EnrichTMDBStats struct {
+	Processed int
+	Matched   int
+	NotFound  int // no TMDB search result
+	Errors    int
+}
+
+// EnrichMissingTMDB links processed movie and TV show lines that have no Movie or
+// TVShow association, e.g. lines processed with --skip-tmdb, using the same
+// enrichment as the processor. Lines that cannot be matched are left untouched.
+func EnrichMissingTMDB(db *gorm.DB, client *tmdb.Client, opts EnrichTMDBOptions) (*EnrichTMDBStats, error) {
+	const batchSize = 100
+
+	stats := &EnrichTMDBStats{}
+	enricher := NewEnricher(db, client)
+	enricher.episodeDetails = opts.EpisodeDetails
+	enricher.normalizeGenres = opts.NormalizeGenres
+	c := classifier.New()
+
+	contentTypes := []models.ContentType{models.ContentTypeMovies, models.ContentTypeTVShows}
+	if opts.ContentType != "" {
+		contentTypes = []models.ContentType{opts.ContentType}
+	}
+
+	// Unmatched lines stay in the result set, so paginate on the ID instead of an offset
+	var lastID uint
+	for {
+		if opts.Limit > 0 && stats.Processed >= opts.Limit {
+			break
+		}
+
+		var lines []models.ProcessedLine
+		if err := db.Where("id > ? AND content_type IN ? AND movie_id IS NULL AND tv_show_id IS NULL", lastID, contentTypes).
+			Order("id").Limit(batchSize).Find(&lines).Error; err != nil {
+			return stats, fmt.Errorf("failed to query processed lines: %w", err)
+		}
+		if len(lines) == 0 {
+			break
+		}
+
+		for i := range lines {
+			if opts.Limit > 0 && stats.Processed >= opts.Limit {
+				break
+			}
+			line := &lines[i]
+			lastID = line.ID
+			stats.Processed++
+
+			if opts.Verbose {
+				fmt.Printf("  [%s] %s\n", line.ContentType, line.TvgName)
+			}
+
+			var lineStats Statistics
+			var err error
+			if line.ContentType == models.ContentTypeMovies {
+				err = enricher.EnrichMovie(line, opts.Language, &lineStats)
+			} else {
+				classification := c.Classify(line.TvgName, line.GroupTitle)
+				err = enricher.EnrichTVShow(line, classification, opts.Language, &lineStats)
+			}
+			stats.NotFound += lineStats.TMDBNotFound
+			stats.Errors += lineStats.TMDBErrors
+			if err != nil {
+				if opts.Verbose {
+					fmt.Printf("  [skip] %s: %v\n", line.TvgName, err)
+				}
+				continue
+			}
+
+			if err := db.Model(&models.ProcessedLine{}).Where("id = ?", line.ID).Updates(map[string]interface{}{
+				"movie_id":   line.MovieID,
+				"tv_show_id": line.TVShowID,
+			}).Error; err != nil {
+				stats.Errors++
+				fmt.Printf("  [warn] Failed to link processed line id=%d: %v\n", line.ID, err)
+				continue
+			}
+			stats.Matched++
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,475 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/glefebvre/stalkeer/internal/classifier"
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/external/tmdb"
+	"github.com/glefebvre/stalkeer/internal/logger"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// Enricher links processed lines to TMDB movie and TV show metadata.
+// It is shared by the processor and the enrich command.
+type Enricher struct {
+	db     *gorm.DB
+	client *tmdb.Client
+	logger *logger.Logger
+
+	// upsertMu serializes movie and TV show upserts, so that concurrent enrichments
+	// of the same title do not both insert it. TMDB requests are not serialized.
+	upsertMu sync.Mutex
+
+	// episodeDetails fetches the episode name and air date from the season endpoint
+	episodeDetails bool
+
+	// normalizeGenres stores genres normalized by tmdb.NormalizeGenres
+	normalizeGenres bool
+}
+
+// NewEnricher creates an enricher using the given database and TMDB client
+func NewEnricher(db *gorm.DB, client *tmdb.Client) *Enricher {
+	return &Enricher{
+		db:     db,
+		client: client,
+		logger: logger.AppLogger(),
+	}
+}
+
+// TMDBClientConfig returns the TMDB client configuration of the tmdb settings
+func TMDBClientConfig(cfg config.TMDBConfig) tmdb.Config {
+	endpointTimeouts := make(map[string]time.Duration, len(cfg.EndpointTimeouts))
+	for kind, seconds := range cfg.EndpointTimeouts {
+		endpointTimeouts[kind] = time.Duration(seconds) * time.Second
+	}
+	return tmdb.Config{
+		APIKey:            cfg.APIKey,
+		Language:          cfg.Language,
+		Timeout:           time.Duration(cfg.Timeout) * time.Second,
+		RequestsPerSecond: cfg.RequestsPerSecond,
+		Burst:             cfg.Burst,
+		EndpointTimeouts:  endpointTimeouts,
+	}
+}
+
+// EnrichMovie fetches movie data from TMDB and creates/updates the Movie association of line
+func (e *Enricher) EnrichMovie(line *models.ProcessedLine, language string, stats *Statistics) error {
+	// Extract title and year from tvg-name
+	title, year := extractTitleAndYear(line.TvgName)
+
+	// Search TMDB. Titles such as "Deathly Hallows Part 1" are real movies, so a
+	// multi-part marker is only dropped when the full title is not found.
+	result, err := e.client.SearchMovie(title, year)
+	if err != nil {
+		if part, base := classifier.ExtractPart(line.TvgName); part != nil {
+			title, year = extractTitleAndYear(base)
+			result, err = e.client.SearchMovie(title, year)
+		}
+	}
+	if err != nil {
+		stats.TMDBNotFound++
+		return err
+	}
+
+	// Get detailed information
+	details, err := e.client.GetMovieDetails(result.ID)
+	if err != nil {
+		stats.TMDBErrors++
+		return err
+	}
+
+	// Get external IDs (including TVDB ID)
+	externalIDs, err := e.client.GetMovieExternalIDs(result.ID)
+	if err != nil {
+		// Log warning but don't fail - external IDs are optional
+		e.logger.WithFields(map[string]interface{}{
+			"tmdb_id": result.ID,
+			"error":   err,
+		}).Warn("Failed to fetch movie external IDs")
+	}
+
+	// Create or find existing movie (atomic upsert to prevent duplicate key on concurrent inserts)
+	e.upsertMu.Lock()
+	defer e.upsertMu.Unlock()
+
+	var movie models.Movie
+	tmdbYear := tmdb.ExtractYear(details.ReleaseDate)
+	genres := e.formatGenres(details.Genres)
+
+	var tvdbID *int
+	if externalIDs != nil {
+		tvdbID = externalIDs.TVDBID
+	}
+	var collectionID *int
+	var collectionName *string
+	if details.BelongsToCollection != nil {
+		collectionID = &details.BelongsToCollection.ID
+		collectionName = &details.BelongsToCollection.Name
+	}
+	attrs := models.Movie{
+		TMDBID:         details.ID,
+		TVDBID:         tvdbID,
+		TMDBTitle:      details.Title,
+		TMDBYear:       tmdbYear,
+		TMDBGenres:     &genres,
+		Duration:       details.Runtime,
+		CollectionID:   collectionID,
+		CollectionName: collectionName,
+	}
+	if result := e.db.Where("tmdb_id = ? AND tmdb_year = ?", details.ID, tmdbYear).
+		Attrs(attrs).
+		FirstOrCreate(&movie); result.Error != nil {
+		stats.TMDBErrors++
+		return fmt.Errorf("failed to upsert movie: %w", result.Error)
+	}
+
+	// Update TVDB ID and collection if they are missing on an existing record
+	updated := false
+	if externalIDs != nil && externalIDs.TVDBID != nil && movie.TVDBID == nil {
+		movie.TVDBID = externalIDs.TVDBID
+		updated = true
+	}
+	if collectionID != nil && movie.CollectionID == nil {
+		movie.CollectionID = collectionID
+		movie.CollectionName = collectionName
+		updated = true
+	}
+	if updated {
+		if err := e.db.Save(&movie).Error; err != nil {
+			e.logger.WithFields(map[string]interface{}{
+				"movie_id": movie.ID,
+				"error":    err,
+			}).Warn("Failed to update movie metadata")
+		}
+	}
+
+	// Associate with processed line
+	line.MovieID = &movie.ID
+	stats.TMDBMatched++
+
+	return nil
+}
+
+// EnrichTVShow fetches TV show data from TMDB and creates/updates the TVShow association of line
+func (e *Enricher) EnrichTVShow(line *models.ProcessedLine, classification classifier.Classification, language string, stats *Statistics) error {
+	// Extract title from tvg-name (remove season/episode or season pack info)
+	tvgName := line.TvgName
+	if classification.SeasonPack {
+		_, tvgName = classifier.ExtractSeasonPack(tvgName)
+	}
+	title := cleanTVShowTitle(tvgName)
+	year := extractTVShowYear(line.TvgName, line.GroupTitle)
+
+	// Search TMDB (the client falls back to a yearless search)
+	result, err := e.client.SearchTVShow(title, year)
+	if err != nil {
+		stats.TMDBNotFound++
+		return err
+	}
+
+	// Get detailed information
+	details, err := e.client.GetTVShowDetails(result.ID)
+	if err != nil {
+		stats.TMDBErrors++
+		return err
+	}
+
+	// Get external IDs (including TVDB ID)
+	externalIDs, err := e.client.GetTVShowExternalIDs(result.ID)
+	if err != nil {
+		// Log warning but don't fail - external IDs are optional
+		e.logger.WithFields(map[string]interface{}{
+			"tmdb_id": result.ID,
+			"error":   err,
+		}).Warn("Failed to fetch TV show external IDs")
+	}
+
+	episodeTitle := classification.EpisodeTitle
+	var airDate *time.Time
+	if e.episodeDetails && classification.Season != nil && classification.Episode != nil {
+		if episode := e.fetchEpisode(details.ID, *classification.Season, *classification.Episode); episode != nil {
+			if episodeTitle == nil && episode.Name != "" {
+				episodeTitle = &episode.Name
+			}
+			if date, err := time.Parse("2006-01-02", episode.AirDate); err == nil {
+				airDate = &date
+			}
+		}
+	}
+
+	// Create or find existing TV show (atomic upsert to prevent duplicate key on concurrent inserts)
+	e.upsertMu.Lock()
+	defer e.upsertMu.Unlock()
+
+	var tvshow models.TVShow
+	tmdbYear := tmdb.ExtractYear(details.FirstAirDate)
+	genres := e.formatGenres(details.Genres)
+
+	var tvdbID *int
+	if externalIDs != nil {
+		tvdbID = externalIDs.TVDBID
+	}
+	attrs := models.TVShow{
+		TMDBID:       details.ID,
+		TVDBID:       tvdbID,
+		TMDBTitle:    details.Name,
+		TMDBYear:     tmdbYear,
+		TMDBGenres:   &genres,
+		Season:       classification.Season,
+		Episode:      classification.Episode,
+		EpisodeEnd:   classification.EpisodeEnd,
+		EpisodeTitle: episodeTitle,
+		AirDate:      airDate,
+		SeasonPack:   classification.SeasonPack,
+	}
+
+	query := e.db.Where("tmdb_id = ?", details.ID)
+	if classification.Season != nil {
+		query = query.Where("season = ?", *classification.Season)
+	} else {
+		query = query.Where("season IS NULL")
+	}
+	if classification.Episode != nil {
+		query = query.Where("episode = ?", *classification.Episode)
+	} else {
+		query = query.Where("episode IS NULL")
+	}
+	if classification.EpisodeEnd != nil {
+		query = query.Where("COALESCE(episode_end, episode) = ?", *classification.EpisodeEnd)
+	}
+	query = query.Where("season_pack = ?", classification.SeasonPack)
+
+	if result := query.Attrs(attrs).FirstOrCreate(&tvshow); result.Error != nil {
+		stats.TMDBErrors++
+		return fmt.Errorf("failed to upsert TV show: %w", result.Error)
+	}
+
+	// Update TVDB ID, episode title and air date if they are missing on an existing record
+	updated := false
+	if externalIDs != nil && externalIDs.TVDBID != nil && tvshow.TVDBID == nil {
+		tvshow.TVDBID = externalIDs.TVDBID
+		updated = true
+	}
+	if episodeTitle != nil && tvshow.EpisodeTitle == nil {
+		tvshow.EpisodeTitle = episodeTitle
+		updated = true
+	}
+	if airDate != nil && tvshow.AirDate == nil {
+		tvshow.AirDate = airDate
+		updated = true
+	}
+	if updated {
+		if err := e.db.Save(&tvshow).Error; err != nil {
+			e.logger.WithFields(map[string]interface{}{
+				"tvshow_id": tvshow.ID,
+				"error":     err,
+			}).Warn("Failed to update TV show metadata")
+		}
+	}
+
+	// Associate with processed line
+	line.TVShowID = &tvshow.ID
+	stats.TMDBMatched++
+
+	return nil
+}
+
+// formatGenres formats TMDB genres for storage, normalized by tmdb.NormalizeGenres
+// when normalizeGenres is set
+func (e *Enricher) formatGenres(genres []tmdb.Genre) string {
+	formatted := tmdb.FormatGenres(genres)
+	if !e.normalizeGenres || formatted == "" {
+		return formatted
+	}
+	return strings.Join(tmdb.NormalizeGenres(strings.Split(formatted, ",")), ", ")
+}
+
+// correctToTVShow turns a line classified as a movie that TMDB does not know as a
+// movie into a TV show, when TMDB has a TV show of exactly that title. It reports
+// whether the line was corrected.
+func (e *Enricher) correctToTVShow(line *models.ProcessedLine, classification classifier.Classification, language string, stats *Statistics) bool {
+	// Search as EnrichTVShow does, so that its own search is served from the cache
+	title := cleanTVShowTitle(line.TvgName)
+	result, err := e.client.SearchTVShow(title, extractTVShowYear(line.TvgName, line.GroupTitle))
+	if err != nil || !(sameTitle(title, result.Name) || sameTitle(title, result.OriginalName)) {
+		return false
+	}
+
+	corrected := *line
+	corrected.ContentType = models.ContentTypeTVShows
+	corrected.MovieID, corrected.PartNumber, corrected.PartGroup = nil, nil, nil
+	var entryStats Statistics
+	if err := e.EnrichTVShow(&corrected, classification, language, &entryStats); err != nil {
+		return false
+	}
+
+	*line = corrected
+	stats.TMDBNotFound--
+	stats.TMDBMatched++
+	e.logContentTypeCorrection(line, models.ContentTypeMovies, result.ID)
+	return true
+}
+
+// correctToMovie turns a line classified as a TV show that TMDB does not know as a
+// TV show into a movie, when TMDB has a movie of exactly that title. It reports
+// whether the line was corrected.
+func (e *Enricher) correctToMovie(line *models.ProcessedLine, language string, stats *Statistics) bool {
+	// Search as EnrichMovie does, so that its own search is served from the cache
+	title, year := extractTitleAndYear(line.TvgName)
+	result, err := e.client.SearchMovie(title, year)
+	if err != nil || !(sameTitle(title, result.Title) || sameTitle(title, result.OriginalTitle)) {
+		return false
+	}
+
+	corrected := *line
+	corrected.ContentType = models.ContentTypeMovies
+	corrected.TVShowID = nil
+	var entryStats Statistics
+	if err := e.EnrichMovie(&corrected, language, &entryStats); err != nil {
+		return false
+	}
+
+	*line = corrected
+	stats.TMDBNotFound--
+	stats.TMDBMatched++
+	e.logContentTypeCorrection(line, models.ContentTypeTVShows, result.ID)
+	return true
+}
+
+func (e *Enricher) logContentTypeCorrection(line *models.ProcessedLine, previous models.ContentType, tmdbID int) {
+	e.logger.WithFields(map[string]interface{}{
+		"title":   line.TvgName,
+		"from":    previous,
+		"to":      line.ContentType,
+		"tmdb_id": tmdbID,
+	}).Info("content type corrected from TMDB")
+}
+
+// sameTitle reports whether two titles are equal once case, punctuation and
+// spacing are ignored
+func sameTitle(a, b string) bool {
+	normalize := func(title string) string {
+		words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		return strings.Join(words, " ")
+	}
+	return a != "" && normalize(a) == normalize(b)
+}
+
+// fetchEpisode returns the TMDB episode of a TV show, or nil when the season cannot
+// be fetched or lacks it. Season responses are cached by the client, so the
+// episodes of a season cost a single request.
+func (e *Enricher) fetchEpisode(tvShowID, season, episode int) *tmdb.Episode {
+	details, err := e.client.GetSeasonDetails(tvShowID, season)
+	if err != nil {
+		e.logger.WithFields(map[string]interface{}{
+			"tmdb_id": tvShowID,
+			"season":  season,
+			"error":   err,
+		}).Warn("Failed to fetch TV show season details")
+		return nil
+	}
+	return details.Episode(episode)
+}
+
+// qualitySuffixRe matches quality/language tokens at the end of a title,
+// e.g. "Movie SD", "Movie HD MULTI", "Movie FHD VOSTFR".
+var qualitySuffixRe = regexp.MustCompile(`(?i)\s+(?:SD|FHD|UHD|HD|4K|MULTI|VOSTFR|VF)(?:\s+.*)?$`)
+
+// yearDashRe matches a year in the "Titre - YYYY" format at the end of a title,
+// e.g. "Super Dark Times - 2017". Requires a 19xx or 20xx year to avoid false positives.
+var yearDashRe = regexp.MustCompile(`\s*-\s*((?:19|20)\d{2})$`)
+
+// extractTitleAndYear extracts title and optional year from a string.
+// It first strips quality/language suffixes (SD, HD, FHD, UHD, 4K, MULTI, VOSTFR, VF),
+// then attempts year extraction from "(YYYY)" and "- YYYY" formats.
+func extractTitleAndYear(title string) (string, *int) {
+	// Strip quality/language suffixes first
+	clean := qualitySuffixRe.ReplaceAllString(title, "")
+	clean = strings.TrimSpace(clean)
+
+	// Try "(YYYY)" format: "Movie Title (2024)"
+	if strings.Contains(clean, "(") {
+		parts := strings.Split(clean, "(")
+		cleanTitle := strings.TrimSpace(parts[0])
+
+		for i := 1; i < len(parts); i++ {
+			if strings.Contains(parts[i], ")") {
+				yearStr := strings.TrimSuffix(parts[i], ")")
+				var year int
+				if _, err := fmt.Sscanf(yearStr, "%d", &year); err == nil && year >= 1900 && year <= 2100 {
+					return cleanTitle, &year
+				}
+			}
+		}
+		return cleanTitle, nil
+	}
+
+	// Try "Titre - YYYY" format: "Super Dark Times - 2017"
+	if m := yearDashRe.FindStringSubmatch(clean); m != nil {
+		var year int
+		if _, err := fmt.Sscanf(m[1], "%d", &year); err == nil && year >= 1900 && year <= 2100 {
+			cleanTitle := strings.TrimSpace(yearDashRe.ReplaceAllString(clean, ""))
+			return cleanTitle, &year
+		}
+	}
+
+	return clean, nil
+}
+
+// episodeSuffixRe matches a season/episode marker and everything after it, e.g. " S01E01 HD"
+var episodeSuffixRe = regexp.MustCompile(`(?i)\s+(?:S\d{1,2}\s*E\d{1,3}|\d{1,2}x\d{1,3}).*$`)
+
+// groupYearRe matches a standalone 19xx or 20xx year in a group-title, e.g. "Séries 2017"
+var groupYearRe = regexp.MustCompile(`(?:^|[^\d])((?:19|20)\d{2})(?:[^\d]|$)`)
+
+// extractTVShowYear extracts the first air year of a TV show, used to tell reboots apart.
+// The tvg-name is checked first ("(YYYY)" or "- YYYY"), then the group-title.
+func extractTVShowYear(tvgName string, groupTitle string) *int {
+	withoutEpisode := episodeSuffixRe.ReplaceAllString(tvgName, "")
+	if _, year := extractTitleAndYear(withoutEpisode); year != nil {
+		return year
+	}
+
+	if m := groupYearRe.FindStringSubmatch(groupTitle); m != nil {
+		var year int
+		if _, err := fmt.Sscanf(m[1], "%d", &year); err == nil {
+			return &year
+		}
+	}
+
+	return nil
+}
+
+// cleanTVShowTitle removes season/episode markers and quality tags from title
+func cleanTVShowTitle(title string) string {
+	// Remove common patterns like "S01 E01", "S01E01", quality tags, etc.
+	patterns := []string{
+		`\s+S\d{2}\s*E\d{2}`,                                 // S01 E01
+		`\s+S\d{2}E\d{2}`,                                    // S01E01
+		`\s+\d{1,2}x\d{1,2}`,                                 // 1x01
+		`\s+\(\d{4}\)`,                                       // (2024)
+		`\s+\(.*?(HD|SD|4K|1080p|720p|480p).*?\)`,            // Quality tags
+		`\s+(HD|FHD|UHD|4K|1080p|720p|480p|SD|SDTV|HDTV).*$`, // Quality suffixes
+		`\s+\(MULTI\)`,                                       // Language tags
+		`\s+\(VOSTFR\)`,
+		`\s+\(VF\)`,
+	}
+
+	// Drop the season/episode marker and anything after it, such as an episode title
+	cleanTitle := episodeSuffixRe.ReplaceAllString(title, "")
+	for _, pattern := range patterns {
+		re := regexp.MustCompile(pattern)
+		cleanTitle = re.ReplaceAllString(cleanTitle, "")
+	}
+
+	return strings.TrimSpace(cleanTitle)
+}
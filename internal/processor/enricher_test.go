@@ -0,0 +1,308 @@
+package processor
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/classifier"
+	"github.com/glefebvre/stalkeer/internal/config"
+	"github.com/glefebvre/stalkeer/internal/external/tmdb"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/testutil"
+)
+
+// newMockTMDBHandler serves search, details and external IDs for The Matrix and
+// Breaking Bad. Any other search returns no result.
+func newMockTMDBHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/search/movie":
+		if r.URL.Query().Get("query") == "The Matrix" {
+			w.Write([]byte(`{"results":[{"id":603,"title":"The Matrix","release_date":"1999-03-30"}]}`))
+			return
+		}
+		w.Write([]byte(`{"results":[]}`))
+	case "/movie/603":
+		w.Write([]byte(`{"id":603,"title":"The Matrix","release_date":"1999-03-30","genres":[{"id":28,"name":"Action"}],"belongs_to_collection":{"id":2344,"name":"The Matrix Collection"}}`))
+	case "/search/tv":
+		if r.URL.Query().Get("query") == "Breaking Bad" {
+			w.Write([]byte(`{"results":[{"id":1396,"name":"Breaking Bad","first_air_date":"2008-01-20"}]}`))
+			return
+		}
+		w.Write([]byte(`{"results":[]}`))
+	case "/tv/1396":
+		w.Write([]byte(`{"id":1396,"name":"Breaking Bad","first_air_date":"2008-01-20"}`))
+	default:
+		w.Write([]byte(`{}`))
+	}
+}
+
+func TestTMDBClientConfig(t *testing.T) {
+	cfg := TMDBClientConfig(config.TMDBConfig{
+		APIKey:            "key",
+		Language:          "fr-FR",
+		Timeout:           30,
+		RequestsPerSecond: 4,
+		Burst:             2,
+		EndpointTimeouts:  map[string]int{tmdb.EndpointSearch: 5},
+	})
+
+	if cfg.APIKey != "key" || cfg.Language != "fr-FR" || cfg.RequestsPerSecond != 4 || cfg.Burst != 2 {
+		t.Errorf("unexpected client config: %+v", cfg)
+	}
+	if cfg.Timeout != 30*time.Second {
+		t.Errorf("expected a 30s timeout, got %v", cfg.Timeout)
+	}
+	if got := cfg.EndpointTimeouts[tmdb.EndpointSearch]; got != 5*time.Second {
+		t.Errorf("expected a 5s search timeout, got %v", got)
+	}
+}
+
+// TestEnrichTVShow_RebootMatchesYear verifies that a reboot is matched to the show
+// aired in the year found in the tvg-name rather than the first search result.
+func TestEnrichTVShow_RebootMatchesYear(t *testing.T) {
+	db := testutil.TestDB(t)
+
+	srv := newTMDBTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/search/tv":
+			if r.URL.Query().Get("first_air_date_year") == "2017" {
+				w.Write([]byte(`{"results":[{"id":67167,"name":"One Day at a Time","first_air_date":"2017-01-06"}]}`))
+				return
+			}
+			w.Write([]byte(`{"results":[{"id":2599,"name":"One Day at a Time","first_air_date":"1975-12-16"}]}`))
+		case "/tv/67167":
+			w.Write([]byte(`{"id":67167,"name":"One Day at a Time","first_air_date":"2017-01-06"}`))
+		case "/tv/2599":
+			w.Write([]byte(`{"id":2599,"name":"One Day at a Time","first_air_date":"1975-12-16"}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	})
+
+	enricher := NewEnricher(db, newTMDBClientForTest(t, srv.URL))
+
+	tests := []struct {
+		tvgName    string
+		wantTMDBID int
+		wantYear   int
+	}{
+		{"One Day at a Time (2017) S01E01", 67167, 2017},
+		{"One Day at a Time S01E01", 2599, 1975},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tvgName, func(t *testing.T) {
+			line := &models.ProcessedLine{TvgName: tt.tvgName, GroupTitle: "Séries"}
+			classification := classifier.New().Classify(tt.tvgName, line.GroupTitle)
+
+			if err := enricher.EnrichTVShow(line, classification, "", &Statistics{}); err != nil {
+				t.Fatalf("EnrichTVShow error: %v", err)
+			}
+
+			var tvshow models.TVShow
+			if err := db.First(&tvshow, *line.TVShowID).Error; err != nil {
+				t.Fatalf("failed to load TV show: %v", err)
+			}
+			if tvshow.TMDBID != tt.wantTMDBID || tvshow.TMDBYear != tt.wantYear {
+				t.Errorf("expected TMDB %d (%d), got %d (%d)", tt.wantTMDBID, tt.wantYear, tvshow.TMDBID, tvshow.TMDBYear)
+			}
+		})
+	}
+}
+
+// TestEnrichTVShow_SeasonPack verifies that a season pack is searched without its
+// pack marker and stored apart from the episodes of the same season.
+func TestEnrichTVShow_SeasonPack(t *testing.T) {
+	db := testutil.TestDB(t)
+	srv := newTMDBTestServer(t, newMockTMDBHandler)
+	enricher := NewEnricher(db, newTMDBClientForTest(t, srv.URL))
+
+	c := classifier.New()
+	var ids []uint
+	for _, tvgName := range []string{"Breaking Bad Saison 1 Intégrale", "Breaking Bad S01E01"} {
+		line := &models.ProcessedLine{TvgName: tvgName, GroupTitle: "Séries"}
+		if err := enricher.EnrichTVShow(line, c.Classify(tvgName, line.GroupTitle), "", &Statistics{}); err != nil {
+			t.Fatalf("EnrichTVShow(%q) error: %v", tvgName, err)
+		}
+		ids = append(ids, *line.TVShowID)
+	}
+
+	if ids[0] == ids[1] {
+		t.Fatal("expected the season pack and the episode to be different TV show records")
+	}
+
+	var pack models.TVShow
+	if err := db.First(&pack, ids[0]).Error; err != nil {
+		t.Fatalf("failed to load season pack: %v", err)
+	}
+	if pack.TMDBID != 1396 || !pack.SeasonPack || pack.Season == nil || *pack.Season != 1 || pack.Episode != nil {
+		t.Errorf("expected Breaking Bad season 1 pack, got %+v", pack)
+	}
+}
+
+// TestEnrichTVShow_EpisodeDetails verifies that the episode name and air date are
+// taken from the season endpoint only when episode details are enabled.
+func TestEnrichTVShow_EpisodeDetails(t *testing.T) {
+	seasonRequests := 0
+	srv := newTMDBTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tv/1396/season/1" {
+			seasonRequests++
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":3572,"season_number":1,"episodes":[{"id":62085,"name":"Pilot","season_number":1,"episode_number":1,"air_date":"2008-01-20"}]}`))
+			return
+		}
+		newMockTMDBHandler(w, r)
+	})
+
+	for _, enabled := range []bool{false, true} {
+		db := testutil.TestDB(t)
+		enricher := NewEnricher(db, newTMDBClientForTest(t, srv.URL))
+		enricher.episodeDetails = enabled
+		seasonRequests = 0
+
+		line := &models.ProcessedLine{TvgName: "Breaking Bad S01E01", GroupTitle: "Séries"}
+		classification := classifier.New().Classify(line.TvgName, line.GroupTitle)
+		if err := enricher.EnrichTVShow(line, classification, "", &Statistics{}); err != nil {
+			t.Fatalf("EnrichTVShow error: %v", err)
+		}
+
+		var tvshow models.TVShow
+		if err := db.First(&tvshow, *line.TVShowID).Error; err != nil {
+			t.Fatalf("failed to load TV show: %v", err)
+		}
+		if !enabled {
+			if seasonRequests != 0 || tvshow.AirDate != nil {
+				t.Errorf("expected no season request when disabled, got %d request(s), air date %v", seasonRequests, tvshow.AirDate)
+			}
+			continue
+		}
+		if seasonRequests != 1 {
+			t.Errorf("expected 1 season request, got %d", seasonRequests)
+		}
+		if tvshow.EpisodeTitle == nil || *tvshow.EpisodeTitle != "Pilot" {
+			t.Errorf("expected episode title Pilot, got %v", tvshow.EpisodeTitle)
+		}
+		if tvshow.AirDate == nil || tvshow.AirDate.Format("2006-01-02") != "2008-01-20" {
+			t.Errorf("expected air date 2008-01-20, got %v", tvshow.AirDate)
+		}
+	}
+}
+
+// TestEnrichMissingTMDB_LinksEntries verifies that unlinked movie and TV show lines
+// are associated with TMDB records, and that other lines are left untouched.
+func TestEnrichMissingTMDB_LinksEntries(t *testing.T) {
+	db := testutil.TestDB(t)
+	srv := newTMDBTestServer(t, newMockTMDBHandler)
+	client := newTMDBClientForTest(t, srv.URL)
+
+	movieLine := testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-matrix"
+		l.TvgName = "The Matrix (1999)"
+	})
+	showLine := testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-breaking-bad"
+		l.TvgName = "Breaking Bad S01E01 - Pilot"
+		l.GroupTitle = "Séries"
+		l.ContentType = models.ContentTypeTVShows
+	})
+	unknownLine := testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-unknown"
+		l.TvgName = "Unknown Movie (2001)"
+	})
+	linkedMovie := testutil.CreateMovie(db)
+	linkedLine := testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-linked"
+		l.MovieID = &linkedMovie.ID
+	})
+	channelLine := testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-channel"
+		l.TvgName = "The Matrix (1999)"
+		l.ContentType = models.ContentTypeChannels
+	})
+
+	stats, err := EnrichMissingTMDB(db, client, EnrichTMDBOptions{})
+	if err != nil {
+		t.Fatalf("EnrichMissingTMDB error: %v", err)
+	}
+
+	if stats.Processed != 3 || stats.Matched != 2 || stats.NotFound != 1 || stats.Errors != 0 {
+		t.Errorf("unexpected stats: %+v", *stats)
+	}
+
+	var line models.ProcessedLine
+	db.Preload("Movie").First(&line, movieLine.ID)
+	if line.Movie == nil || line.Movie.TMDBID != 603 {
+		t.Errorf("expected movie line linked to TMDB 603, got %+v", line.Movie)
+	}
+	if line.Movie != nil && (line.Movie.CollectionID == nil || *line.Movie.CollectionID != 2344) {
+		t.Errorf("expected movie in collection 2344, got %v", line.Movie.CollectionID)
+	}
+
+	line = models.ProcessedLine{}
+	db.Preload("TVShow").First(&line, showLine.ID)
+	if line.TVShow == nil || line.TVShow.TMDBID != 1396 {
+		t.Fatalf("expected TV show line linked to TMDB 1396, got %+v", line.TVShow)
+	}
+	if line.TVShow.Season == nil || *line.TVShow.Season != 1 || line.TVShow.Episode == nil || *line.TVShow.Episode != 1 {
+		t.Errorf("expected S01E01, got season=%v episode=%v", line.TVShow.Season, line.TVShow.Episode)
+	}
+
+	for _, id := range []uint{unknownLine.ID, channelLine.ID} {
+		line = models.ProcessedLine{}
+		db.First(&line, id)
+		if line.MovieID != nil || line.TVShowID != nil {
+			t.Errorf("expected line %d to stay unlinked", id)
+		}
+	}
+
+	line = models.ProcessedLine{}
+	db.First(&line, linkedLine.ID)
+	if line.MovieID == nil || *line.MovieID != linkedMovie.ID {
+		t.Errorf("expected already linked line to keep its movie")
+	}
+}
+
+// TestEnrichMissingTMDB_ContentTypeAndLimit verifies the content type filter and limit.
+func TestEnrichMissingTMDB_ContentTypeAndLimit(t *testing.T) {
+	db := testutil.TestDB(t)
+	srv := newTMDBTestServer(t, newMockTMDBHandler)
+	client := newTMDBClientForTest(t, srv.URL)
+
+	for _, hash := range []string{"hash-1", "hash-2"} {
+		h := hash
+		testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+			l.LineHash = h
+			l.TvgName = "The Matrix (1999)"
+		})
+	}
+	testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-show"
+		l.TvgName = "Breaking Bad S01E01"
+		l.ContentType = models.ContentTypeTVShows
+	})
+
+	stats, err := EnrichMissingTMDB(db, client, EnrichTMDBOptions{ContentType: models.ContentTypeTVShows})
+	if err != nil {
+		t.Fatalf("EnrichMissingTMDB error: %v", err)
+	}
+	if stats.Processed != 1 || stats.Matched != 1 {
+		t.Errorf("expected only the TV show to be processed, got %+v", *stats)
+	}
+
+	stats, err = EnrichMissingTMDB(db, client, EnrichTMDBOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("EnrichMissingTMDB error: %v", err)
+	}
+	if stats.Processed != 1 || stats.Matched != 1 {
+		t.Errorf("expected a single movie to be processed, got %+v", *stats)
+	}
+
+	var unlinked int64
+	db.Model(&models.ProcessedLine{}).Where("movie_id IS NULL AND tv_show_id IS NULL").Count(&unlinked)
+	if unlinked != 1 {
+		t.Errorf("expected 1 unlinked line left, got %d", unlinked)
+	}
+}
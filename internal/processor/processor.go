@@ -1,16 +1,18 @@
 package processor
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
-	"regexp"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/glefebvre/stalkeer/internal/classifier"
 	"github.com/glefebvre/stalkeer/internal/config"
 	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/dedup"
 	"github.com/glefebvre/stalkeer/internal/external/tmdb"
 	"github.com/glefebvre/stalkeer/internal/filter"
 	"github.com/glefebvre/stalkeer/internal/logger"
@@ -22,29 +24,60 @@ import (
 // ProcessOptions holds configuration for processing
 type ProcessOptions struct {
 	Force            bool
+	ChangedOnly      bool // only reprocess entries that are new or whose URL changed
+	MarkRemoved      bool // mark stored entries absent from the playlist as removed
 	Limit            int
 	BatchSize        int
 	ProgressInterval int
 	SkipTMDB         bool
 	TMDBLanguage     string
+	TMDBParallel     int  // number of concurrent TMDB enrichments (0 or 1 = serial)
+	DedupeByMetadata bool // keep only the highest resolution entry per TMDB movie or episode
+
+	// OnProgress, when set, is called when a phase starts and after each batch
+	OnProgress func(event ProgressEvent)
+}
+
+// Processing phases reported by ProgressEvent
+const (
+	PhaseParsing    = "parsing"    // reading the playlist, Total is not known yet
+	PhaseProcessing = "processing" // classifying, enriching and saving the entries
+	PhaseFinalizing = "finalizing" // marking seen and removed entries, refreshing stats
+)
+
+// ProgressEvent reports the progress of a run to ProcessOptions.OnProgress. Processed
+// and Errors never decrease during a run.
+type ProgressEvent struct {
+	Phase     string
+	Processed int // entries saved so far
+	Total     int // entries in the playlist
+	Errors    int
+	Stats     Statistics // snapshot of the full statistics
 }
 
 // Statistics holds processing statistics
 type Statistics struct {
-	TotalLines      int
-	Processed       int
-	DuplicatesFound int
-	FilteredOut     int
-	Errors          int
-	Movies          int
-	TVShows         int
-	Channels        int
-	Uncategorized   int
-	TMDBMatched     int
-	TMDBNotFound    int
-	TMDBErrors      int
-	Duration        time.Duration
-	ErrorMessages   []string
+	TotalLines         int           `json:"total_lines"`
+	Processed          int           `json:"processed"`
+	DuplicatesFound    int           `json:"duplicates_found"`
+	MetadataDuplicates int           `json:"metadata_duplicates"`
+	URLDuplicates      int           `json:"url_duplicates"` // entries skipped by m3u.url_duplicate_policy
+	Unchanged          int           `json:"unchanged"`
+	Changed            int           `json:"changed"`
+	FilteredOut        int           `json:"filtered_out"`
+	Removed            int           `json:"removed"`
+	Reclassified       int           `json:"reclassified"`
+	Errors             int           `json:"errors"`
+	Movies             int           `json:"movies"`
+	TVShows            int           `json:"tvshows"`
+	Channels           int           `json:"channels"`
+	Uncategorized      int           `json:"uncategorized"`
+	TMDBMatched        int           `json:"tmdb_matched"`
+	TMDBNotFound       int           `json:"tmdb_not_found"`
+	TMDBErrors         int           `json:"tmdb_errors"`
+	Duration           time.Duration `json:"duration_ns"`
+	Cancelled          bool          `json:"cancelled,omitempty"`
+	ErrorMessages      []string      `json:"error_messages"`
 }
 
 // Processor handles M3U playlist processing
@@ -53,9 +86,17 @@ type Processor struct {
 	parser     *parser.Parser
 	classifier *classifier.Classifier
 	filter     *filter.Manager
-	tmdbClient *tmdb.Client
+	enricher   *Enricher
 	logger     *logger.Logger
 	db         *gorm.DB
+
+	// enrichTypes are the content types enriched with TMDB, nil enriches movies
+	// and TV shows
+	enrichTypes map[models.ContentType]bool
+
+	// errorThreshold is the percentage of failed entries above which a run is
+	// marked completed_with_errors
+	errorThreshold float64
 }
 
 // NewProcessor creates a new processor instance
@@ -71,39 +112,76 @@ func NewProcessor(filePath string) (*Processor, error) {
 	c := classifier.New()
 	f := filter.NewManager()
 
+	if err := c.LoadFromConfig(); err != nil {
+		return nil, fmt.Errorf("failed to load classifier group overrides: %w", err)
+	}
+
 	// Load filters from config and database
 	if err := f.LoadAll(); err != nil {
 		log.WithFields(map[string]interface{}{
 			"error": err,
 		}).Warn("failed to load filters, continuing without filters")
 	}
-	// Initialize TMDB client if enabled
-	var tmdbClient *tmdb.Client
 	cfg := config.Get()
+	urlPolicy, err := parser.ParseURLDuplicatePolicy(cfg.M3U.URLDuplicatePolicy)
+	if err != nil {
+		return nil, err
+	}
+	p.SetURLDuplicatePolicy(urlPolicy)
+	if err := p.ConfigureURLs(cfg.M3U.BaseURL, cfg.M3U.URLVariables); err != nil {
+		return nil, err
+	}
+	p.SetKeepURLless(cfg.M3U.KeepURLless)
+
+	// Initialize TMDB enrichment if enabled
+	var enricher *Enricher
 	if cfg.TMDB.Enabled && cfg.TMDB.APIKey != "" {
-		tmdbClient = tmdb.NewClient(tmdb.Config{
-			APIKey:            cfg.TMDB.APIKey,
-			Language:          cfg.TMDB.Language,
-			RequestsPerSecond: cfg.TMDB.RequestsPerSecond,
-		})
+		tmdbClient := tmdb.NewClient(TMDBClientConfig(cfg.TMDB))
+		enricher = NewEnricher(db, tmdbClient)
+		enricher.episodeDetails = cfg.TMDB.EpisodeDetails
+		enricher.normalizeGenres = cfg.TMDB.NormalizeGenres
 		log.Info("TMDB client initialized")
+
+		// A rejected key would fail every entry, disable enrichment once instead
+		if cfg.TMDB.ValidateKey {
+			if err := tmdbClient.Validate(context.Background()); errors.Is(err, tmdb.ErrInvalidAPIKey) {
+				log.Error("TMDB enrichment disabled, check tmdb.api_key", err)
+				enricher = nil
+			} else if err != nil {
+				log.WithFields(map[string]interface{}{
+					"error": err,
+				}).Warn("could not validate the TMDB API key, continuing with TMDB enrichment")
+			}
+		}
 	} else {
 		log.Warn("TMDB integration disabled or API key not configured")
 	}
 
+	var enrichTypes map[models.ContentType]bool
+	if len(cfg.TMDB.EnrichContentTypes) > 0 {
+		enrichTypes = make(map[models.ContentType]bool, len(cfg.TMDB.EnrichContentTypes))
+		for _, contentType := range cfg.TMDB.EnrichContentTypes {
+			enrichTypes[models.ContentType(contentType)] = true
+		}
+	}
+
 	return &Processor{
-		filePath:   filePath,
-		parser:     p,
-		classifier: c,
-		filter:     f,
-		tmdbClient: tmdbClient,
-		logger:     log,
-		db:         db,
+		filePath:       filePath,
+		parser:         p,
+		classifier:     c,
+		filter:         f,
+		enricher:       enricher,
+		logger:         log,
+		db:             db,
+		enrichTypes:    enrichTypes,
+		errorThreshold: cfg.Processing.ErrorThresholdPercent,
 	}, nil
 }
 
-// Process parses and processes the M3U file
-func (p *Processor) Process(opts ProcessOptions) (*Statistics, error) {
+// Process parses and processes the M3U file. When ctx is cancelled, processing stops
+// before the next entry, the entries already handled are saved and the partial
+// statistics are returned with Cancelled set.
+func (p *Processor) Process(ctx context.Context, opts ProcessOptions) (*Statistics, error) {
 	startTime := time.Now()
 
 	stats := &Statistics{
@@ -111,22 +189,40 @@ func (p *Processor) Process(opts ProcessOptions) (*Statistics, error) {
 	}
 
 	p.logger.WithFields(map[string]interface{}{
-		"file":  p.filePath,
-		"limit": opts.Limit,
-		"force": opts.Force,
+		"file":         p.filePath,
+		"limit":        opts.Limit,
+		"force":        opts.Force,
+		"changed_only": opts.ChangedOnly,
 	}).Info("starting M3U processing")
 
 	// Create processing log entry
 	logEntry := &models.ProcessingLog{
 		Action:    "process_m3u",
 		Status:    "in_progress",
-		StartedAt: time.Now(),
+		StartedAt: startTime,
 	}
 	if err := p.db.Create(logEntry).Error; err != nil {
 		return nil, fmt.Errorf("failed to create processing log: %w", err)
 	}
 
+	// reportProgress sends the current counts to opts.OnProgress
+	reportProgress := func(phase string) {
+		if opts.OnProgress == nil {
+			return
+		}
+		snapshot := *stats
+		snapshot.ErrorMessages = append([]string(nil), stats.ErrorMessages...)
+		opts.OnProgress(ProgressEvent{
+			Phase:     phase,
+			Processed: stats.Processed,
+			Total:     stats.TotalLines,
+			Errors:    stats.Errors,
+			Stats:     snapshot,
+		})
+	}
+
 	// Parse the M3U file
+	reportProgress(PhaseParsing)
 	lines, err := p.parser.Parse()
 	if err != nil {
 		p.updateProcessingLog(logEntry, "failed", stats, err.Error())
@@ -134,6 +230,8 @@ func (p *Processor) Process(opts ProcessOptions) (*Statistics, error) {
 	}
 
 	stats.TotalLines = len(lines)
+	stats.URLDuplicates = p.parser.GetStats().SkippedURLDuplicates
+	reportProgress(PhaseProcessing)
 
 	// Process entries in batches
 	if opts.BatchSize <= 0 {
@@ -143,19 +241,103 @@ func (p *Processor) Process(opts ProcessOptions) (*Statistics, error) {
 		opts.ProgressInterval = 1000
 	}
 
+	// In changed-only mode, index the stored lines once up front
+	var storedHashes map[string]bool
+	var storedKeys map[string]uint
+	if opts.ChangedOnly {
+		storedHashes, storedKeys, err = p.loadStoredLines()
+		if err != nil {
+			p.updateProcessingLog(logEntry, "failed", stats, err.Error())
+			return nil, fmt.Errorf("failed to load stored lines: %w", err)
+		}
+	}
+
 	batch := make([]*models.ProcessedLine, 0, opts.BatchSize)
+	pending := make([]*pendingLine, 0, opts.BatchSize)
 	processed := 0
+	seen := make(map[string]*models.ProcessedLine) // best entry per title, for metadata dedupe
+
+	// flushPending sets the content type of the pending entries, enriching them
+	// concurrently when enabled, then adds them to the save batch in playlist order
+	flushPending := func() {
+		p.setContentTypes(pending, &opts, stats)
+
+		for _, entry := range pending {
+			if entry.err != nil {
+				stats.Errors++
+				errMsg := fmt.Sprintf("error setting content type for line %d: %v", entry.index+1, entry.err)
+				stats.ErrorMessages = append(stats.ErrorMessages, errMsg)
+				continue
+			}
+
+			// Keep only the highest resolution entry per TMDB title
+			if opts.DedupeByMetadata {
+				keep, err := p.dedupeByMetadata(entry.line, seen, &batch, stats)
+				if err != nil {
+					stats.Errors++
+					errMsg := fmt.Sprintf("error checking metadata duplicate for line %d: %v", entry.index+1, err)
+					stats.ErrorMessages = append(stats.ErrorMessages, errMsg)
+					continue
+				}
+				if !keep {
+					continue
+				}
+			}
+
+			// Add to batch
+			batch = append(batch, entry.line)
+
+			// Process batch when full
+			if len(batch) >= opts.BatchSize {
+				if err := p.saveBatch(batch, stats); err != nil {
+					stats.Errors++
+					errMsg := fmt.Sprintf("error saving batch: %v", err)
+					stats.ErrorMessages = append(stats.ErrorMessages, errMsg)
+				}
+				batch = batch[:0]
+			}
+
+			processed++
+
+			// Show progress
+			if processed%opts.ProgressInterval == 0 {
+				p.logger.Info(fmt.Sprintf("processed %d/%d entries", processed, stats.TotalLines))
+			}
+		}
+		pending = pending[:0]
+
+		reportProgress(PhaseProcessing)
+	}
+
+	// Hashes claimed by the entries of this run, so that an entry is never inserted
+	// twice, even when checked concurrently before the first one is stored
+	claimed := dedup.New(len(lines))
 
 	for i, line := range lines {
+		if ctx.Err() != nil {
+			stats.Cancelled = true
+			break
+		}
+
 		// Check limit
-		if opts.Limit > 0 && processed >= opts.Limit {
+		if opts.Limit > 0 && processed+len(pending) >= opts.Limit {
 			p.logger.Info(fmt.Sprintf("reached processing limit of %d entries", opts.Limit))
 			break
 		}
 
-		// Check for duplicate
-		if !opts.Force {
-			exists, err := p.checkDuplicate(line.LineHash)
+		// In changed-only mode, skip untouched entries and update changed ones in place
+		if opts.ChangedOnly {
+			if storedHashes[line.LineHash] {
+				stats.Unchanged++
+				continue
+			}
+			if id, ok := storedKeys[line.ContentKey]; ok {
+				line.ID = id
+				stats.Changed++
+			}
+		} else if !opts.Force {
+			// Check for duplicate
+			exists, err := p.checkDuplicate(claimed, line.LineHash)
 			if err != nil {
 				stats.Errors++
 				errMsg := fmt.Sprintf("error checking duplicate for line %d: %v", i+1, err)
@@ -174,45 +356,72 @@ func (p *Processor) Process(opts ProcessOptions) (*Statistics, error) {
 			continue
 		}
 
-		// Classify content
-		classification := p.classifier.Classify(line.TvgName, line.GroupTitle)
+		// Classify content, the content type is set when the pending entries are flushed
+		pending = append(pending, &pendingLine{
+			index:          i,
+			line:           &line,
+			classification: p.classifier.ClassifyStream(line.TvgName, line.GroupTitle, line.Duration, valueOrEmpty(line.LineURL)),
+		})
+		if len(pending) >= opts.BatchSize {
+			flushPending()
+		}
+	}
+	// Entries already classified are still saved when cancelled
+	flushPending()
 
-		// Set content type and create associations (with TMDB enrichment)
-		if err := p.setContentType(&line, classification, &opts, stats); err != nil {
+	// Process remaining entries in batch
+	if len(batch) > 0 {
+		if err := p.saveBatch(batch, stats); err != nil {
 			stats.Errors++
-			errMsg := fmt.Sprintf("error setting content type for line %d: %v", i+1, err)
+			errMsg := fmt.Sprintf("error saving final batch: %v", err)
 			stats.ErrorMessages = append(stats.ErrorMessages, errMsg)
-			continue
 		}
+	}
 
-		// Add to batch
-		batch = append(batch, &line)
+	if stats.Cancelled {
+		stats.Duration = time.Since(startTime)
+		msg := fmt.Sprintf("processing cancelled after %d entries: %v", stats.Processed, ctx.Err())
+		p.updateProcessingLog(logEntry, "cancelled", stats, msg)
 
-		// Process batch when full
-		if len(batch) >= opts.BatchSize {
-			if err := p.saveBatch(batch, stats); err != nil {
-				stats.Errors++
-				errMsg := fmt.Sprintf("error saving batch: %v", err)
-				stats.ErrorMessages = append(stats.ErrorMessages, errMsg)
-			}
-			batch = batch[:0]
-		}
-
-		processed++
+		p.logger.WithFields(map[string]interface{}{
+			"processed":        stats.Processed,
+			"duration_seconds": stats.Duration.Seconds(),
+		}).Warn("processing cancelled")
 
-		// Show progress
-		if processed%opts.ProgressInterval == 0 {
-			p.logger.Info(fmt.Sprintf("processed %d/%d entries", processed, stats.TotalLines))
-		}
+		return stats, nil
 	}
 
-	// Process remaining entries in batch
-	if len(batch) > 0 {
-		if err := p.saveBatch(batch, stats); err != nil {
+	reportProgress(PhaseFinalizing)
+
+	// Record which stored entries are still present in the playlist
+	if err := p.markSeen(lines, startTime); err != nil {
+		stats.Errors++
+		errMsg := fmt.Sprintf("error updating last seen timestamps: %v", err)
+		stats.ErrorMessages = append(stats.ErrorMessages, errMsg)
+	} else if opts.MarkRemoved {
+		removed, err := p.markRemoved(startTime)
+		if err != nil {
 			stats.Errors++
-			errMsg := fmt.Sprintf("error saving final batch: %v", err)
+			errMsg := fmt.Sprintf("error marking removed entries: %v", err)
 			stats.ErrorMessages = append(stats.ErrorMessages, errMsg)
 		}
+		stats.Removed = int(removed)
+	}
+
+	// Upgrade uncategorized entries whose downloaded stream turned out to be a video
+	reclassified, err := ReclassifyByContentType(p.db)
+	if err != nil {
+		stats.Errors++
+		errMsg := fmt.Sprintf("error reclassifying by content type: %v", err)
+		stats.ErrorMessages = append(stats.ErrorMessages, errMsg)
+	}
+	stats.Reclassified = int(reclassified)
+
+	// Keep the stats endpoint in line with the new item counts
+	if _, err := RefreshStatsSnapshot(p.db); err != nil {
+		p.logger.WithFields(map[string]interface{}{
+			"error": err,
+		}).Warn("failed to refresh stats snapshot")
 	}
 
 	stats.Duration = time.Since(startTime)
@@ -221,7 +430,9 @@ func (p *Processor) Process(opts ProcessOptions) (*Statistics, error) {
 	status := "success"
 	var errorMsg *string
 	if stats.Errors > 0 {
-		status = "completed_with_errors"
+		if p.errorsExceedThreshold(stats) {
+			status = "completed_with_errors"
+		}
 		msg := fmt.Sprintf("%d errors occurred during processing", stats.Errors)
 		errorMsg = &msg
 	}
@@ -234,7 +445,12 @@ func (p *Processor) Process(opts ProcessOptions) (*Statistics, error) {
 	p.logger.WithFields(map[string]interface{}{
 		"processed":        stats.Processed,
 		"duplicates":       stats.DuplicatesFound,
+		"metadata_dups":    stats.MetadataDuplicates,
+		"unchanged":        stats.Unchanged,
+		"changed":          stats.Changed,
 		"filtered":         stats.FilteredOut,
+		"removed":          stats.Removed,
+		"reclassified":     stats.Reclassified,
 		"errors":           stats.Errors,
 		"duration_seconds": stats.Duration.Seconds(),
 	}).Info("processing completed")
@@ -242,17 +458,149 @@ func (p *Processor) Process(opts ProcessOptions) (*Statistics, error) {
 	return stats, nil
 }
 
-// checkDuplicate checks if a line with the given hash already exists
-func (p *Processor) checkDuplicate(lineHash string) (bool, error) {
+// errorsExceedThreshold reports whether the failed entries of a run are above
+// errorThreshold percent of its entries
+func (p *Processor) errorsExceedThreshold(stats *Statistics) bool {
+	if stats.Errors == 0 {
+		return false
+	}
+	if stats.TotalLines == 0 {
+		return true
+	}
+	return float64(stats.Errors)*100/float64(stats.TotalLines) > p.errorThreshold
+}
+
+// markSeen stamps last_seen_at on every stored line that is present in the playlist,
+// including soft-deleted ones so that prune does not treat them as stale.
+// Entries previously marked as removed are restored to the processed state.
+func (p *Processor) markSeen(lines []models.ProcessedLine, seenAt time.Time) error {
+	const chunkSize = 500
+
+	for start := 0; start < len(lines); start += chunkSize {
+		end := min(start+chunkSize, len(lines))
+		hashes := make([]string, 0, end-start)
+		for _, line := range lines[start:end] {
+			hashes = append(hashes, line.LineHash)
+		}
+
+		if err := p.db.Unscoped().Model(&models.ProcessedLine{}).
+			Where("line_hash IN ?", hashes).
+			UpdateColumns(map[string]interface{}{
+				"last_seen_at": seenAt,
+				"state":        gorm.Expr("CASE WHEN state = ? THEN ? ELSE state END", models.StateRemoved, models.StateProcessed),
+			}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markRemoved flags stored lines that were not seen during the run started at runStart
+func (p *Processor) markRemoved(runStart time.Time) (int64, error) {
+	result := p.db.Model(&models.ProcessedLine{}).
+		Where("(last_seen_at IS NULL OR last_seen_at < ?) AND state <> ?", runStart, models.StateRemoved).
+		UpdateColumn("state", models.StateRemoved)
+	return result.RowsAffected, result.Error
+}
+
+// checkDuplicate checks if a line with the given hash already exists, or was
+// already claimed in claimed by another entry of the run. The hash is claimed
+// for the caller when it is new.
+func (p *Processor) checkDuplicate(claimed *dedup.Set, lineHash string) (bool, error) {
+	if !claimed.Add(lineHash) {
+		return true, nil
+	}
+
 	var count int64
-	err := p.db.Model(&models.ProcessedLine{}).Where("line_hash = ?", lineHash).Count(&count).Error
+	err := p.db.Unscoped().Model(&models.ProcessedLine{}).Where("line_hash = ?", lineHash).Count(&count).Error
+	if err != nil {
+		// Release the claim, the entry was not checked
+		claimed.Remove(lineHash)
+	}
 	return count > 0, err
 }
 
+// loadStoredLines indexes the stored lines by line hash and by content key.
+// Soft-deleted lines are included so that they are not recreated.
+func (p *Processor) loadStoredLines() (map[string]bool, map[string]uint, error) {
+	var rows []struct {
+		ID         uint
+		TvgName    string
+		GroupTitle string
+		LineHash   string
+	}
+	if err := p.db.Unscoped().Model(&models.ProcessedLine{}).
+		Select("id, tvg_name, group_title, line_hash").
+		Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make(map[string]bool, len(rows))
+	keys := make(map[string]uint, len(rows))
+	for _, row := range rows {
+		hashes[row.LineHash] = true
+		keys[parser.ContentKey(row.TvgName, row.GroupTitle)] = row.ID
+	}
+	return hashes, keys, nil
+}
+
+// pendingLine is a classified entry waiting for its content type to be set
+type pendingLine struct {
+	index          int // position in the playlist, for error messages
+	line           *models.ProcessedLine
+	classification classifier.Classification
+	err            error
+}
+
+// setContentTypes calls setContentType on every entry. With TMDB enrichment enabled
+// and opts.TMDBParallel > 1, entries are enriched by a bounded pool of workers. Each
+// worker records its counters in per-entry statistics, merged into stats once all
+// entries are done, so that the counters stay consistent with the serial path.
+func (p *Processor) setContentTypes(entries []*pendingLine, opts *ProcessOptions, stats *Statistics) {
+	workers := opts.TMDBParallel
+	if workers > len(entries) {
+		workers = len(entries)
+	}
+	if workers <= 1 || opts.SkipTMDB || p.enricher == nil {
+		for _, entry := range entries {
+			entry.err = p.setContentType(entry.line, entry.classification, opts, stats)
+		}
+		return
+	}
+
+	entryStats := make([]Statistics, len(entries))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				entries[i].err = p.setContentType(entries[i].line, entries[i].classification, opts, &entryStats[i])
+			}
+		}()
+	}
+	for i := range entries {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i := range entryStats {
+		stats.TMDBMatched += entryStats[i].TMDBMatched
+		stats.TMDBNotFound += entryStats[i].TMDBNotFound
+		stats.TMDBErrors += entryStats[i].TMDBErrors
+	}
+}
+
 // setContentType sets the content type and creates necessary associations with TMDB enrichment
 func (p *Processor) setContentType(line *models.ProcessedLine, classification classifier.Classification, opts *ProcessOptions, stats *Statistics) error {
-	// Persist resolution detected by the classifier
+	// Persist resolution, language, normalized group and confidence detected by the classifier
 	line.Resolution = classification.Resolution
+	line.Language = classification.Language
+	line.NormalizedGroup = classification.Group
+	confidence := classification.Confidence
+	line.ClassificationConfidence = &confidence
 
 	// Determine language for TMDB
 	language := opts.TMDBLanguage
@@ -268,9 +616,22 @@ func (p *Processor) setContentType(line *models.ProcessedLine, classification cl
 	case classifier.ContentTypeMovie:
 		line.ContentType = models.ContentTypeMovies
 
+		// Link the parts of a multi-part stream through a group key shared by all parts
+		if classification.PartNumber != nil {
+			_, base := classifier.ExtractPart(line.TvgName)
+			partGroup := computeLineHash(parser.ContentKey(base, line.GroupTitle))
+			line.PartNumber = classification.PartNumber
+			line.PartGroup = &partGroup
+		}
+
 		// Try to enrich with TMDB if enabled
-		if !opts.SkipTMDB && p.tmdbClient != nil {
-			if err := p.enrichMovie(line, language, stats); err != nil {
+		if p.enrichEnabled(line.ContentType, opts) {
+			if err := p.enricher.EnrichMovie(line, language, stats); err != nil {
+				// A title TMDB only knows as a TV show was misclassified
+				if errors.Is(err, tmdb.ErrNoResults) && p.enrichEnabled(models.ContentTypeTVShows, opts) &&
+					p.enricher.correctToTVShow(line, classification, language, stats) {
+					return nil
+				}
 				// Log error but don't fail the processing
 				p.logger.WithFields(map[string]interface{}{
 					"title": line.TvgName,
@@ -284,8 +645,13 @@ func (p *Processor) setContentType(line *models.ProcessedLine, classification cl
 		line.ContentType = models.ContentTypeTVShows
 
 		// Try to enrich with TMDB if enabled
-		if !opts.SkipTMDB && p.tmdbClient != nil {
-			if err := p.enrichTVShow(line, classification, language, stats); err != nil {
+		if p.enrichEnabled(line.ContentType, opts) {
+			if err := p.enricher.EnrichTVShow(line, classification, language, stats); err != nil {
+				// A title TMDB only knows as a movie was misclassified
+				if errors.Is(err, tmdb.ErrNoResults) && p.enrichEnabled(models.ContentTypeMovies, opts) &&
+					p.enricher.correctToMovie(line, language, stats) {
+					return nil
+				}
 				// Log error but don't fail the processing
 				p.logger.WithFields(map[string]interface{}{
 					"title": line.TvgName,
@@ -295,233 +661,23 @@ func (p *Processor) setContentType(line *models.ProcessedLine, classification cl
 		}
 		return nil
 
+	case classifier.ContentTypeChannel:
+		line.ContentType = models.ContentTypeChannels
+		return nil
+
 	default:
 		line.ContentType = models.ContentTypeUncategorized
 		return nil
 	}
 }
 
-// enrichMovie fetches movie data from TMDB and creates/updates Movie association
-func (p *Processor) enrichMovie(line *models.ProcessedLine, language string, stats *Statistics) error {
-	// Extract title and year from tvg-name
-	title, year := p.extractTitleAndYear(line.TvgName)
-
-	// Search TMDB
-	result, err := p.tmdbClient.SearchMovie(title, year)
-	if err != nil {
-		stats.TMDBNotFound++
-		return err
-	}
-
-	// Get detailed information
-	details, err := p.tmdbClient.GetMovieDetails(result.ID)
-	if err != nil {
-		stats.TMDBErrors++
-		return err
+// enrichEnabled reports whether entries of contentType are enriched with TMDB.
+// Channels and uncategorized entries are never enriched.
+func (p *Processor) enrichEnabled(contentType models.ContentType, opts *ProcessOptions) bool {
+	if opts.SkipTMDB || p.enricher == nil {
+		return false
 	}
-
-	// Get external IDs (including TVDB ID)
-	externalIDs, err := p.tmdbClient.GetMovieExternalIDs(result.ID)
-	if err != nil {
-		// Log warning but don't fail - external IDs are optional
-		p.logger.WithFields(map[string]interface{}{
-			"tmdb_id": result.ID,
-			"error":   err,
-		}).Warn("Failed to fetch movie external IDs")
-	}
-
-	// Create or find existing movie (atomic upsert to prevent duplicate key on concurrent inserts)
-	var movie models.Movie
-	tmdbYear := tmdb.ExtractYear(details.ReleaseDate)
-	genres := tmdb.FormatGenres(details.Genres)
-
-	var tvdbID *int
-	if externalIDs != nil {
-		tvdbID = externalIDs.TVDBID
-	}
-	attrs := models.Movie{
-		TMDBID:     details.ID,
-		TVDBID:     tvdbID,
-		TMDBTitle:  details.Title,
-		TMDBYear:   tmdbYear,
-		TMDBGenres: &genres,
-		Duration:   details.Runtime,
-	}
-	if result := p.db.Where("tmdb_id = ? AND tmdb_year = ?", details.ID, tmdbYear).
-		Attrs(attrs).
-		FirstOrCreate(&movie); result.Error != nil {
-		stats.TMDBErrors++
-		return fmt.Errorf("failed to upsert movie: %w", result.Error)
-	}
-
-	// Update TVDB ID if it's missing on an existing record
-	if externalIDs != nil && externalIDs.TVDBID != nil && movie.TVDBID == nil {
-		movie.TVDBID = externalIDs.TVDBID
-		if err := p.db.Save(&movie).Error; err != nil {
-			p.logger.WithFields(map[string]interface{}{
-				"movie_id": movie.ID,
-				"error":    err,
-			}).Warn("Failed to update movie with TVDB ID")
-		}
-	}
-
-	// Associate with processed line
-	line.MovieID = &movie.ID
-	stats.TMDBMatched++
-
-	return nil
-}
-
-// enrichTVShow fetches TV show data from TMDB and creates/updates TVShow association
-func (p *Processor) enrichTVShow(line *models.ProcessedLine, classification classifier.Classification, language string, stats *Statistics) error {
-	// Extract title from tvg-name (remove season/episode info)
-	title := p.cleanTVShowTitle(line.TvgName)
-
-	// Search TMDB
-	result, err := p.tmdbClient.SearchTVShow(title)
-	if err != nil {
-		stats.TMDBNotFound++
-		return err
-	}
-
-	// Get detailed information
-	details, err := p.tmdbClient.GetTVShowDetails(result.ID)
-	if err != nil {
-		stats.TMDBErrors++
-		return err
-	}
-
-	// Get external IDs (including TVDB ID)
-	externalIDs, err := p.tmdbClient.GetTVShowExternalIDs(result.ID)
-	if err != nil {
-		// Log warning but don't fail - external IDs are optional
-		p.logger.WithFields(map[string]interface{}{
-			"tmdb_id": result.ID,
-			"error":   err,
-		}).Warn("Failed to fetch TV show external IDs")
-	}
-
-	// Create or find existing TV show (atomic upsert to prevent duplicate key on concurrent inserts)
-	var tvshow models.TVShow
-	tmdbYear := tmdb.ExtractYear(details.FirstAirDate)
-	genres := tmdb.FormatGenres(details.Genres)
-
-	var tvdbID *int
-	if externalIDs != nil {
-		tvdbID = externalIDs.TVDBID
-	}
-	attrs := models.TVShow{
-		TMDBID:     details.ID,
-		TVDBID:     tvdbID,
-		TMDBTitle:  details.Name,
-		TMDBYear:   tmdbYear,
-		TMDBGenres: &genres,
-		Season:     classification.Season,
-		Episode:    classification.Episode,
-	}
-
-	query := p.db.Where("tmdb_id = ?", details.ID)
-	if classification.Season != nil {
-		query = query.Where("season = ?", *classification.Season)
-	} else {
-		query = query.Where("season IS NULL")
-	}
-	if classification.Episode != nil {
-		query = query.Where("episode = ?", *classification.Episode)
-	} else {
-		query = query.Where("episode IS NULL")
-	}
-
-	if result := query.Attrs(attrs).FirstOrCreate(&tvshow); result.Error != nil {
-		stats.TMDBErrors++
-		return fmt.Errorf("failed to upsert TV show: %w", result.Error)
-	}
-
-	// Update TVDB ID if it's missing on an existing record
-	if externalIDs != nil && externalIDs.TVDBID != nil && tvshow.TVDBID == nil {
-		tvshow.TVDBID = externalIDs.TVDBID
-		if err := p.db.Save(&tvshow).Error; err != nil {
-			p.logger.WithFields(map[string]interface{}{
-				"tvshow_id": tvshow.ID,
-				"error":     err,
-			}).Warn("Failed to update TV show with TVDB ID")
-		}
-	}
-
-	// Associate with processed line
-	line.TVShowID = &tvshow.ID
-	stats.TMDBMatched++
-
-	return nil
-}
-
-// qualitySuffixRe matches quality/language tokens at the end of a title,
-// e.g. "Movie SD", "Movie HD MULTI", "Movie FHD VOSTFR".
-var qualitySuffixRe = regexp.MustCompile(`(?i)\s+(?:SD|FHD|UHD|HD|4K|MULTI|VOSTFR|VF)(?:\s+.*)?$`)
-
-// yearDashRe matches a year in the "Titre - YYYY" format at the end of a title,
-// e.g. "Super Dark Times - 2017". Requires a 19xx or 20xx year to avoid false positives.
-var yearDashRe = regexp.MustCompile(`\s*-\s*((?:19|20)\d{2})$`)
-
-// extractTitleAndYear extracts title and optional year from a string.
-// It first strips quality/language suffixes (SD, HD, FHD, UHD, 4K, MULTI, VOSTFR, VF),
-// then attempts year extraction from "(YYYY)" and "- YYYY" formats.
-func (p *Processor) extractTitleAndYear(title string) (string, *int) {
-	// Strip quality/language suffixes first
-	clean := qualitySuffixRe.ReplaceAllString(title, "")
-	clean = strings.TrimSpace(clean)
-
-	// Try "(YYYY)" format: "Movie Title (2024)"
-	if strings.Contains(clean, "(") {
-		parts := strings.Split(clean, "(")
-		cleanTitle := strings.TrimSpace(parts[0])
-
-		for i := 1; i < len(parts); i++ {
-			if strings.Contains(parts[i], ")") {
-				yearStr := strings.TrimSuffix(parts[i], ")")
-				var year int
-				if _, err := fmt.Sscanf(yearStr, "%d", &year); err == nil && year >= 1900 && year <= 2100 {
-					return cleanTitle, &year
-				}
-			}
-		}
-		return cleanTitle, nil
-	}
-
-	// Try "Titre - YYYY" format: "Super Dark Times - 2017"
-	if m := yearDashRe.FindStringSubmatch(clean); m != nil {
-		var year int
-		if _, err := fmt.Sscanf(m[1], "%d", &year); err == nil && year >= 1900 && year <= 2100 {
-			cleanTitle := strings.TrimSpace(yearDashRe.ReplaceAllString(clean, ""))
-			return cleanTitle, &year
-		}
-	}
-
-	return clean, nil
-}
-
-// cleanTVShowTitle removes season/episode markers and quality tags from title
-func (p *Processor) cleanTVShowTitle(title string) string {
-	// Remove common patterns like "S01 E01", "S01E01", quality tags, etc.
-	patterns := []string{
-		`\s+S\d{2}\s*E\d{2}`,                                 // S01 E01
-		`\s+S\d{2}E\d{2}`,                                    // S01E01
-		`\s+\d{1,2}x\d{1,2}`,                                 // 1x01
-		`\s+\(\d{4}\)`,                                       // (2024)
-		`\s+\(.*?(HD|SD|4K|1080p|720p|480p).*?\)`,            // Quality tags
-		`\s+(HD|FHD|UHD|4K|1080p|720p|480p|SD|SDTV|HDTV).*$`, // Quality suffixes
-		`\s+\(MULTI\)`,                                       // Language tags
-		`\s+\(VOSTFR\)`,
-		`\s+\(VF\)`,
-	}
-
-	cleanTitle := title
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		cleanTitle = re.ReplaceAllString(cleanTitle, "")
-	}
-
-	return strings.TrimSpace(cleanTitle)
+	return p.enrichTypes == nil || p.enrichTypes[contentType]
 }
 
 // saveBatch saves a batch of processed lines to the database
@@ -531,19 +687,29 @@ func (p *Processor) saveBatch(batch []*models.ProcessedLine, stats *Statistics)
 			// Set timestamps
 			now := time.Now()
 			line.ProcessedAt = now
-			line.State = models.StateProcessed
+			line.LastSeenAt = &now
+			if line.State != models.StateNoURL {
+				line.State = models.StateProcessed
+			}
 			line.CreatedAt = now
 			line.UpdatedAt = now
 
-			// Check if entry exists and handle based on force mode
+			// Check if entry exists and handle based on force mode.
+			// Soft-deleted entries are matched too and stay deleted.
 			var existing models.ProcessedLine
-			err := tx.Where("line_hash = ?", line.LineHash).First(&existing).Error
+			err := tx.Unscoped().Where("line_hash = ?", line.LineHash).First(&existing).Error
+			if err == gorm.ErrRecordNotFound && line.ID != 0 {
+				// Changed entry: same content key, new URL - update the stored row
+				err = tx.Unscoped().First(&existing, line.ID).Error
+			}
 
 			if err == nil {
-				// Entry exists - update it
+				// Entry exists - update it. The line is freshly parsed, so the saved
+				// associations are only the ones of its current content type.
 				line.ID = existing.ID
 				line.CreatedAt = existing.CreatedAt
-				if err := tx.Save(line).Error; err != nil {
+				line.DeletedAt = existing.DeletedAt
+				if err := tx.Unscoped().Save(line).Error; err != nil {
 					return fmt.Errorf("failed to update processed line: %w", err)
 				}
 			} else if err == gorm.ErrRecordNotFound {
@@ -577,6 +743,8 @@ func (p *Processor) updateProcessingLog(logEntry *models.ProcessingLog, status s
 	now := time.Now()
 	logEntry.Status = status
 	logEntry.ItemCount = stats.Processed
+	logEntry.TMDBMatched = stats.TMDBMatched
+	logEntry.TMDBNotFound = stats.TMDBNotFound
 	logEntry.CompletedAt = &now
 	if errorMsg != "" {
 		logEntry.ErrorMessage = &errorMsg
@@ -589,3 +757,10 @@ func computeLineHash(content string) string {
 	hash := sha256.Sum256([]byte(content))
 	return hex.EncodeToString(hash[:])
 }
+
+func valueOrEmpty(ptr *string) string {
+	if ptr == nil {
+		return ""
+	}
+	return *ptr
+}
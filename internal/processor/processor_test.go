@@ -1,14 +1,24 @@
 package processor
 
 import (
+	"context"
+	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/glefebvre/stalkeer/internal/classifier"
 	"github.com/glefebvre/stalkeer/internal/config"
 	"github.com/glefebvre/stalkeer/internal/database"
+	"github.com/glefebvre/stalkeer/internal/filter"
+	"github.com/glefebvre/stalkeer/internal/logger"
 	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/parser"
+	"github.com/glefebvre/stalkeer/internal/testutil"
+	"gorm.io/gorm"
 )
 
 func setupTestDB(t *testing.T) {
@@ -114,7 +124,7 @@ http://example.com/movie2.mp4`
 		ProgressInterval: 100,
 	}
 
-	stats, err := proc.Process(opts)
+	stats, err := proc.Process(context.Background(), opts)
 	if err != nil {
 		t.Fatalf("Process failed: %v", err)
 	}
@@ -159,7 +169,7 @@ http://example.com/3.mkv`
 		ProgressInterval: 100,
 	}
 
-	stats, err := proc.Process(opts)
+	stats, err := proc.Process(context.Background(), opts)
 	if err != nil {
 		t.Fatalf("Process failed: %v", err)
 	}
@@ -197,13 +207,13 @@ http://example.com/movie.mkv`
 	}
 
 	// First processing
-	stats1, err := proc.Process(opts)
+	stats1, err := proc.Process(context.Background(), opts)
 	if err != nil {
 		t.Fatalf("First Process failed: %v", err)
 	}
 
 	// Second processing (should detect duplicate)
-	stats2, err := proc.Process(opts)
+	stats2, err := proc.Process(context.Background(), opts)
 	if err != nil {
 		t.Fatalf("Second Process failed: %v", err)
 	}
@@ -241,13 +251,13 @@ http://example.com/movie.mkv`
 	}
 
 	// First processing
-	stats1, err := proc.Process(opts)
+	stats1, err := proc.Process(context.Background(), opts)
 	if err != nil {
 		t.Fatalf("First Process failed: %v", err)
 	}
 
 	// Second processing with force (should process again)
-	stats2, err := proc.Process(opts)
+	stats2, err := proc.Process(context.Background(), opts)
 	if err != nil {
 		t.Fatalf("Second Process failed: %v", err)
 	}
@@ -263,6 +273,240 @@ http://example.com/movie.mkv`
 	}
 }
 
+func TestProcessChangedOnly_UnchangedEntry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	content := `#EXTM3U
+#EXTINF:-1 tvg-name="Test Movie" group-title="Movies",Test Movie
+http://example.com/movie.mkv`
+
+	tmpFile := createTestM3U(t, content)
+
+	proc, err := NewProcessor(tmpFile)
+	if err != nil {
+		t.Fatalf("NewProcessor failed: %v", err)
+	}
+
+	opts := ProcessOptions{
+		BatchSize:        10,
+		ProgressInterval: 100,
+		SkipTMDB:         true,
+	}
+	if _, err := proc.Process(context.Background(), opts); err != nil {
+		t.Fatalf("First Process failed: %v", err)
+	}
+
+	var before models.ProcessedLine
+	if err := database.Get().Where("tvg_name = ?", "Test Movie").First(&before).Error; err != nil {
+		t.Fatalf("expected line to be stored: %v", err)
+	}
+
+	// Second run with an identical playlist should leave the entry alone
+	proc, err = NewProcessor(tmpFile)
+	if err != nil {
+		t.Fatalf("NewProcessor failed: %v", err)
+	}
+
+	opts.ChangedOnly = true
+	stats, err := proc.Process(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Second Process failed: %v", err)
+	}
+
+	if stats.Unchanged != 1 {
+		t.Errorf("expected 1 unchanged entry, got %d", stats.Unchanged)
+	}
+	if stats.Changed != 0 {
+		t.Errorf("expected 0 changed entries, got %d", stats.Changed)
+	}
+	if stats.Processed != 0 {
+		t.Errorf("expected 0 processed entries, got %d", stats.Processed)
+	}
+
+	var after models.ProcessedLine
+	database.Get().First(&after, before.ID)
+	if !after.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Error("expected unchanged entry not to be rewritten")
+	}
+}
+
+func TestProcessChangedOnly_ChangedURL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	first := createTestM3U(t, `#EXTM3U
+#EXTINF:-1 tvg-name="Test Movie" group-title="Movies",Test Movie
+http://example.com/old.mkv`)
+
+	proc, err := NewProcessor(first)
+	if err != nil {
+		t.Fatalf("NewProcessor failed: %v", err)
+	}
+
+	opts := ProcessOptions{
+		BatchSize:        10,
+		ProgressInterval: 100,
+		SkipTMDB:         true,
+	}
+	if _, err := proc.Process(context.Background(), opts); err != nil {
+		t.Fatalf("First Process failed: %v", err)
+	}
+
+	var before models.ProcessedLine
+	if err := database.Get().Where("tvg_name = ?", "Test Movie").First(&before).Error; err != nil {
+		t.Fatalf("expected line to be stored: %v", err)
+	}
+
+	// The provider changed the stream URL for the same entry
+	second := createTestM3U(t, `#EXTM3U
+#EXTINF:-1 tvg-name="Test Movie" group-title="Movies",Test Movie
+http://example.com/new.mkv`)
+
+	proc, err = NewProcessor(second)
+	if err != nil {
+		t.Fatalf("NewProcessor failed: %v", err)
+	}
+
+	opts.ChangedOnly = true
+	stats, err := proc.Process(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Second Process failed: %v", err)
+	}
+
+	if stats.Changed != 1 {
+		t.Errorf("expected 1 changed entry, got %d", stats.Changed)
+	}
+	if stats.Unchanged != 0 {
+		t.Errorf("expected 0 unchanged entries, got %d", stats.Unchanged)
+	}
+
+	// The existing row is updated in place rather than duplicated
+	var lines []models.ProcessedLine
+	database.Get().Where("tvg_name = ?", "Test Movie").Find(&lines)
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 stored line, got %d", len(lines))
+	}
+	if lines[0].ID != before.ID {
+		t.Errorf("expected row %d to be updated, got row %d", before.ID, lines[0].ID)
+	}
+	if lines[0].LineURL == nil || *lines[0].LineURL != "http://example.com/new.mkv" {
+		t.Errorf("expected URL to be updated, got %v", lines[0].LineURL)
+	}
+	if lines[0].LineHash == before.LineHash {
+		t.Error("expected line hash to be updated")
+	}
+}
+
+func TestProcessMarkRemoved_EntryDisappears(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	first := createTestM3U(t, `#EXTM3U
+#EXTINF:-1 tvg-name="Kept Movie" group-title="Movies",Kept Movie
+http://example.com/kept.mkv
+#EXTINF:-1 tvg-name="Dropped Movie" group-title="Movies",Dropped Movie
+http://example.com/dropped.mkv`)
+
+	proc, err := NewProcessor(first)
+	if err != nil {
+		t.Fatalf("NewProcessor failed: %v", err)
+	}
+
+	opts := ProcessOptions{
+		BatchSize:        10,
+		ProgressInterval: 100,
+		SkipTMDB:         true,
+		MarkRemoved:      true,
+	}
+	if _, err := proc.Process(context.Background(), opts); err != nil {
+		t.Fatalf("First Process failed: %v", err)
+	}
+
+	// The provider dropped one entry from the playlist
+	second := createTestM3U(t, `#EXTM3U
+#EXTINF:-1 tvg-name="Kept Movie" group-title="Movies",Kept Movie
+http://example.com/kept.mkv`)
+
+	proc, err = NewProcessor(second)
+	if err != nil {
+		t.Fatalf("NewProcessor failed: %v", err)
+	}
+
+	stats, err := proc.Process(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Second Process failed: %v", err)
+	}
+
+	if stats.Removed != 1 {
+		t.Errorf("expected 1 removed entry, got %d", stats.Removed)
+	}
+
+	db := database.Get()
+
+	var dropped models.ProcessedLine
+	db.Where("tvg_name = ?", "Dropped Movie").First(&dropped)
+	if dropped.State != models.StateRemoved {
+		t.Errorf("expected dropped entry state 'removed', got '%s'", dropped.State)
+	}
+
+	var kept models.ProcessedLine
+	db.Where("tvg_name = ?", "Kept Movie").First(&kept)
+	if kept.State == models.StateRemoved {
+		t.Error("expected kept entry not to be marked removed")
+	}
+	if kept.LastSeenAt == nil || dropped.LastSeenAt == nil || !kept.LastSeenAt.After(*dropped.LastSeenAt) {
+		t.Error("expected kept entry to be seen more recently than the dropped entry")
+	}
+
+	// Pruning entries not seen in the last run deletes only the dropped entry
+	pruneStats, err := PruneStaleLines(db, PruneOptions{Runs: 1})
+	if err != nil {
+		t.Fatalf("PruneStaleLines failed: %v", err)
+	}
+	if pruneStats.Deleted != 1 {
+		t.Errorf("expected 1 deleted entry, got %d", pruneStats.Deleted)
+	}
+
+	var remaining int64
+	db.Model(&models.ProcessedLine{}).Count(&remaining)
+	if remaining != 1 {
+		t.Errorf("expected 1 remaining entry, got %d", remaining)
+	}
+}
+
+func TestPruneStaleLines_NotEnoughRuns(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	stats, err := PruneStaleLines(database.Get(), PruneOptions{Runs: 5})
+	if err != nil {
+		t.Fatalf("PruneStaleLines failed: %v", err)
+	}
+	if stats.Cutoff != nil {
+		t.Error("expected no cutoff when fewer runs than requested exist")
+	}
+	if stats.Deleted != 0 {
+		t.Errorf("expected nothing deleted, got %d", stats.Deleted)
+	}
+}
+
 func TestProcessingLogCreation(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test in short mode")
@@ -289,7 +533,7 @@ http://example.com/movie.mkv`
 		ProgressInterval: 100,
 	}
 
-	_, err = proc.Process(opts)
+	_, err = proc.Process(context.Background(), opts)
 	if err != nil {
 		t.Fatalf("Process failed: %v", err)
 	}
@@ -314,8 +558,6 @@ http://example.com/movie.mkv`
 }
 
 func TestExtractTitleAndYear(t *testing.T) {
-	p := &Processor{}
-
 	tests := []struct {
 		name        string
 		input       string
@@ -386,7 +628,7 @@ func TestExtractTitleAndYear(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotTitle, gotYear := p.extractTitleAndYear(tt.input)
+			gotTitle, gotYear := extractTitleAndYear(tt.input)
 			if gotTitle != tt.wantTitle {
 				t.Errorf("title: got %q, want %q", gotTitle, tt.wantTitle)
 			}
@@ -403,6 +645,33 @@ func TestExtractTitleAndYear(t *testing.T) {
 
 func intPtr(i int) *int { return &i }
 
+func TestExtractTVShowYear(t *testing.T) {
+	tests := []struct {
+		name       string
+		tvgName    string
+		groupTitle string
+		wantYear   *int
+	}{
+		{"year in parentheses before episode", "One Day at a Time (2017) S01E01", "Séries", intPtr(2017)},
+		{"year with dash", "Doctor Who - 2005 S01E01", "Séries", intPtr(2005)},
+		{"year in group title", "Battlestar Galactica S01E01", "Séries 2004", intPtr(2004)},
+		{"tvg-name wins over group", "Battlestar Galactica (1978) S01E01", "Séries 2004", intPtr(1978)},
+		{"no year", "Breaking Bad S01E01", "Séries VF", nil},
+		{"non-year digits in group", "Breaking Bad S01E01", "Séries 720p", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractTVShowYear(tt.tvgName, tt.groupTitle)
+			if tt.wantYear == nil && got != nil {
+				t.Errorf("year: got %d, want nil", *got)
+			} else if tt.wantYear != nil && (got == nil || *got != *tt.wantYear) {
+				t.Errorf("year: got %v, want %d", got, *tt.wantYear)
+			}
+		})
+	}
+}
+
 func TestSetContentTypeResolution(t *testing.T) {
 	// Unit test: verifies that setContentType persists the resolution from the classifier.
 	// Uses SkipTMDB=true and TMDBLanguage set to avoid config/DB dependencies.
@@ -432,6 +701,40 @@ func TestSetContentTypeResolution(t *testing.T) {
 	}
 }
 
+func TestSetContentTypeParts(t *testing.T) {
+	// Unit test: the parts of a multi-part movie share a part group, distinct from other movies.
+	p := &Processor{
+		classifier: classifier.New(),
+	}
+	opts := &ProcessOptions{SkipTMDB: true, TMDBLanguage: "en-US"}
+
+	classify := func(tvgName string) *models.ProcessedLine {
+		line := &models.ProcessedLine{TvgName: tvgName, GroupTitle: "FR: FILMS"}
+		if err := p.setContentType(line, p.classifier.Classify(tvgName, line.GroupTitle), opts, &Statistics{}); err != nil {
+			t.Fatalf("setContentType returned error: %v", err)
+		}
+		return line
+	}
+
+	part1 := classify("The Irishman (2019) Part 1")
+	part2 := classify("The Irishman (2019) Part 2")
+	other := classify("Kill Bill (2003) Part 1")
+	single := classify("Inception (2010)")
+
+	if part1.PartNumber == nil || *part1.PartNumber != 1 || part2.PartNumber == nil || *part2.PartNumber != 2 {
+		t.Fatalf("expected part numbers 1 and 2, got %v and %v", part1.PartNumber, part2.PartNumber)
+	}
+	if part1.PartGroup == nil || part2.PartGroup == nil || *part1.PartGroup != *part2.PartGroup {
+		t.Errorf("expected both parts to share a part group, got %v and %v", part1.PartGroup, part2.PartGroup)
+	}
+	if other.PartGroup == nil || *other.PartGroup == *part1.PartGroup {
+		t.Errorf("expected a distinct part group for another movie")
+	}
+	if single.PartNumber != nil || single.PartGroup != nil {
+		t.Errorf("expected no part for a single stream, got %v / %v", single.PartNumber, single.PartGroup)
+	}
+}
+
 func TestSetContentTypeResolutionNil(t *testing.T) {
 	// Verifies that nil resolution from classifier results in nil on ProcessedLine.
 	p := &Processor{
@@ -473,3 +776,384 @@ func TestComputeLineHash(t *testing.T) {
 		t.Errorf("expected hash length 64, got %d", len(hash1))
 	}
 }
+
+// newSQLiteProcessor creates a processor for file backed by its own in-memory database
+// and enriching through the mock TMDB server at tmdbURL
+func newSQLiteProcessor(t *testing.T, file, tmdbURL string) (*Processor, *gorm.DB) {
+	t.Helper()
+
+	db := testutil.TestDB(t)
+	if err := db.AutoMigrate(&models.ProcessingLog{}, &models.DownloadInfo{}); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+	// Each connection to :memory: is a separate database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get database instance: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	return &Processor{
+		filePath:   file,
+		parser:     parser.NewParserWithLogger(file, logger.AppLogger()),
+		classifier: classifier.New(),
+		filter:     filter.NewManager(),
+		enricher:   NewEnricher(db, newTMDBClientForTest(t, tmdbURL)),
+		logger:     logger.AppLogger(),
+		db:         db,
+	}, db
+}
+
+func TestProcessTMDBParallel_MatchesSerial(t *testing.T) {
+	srv := newTMDBTestServer(t, newMockTMDBHandler)
+
+	var content strings.Builder
+	content.WriteString("#EXTM3U\n")
+	for i := 0; i < 12; i++ {
+		fmt.Fprintf(&content, "#EXTINF:-1 tvg-name=\"The Matrix (1999)\" group-title=\"Movies\",The Matrix\nhttp://example.com/matrix-%d.mkv\n", i)
+		fmt.Fprintf(&content, "#EXTINF:-1 tvg-name=\"Breaking Bad S01E%02d\" group-title=\"Séries\",Breaking Bad\nhttp://example.com/bb-%d.mkv\n", i+1, i)
+		fmt.Fprintf(&content, "#EXTINF:-1 tvg-name=\"Unknown Movie %d (2001)\" group-title=\"Movies\",Unknown\nhttp://example.com/unknown-%d.mkv\n", i, i)
+	}
+	file := createTestM3U(t, content.String())
+
+	run := func(parallel int) (*Statistics, []models.ProcessedLine, int64, int64) {
+		p, db := newSQLiteProcessor(t, file, srv.URL)
+		stats, err := p.Process(context.Background(), ProcessOptions{BatchSize: 5, TMDBLanguage: "en-US", TMDBParallel: parallel})
+		if err != nil {
+			t.Fatalf("Process(parallel=%d) error: %v", parallel, err)
+		}
+
+		var lines []models.ProcessedLine
+		db.Order("id").Find(&lines)
+		var movies, tvshows int64
+		db.Model(&models.Movie{}).Count(&movies)
+		db.Model(&models.TVShow{}).Count(&tvshows)
+		return stats, lines, movies, tvshows
+	}
+
+	serial, serialLines, serialMovies, serialTVShows := run(1)
+	parallel, parallelLines, parallelMovies, parallelTVShows := run(4)
+
+	if serial.TMDBMatched == 0 || serial.TMDBNotFound == 0 {
+		t.Fatalf("expected both matches and misses in the serial run, got %+v", serial)
+	}
+
+	counts := []struct {
+		name             string
+		serial, parallel int
+	}{
+		{"processed", serial.Processed, parallel.Processed},
+		{"movies", serial.Movies, parallel.Movies},
+		{"tvshows", serial.TVShows, parallel.TVShows},
+		{"errors", serial.Errors, parallel.Errors},
+		{"tmdb_matched", serial.TMDBMatched, parallel.TMDBMatched},
+		{"tmdb_not_found", serial.TMDBNotFound, parallel.TMDBNotFound},
+		{"tmdb_errors", serial.TMDBErrors, parallel.TMDBErrors},
+		{"movie rows", int(serialMovies), int(parallelMovies)},
+		{"tvshow rows", int(serialTVShows), int(parallelTVShows)},
+	}
+	for _, c := range counts {
+		if c.serial != c.parallel {
+			t.Errorf("%s: serial = %d, parallel = %d", c.name, c.serial, c.parallel)
+		}
+	}
+
+	// Entries are saved in playlist order
+	if len(serialLines) != len(parallelLines) {
+		t.Fatalf("saved lines: serial = %d, parallel = %d", len(serialLines), len(parallelLines))
+	}
+	for i := range serialLines {
+		if serialLines[i].LineHash != parallelLines[i].LineHash {
+			t.Errorf("line %d: serial hash %s, parallel hash %s", i, serialLines[i].LineHash, parallelLines[i].LineHash)
+		}
+	}
+}
+
+func TestProcess_ReportsProgress(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("#EXTM3U\n")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&content, "#EXTINF:-1 tvg-name=\"Movie %d (2001)\" group-title=\"Movies\",Movie %d\nhttp://example.com/movie-%d.mkv\n", i, i, i)
+	}
+	file := createTestM3U(t, content.String())
+	p, _ := newSQLiteProcessor(t, file, "")
+
+	var events []ProgressEvent
+	stats, err := p.Process(context.Background(), ProcessOptions{
+		BatchSize:  3,
+		SkipTMDB:   true,
+		OnProgress: func(event ProgressEvent) { events = append(events, event) },
+	})
+	if err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+
+	if len(events) < 4 {
+		t.Fatalf("expected parsing, batch and finalizing events, got %d events", len(events))
+	}
+	if events[0].Phase != PhaseParsing {
+		t.Errorf("expected the first event in phase %s, got %s", PhaseParsing, events[0].Phase)
+	}
+	if last := events[len(events)-1]; last.Phase != PhaseFinalizing || last.Processed != stats.Processed {
+		t.Errorf("expected a final %s event with %d processed, got %+v", PhaseFinalizing, stats.Processed, last)
+	}
+
+	phaseOrder := map[string]int{PhaseParsing: 0, PhaseProcessing: 1, PhaseFinalizing: 2}
+	for i := 1; i < len(events); i++ {
+		prev, cur := events[i-1], events[i]
+		if cur.Processed < prev.Processed || cur.Errors < prev.Errors {
+			t.Errorf("event %d: counts went backwards from %+v to %+v", i, prev, cur)
+		}
+		if phaseOrder[cur.Phase] < phaseOrder[prev.Phase] {
+			t.Errorf("event %d: phase went back from %s to %s", i, prev.Phase, cur.Phase)
+		}
+		if cur.Phase != PhaseParsing && cur.Total != 10 {
+			t.Errorf("event %d: expected a total of 10, got %d", i, cur.Total)
+		}
+	}
+}
+
+func TestProcess_EnrichContentTypes(t *testing.T) {
+	var mu sync.Mutex
+	var queries []string
+	srv := newTMDBTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries = append(queries, r.URL.Path+"?"+r.URL.Query().Get("query"))
+		mu.Unlock()
+		newMockTMDBHandler(w, r)
+	})
+
+	file := createTestM3U(t, `#EXTM3U
+#EXTINF:-1 tvg-name="TF1 HD" group-title="FR: TV",TF1 HD
+http://example.com/live/tf1.m3u8
+#EXTINF:-1 tvg-name="The Matrix (1999)" group-title="Movies",The Matrix
+http://example.com/matrix.mkv
+`)
+
+	t.Run("channels are never enriched", func(t *testing.T) {
+		queries = nil
+		p, db := newSQLiteProcessor(t, file, srv.URL)
+		if _, err := p.Process(context.Background(), ProcessOptions{TMDBLanguage: "en-US"}); err != nil {
+			t.Fatalf("Process() error: %v", err)
+		}
+
+		var channel models.ProcessedLine
+		if err := db.Where("tvg_name = ?", "TF1 HD").First(&channel).Error; err != nil {
+			t.Fatalf("channel not saved: %v", err)
+		}
+		if channel.ContentType != models.ContentTypeChannels {
+			t.Fatalf("expected TF1 HD to be classified as a channel, got %s", channel.ContentType)
+		}
+		for _, query := range queries {
+			if strings.Contains(query, "TF1") {
+				t.Errorf("expected no TMDB call for the channel, got %s", query)
+			}
+		}
+		if len(queries) == 0 {
+			t.Error("expected the movie to be enriched")
+		}
+	})
+
+	t.Run("excluded content types are not enriched", func(t *testing.T) {
+		queries = nil
+		p, _ := newSQLiteProcessor(t, file, srv.URL)
+		p.enrichTypes = map[models.ContentType]bool{models.ContentTypeTVShows: true}
+		stats, err := p.Process(context.Background(), ProcessOptions{TMDBLanguage: "en-US"})
+		if err != nil {
+			t.Fatalf("Process() error: %v", err)
+		}
+
+		if len(queries) != 0 {
+			t.Errorf("expected no TMDB call when movies are not enriched, got %v", queries)
+		}
+		if stats.Movies != 1 || stats.TMDBMatched != 0 {
+			t.Errorf("expected 1 movie saved without TMDB match, got %+v", stats)
+		}
+	})
+}
+
+func TestProcessForce_ClassificationFlipResetsAssociations(t *testing.T) {
+	srv := newTMDBTestServer(t, newMockTMDBHandler)
+	file := createTestM3U(t, `#EXTM3U
+#EXTINF:-1 tvg-name="The Matrix (1999)" group-title="Movies",The Matrix
+http://example.com/matrix.mkv
+`)
+
+	p, db := newSQLiteProcessor(t, file, srv.URL)
+	if _, err := p.Process(context.Background(), ProcessOptions{TMDBLanguage: "en-US"}); err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+
+	var line models.ProcessedLine
+	if err := db.Where("tvg_name = ?", "The Matrix (1999)").First(&line).Error; err != nil {
+		t.Fatalf("line not saved: %v", err)
+	}
+	if line.ContentType != models.ContentTypeMovies || line.MovieID == nil {
+		t.Fatalf("expected a movie linked to its TMDB entry, got %s with movie %v", line.ContentType, line.MovieID)
+	}
+
+	// Reprocess with the group forced to series
+	if err := p.classifier.AddGroupOverride("^Movies$", classifier.ContentTypeSeries); err != nil {
+		t.Fatalf("AddGroupOverride() error: %v", err)
+	}
+	p.parser = parser.NewParserWithLogger(file, logger.AppLogger())
+	if _, err := p.Process(context.Background(), ProcessOptions{Force: true, SkipTMDB: true}); err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+
+	var updated models.ProcessedLine
+	if err := db.First(&updated, line.ID).Error; err != nil {
+		t.Fatalf("line not found after reprocessing: %v", err)
+	}
+	if updated.ContentType != models.ContentTypeTVShows {
+		t.Errorf("expected content type %s, got %s", models.ContentTypeTVShows, updated.ContentType)
+	}
+	if updated.MovieID != nil {
+		t.Errorf("expected the movie association to be reset, got movie %d", *updated.MovieID)
+	}
+}
+
+func TestProcess_CorrectsContentTypeFromTMDB(t *testing.T) {
+	srv := newTMDBTestServer(t, newMockTMDBHandler)
+	file := createTestM3U(t, `#EXTM3U
+#EXTINF:-1 tvg-name="Breaking Bad (2008)" group-title="Movies",Breaking Bad
+http://example.com/breaking-bad.mkv
+#EXTINF:-1 tvg-name="The Matrix (1999)" group-title="Movies",The Matrix
+http://example.com/matrix.mkv
+`)
+
+	p, db := newSQLiteProcessor(t, file, srv.URL)
+	stats, err := p.Process(context.Background(), ProcessOptions{TMDBLanguage: "en-US"})
+	if err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+	if stats.TMDBMatched != 2 || stats.TMDBNotFound != 0 {
+		t.Errorf("expected 2 TMDB matches and no miss, got %d matched and %d not found", stats.TMDBMatched, stats.TMDBNotFound)
+	}
+
+	var corrected models.ProcessedLine
+	if err := db.Preload("TVShow").Where("tvg_name = ?", "Breaking Bad (2008)").First(&corrected).Error; err != nil {
+		t.Fatalf("line not saved: %v", err)
+	}
+	if corrected.ContentType != models.ContentTypeTVShows {
+		t.Errorf("expected content type %s, got %s", models.ContentTypeTVShows, corrected.ContentType)
+	}
+	if corrected.MovieID != nil {
+		t.Errorf("expected no movie association, got movie %d", *corrected.MovieID)
+	}
+	if corrected.TVShow == nil || corrected.TVShow.TMDBID != 1396 {
+		t.Errorf("expected the line to be linked to TMDB TV show 1396, got %+v", corrected.TVShow)
+	}
+
+	var movie models.ProcessedLine
+	if err := db.Where("tvg_name = ?", "The Matrix (1999)").First(&movie).Error; err != nil {
+		t.Fatalf("line not saved: %v", err)
+	}
+	if movie.ContentType != models.ContentTypeMovies || movie.MovieID == nil {
+		t.Errorf("expected The Matrix to stay a linked movie, got %s with movie %v", movie.ContentType, movie.MovieID)
+	}
+}
+
+func TestProcess_ErrorThreshold(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("#EXTM3U\n")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&content, "#EXTINF:-1 tvg-name=\"Movie %d (2020)\" group-title=\"Movies\",Movie %d\nhttp://example.com/movie%d.mkv\n", i, i, i)
+	}
+	file := createTestM3U(t, content.String())
+
+	tests := []struct {
+		threshold  float64
+		wantStatus string
+	}{
+		{0, "completed_with_errors"},
+		{5, "completed_with_errors"},
+		{10, "success"},
+		{50, "success"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("threshold %.0f%%", tt.threshold), func(t *testing.T) {
+			p, db := newSQLiteProcessor(t, file, "")
+			p.errorThreshold = tt.threshold
+			// Without download_info the reclassification step fails: 1 error in 10 entries
+			if err := db.Migrator().DropTable(&models.DownloadInfo{}); err != nil {
+				t.Fatalf("failed to drop download_info: %v", err)
+			}
+
+			stats, err := p.Process(context.Background(), ProcessOptions{SkipTMDB: true})
+			if err != nil {
+				t.Fatalf("Process() error: %v", err)
+			}
+			if stats.Errors != 1 || stats.TotalLines != 10 {
+				t.Fatalf("expected 1 error in 10 entries, got %d in %d", stats.Errors, stats.TotalLines)
+			}
+
+			var log models.ProcessingLog
+			if err := db.Order("id DESC").First(&log).Error; err != nil {
+				t.Fatalf("failed to fetch processing log: %v", err)
+			}
+			if log.Status != tt.wantStatus {
+				t.Errorf("expected status %q, got %q", tt.wantStatus, log.Status)
+			}
+			if log.ErrorMessage == nil {
+				t.Error("expected the errors to be recorded on the processing log")
+			}
+		})
+	}
+}
+
+// cancelAfterContext reports itself cancelled once Err has been called more than n times,
+// so that a run can be cancelled at a deterministic entry
+type cancelAfterContext struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfterContext) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestProcess_CancelledMidRun(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("#EXTM3U\n")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&content, "#EXTINF:-1 tvg-name=\"Movie %d (2001)\" group-title=\"Movies\",Movie %d\nhttp://example.com/movie-%d.mkv\n", i, i, i)
+	}
+	file := createTestM3U(t, content.String())
+	p, db := newSQLiteProcessor(t, file, "")
+
+	ctx := &cancelAfterContext{Context: context.Background(), n: 4}
+	stats, err := p.Process(ctx, ProcessOptions{BatchSize: 3, SkipTMDB: true})
+	if err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+
+	if !stats.Cancelled {
+		t.Error("expected the run to be reported as cancelled")
+	}
+	if stats.TotalLines != 10 {
+		t.Errorf("expected 10 total lines, got %d", stats.TotalLines)
+	}
+	if stats.Processed != 4 {
+		t.Errorf("expected the 4 entries handled before cancellation to be saved, got %d", stats.Processed)
+	}
+
+	var count int64
+	db.Model(&models.ProcessedLine{}).Count(&count)
+	if count != 4 {
+		t.Errorf("expected 4 stored lines, got %d", count)
+	}
+
+	var log models.ProcessingLog
+	if err := db.Order("id DESC").First(&log).Error; err != nil {
+		t.Fatalf("failed to fetch processing log: %v", err)
+	}
+	if log.Status != "cancelled" {
+		t.Errorf("expected processing log status 'cancelled', got %q", log.Status)
+	}
+}
@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// PruneOptions holds configuration for the stale entry pruning operation.
+type PruneOptions struct {
+	Runs   int // delete entries not seen in the last N processing runs
+	DryRun bool
+}
+
+// PruneStats holds the results of a prune run.
+type PruneStats struct {
+	Cutoff  *time.Time // start of the oldest run considered; nil when not enough runs exist
+	Matched int64
+	Deleted int64
+}
+
+// PruneStaleLines deletes processed lines that were not seen by any of the last
+// opts.Runs processing runs. Entries that have never been stamped with last_seen_at
+// (created before tracking existed and absent since) are left untouched.
+func PruneStaleLines(db *gorm.DB, opts PruneOptions) (*PruneStats, error) {
+	if opts.Runs <= 0 {
+		return nil, fmt.Errorf("number of runs must be greater than zero")
+	}
+
+	stats := &PruneStats{}
+
+	// Find the start of the Nth most recent completed processing run
+	var runs []models.ProcessingLog
+	if err := db.Where("action = ? AND status IN ?", "process_m3u", []string{"success", "completed_with_errors"}).
+		Order("started_at DESC").
+		Limit(opts.Runs).
+		Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to query processing runs: %w", err)
+	}
+	if len(runs) < opts.Runs {
+		return stats, nil
+	}
+	cutoff := runs[len(runs)-1].StartedAt
+	stats.Cutoff = &cutoff
+
+	// Pruning permanently removes stale entries, including soft-deleted ones
+	query := db.Unscoped().Model(&models.ProcessedLine{}).Where("last_seen_at < ?", cutoff)
+	if err := query.Count(&stats.Matched).Error; err != nil {
+		return nil, fmt.Errorf("failed to count stale entries: %w", err)
+	}
+
+	if opts.DryRun || stats.Matched == 0 {
+		return stats, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Neither the join table of fresh databases nor the one created by the tags
+		// migration cascades deletes, so the tag links are removed first
+		stale := tx.Unscoped().Model(&models.ProcessedLine{}).Select("id").Where("last_seen_at < ?", cutoff)
+		if err := tx.Where("processed_line_id IN (?)", stale).Delete(&models.ProcessedLineTag{}).Error; err != nil {
+			return fmt.Errorf("failed to delete tags of stale entries: %w", err)
+		}
+
+		result := tx.Unscoped().Where("last_seen_at < ?", cutoff).Delete(&models.ProcessedLine{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete stale entries: %w", result.Error)
+		}
+		stats.Deleted = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/testutil"
+)
+
+func TestPruneStaleLines_DeletesTagLinks(t *testing.T) {
+	db := testutil.TestDB(t)
+	if err := db.AutoMigrate(&models.ProcessingLog{}, &models.Tag{}, &models.ProcessedLineTag{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	now := time.Now()
+	run := models.ProcessingLog{Action: "process_m3u", Status: "success", StartedAt: now.Add(-time.Hour)}
+	if err := db.Create(&run).Error; err != nil {
+		t.Fatalf("failed to create processing log: %v", err)
+	}
+
+	stale, seen := now.Add(-48*time.Hour), now
+	old := testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-old"
+		l.LastSeenAt = &stale
+	})
+	current := testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-current"
+		l.LastSeenAt = &seen
+	})
+
+	tag := models.Tag{Name: "keep"}
+	if err := db.Create(&tag).Error; err != nil {
+		t.Fatalf("failed to create tag: %v", err)
+	}
+	for _, line := range []*models.ProcessedLine{old, current} {
+		if err := db.Create(&models.ProcessedLineTag{ProcessedLineID: line.ID, TagID: tag.ID}).Error; err != nil {
+			t.Fatalf("failed to tag line %d: %v", line.ID, err)
+		}
+	}
+
+	stats, err := PruneStaleLines(db, PruneOptions{Runs: 1})
+	if err != nil {
+		t.Fatalf("PruneStaleLines failed: %v", err)
+	}
+	if stats.Deleted != 1 {
+		t.Fatalf("expected 1 stale entry deleted, got %d", stats.Deleted)
+	}
+
+	var links []models.ProcessedLineTag
+	if err := db.Find(&links).Error; err != nil {
+		t.Fatalf("failed to load tag links: %v", err)
+	}
+	if len(links) != 1 || links[0].ProcessedLineID != current.ID {
+		t.Errorf("expected only the tag link of the current entry to remain, got %+v", links)
+	}
+}
@@ -0,0 +1,238 @@
+package processor
+
+import (
+	"fmt"
+
+	"github.com/glefebvre/stalkeer/internal/classifier"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// ReclassifyByContentType upgrades uncategorized lines whose stream was downloaded
+// with a video content type to movies, so that a generic title served as a video
+// file is no longer left uncategorized. It returns the number of reclassified lines.
+func ReclassifyByContentType(db *gorm.DB) (int64, error) {
+	var rows []struct {
+		ID                  uint
+		DetectedContentType string
+	}
+	if err := db.Model(&models.ProcessedLine{}).
+		Select("processed_lines.id, download_info.detected_content_type").
+		Joins("JOIN download_info ON download_info.id = processed_lines.download_info_id").
+		Where("processed_lines.content_type = ? AND download_info.detected_content_type IS NOT NULL", models.ContentTypeUncategorized).
+		Scan(&rows).Error; err != nil {
+		return 0, fmt.Errorf("failed to query downloaded uncategorized lines: %w", err)
+	}
+
+	var ids []uint
+	for _, row := range rows {
+		classification := classifier.ReclassifyByContentType(
+			classifier.Classification{ContentType: classifier.ContentTypeUncategorized},
+			row.DetectedContentType,
+		)
+		if classification.ContentType == classifier.ContentTypeMovie {
+			ids = append(ids, row.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := db.Model(&models.ProcessedLine{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"content_type":     models.ContentTypeMovies,
+			"uncategorized_id": nil,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to reclassify lines: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ReclassifyOptions configures Reclassify
+type ReclassifyOptions struct {
+	ContentType models.ContentType // only reclassify lines currently of this type, empty for all
+	DryRun      bool
+}
+
+// ReclassifyStats reports the outcome of Reclassify
+type ReclassifyStats struct {
+	Scanned  int            `json:"scanned"`
+	Changed  int            `json:"changed"`  // lines whose content type changed
+	Unlinked int            `json:"unlinked"` // lines detached from TMDB metadata that no longer applies
+	ByType   map[string]int `json:"by_type"`  // new content type of the changed lines
+}
+
+// Reclassify re-runs the classifier on the stored tvg-name and group-title of every
+// line, without re-parsing the playlist or calling TMDB. Channels are left untouched
+// as the classifier does not detect them. Lines whose TMDB movie or episode no longer
+// matches their classification are unlinked so that the enrich command can match
+// them again.
+func Reclassify(db *gorm.DB, c *classifier.Classifier, opts ReclassifyOptions) (*ReclassifyStats, error) {
+	const batchSize = 500
+
+	stats := &ReclassifyStats{ByType: make(map[string]int)}
+
+	var lastID uint
+	for {
+		query := db.Preload("TVShow").
+			Where("id > ? AND content_type <> ?", lastID, models.ContentTypeChannels)
+		if opts.ContentType != "" {
+			query = query.Where("content_type = ?", opts.ContentType)
+		}
+
+		var lines []models.ProcessedLine
+		if err := query.Order("id").Limit(batchSize).Find(&lines).Error; err != nil {
+			return stats, fmt.Errorf("failed to query processed lines: %w", err)
+		}
+		if len(lines) == 0 {
+			break
+		}
+
+		for i := range lines {
+			line := &lines[i]
+			lastID = line.ID
+			stats.Scanned++
+
+			updates, err := reclassifyUpdates(db, c, line)
+			if err != nil {
+				return stats, err
+			}
+			if len(updates) == 0 {
+				continue
+			}
+
+			if contentType, ok := updates["content_type"]; ok {
+				stats.Changed++
+				stats.ByType[string(contentType.(models.ContentType))]++
+			}
+			if unlinksMetadata(updates) {
+				stats.Unlinked++
+			}
+
+			if opts.DryRun {
+				continue
+			}
+			if err := db.Model(&models.ProcessedLine{}).Where("id = ?", line.ID).Updates(updates).Error; err != nil {
+				return stats, fmt.Errorf("failed to reclassify line %d: %w", line.ID, err)
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// ReclassifyLine re-runs the classifier on a single line, which must have its TVShow
+// association loaded, and saves the result. It reports whether the content type changed.
+func ReclassifyLine(db *gorm.DB, c *classifier.Classifier, line *models.ProcessedLine) (bool, error) {
+	if line.ContentType == models.ContentTypeChannels {
+		return false, nil
+	}
+
+	updates, err := reclassifyUpdates(db, c, line)
+	if err != nil || len(updates) == 0 {
+		return false, err
+	}
+
+	if err := db.Model(&models.ProcessedLine{}).Where("id = ?", line.ID).Updates(updates).Error; err != nil {
+		return false, fmt.Errorf("failed to reclassify line %d: %w", line.ID, err)
+	}
+	_, changed := updates["content_type"]
+	return changed, nil
+}
+
+// reclassifyUpdates classifies line and returns the columns to update, empty when the
+// stored classification is still current. A stream already downloaded as a video file
+// keeps the upgrade applied by ReclassifyByContentType.
+func reclassifyUpdates(db *gorm.DB, c *classifier.Classifier, line *models.ProcessedLine) (map[string]interface{}, error) {
+	classification := c.Classify(line.TvgName, line.GroupTitle)
+
+	if line.DownloadInfoID != nil {
+		var download models.DownloadInfo
+		if err := db.Select("detected_content_type").First(&download, *line.DownloadInfoID).Error; err != nil && err != gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("failed to fetch download info of line %d: %w", line.ID, err)
+		}
+		if download.DetectedContentType != nil {
+			classification = classifier.ReclassifyByContentType(classification, *download.DetectedContentType)
+		}
+	}
+
+	var contentType models.ContentType
+	switch classification.ContentType {
+	case classifier.ContentTypeMovie:
+		contentType = models.ContentTypeMovies
+	case classifier.ContentTypeSeries:
+		contentType = models.ContentTypeTVShows
+	default:
+		contentType = models.ContentTypeUncategorized
+	}
+
+	updates := make(map[string]interface{})
+	if contentType != line.ContentType {
+		updates["content_type"] = contentType
+		if contentType != models.ContentTypeUncategorized && line.UncategorizedID != nil {
+			updates["uncategorized_id"] = nil
+		}
+	}
+	if line.MovieID != nil && contentType != models.ContentTypeMovies {
+		updates["movie_id"] = nil
+	}
+	if line.TVShowID != nil && (contentType != models.ContentTypeTVShows || !episodeMatches(line.TVShow, classification)) {
+		updates["tv_show_id"] = nil
+	}
+	if !stringPtrEqual(line.Resolution, classification.Resolution) {
+		updates["resolution"] = classification.Resolution
+	}
+	if !stringPtrEqual(line.Language, classification.Language) {
+		updates["language"] = classification.Language
+	}
+	if line.NormalizedGroup != classification.Group {
+		updates["normalized_group"] = classification.Group
+	}
+	if line.ClassificationConfidence == nil || *line.ClassificationConfidence != classification.Confidence {
+		updates["classification_confidence"] = classification.Confidence
+	}
+	return updates, nil
+}
+
+// episodeMatches reports whether the linked show is the episode (or season pack) classified
+func episodeMatches(show *models.TVShow, classification classifier.Classification) bool {
+	if show == nil {
+		return true
+	}
+	return intPtrEqual(show.Season, classification.Season) &&
+		intPtrEqual(show.Episode, classification.Episode) &&
+		intPtrEqual(lastEpisode(show.Episode, show.EpisodeEnd), lastEpisode(classification.Episode, classification.EpisodeEnd)) &&
+		show.SeasonPack == classification.SeasonPack
+}
+
+// lastEpisode returns the last episode of a range, which is the episode itself when
+// episodeEnd is not set
+func lastEpisode(episode, episodeEnd *int) *int {
+	if episodeEnd != nil {
+		return episodeEnd
+	}
+	return episode
+}
+
+// unlinksMetadata reports whether updates detach a line from its TMDB movie or show
+func unlinksMetadata(updates map[string]interface{}) bool {
+	_, movie := updates["movie_id"]
+	_, show := updates["tv_show_id"]
+	return movie || show
+}
+
+func intPtrEqual(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func stringPtrEqual(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
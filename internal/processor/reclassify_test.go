@@ -0,0 +1,171 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/classifier"
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/testutil"
+)
+
+func TestReclassifyByContentType(t *testing.T) {
+	db := testutil.TestDB(t)
+	if err := db.AutoMigrate(&models.DownloadInfo{}); err != nil {
+		t.Fatalf("failed to migrate download info: %v", err)
+	}
+
+	// createDownloaded creates an uncategorized line downloaded with the given content type
+	createDownloaded := func(hash string, contentType *string, lineType models.ContentType) *models.ProcessedLine {
+		download := &models.DownloadInfo{Status: string(models.DownloadStatusCompleted), DetectedContentType: contentType}
+		if err := db.Create(download).Error; err != nil {
+			t.Fatalf("failed to create download info: %v", err)
+		}
+		return testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+			l.LineHash = hash
+			l.TvgName = "Untitled stream"
+			l.ContentType = lineType
+			l.DownloadInfoID = &download.ID
+		})
+	}
+	mp4, ts, html := "video/mp4", "video/mp2t", "text/html"
+
+	video := createDownloaded("hash-mp4", &mp4, models.ContentTypeUncategorized)
+	live := createDownloaded("hash-ts", &ts, models.ContentTypeUncategorized)
+	page := createDownloaded("hash-html", &html, models.ContentTypeUncategorized)
+	unknown := createDownloaded("hash-unknown", nil, models.ContentTypeUncategorized)
+	show := createDownloaded("hash-show", &mp4, models.ContentTypeTVShows)
+	notDownloaded := testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-not-downloaded"
+		l.ContentType = models.ContentTypeUncategorized
+	})
+
+	count, err := ReclassifyByContentType(db)
+	if err != nil {
+		t.Fatalf("ReclassifyByContentType error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 reclassified line, got %d", count)
+	}
+
+	expected := map[uint]models.ContentType{
+		video.ID:         models.ContentTypeMovies,
+		live.ID:          models.ContentTypeUncategorized,
+		page.ID:          models.ContentTypeUncategorized,
+		unknown.ID:       models.ContentTypeUncategorized,
+		show.ID:          models.ContentTypeTVShows,
+		notDownloaded.ID: models.ContentTypeUncategorized,
+	}
+	for id, contentType := range expected {
+		var line models.ProcessedLine
+		if err := db.First(&line, id).Error; err != nil {
+			t.Fatalf("failed to reload line %d: %v", id, err)
+		}
+		if line.ContentType != contentType {
+			t.Errorf("line %d: expected content type %s, got %s", id, contentType, line.ContentType)
+		}
+	}
+}
+
+func TestReclassify_AfterRuleChange(t *testing.T) {
+	db := testutil.TestDB(t)
+	if err := db.AutoMigrate(&models.DownloadInfo{}); err != nil {
+		t.Fatalf("failed to migrate download info: %v", err)
+	}
+
+	special := testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-special"
+		l.TvgName = "Doctor Who Christmas Special"
+		l.GroupTitle = "Britbox"
+		l.ContentType = models.ContentTypeUncategorized
+	})
+	movie := testutil.CreateMovie(db)
+	matrix := testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-matrix"
+		l.TvgName = "The Matrix (1999)"
+		l.MovieID = &movie.ID
+	})
+	season, episode := 1, 2
+	show := &models.TVShow{TMDBID: 1396, TMDBTitle: "Breaking Bad", Season: &season, Episode: &episode}
+	if err := db.Create(show).Error; err != nil {
+		t.Fatalf("failed to create TV show: %v", err)
+	}
+	mislinked := testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-bb"
+		l.TvgName = "Breaking Bad S01E03"
+		l.GroupTitle = "Séries"
+		l.ContentType = models.ContentTypeTVShows
+		l.TVShowID = &show.ID
+	})
+	channel := testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "hash-channel"
+		l.TvgName = "BBC One"
+		l.GroupTitle = "Britbox"
+		l.ContentType = models.ContentTypeChannels
+	})
+
+	c := classifier.New()
+	if got := c.Classify(special.TvgName, special.GroupTitle).ContentType; got != classifier.ContentTypeUncategorized {
+		t.Fatalf("expected the special to be uncategorized before the rule change, got %s", got)
+	}
+	if err := c.AddGroupOverride("(?i)britbox", classifier.ContentTypeSeries); err != nil {
+		t.Fatalf("failed to add group override: %v", err)
+	}
+
+	stats, err := Reclassify(db, c, ReclassifyOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Reclassify(dry run) error: %v", err)
+	}
+	if stats.Scanned != 3 || stats.Changed != 1 || stats.Unlinked != 1 {
+		t.Errorf("unexpected dry run stats: %+v", stats)
+	}
+	var reloaded models.ProcessedLine
+	db.First(&reloaded, special.ID)
+	if reloaded.ContentType != models.ContentTypeUncategorized {
+		t.Errorf("dry run must not update lines, got content type %s", reloaded.ContentType)
+	}
+
+	stats, err = Reclassify(db, c, ReclassifyOptions{})
+	if err != nil {
+		t.Fatalf("Reclassify error: %v", err)
+	}
+	if stats.Changed != 1 || stats.ByType[string(models.ContentTypeTVShows)] != 1 {
+		t.Errorf("expected 1 line changed to tvshows, got %+v", stats)
+	}
+
+	expected := map[uint]models.ContentType{
+		special.ID:   models.ContentTypeTVShows,
+		matrix.ID:    models.ContentTypeMovies,
+		mislinked.ID: models.ContentTypeTVShows,
+		channel.ID:   models.ContentTypeChannels,
+	}
+	lines := make(map[uint]models.ProcessedLine)
+	for id, contentType := range expected {
+		var line models.ProcessedLine
+		if err := db.First(&line, id).Error; err != nil {
+			t.Fatalf("failed to reload line %d: %v", id, err)
+		}
+		if line.ContentType != contentType {
+			t.Errorf("line %d: expected content type %s, got %s", id, contentType, line.ContentType)
+		}
+		lines[id] = line
+	}
+	confidence := c.Classify(special.TvgName, special.GroupTitle).Confidence
+	if got := lines[special.ID].ClassificationConfidence; got == nil || *got != confidence {
+		t.Errorf("expected the stored confidence to be updated to %d, got %v", confidence, got)
+	}
+	if lines[matrix.ID].MovieID == nil {
+		t.Error("expected the movie link to be kept")
+	}
+	if lines[mislinked.ID].TVShowID != nil {
+		t.Error("expected the episode linked to the wrong TMDB episode to be unlinked")
+	}
+
+	// A second run finds nothing left to change
+	stats, err = Reclassify(db, c, ReclassifyOptions{})
+	if err != nil {
+		t.Fatalf("Reclassify error: %v", err)
+	}
+	if stats.Changed != 0 || stats.Unlinked != 0 {
+		t.Errorf("expected reclassification to be idempotent, got %+v", stats)
+	}
+}
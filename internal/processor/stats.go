@@ -0,0 +1,277 @@
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"gorm.io/gorm"
+)
+
+// topGroupsLimit is the number of group-titles reported by item statistics
+const topGroupsLimit = 10
+
+// ItemStats holds the item counts reported by the stats endpoint
+type ItemStats struct {
+	TotalItems    int64
+	ByContentType map[string]int64
+	ByResolution  map[string]int64
+	ByState       map[string]int64
+	TopGroups     []GroupCount
+	RefreshedAt   *time.Time // When the snapshot was taken, nil for live counts
+}
+
+// GroupCount is the number of items of a group-title
+type GroupCount struct {
+	GroupTitle string
+	Count      int64
+}
+
+// statsContentTypes and statsStates are always reported, with a zero count when empty
+var (
+	statsContentTypes = []models.ContentType{
+		models.ContentTypeMovies,
+		models.ContentTypeTVShows,
+		models.ContentTypeChannels,
+		models.ContentTypeUncategorized,
+	}
+	statsStates = []models.ProcessingState{
+		models.StateProcessed,
+		models.StatePending,
+		models.StateDownloading,
+		models.StateDownloaded,
+		models.StateFailed,
+		models.StateRemoved,
+	}
+)
+
+// ComputeItemStats counts the items live, with one grouped query per dimension
+func ComputeItemStats(db *gorm.DB) (*ItemStats, error) {
+	stats := &ItemStats{
+		ByContentType: make(map[string]int64),
+		ByResolution:  make(map[string]int64),
+		ByState:       make(map[string]int64),
+	}
+	for _, ct := range statsContentTypes {
+		stats.ByContentType[string(ct)] = 0
+	}
+	for _, state := range statsStates {
+		stats.ByState[string(state)] = 0
+	}
+
+	if err := db.Model(&models.ProcessedLine{}).Count(&stats.TotalItems).Error; err != nil {
+		return nil, fmt.Errorf("failed to count items: %w", err)
+	}
+
+	for column, counts := range map[string]map[string]int64{
+		"content_type": stats.ByContentType,
+		"state":        stats.ByState,
+		"resolution":   stats.ByResolution,
+	} {
+		if err := countItemsBy(db, column, counts); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := db.Model(&models.ProcessedLine{}).
+		Select("group_title, COUNT(*) AS count").
+		Group("group_title").
+		Order("count DESC").
+		Limit(topGroupsLimit).
+		Scan(&stats.TopGroups).Error; err != nil {
+		return nil, fmt.Errorf("failed to count items by group: %w", err)
+	}
+
+	return stats, nil
+}
+
+// countItemsBy adds the number of items of each non-null value of column to counts
+func countItemsBy(db *gorm.DB, column string, counts map[string]int64) error {
+	var rows []struct {
+		Value *string
+		Count int64
+	}
+	if err := db.Model(&models.ProcessedLine{}).
+		Select(column + " AS value, COUNT(*) AS count").
+		Where(column + " IS NOT NULL").
+		Group(column).
+		Scan(&rows).Error; err != nil {
+		return fmt.Errorf("failed to count items by %s: %w", column, err)
+	}
+	for _, row := range rows {
+		if row.Value != nil {
+			counts[*row.Value] = row.Count
+		}
+	}
+	return nil
+}
+
+// ConfidenceBucketSize is the width of the buckets of a confidence distribution
+const ConfidenceBucketSize = 10
+
+// ConfidenceDistribution counts the classified items of each content type per
+// confidence bucket. Buckets[i] covers confidences from i*ConfidenceBucketSize,
+// the last bucket also holding a confidence of 100.
+type ConfidenceDistribution struct {
+	Buckets map[string][]int64
+	Unknown int64 // Items classified before their confidence was stored
+}
+
+// ComputeConfidenceDistribution builds the classification confidence histogram
+// of every content type with a single grouped query
+func ComputeConfidenceDistribution(db *gorm.DB) (*ConfidenceDistribution, error) {
+	bucketCount := 100 / ConfidenceBucketSize
+	dist := &ConfidenceDistribution{Buckets: make(map[string][]int64)}
+	for _, ct := range statsContentTypes {
+		dist.Buckets[string(ct)] = make([]int64, bucketCount)
+	}
+
+	var rows []struct {
+		ContentType string
+		Bucket      int
+		Count       int64
+	}
+	if err := db.Model(&models.ProcessedLine{}).
+		Select(fmt.Sprintf("content_type, classification_confidence / %d AS bucket, COUNT(*) AS count", ConfidenceBucketSize)).
+		Where("classification_confidence IS NOT NULL").
+		Group("content_type, bucket").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count items by confidence: %w", err)
+	}
+	for _, row := range rows {
+		buckets, ok := dist.Buckets[row.ContentType]
+		if !ok {
+			buckets = make([]int64, bucketCount)
+			dist.Buckets[row.ContentType] = buckets
+		}
+		bucket := min(max(row.Bucket, 0), bucketCount-1)
+		buckets[bucket] += row.Count
+	}
+
+	if err := db.Model(&models.ProcessedLine{}).
+		Where("classification_confidence IS NULL").
+		Count(&dist.Unknown).Error; err != nil {
+		return nil, fmt.Errorf("failed to count items without confidence: %w", err)
+	}
+
+	return dist, nil
+}
+
+// TMDBMatchRun holds the TMDB match counts of a processing run
+type TMDBMatchRun struct {
+	RunID     uint
+	StartedAt time.Time
+	Matched   int
+	NotFound  int
+}
+
+// MatchRate returns the percentage of TMDB lookups of the run that found a match
+func (r TMDBMatchRun) MatchRate() float64 {
+	if r.Matched+r.NotFound == 0 {
+		return 0
+	}
+	return float64(r.Matched) / float64(r.Matched+r.NotFound) * 100
+}
+
+// TMDBMatchHistory returns the TMDB match counts of the last limit finished
+// processing runs that looked items up on TMDB, oldest first
+func TMDBMatchHistory(db *gorm.DB, limit int) ([]TMDBMatchRun, error) {
+	var logs []models.ProcessingLog
+	if err := db.Where("action = ? AND status <> ?", "process_m3u", "in_progress").
+		Where("tmdb_matched + tmdb_not_found > 0").
+		Order("started_at DESC, id DESC").
+		Limit(limit).
+		Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch processing runs: %w", err)
+	}
+
+	runs := make([]TMDBMatchRun, len(logs))
+	for i, log := range logs {
+		runs[len(logs)-1-i] = TMDBMatchRun{
+			RunID:     log.ID,
+			StartedAt: log.StartedAt,
+			Matched:   log.TMDBMatched,
+			NotFound:  log.TMDBNotFound,
+		}
+	}
+	return runs, nil
+}
+
+// RefreshStatsSnapshot recomputes the item statistics and replaces the stored
+// snapshot with them. The counts by state are not stored, see LoadStatsSnapshot.
+func RefreshStatsSnapshot(db *gorm.DB) (*ItemStats, error) {
+	stats, err := ComputeItemStats(db)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	stats.RefreshedAt = &now
+
+	rows := []models.StatsSnapshot{{Dimension: models.StatsDimensionTotal, Count: stats.TotalItems, RefreshedAt: now}}
+	for dimension, counts := range map[string]map[string]int64{
+		models.StatsDimensionContentType: stats.ByContentType,
+		models.StatsDimensionResolution:  stats.ByResolution,
+	} {
+		for key, count := range counts {
+			rows = append(rows, models.StatsSnapshot{Dimension: dimension, Key: key, Count: count, RefreshedAt: now})
+		}
+	}
+	for _, group := range stats.TopGroups {
+		rows = append(rows, models.StatsSnapshot{Dimension: models.StatsDimensionGroup, Key: group.GroupTitle, Count: group.Count, RefreshedAt: now})
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.StatsSnapshot{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store stats snapshot: %w", err)
+	}
+
+	return stats, nil
+}
+
+// LoadStatsSnapshot returns the stored item statistics, or nil when no snapshot
+// was taken yet. The counts by state are computed live, as downloads move items
+// between states without refreshing the snapshot.
+func LoadStatsSnapshot(db *gorm.DB) (*ItemStats, error) {
+	var rows []models.StatsSnapshot
+	if err := db.Order("count DESC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load stats snapshot: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	stats := &ItemStats{
+		ByContentType: make(map[string]int64),
+		ByResolution:  make(map[string]int64),
+		ByState:       make(map[string]int64),
+		TopGroups:     []GroupCount{},
+	}
+	for _, row := range rows {
+		switch row.Dimension {
+		case models.StatsDimensionTotal:
+			stats.TotalItems = row.Count
+			refreshedAt := row.RefreshedAt
+			stats.RefreshedAt = &refreshedAt
+		case models.StatsDimensionContentType:
+			stats.ByContentType[row.Key] = row.Count
+		case models.StatsDimensionResolution:
+			stats.ByResolution[row.Key] = row.Count
+		case models.StatsDimensionGroup:
+			stats.TopGroups = append(stats.TopGroups, GroupCount{GroupTitle: row.Key, Count: row.Count})
+		}
+	}
+
+	for _, state := range statsStates {
+		stats.ByState[string(state)] = 0
+	}
+	if err := countItemsBy(db, "state", stats.ByState); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
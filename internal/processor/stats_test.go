@@ -0,0 +1,95 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/glefebvre/stalkeer/internal/models"
+	"github.com/glefebvre/stalkeer/internal/testutil"
+)
+
+func TestRefreshStatsSnapshot_ReflectsInserts(t *testing.T) {
+	db := testutil.TestDB(t)
+	if err := db.AutoMigrate(&models.StatsSnapshot{}); err != nil {
+		t.Fatalf("failed to migrate stats snapshot: %v", err)
+	}
+
+	snapshot, err := LoadStatsSnapshot(db)
+	if err != nil {
+		t.Fatalf("LoadStatsSnapshot() error: %v", err)
+	}
+	if snapshot != nil {
+		t.Fatalf("expected no snapshot before the first refresh, got %+v", snapshot)
+	}
+
+	hd := "1080p"
+	testutil.CreateProcessedLine(db, func(l *models.ProcessedLine) {
+		l.LineHash = "stats-movie"
+		l.Resolution = &hd
+	})
+	if _, err := RefreshStatsSnapshot(db); err != nil {
+		t.Fatalf("RefreshStatsSnapshot() error: %v", err)
+	}
+
+	// Items inserted after a refresh only show up in the next one
+	testutil.CreateProcessedLine(db, testutil.WithTVShow(), testutil.WithGroupTitle("Séries"), func(l *models.ProcessedLine) {
+		l.LineHash = "stats-episode"
+	})
+	snapshot, err = LoadStatsSnapshot(db)
+	if err != nil {
+		t.Fatalf("LoadStatsSnapshot() error: %v", err)
+	}
+	if snapshot.TotalItems != 1 {
+		t.Errorf("expected 1 item in the stale snapshot, got %d", snapshot.TotalItems)
+	}
+
+	if _, err := RefreshStatsSnapshot(db); err != nil {
+		t.Fatalf("RefreshStatsSnapshot() error: %v", err)
+	}
+	snapshot, err = LoadStatsSnapshot(db)
+	if err != nil {
+		t.Fatalf("LoadStatsSnapshot() error: %v", err)
+	}
+
+	if snapshot.RefreshedAt == nil {
+		t.Error("expected the snapshot time to be set")
+	}
+	if snapshot.TotalItems != 2 {
+		t.Errorf("expected 2 items, got %d", snapshot.TotalItems)
+	}
+	if got := snapshot.ByContentType[string(models.ContentTypeMovies)]; got != 1 {
+		t.Errorf("expected 1 movie, got %d", got)
+	}
+	if got := snapshot.ByContentType[string(models.ContentTypeTVShows)]; got != 1 {
+		t.Errorf("expected 1 tv show, got %d", got)
+	}
+	if got, ok := snapshot.ByContentType[string(models.ContentTypeChannels)]; !ok || got != 0 {
+		t.Errorf("expected channels to be reported with 0 items, got %d (present: %v)", got, ok)
+	}
+	if got := snapshot.ByResolution["1080p"]; got != 1 {
+		t.Errorf("expected 1 item in 1080p, got %d", got)
+	}
+	if got := snapshot.ByState[string(models.StateProcessed)]; got != 2 {
+		t.Errorf("expected 2 processed items, got %d", got)
+	}
+	if len(snapshot.TopGroups) != 2 {
+		t.Errorf("expected 2 groups, got %+v", snapshot.TopGroups)
+	}
+
+	// Counts by state follow downloads without a refresh
+	if err := db.Model(&models.ProcessedLine{}).Where("line_hash = ?", "stats-movie").Update("state", models.StateDownloaded).Error; err != nil {
+		t.Fatalf("failed to update state: %v", err)
+	}
+	snapshot, err = LoadStatsSnapshot(db)
+	if err != nil {
+		t.Fatalf("LoadStatsSnapshot() error: %v", err)
+	}
+	if got := snapshot.ByState[string(models.StateDownloaded)]; got != 1 {
+		t.Errorf("expected 1 downloaded item without a refresh, got %d", got)
+	}
+	if got := snapshot.ByState[string(models.StateProcessed)]; got != 1 {
+		t.Errorf("expected 1 processed item without a refresh, got %d", got)
+	}
+	if got, ok := snapshot.ByState[string(models.StateFailed)]; !ok || got != 0 {
+		t.Errorf("expected failed to be reported with 0 items, got %d (present: %v)", got, ok)
+	}
+}
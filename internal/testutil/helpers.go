@@ -189,6 +189,21 @@ func WithTVShowID(id uint) func(*models.ProcessedLine) {
 	}
 }
 
+// WithTvgName sets the tvg-name of a processed line, with a line hash derived from it
+func WithTvgName(name string) func(*models.ProcessedLine) {
+	return func(line *models.ProcessedLine) {
+		line.TvgName = name
+		line.LineHash = "hash-" + name
+	}
+}
+
+// WithDownloadInfoID links a processed line to a download
+func WithDownloadInfoID(id uint) func(*models.ProcessedLine) {
+	return func(line *models.ProcessedLine) {
+		line.DownloadInfoID = &id
+	}
+}
+
 // WithTMDBID sets the TMDB ID for a movie
 func WithTMDBID(id int) func(*models.Movie) {
 	return func(movie *models.Movie) {